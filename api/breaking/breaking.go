@@ -0,0 +1,245 @@
+// Package breaking finds the changes of a proto file that break the clients of its previous version. It follows the FILE
+// and WIRE_JSON rules of buf breaking: the generated code, the binary encoding and the JSON encoding of the messages
+// and the calls of the services have to keep working.
+package breaking
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Violation - a change that breaks the clients of the previous version
+type Violation struct {
+	//Rule - the buf breaking rule the change breaks, as in FIELD_SAME_TYPE
+	Rule string
+	//Message - describes the change
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+// Check - returns every change of current that breaks the clients of previous, ordered by rule and message
+func Check(previous, current *descriptorpb.FileDescriptorProto) []Violation {
+
+	c := &checker{}
+
+	if previous.GetPackage() != current.GetPackage() {
+		c.add("FILE_SAME_PACKAGE", "package changed from %q to %q", previous.GetPackage(), current.GetPackage())
+	}
+
+	if previous.GetOptions().GetGoPackage() != current.GetOptions().GetGoPackage() {
+		c.add("FILE_SAME_GO_PACKAGE", "go_package changed from %q to %q", previous.GetOptions().GetGoPackage(), current.GetOptions().GetGoPackage())
+	}
+
+	c.messages(previous.GetPackage(), previous.GetMessageType(), current.GetMessageType())
+	c.enums(previous.GetPackage(), previous.GetEnumType(), current.GetEnumType())
+	c.services(previous.GetPackage(), previous.GetService(), current.GetService())
+
+	sort.Slice(c.violations, func(i, j int) bool {
+		if c.violations[i].Rule != c.violations[j].Rule {
+			return c.violations[i].Rule < c.violations[j].Rule
+		}
+		return c.violations[i].Message < c.violations[j].Message
+	})
+
+	return c.violations
+}
+
+type checker struct {
+	violations []Violation
+}
+
+func (c *checker) add(rule, format string, args ...interface{}) {
+	c.violations = append(c.violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) messages(scope string, previous, current []*descriptorpb.DescriptorProto) {
+
+	byName := map[string]*descriptorpb.DescriptorProto{}
+
+	for _, m := range current {
+		byName[m.GetName()] = m
+	}
+
+	for _, p := range previous {
+
+		name := scope + "." + p.GetName()
+		m, ok := byName[p.GetName()]
+
+		if !ok {
+			c.add("MESSAGE_NO_DELETE", "message %s was deleted", name)
+			continue
+		}
+
+		c.fields(name, p, m)
+		c.messages(name, p.GetNestedType(), m.GetNestedType())
+		c.enums(name, p.GetEnumType(), m.GetEnumType())
+	}
+}
+
+func (c *checker) fields(message string, previous, current *descriptorpb.DescriptorProto) {
+
+	byNumber := map[int32]*descriptorpb.FieldDescriptorProto{}
+
+	for _, f := range current.GetField() {
+		byNumber[f.GetNumber()] = f
+	}
+
+	for _, p := range previous.GetField() {
+
+		f, ok := byNumber[p.GetNumber()]
+
+		if !ok {
+			if !reservedField(current, p) {
+				c.add("FIELD_NO_DELETE_UNLESS_NUMBER_RESERVED", "field %d of %s was deleted without reserving its number", p.GetNumber(), message)
+			}
+			continue
+		}
+
+		field := fmt.Sprintf("field %d of %s", p.GetNumber(), message)
+
+		if p.GetType() != f.GetType() || p.GetTypeName() != f.GetTypeName() {
+			c.add("FIELD_SAME_TYPE", "%s changed type from %s to %s", field, fieldType(p), fieldType(f))
+		}
+		if p.GetLabel() != f.GetLabel() {
+			c.add("FIELD_SAME_LABEL", "%s changed label from %s to %s", field, p.GetLabel(), f.GetLabel())
+		}
+		if p.GetName() != f.GetName() {
+			c.add("FIELD_SAME_NAME", "%s changed name from %q to %q", field, p.GetName(), f.GetName())
+		}
+		if p.GetJsonName() != f.GetJsonName() {
+			c.add("FIELD_SAME_JSON_NAME", "%s changed json name from %q to %q", field, p.GetJsonName(), f.GetJsonName())
+		}
+		if oneofName(previous, p) != oneofName(current, f) {
+			c.add("FIELD_SAME_ONEOF", "%s moved from oneof %q to %q", field, oneofName(previous, p), oneofName(current, f))
+		}
+	}
+}
+
+func (c *checker) enums(scope string, previous, current []*descriptorpb.EnumDescriptorProto) {
+
+	byName := map[string]*descriptorpb.EnumDescriptorProto{}
+
+	for _, e := range current {
+		byName[e.GetName()] = e
+	}
+
+	for _, p := range previous {
+
+		name := scope + "." + p.GetName()
+		e, ok := byName[p.GetName()]
+
+		if !ok {
+			c.add("ENUM_NO_DELETE", "enum %s was deleted", name)
+			continue
+		}
+
+		byNumber := map[int32]*descriptorpb.EnumValueDescriptorProto{}
+
+		for _, v := range e.GetValue() {
+			byNumber[v.GetNumber()] = v
+		}
+
+		for _, pv := range p.GetValue() {
+
+			v, ok := byNumber[pv.GetNumber()]
+
+			switch {
+			case !ok && !reservedValue(e, pv):
+				c.add("ENUM_VALUE_NO_DELETE_UNLESS_NUMBER_RESERVED", "value %d of %s was deleted without reserving its number", pv.GetNumber(), name)
+			case ok && pv.GetName() != v.GetName():
+				c.add("ENUM_VALUE_SAME_NAME", "value %d of %s changed name from %q to %q", pv.GetNumber(), name, pv.GetName(), v.GetName())
+			}
+		}
+	}
+}
+
+func (c *checker) services(scope string, previous, current []*descriptorpb.ServiceDescriptorProto) {
+
+	byName := map[string]*descriptorpb.ServiceDescriptorProto{}
+
+	for _, s := range current {
+		byName[s.GetName()] = s
+	}
+
+	for _, p := range previous {
+
+		name := scope + "." + p.GetName()
+		s, ok := byName[p.GetName()]
+
+		if !ok {
+			c.add("SERVICE_NO_DELETE", "service %s was deleted", name)
+			continue
+		}
+
+		methods := map[string]*descriptorpb.MethodDescriptorProto{}
+
+		for _, m := range s.GetMethod() {
+			methods[m.GetName()] = m
+		}
+
+		for _, pm := range p.GetMethod() {
+
+			rpc := name + "." + pm.GetName()
+			m, ok := methods[pm.GetName()]
+
+			if !ok {
+				c.add("RPC_NO_DELETE", "rpc %s was deleted", rpc)
+				continue
+			}
+
+			if pm.GetInputType() != m.GetInputType() {
+				c.add("RPC_SAME_REQUEST_TYPE", "rpc %s changed request type from %s to %s", rpc, pm.GetInputType(), m.GetInputType())
+			}
+			if pm.GetOutputType() != m.GetOutputType() {
+				c.add("RPC_SAME_RESPONSE_TYPE", "rpc %s changed response type from %s to %s", rpc, pm.GetOutputType(), m.GetOutputType())
+			}
+			if pm.GetClientStreaming() != m.GetClientStreaming() {
+				c.add("RPC_SAME_CLIENT_STREAMING", "rpc %s changed client streaming to %t", rpc, m.GetClientStreaming())
+			}
+			if pm.GetServerStreaming() != m.GetServerStreaming() {
+				c.add("RPC_SAME_SERVER_STREAMING", "rpc %s changed server streaming to %t", rpc, m.GetServerStreaming())
+			}
+		}
+	}
+}
+
+// reservedField - reports whether the number of a deleted field is reserved by the message
+func reservedField(message *descriptorpb.DescriptorProto, field *descriptorpb.FieldDescriptorProto) bool {
+	for _, r := range message.GetReservedRange() {
+		if field.GetNumber() >= r.GetStart() && field.GetNumber() < r.GetEnd() {
+			return true
+		}
+	}
+	return false
+}
+
+// reservedValue - reports whether the number of a deleted enum value is reserved by the enum
+func reservedValue(enum *descriptorpb.EnumDescriptorProto, value *descriptorpb.EnumValueDescriptorProto) bool {
+	for _, r := range enum.GetReservedRange() {
+		if value.GetNumber() >= r.GetStart() && value.GetNumber() <= r.GetEnd() {
+			return true
+		}
+	}
+	return false
+}
+
+// oneofName - the name of the oneof of the field, empty when it is in none
+func oneofName(message *descriptorpb.DescriptorProto, field *descriptorpb.FieldDescriptorProto) string {
+	if field.OneofIndex == nil || int(field.GetOneofIndex()) >= len(message.GetOneofDecl()) {
+		return ""
+	}
+	return message.GetOneofDecl()[field.GetOneofIndex()].GetName()
+}
+
+// fieldType - the type of the field as it is written in the proto file
+func fieldType(field *descriptorpb.FieldDescriptorProto) string {
+	if field.GetTypeName() != "" {
+		return field.GetTypeName()
+	}
+	return field.GetType().String()
+}
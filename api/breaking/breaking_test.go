@@ -0,0 +1,129 @@
+package breaking_test
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/casmelad/GlobantPOC/api/breaking"
+	usersv1 "github.com/casmelad/GlobantPOC/api/users/v1"
+	usersv2 "github.com/casmelad/GlobantPOC/api/users/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// update rewrites the snapshots of the published API with the current
+// descriptors, once the breaking changes were agreed on.
+var update = flag.Bool("update", false, "rewrite the snapshots of the published API")
+
+// published are the snapshots of the published API, by the proto file they
+// were taken from.
+var published = map[string]protoreflect.FileDescriptor{
+	"users_v1.json": usersv1.File_users_v1_users_proto,
+	"users_v2.json": usersv2.File_users_v2_users_proto,
+}
+
+func Test_Check_PublishedAPI_NoBreakingChanges(t *testing.T) {
+	for snapshot, file := range published {
+		t.Run(snapshot, func(t *testing.T) {
+			//Arrange
+			current := protodesc.ToFileDescriptorProto(file)
+			path := filepath.Join("testdata", snapshot)
+			if *update {
+				data, err := protojson.MarshalOptions{Multiline: true}.Marshal(current)
+				assert.Nil(t, err)
+				assert.Nil(t, ioutil.WriteFile(path, data, 0644))
+			}
+			data, err := ioutil.ReadFile(path)
+			assert.Nil(t, err)
+			previous := &descriptorpb.FileDescriptorProto{}
+			assert.Nil(t, protojson.Unmarshal(data, previous))
+			//Act
+			violations := breaking.Check(previous, current)
+			//Assert
+			assert.Empty(t, violations, "the changes break the clients of %s, run the test with -update once they are agreed on", file.Path())
+		})
+	}
+}
+
+// edit returns a copy of the v2 file descriptor changed by change.
+func edit(change func(*descriptorpb.FileDescriptorProto)) *descriptorpb.FileDescriptorProto {
+	file := proto.Clone(protodesc.ToFileDescriptorProto(usersv2.File_users_v2_users_proto)).(*descriptorpb.FileDescriptorProto)
+	change(file)
+	return file
+}
+
+func message(file *descriptorpb.FileDescriptorProto, name string) *descriptorpb.DescriptorProto {
+	for _, m := range file.MessageType {
+		if m.GetName() == name {
+			return m
+		}
+	}
+	return nil
+}
+
+func rules(violations []breaking.Violation) []string {
+	var rules []string
+	for _, v := range violations {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestCases_Check_Changes(t *testing.T) {
+	previous := protodesc.ToFileDescriptorProto(usersv2.File_users_v2_users_proto)
+
+	testCases := []struct {
+		name   string
+		change func(*descriptorpb.FileDescriptorProto)
+		rules  []string
+	}{
+		{"NewField", func(f *descriptorpb.FileDescriptorProto) {
+			user := message(f, "User")
+			user.Field = append(user.Field, &descriptorpb.FieldDescriptorProto{Name: proto.String("phone"), JsonName: proto.String("phone"), Number: proto.Int32(12), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()})
+		}, nil},
+		{"DeletedField", func(f *descriptorpb.FileDescriptorProto) {
+			user := message(f, "User")
+			user.Field = user.Field[1:]
+		}, []string{"FIELD_NO_DELETE_UNLESS_NUMBER_RESERVED"}},
+		{"DeletedReservedField", func(f *descriptorpb.FileDescriptorProto) {
+			user := message(f, "User")
+			user.Field = user.Field[1:]
+			user.ReservedRange = append(user.ReservedRange, &descriptorpb.DescriptorProto_ReservedRange{Start: proto.Int32(1), End: proto.Int32(2)})
+		}, nil},
+		{"RenamedField", func(f *descriptorpb.FileDescriptorProto) {
+			user := message(f, "User")
+			user.Field[3].Name = proto.String("surname")
+			user.Field[3].JsonName = proto.String("surname")
+		}, []string{"FIELD_SAME_JSON_NAME", "FIELD_SAME_NAME"}},
+		{"ChangedFieldType", func(f *descriptorpb.FileDescriptorProto) {
+			message(f, "User").Field[0].Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+		}, []string{"FIELD_SAME_TYPE"}},
+		{"DeletedEnumValue", func(f *descriptorpb.FileDescriptorProto) {
+			f.EnumType[0].Value = f.EnumType[0].Value[:4]
+		}, []string{"ENUM_VALUE_NO_DELETE_UNLESS_NUMBER_RESERVED"}},
+		{"DeletedRPC", func(f *descriptorpb.FileDescriptorProto) {
+			f.Service[0].Method = f.Service[0].Method[1:]
+		}, []string{"RPC_NO_DELETE"}},
+		{"ChangedResponseType", func(f *descriptorpb.FileDescriptorProto) {
+			f.Service[0].Method[0].OutputType = proto.String(".google.protobuf.Empty")
+		}, []string{"RPC_SAME_RESPONSE_TYPE"}},
+		{"ChangedPackage", func(f *descriptorpb.FileDescriptorProto) {
+			f.Package = proto.String("users.v3")
+		}, []string{"FILE_SAME_PACKAGE"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Act
+			violations := breaking.Check(previous, edit(tc.change))
+			//Assert
+			assert.Equal(t, tc.rules, rules(violations))
+		})
+	}
+}
@@ -1,101 +1,274 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 
 	"github.com/caarlos0/env/v6"
+	proto "github.com/casmelad/GlobantPOC/api/users/v1"
+	usersv2 "github.com/casmelad/GlobantPOC/api/users/v2"
 	grpcServiceImpl "github.com/casmelad/GlobantPOC/cmd/grpcService/users"
-	proto "github.com/casmelad/GlobantPOC/cmd/grpcService/users/proto"
+	"github.com/casmelad/GlobantPOC/pkg/audit"
+	"github.com/casmelad/GlobantPOC/pkg/auth"
+	"github.com/casmelad/GlobantPOC/pkg/events"
+	"github.com/casmelad/GlobantPOC/pkg/instrumentation"
+	"github.com/casmelad/GlobantPOC/pkg/logging"
+	"github.com/casmelad/GlobantPOC/pkg/notify"
 	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
 	mysql "github.com/casmelad/GlobantPOC/pkg/repository/mysql"
+	"github.com/casmelad/GlobantPOC/pkg/tracing"
 	domain "github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/casmelad/GlobantPOC/pkg/webhooks"
 
 	kitgrpc "github.com/go-kit/kit/transport/grpc"
-	stdopentracing "github.com/opentracing/opentracing-go"
-	zipkin "github.com/openzipkin/zipkin-go"
-	zipkinhttp "github.com/openzipkin/zipkin-go/reporter/http"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
 	"google.golang.org/grpc"
 )
 
 func main() {
 
-	var (
-		zipkinURL = "http://localhost:9411/api/v2/spans"
-	)
+	cfg := config{}
+	cfgErr := env.Parse(&cfg)
 
 	// Create a single logger, which we'll use and give to other components.
-	var logger log.Logger
-	{
-		logger = log.NewLogfmtLogger(os.Stderr)
-		logger = log.With(logger, "ts", log.DefaultTimestampUTC)
-		logger = log.With(logger, "caller", log.DefaultCaller)
-	}
-
-	var zipkinTracer *zipkin.Tracer
-	{
-		if zipkinURL != "" {
-			var (
-				err         error
-				hostPort    = "localhost:8081"
-				serviceName = "accounts"
-				reporter    = zipkinhttp.NewReporter(zipkinURL)
-			)
-			defer reporter.Close()
-			//sampler, err := zipkin.NewCountingSampler(100)
-			zEP, _ := zipkin.NewEndpoint(serviceName, hostPort)
-			zipkinTracer, err = zipkin.NewTracer(reporter, zipkin.WithLocalEndpoint(zEP))
-			if err != nil {
-				logger.Log("err", err)
-				os.Exit(1)
-			}
-		}
-	}
+	logger, err := logging.New(os.Stderr, logging.Config{Level: cfg.LogLevel, Format: cfg.LogFormat})
 
-	// Determine which OpenTracing tracer to use. We'll pass the tracer to all the
-	// components that use it, as a dependency.
-	var tracer stdopentracing.Tracer
-	/* {
-
-		logger.Log("tracer", "Zipkin", "type", "OpenTracing", "URL", zipkinURL)
-		tracer = zipkinot.Wrap(zipkinTracer)
-		zipkinTracer = nil // do not instrument with both native tracer and opentracing bridge
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
-	} */
+	if cfgErr != nil {
+		level.Error(logger).Log("err", cfgErr)
+	}
 
-	cfg := config{}
+	// The zipkin tracer is a no-op unless a collector is configured, the spans
+	// of the REST gateway are joined through the B3 metadata, or the W3C trace
+	// context when OpenTelemetry is the provider.
+	zipkinTracer, closeTracer, err := tracing.Setup(context.Background(), tracing.Settings{
+		Provider: cfg.TracingProvider,
+		Zipkin: tracing.Config{
+			URL:         cfg.TracingURL,
+			ServiceName: cfg.TracingServiceName,
+			HostPort:    fmt.Sprintf("%s:%d", cfg.TracingHost, cfg.Port),
+			SampleRate:  cfg.TracingSampleRate,
+		},
+		OTel: tracing.OTelConfig{
+			Exporter:    cfg.TracingExporter,
+			Endpoint:    cfg.TracingEndpoint,
+			Insecure:    cfg.TracingInsecure,
+			File:        cfg.TracingFile,
+			ServiceName: cfg.TracingServiceName,
+			SampleRate:  cfg.TracingSampleRate,
+		},
+	})
 
-	if err := env.Parse(&cfg); err != nil {
-		fmt.Printf("%+v\n", err)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
 	}
 
+	defer closeTracer()
+
 	ls, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
 
 	if err != nil {
 		panic(fmt.Sprintf("Could not create the listener %v", err))
 	}
 
-	userService := domain.NewUserService(getActiveRepository())
-	endpoints := grpcServiceImpl.NewGrpcUsersServer(userService)
+	stores, err := getActiveRepository()
+
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+	repository := decorateStorage(stores.users,
+		instrumentation.NewRepository(tracing.NewRepository(stores.users, zipkinTracer), instrumentation.NewRED("users", "repository")))
+
+	if stores.db != nil {
+		stdprometheus.MustRegister(collectors.NewDBStatsCollector(stores.db, "users"))
+	}
+
+	go serveMetrics(cfg.MetricsAddr, log.With(logger, "component", "metrics"))
+
+	instrumenting := grpcServiceImpl.InstrumentingMiddleware(instrumentation.NewRED("users", "grpc"))
+	logged := grpcServiceImpl.LoggingMiddleware(log.With(logger, "component", "endpoint"))
+
+	publishers, closePublishers, err := getEventPublisher(cfg.EventsFile)
+
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+	defer closePublishers()
+
+	notifier, closeNotifier, err := getNotifier(cfg.Notifier, cfg.NotificationsFile)
+
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+
+	defer closeNotifier()
+
+	secret, err := verificationSecret(cfg.VerificationSecret)
+
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+
+	dispatcher := webhooks.NewDispatcher(stores.subscriptions, stores.deliveries,
+		&http.Client{Timeout: cfg.WebhooksTimeout},
+		webhooks.RetryPolicy{MaxAttempts: cfg.WebhooksMaxAttempts, InitialBackoff: cfg.WebhooksInitialBackoff, MaxBackoff: cfg.WebhooksMaxBackoff},
+		domain.SystemClock{}, cfg.WebhooksBatch)
+	go runWebhookDeliveries(context.Background(), dispatcher, cfg.WebhooksInterval, log.With(logger, "component", "webhooks"))
+
+	broadcaster := events.NewBroadcaster()
+	relay := events.NewRelay(stores.outbox, append(publishers, broadcaster, dispatcher), cfg.EventsRelayBatch, domain.SystemClock{})
+	go runEventRelay(context.Background(), relay, cfg.EventsRelayInterval, log.With(logger, "component", "events"))
+
+	domainService := domain.NewUserService(repository,
+		domain.WithTransactor(repository),
+		domain.WithEventEmitter(events.NewOutboxEmitter(stores.outbox)),
+		domain.WithEmailChanges(domain.EmailChanges{
+			Store:      repository,
+			Notifier:   notifier,
+			Clock:      domain.SystemClock{},
+			TokenTTL:   cfg.EmailTokenTTL,
+			AliasGrace: cfg.EmailAliasGrace,
+		}),
+		domain.WithVerification(domain.Verification{
+			Secret:   secret,
+			Notifier: notifier,
+			Clock:    domain.SystemClock{},
+			TokenTTL: cfg.VerificationTokenTTL,
+		}))
+	userService := audit.NewService(domainService, repository, stores.audit, repository, domain.SystemClock{})
 
-	grpcUserServer := grpcServiceImpl.NewGrpcUserServer(*endpoints, tracer, zipkinTracer, logger)
+	go runRetentionJob(context.Background(), domain.NewRetentionJob(repository, userService, cfg.RetentionPeriod, domain.SystemClock{}), cfg.RetentionInterval, log.With(logger, "component", "retention"))
 
-	baseServer := grpc.NewServer(grpc.UnaryInterceptor(kitgrpc.Interceptor))
+	endpoints := grpcServiceImpl.NewGrpcUsersServer(tracing.NewService(userService, otel.Tracer("users")), userService, instrumenting, logged)
+
+	grpcUserServer := grpcServiceImpl.NewGrpcUserServer(*endpoints, events.NewChangeFeed(stores.outbox, broadcaster, cfg.EventsRelayBatch), nil, zipkinTracer, logger)
+
+	if cfg.GatewaySecret == "" {
+		level.Warn(logger).Log("msg", "GATEWAY_SECRET is not set, the callers forwarded by the REST gateway are not trusted")
+	}
+
+	gatewayAuthenticator := grpcServiceImpl.NewGatewayAuthenticator(cfg.GatewaySecret, domain.SystemClock{})
+
+	baseServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor(), gatewayAuthenticator.UnaryInterceptor, grpcServiceImpl.RequestIDUnaryInterceptor, kitgrpc.Interceptor),
+		grpc.ChainStreamInterceptor(otelgrpc.StreamServerInterceptor(), gatewayAuthenticator.StreamInterceptor, grpcServiceImpl.RequestIDStreamInterceptor))
 	proto.RegisterUsersServer(baseServer, grpcUserServer)
+	usersv2.RegisterUsersServer(baseServer, grpcServiceImpl.NewGrpcUserV2Server(*grpcServiceImpl.NewGrpcUsersV2Endpoints(tracing.NewService(userService, otel.Tracer("users")), instrumenting, logged), zipkinTracer, logger))
+
+	webhookService := webhooks.NewService(stores.subscriptions, stores.deliveries, domain.SystemClock{})
+	proto.RegisterWebhooksServer(baseServer, grpcServiceImpl.NewGrpcWebhookServer(*grpcServiceImpl.NewGrpcWebhookEndpoints(webhookService, instrumenting, logged), zipkinTracer, logger))
+
+	authService, err := auth.NewService(repository, stores.auth,
+		auth.NewIssuer(signingKey(cfg.AuthPrivateKeyFile, log.With(logger, "component", "auth")), cfg.AuthIssuer, cfg.AuthAccessTTL, domain.SystemClock{}),
+		domain.SystemClock{},
+		auth.WithTransactor(repository),
+		auth.WithHasher(passwordHasher(cfg.AuthPasswordHasher)),
+		auth.WithLockout(auth.Lockout{MaxFailures: cfg.AuthMaxFailures, Duration: cfg.AuthLockout}),
+		auth.WithRefreshTTL(cfg.AuthRefreshTTL),
+		auth.WithBootstrapAdmins(cfg.AuthBootstrapAdmins...),
+		auth.WithPasswordReset(auth.PasswordReset{
+			Store:      stores.auth,
+			Notifier:   notifier,
+			TokenTTL:   cfg.AuthResetTokenTTL,
+			PerEmail:   auth.Limit{Max: cfg.AuthResetPerEmail, Window: cfg.AuthResetWindow},
+			PerAddress: auth.Limit{Max: cfg.AuthResetPerAddress, Window: cfg.AuthResetWindow},
+			QueueSize:  cfg.AuthResetQueue,
+		}),
+		auth.WithMFA(auth.MultiFactor{
+			Store:        stores.auth,
+			Issuer:       cfg.AuthIssuer,
+			ChallengeTTL: cfg.AuthMFAChallengeTTL,
+		}),
+		auth.WithAPIKeys(auth.APIKeys{
+			Store:           stores.auth,
+			UsageResolution: cfg.AuthAPIKeyUsage,
+		}))
+
+	if err != nil {
+		panic(fmt.Sprintf("could not create the auth service: %s", err))
+	}
+
+	go runPasswordResets(context.Background(), authService, log.With(logger, "component", "auth"))
+
+	proto.RegisterAuthServer(baseServer, grpcServiceImpl.NewGrpcAuthServer(*grpcServiceImpl.NewGrpcAuthEndpoints(authService, instrumenting, logged), domain.SystemClock{}, zipkinTracer, logger))
 
 	if err := baseServer.Serve(ls); err != nil {
 		panic(fmt.Sprintf("failed to serve: %s", err))
 	}
 }
 
-func getActiveRepository() domain.Repository {
+// storage is a users repository able to run the writes, their audit entries
+// and their events in a single transaction, and to keep the email changes.
+type storage interface {
+	domain.Repository
+	domain.Transactor
+	domain.EmailStore
+}
+
+// authStore keeps the credentials, the refresh tokens, the password
+// resets, the second factors and the API keys.
+type authStore interface {
+	auth.Store
+	auth.ResetStore
+	auth.MFAStore
+	auth.APIKeyStore
+}
 
-	envVar := os.Getenv("USERS_REPOSITORY")
+// decoratedStorage is a storage whose users repository is decorated, to
+// record the metrics and the spans of its queries.
+type decoratedStorage struct {
+	domain.Repository
+	domain.Transactor
+	domain.EmailStore
+}
 
-	fmt.Println(envVar)
+func decorateStorage(s storage, repository domain.Repository) storage {
+	return decoratedStorage{
+		Repository: repository,
+		Transactor: s,
+		EmailStore: s,
+	}
+}
+
+// stores groups the persistence of the service, all of them backed by the
+// same repository kind. The connection pool is only set for mysql.
+type stores struct {
+	users         storage
+	audit         audit.Store
+	outbox        events.Outbox
+	subscriptions webhooks.SubscriptionRepository
+	deliveries    webhooks.DeliveryRepository
+	auth          authStore
+	db            *sql.DB
+}
+
+func getActiveRepository() (stores, error) {
+
+	envVar := os.Getenv("USERS_REPOSITORY")
 
 	if len(envVar) == 0 {
 		envVar = "mysql"
@@ -104,17 +277,267 @@ func getActiveRepository() domain.Repository {
 	switch envVar {
 	case "memory":
 		repo := memory.NewInMemoryUserRepository()
-		return repo
+		return stores{
+			users:         repo,
+			audit:         memory.NewInMemoryAuditRepository(),
+			outbox:        memory.NewInMemoryOutboxRepository(),
+			subscriptions: memory.NewInMemorySubscriptionRepository(),
+			deliveries:    memory.NewInMemoryDeliveryRepository(),
+			auth:          memory.NewInMemoryAuthRepository(),
+		}, nil
 	case "mysql":
 		repo, err := mysql.NewMySQLUserRepository()
 		if err != nil {
-			panic(fmt.Sprintf("mysql connection failed: %s", err))
+			return stores{}, fmt.Errorf("mysql connection failed: %w", err)
+		}
+		return stores{
+			users:         repo,
+			audit:         mysql.NewMySQLAuditRepository(repo),
+			outbox:        mysql.NewMySQLOutboxRepository(repo),
+			subscriptions: mysql.NewMySQLSubscriptionRepository(repo),
+			deliveries:    mysql.NewMySQLDeliveryRepository(repo),
+			auth:          mysql.NewMySQLAuthRepository(repo),
+			db:            repo.DB(),
+		}, nil
+	}
+	return stores{}, fmt.Errorf("unknown repository %q", envVar)
+}
+
+// getEventPublisher returns the publishers the relay also delivers the user
+// events to: a file when a path is configured, none otherwise. The events
+// carry the personal data of the users, so they are never logged by default.
+func getEventPublisher(path string) (events.MultiPublisher, func(), error) {
+
+	if path == "" {
+		return events.MultiPublisher{}, func() {}, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not open the events file: %w", err)
+	}
+
+	return events.MultiPublisher{events.NewLogPublisher(f)}, func() { f.Close() }, nil
+}
+
+// getNotifier returns the notifier the verification, email change and
+// password reset tokens are delivered through. The tokens grant access to the
+// accounts, so there is no default: "file" writes them to the notifications
+// file, for local use only.
+func getNotifier(kind string, path string) (domain.Notifier, func(), error) {
+
+	switch kind {
+	case "file":
+		if path == "" {
+			return nil, nil, errors.New("USERS_NOTIFICATIONS_FILE is not set, the file notifier needs it")
+		}
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not open the notifications file: %w", err)
+		}
+
+		return notify.NewLogNotifier(f), func() { f.Close() }, nil
+	case "":
+		return nil, nil, errors.New("USERS_NOTIFIER is not set, the tokens sent to the users would have nowhere to go")
+	default:
+		return nil, nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
+
+// verificationSecret returns the configured key the verification tokens are
+// signed with, it is required so that the tokens outlive a restart and are
+// accepted by every instance.
+func verificationSecret(secret string) ([]byte, error) {
+
+	if secret == "" {
+		return nil, errors.New("USERS_VERIFICATION_SECRET is not set")
+	}
+
+	return []byte(secret), nil
+}
+
+// signingKey reads the RSA private key the access tokens are signed with, the
+// REST gateway verifies them with its public key. Without one a random key is
+// used, the tokens can then only be verified until a restart.
+func signingKey(path string, logger log.Logger) *rsa.PrivateKey {
+
+	if path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			panic(fmt.Sprintf("could not read the signing key: %s", err))
+		}
+		key, err := auth.ParsePrivateKey(pem)
+		if err != nil {
+			panic(fmt.Sprintf("could not parse the signing key: %s", err))
+		}
+		return key
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		panic(fmt.Sprintf("could not generate the signing key: %s", err))
+	}
+
+	level.Warn(logger).Log("msg", "AUTH_PRIVATE_KEY_FILE is not set, using a random signing key")
+
+	return key
+}
+
+// passwordHasher returns the hasher the new passwords are hashed with:
+// argon2id or bcrypt.
+func passwordHasher(name string) auth.Hasher {
+
+	switch name {
+	case "argon2id":
+		return auth.DefaultArgon2id
+	case "bcrypt":
+		return auth.DefaultBcrypt
+	}
+
+	panic(fmt.Sprintf("unknown password hasher: %s", name))
+}
+
+// serveMetrics exposes the prometheus metrics on addr, an empty address
+// disables the listener.
+func serveMetrics(addr string, logger log.Logger) {
+
+	if addr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	logger.Log("transport", "HTTP", "addr", addr)
+
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		level.Error(logger).Log("err", err)
+	}
+}
+
+// runRetentionJob purges, on every tick, the users that were soft deleted
+// longer than the retention period.
+func runRetentionJob(ctx context.Context, job *domain.RetentionJob, interval time.Duration, logger log.Logger) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := job.RunOnce(ctx)
+			if err != nil {
+				level.Error(logger).Log("err", err)
+				continue
+			}
+			logger.Log("purged", purged)
+		}
+	}
+}
+
+// runPasswordResets sends the password reset tokens as they are requested,
+// so the requests do not wait for the lookup of the email or the notifier.
+func runPasswordResets(ctx context.Context, s *auth.Service, logger log.Logger) {
+
+	for {
+		if err := s.DeliverPasswordReset(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			level.Error(logger).Log("err", err)
+		}
+	}
+}
+
+// runEventRelay publishes, on every tick, the user events waiting in the
+// outbox.
+func runEventRelay(ctx context.Context, relay *events.Relay, interval time.Duration, logger log.Logger) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := relay.RunOnce(ctx); err != nil {
+				level.Error(logger).Log("err", err)
+			}
+		}
+	}
+}
+
+// runWebhookDeliveries attempts, on every tick, the webhook deliveries that
+// are due.
+func runWebhookDeliveries(ctx context.Context, dispatcher *webhooks.Dispatcher, interval time.Duration, logger log.Logger) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := dispatcher.RunOnce(ctx); err != nil {
+				level.Error(logger).Log("err", err)
+			}
 		}
-		return repo
 	}
-	return nil
 }
 
 type config struct {
-	Port int `env:"GRPCSERVICE_PORT" envDefault:"9000"`
+	Port                   int           `env:"GRPCSERVICE_PORT" envDefault:"9000"`
+	RetentionPeriod        time.Duration `env:"USERS_RETENTION_PERIOD" envDefault:"720h"`
+	RetentionInterval      time.Duration `env:"USERS_RETENTION_INTERVAL" envDefault:"1h"`
+	EventsFile             string        `env:"USERS_EVENTS_FILE"`
+	EventsRelayInterval    time.Duration `env:"USERS_EVENTS_RELAY_INTERVAL" envDefault:"1s"`
+	EventsRelayBatch       int           `env:"USERS_EVENTS_RELAY_BATCH" envDefault:"100"`
+	WebhooksInterval       time.Duration `env:"USERS_WEBHOOKS_INTERVAL" envDefault:"1s"`
+	WebhooksBatch          int           `env:"USERS_WEBHOOKS_BATCH" envDefault:"100"`
+	WebhooksTimeout        time.Duration `env:"USERS_WEBHOOKS_TIMEOUT" envDefault:"10s"`
+	WebhooksMaxAttempts    int           `env:"USERS_WEBHOOKS_MAX_ATTEMPTS" envDefault:"8"`
+	WebhooksInitialBackoff time.Duration `env:"USERS_WEBHOOKS_INITIAL_BACKOFF" envDefault:"30s"`
+	WebhooksMaxBackoff     time.Duration `env:"USERS_WEBHOOKS_MAX_BACKOFF" envDefault:"1h"`
+	Notifier               string        `env:"USERS_NOTIFIER"`
+	NotificationsFile      string        `env:"USERS_NOTIFICATIONS_FILE"`
+	EmailTokenTTL          time.Duration `env:"USERS_EMAIL_TOKEN_TTL" envDefault:"24h"`
+	EmailAliasGrace        time.Duration `env:"USERS_EMAIL_ALIAS_GRACE" envDefault:"720h"`
+	VerificationSecret     string        `env:"USERS_VERIFICATION_SECRET"`
+	GatewaySecret          string        `env:"GATEWAY_SECRET"`
+	VerificationTokenTTL   time.Duration `env:"USERS_VERIFICATION_TOKEN_TTL" envDefault:"48h"`
+	AuthPrivateKeyFile     string        `env:"AUTH_PRIVATE_KEY_FILE"`
+	AuthIssuer             string        `env:"AUTH_ISSUER" envDefault:"users"`
+	AuthAccessTTL          time.Duration `env:"AUTH_ACCESS_TTL" envDefault:"15m"`
+	AuthRefreshTTL         time.Duration `env:"AUTH_REFRESH_TTL" envDefault:"720h"`
+	AuthMaxFailures        int           `env:"AUTH_MAX_FAILURES" envDefault:"5"`
+	AuthLockout            time.Duration `env:"AUTH_LOCKOUT" envDefault:"15m"`
+	AuthPasswordHasher     string        `env:"AUTH_PASSWORD_HASHER" envDefault:"argon2id"`
+	AuthBootstrapAdmins    []string      `env:"AUTH_BOOTSTRAP_ADMINS" envSeparator:","`
+	AuthResetTokenTTL      time.Duration `env:"AUTH_RESET_TOKEN_TTL" envDefault:"1h"`
+	AuthResetWindow        time.Duration `env:"AUTH_RESET_WINDOW" envDefault:"1h"`
+	AuthResetPerEmail      int           `env:"AUTH_RESET_PER_EMAIL" envDefault:"3"`
+	AuthResetPerAddress    int           `env:"AUTH_RESET_PER_ADDRESS" envDefault:"20"`
+	AuthResetQueue         int           `env:"AUTH_RESET_QUEUE" envDefault:"100"`
+	AuthMFAChallengeTTL    time.Duration `env:"AUTH_MFA_CHALLENGE_TTL" envDefault:"5m"`
+	AuthAPIKeyUsage        time.Duration `env:"AUTH_API_KEY_USAGE_RESOLUTION" envDefault:"1m"`
+	MetricsAddr            string        `env:"GRPCSERVICE_METRICS_ADDR" envDefault:":9100"`
+	TracingURL             string        `env:"TRACING_ZIPKIN_URL"`
+	TracingServiceName     string        `env:"TRACING_SERVICE_NAME" envDefault:"users-grpc"`
+	TracingHost            string        `env:"TRACING_HOST" envDefault:"localhost"`
+	TracingSampleRate      float64       `env:"TRACING_SAMPLE_RATE" envDefault:"1"`
+	TracingProvider        string        `env:"TRACING_PROVIDER" envDefault:"zipkin"`
+	TracingExporter        string        `env:"TRACING_OTEL_EXPORTER" envDefault:"otlp"`
+	TracingEndpoint        string        `env:"TRACING_OTLP_ENDPOINT" envDefault:"localhost:4317"`
+	TracingInsecure        bool          `env:"TRACING_OTLP_INSECURE" envDefault:"true"`
+	TracingFile            string        `env:"TRACING_OTEL_FILE"`
+	LogLevel               string        `env:"LOG_LEVEL" envDefault:"info"`
+	LogFormat              string        `env:"LOG_FORMAT" envDefault:"logfmt"`
 }
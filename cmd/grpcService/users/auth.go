@@ -0,0 +1,880 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/tracing/zipkin"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	stdzipkin "github.com/openzipkin/zipkin-go"
+
+	proto "github.com/casmelad/GlobantPOC/api/users/v1"
+	"github.com/casmelad/GlobantPOC/cmd/grpcService/users/mappers"
+	"github.com/casmelad/GlobantPOC/pkg/auth"
+	"github.com/casmelad/GlobantPOC/pkg/logging"
+	domain "github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+const (
+	// PasswordGrant issues the tokens of a user with its email and password.
+	PasswordGrant = "password"
+	// RefreshTokenGrant issues new tokens with a refresh token, which is rotated.
+	RefreshTokenGrant = "refresh_token"
+	// MFAGrant issues the tokens of a user with the MFA token of its login and a second factor code.
+	MFAGrant = "mfa"
+)
+
+// errUnsupportedGrant is returned for a token request of an unknown grant type.
+var errUnsupportedGrant = errors.New("unsupported grant type")
+
+// authenticator checks the passwords and issues the tokens, it is
+// implemented by auth.Service.
+type authenticator interface {
+	Login(context.Context, string, string) (auth.Tokens, error)
+	Refresh(context.Context, string) (auth.Tokens, error)
+	Revoke(context.Context, string) error
+	SetPassword(context.Context, string, string) error
+	SetRoles(context.Context, string, []string) error
+	RequestPasswordReset(context.Context, string) error
+	ConfirmPasswordReset(context.Context, string, string) error
+	VerifyMFA(context.Context, string, string) (auth.Tokens, error)
+	EnrollTOTP(context.Context, string) (auth.Enrollment, error)
+	ConfirmTOTP(context.Context, string, string) ([]string, error)
+	RegenerateRecoveryCodes(context.Context, string) ([]string, error)
+	DisableMFA(context.Context, string) error
+	CreateAPIKey(context.Context, string, []string, *time.Time) (auth.APIKey, string, error)
+	ListAPIKeys(context.Context) ([]auth.APIKey, error)
+	RevokeAPIKey(context.Context, string) error
+	AuthenticateAPIKey(context.Context, string) (auth.APIKey, error)
+}
+
+type grpcAuthServerEndpoints struct {
+	TokenEndpoint        endpoint.Endpoint
+	RevokeEndpoint       endpoint.Endpoint
+	SetPasswordEndpoint  endpoint.Endpoint
+	SetRolesEndpoint     endpoint.Endpoint
+	RequestResetEndpoint endpoint.Endpoint
+	ConfirmResetEndpoint endpoint.Endpoint
+	EnrollTOTPEndpoint   endpoint.Endpoint
+	ConfirmTOTPEndpoint  endpoint.Endpoint
+	RecoveryEndpoint     endpoint.Endpoint
+	DisableMFAEndpoint   endpoint.Endpoint
+	CreateAPIKeyEndpoint endpoint.Endpoint
+	ListAPIKeysEndpoint  endpoint.Endpoint
+	RevokeAPIKeyEndpoint endpoint.Endpoint
+	AuthAPIKeyEndpoint   endpoint.Endpoint
+}
+
+func NewGrpcAuthEndpoints(s authenticator, middleware ...endpoint.Middleware) *grpcAuthServerEndpoints {
+	mw := chain(middleware)
+
+	return &grpcAuthServerEndpoints{
+		TokenEndpoint:        mw(MakeTokenEndpoint(s)),
+		RevokeEndpoint:       mw(MakeRevokeEndpoint(s)),
+		SetPasswordEndpoint:  mw(MakeSetPasswordEndpoint(s)),
+		SetRolesEndpoint:     mw(MakeSetRolesEndpoint(s)),
+		RequestResetEndpoint: mw(MakeRequestResetEndpoint(s)),
+		ConfirmResetEndpoint: mw(MakeConfirmResetEndpoint(s)),
+		EnrollTOTPEndpoint:   mw(MakeEnrollTOTPEndpoint(s)),
+		ConfirmTOTPEndpoint:  mw(MakeConfirmTOTPEndpoint(s)),
+		RecoveryEndpoint:     mw(MakeRecoveryCodesEndpoint(s)),
+		DisableMFAEndpoint:   mw(MakeDisableMFAEndpoint(s)),
+		CreateAPIKeyEndpoint: mw(MakeCreateAPIKeyEndpoint(s)),
+		ListAPIKeysEndpoint:  mw(MakeListAPIKeysEndpoint(s)),
+		RevokeAPIKeyEndpoint: mw(MakeRevokeAPIKeyEndpoint(s)),
+		AuthAPIKeyEndpoint:   mw(MakeAuthenticateAPIKeyEndpoint(s)),
+	}
+}
+
+func MakeTokenEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(tokenRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		var tokens auth.Tokens
+
+		switch reqData.GrantType {
+		case PasswordGrant:
+			tokens, err = s.Login(ctx, reqData.Email, reqData.Password)
+		case RefreshTokenGrant:
+			tokens, err = s.Refresh(ctx, reqData.RefreshToken)
+		case MFAGrant:
+			tokens, err = s.VerifyMFA(ctx, reqData.MFAToken, reqData.Code)
+		default:
+			err = errUnsupportedGrant
+		}
+
+		return tokenResponse{Tokens: tokens, Error: err}, nil
+	}
+}
+
+func MakeRevokeEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(revokeRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		err = s.Revoke(ctx, reqData.RefreshToken)
+
+		return revokeResponse{Error: err}, nil
+	}
+}
+
+func MakeSetPasswordEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(setPasswordRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		err = s.SetPassword(ctx, reqData.Email, reqData.Password)
+
+		return setPasswordResponse{Error: err}, nil
+	}
+}
+
+func MakeSetRolesEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(setRolesRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		err = s.SetRoles(ctx, reqData.Email, reqData.Roles)
+
+		return setRolesResponse{Error: err}, nil
+	}
+}
+
+func MakeRequestResetEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(requestResetRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		err = s.RequestPasswordReset(ctx, reqData.Email)
+
+		return requestResetResponse{Error: err}, nil
+	}
+}
+
+func MakeConfirmResetEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(confirmResetRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		err = s.ConfirmPasswordReset(ctx, reqData.Token, reqData.Password)
+
+		return confirmResetResponse{Error: err}, nil
+	}
+}
+
+func MakeEnrollTOTPEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(mfaRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		enrollment, err := s.EnrollTOTP(ctx, reqData.Email)
+
+		return enrollTOTPResponse{Enrollment: enrollment, Error: err}, nil
+	}
+}
+
+func MakeConfirmTOTPEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(confirmTOTPRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		codes, err := s.ConfirmTOTP(ctx, reqData.Email, reqData.Code)
+
+		return recoveryCodesResponse{Codes: codes, Error: err}, nil
+	}
+}
+
+func MakeRecoveryCodesEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(mfaRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		codes, err := s.RegenerateRecoveryCodes(ctx, reqData.Email)
+
+		return recoveryCodesResponse{Codes: codes, Error: err}, nil
+	}
+}
+
+func MakeDisableMFAEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(mfaRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		err = s.DisableMFA(ctx, reqData.Email)
+
+		return disableMFAResponse{Error: err}, nil
+	}
+}
+
+func MakeCreateAPIKeyEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(createAPIKeyRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		key, secret, err := s.CreateAPIKey(ctx, reqData.Name, reqData.Scopes, reqData.ExpiresAt)
+
+		return createAPIKeyResponse{APIKey: key, Key: secret, Error: err}, nil
+	}
+}
+
+func MakeListAPIKeysEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		if _, validCast := request.(listAPIKeysRequest); !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		keys, err := s.ListAPIKeys(ctx)
+
+		return listAPIKeysResponse{APIKeys: keys, Error: err}, nil
+	}
+}
+
+func MakeRevokeAPIKeyEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(revokeAPIKeyRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		err = s.RevokeAPIKey(ctx, reqData.ID)
+
+		return revokeAPIKeyResponse{Error: err}, nil
+	}
+}
+
+func MakeAuthenticateAPIKeyEndpoint(s authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(authenticateAPIKeyRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		key, err := s.AuthenticateAPIKey(ctx, reqData.Key)
+
+		return authenticateAPIKeyResponse{APIKey: key, Error: err}, nil
+	}
+}
+
+type grpcAuthServer struct {
+	proto.AuthServer
+	token        grpctransport.Handler
+	revoke       grpctransport.Handler
+	setPassword  grpctransport.Handler
+	setRoles     grpctransport.Handler
+	requestReset grpctransport.Handler
+	confirmReset grpctransport.Handler
+	enrollTOTP   grpctransport.Handler
+	confirmTOTP  grpctransport.Handler
+	recovery     grpctransport.Handler
+	disableMFA   grpctransport.Handler
+	createAPIKey grpctransport.Handler
+	listAPIKeys  grpctransport.Handler
+	revokeAPIKey grpctransport.Handler
+	authAPIKey   grpctransport.Handler
+}
+
+func NewGrpcAuthServer(endpoints grpcAuthServerEndpoints, clock domain.Clock, zipkinTracer *stdzipkin.Tracer, logger log.Logger) proto.AuthServer {
+
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorHandler(logging.NewErrorHandler(logger)),
+		grpctransport.ServerBefore(callerFromMetadata, requestFromMetadata),
+	}
+
+	if zipkinTracer != nil {
+		options = append(options, zipkin.GRPCServerTrace(zipkinTracer))
+	}
+
+	return &grpcAuthServer{
+		token:        grpctransport.NewServer(endpoints.TokenEndpoint, decodeTokenRequest, encodeTokenResponse(clock), options...),
+		revoke:       grpctransport.NewServer(endpoints.RevokeEndpoint, decodeRevokeRequest, encodeRevokeResponse, options...),
+		setPassword:  grpctransport.NewServer(endpoints.SetPasswordEndpoint, decodeSetPasswordRequest, encodeSetPasswordResponse, options...),
+		setRoles:     grpctransport.NewServer(endpoints.SetRolesEndpoint, decodeSetRolesRequest, encodeSetRolesResponse, options...),
+		requestReset: grpctransport.NewServer(endpoints.RequestResetEndpoint, decodeRequestResetRequest, encodeRequestResetResponse, options...),
+		confirmReset: grpctransport.NewServer(endpoints.ConfirmResetEndpoint, decodeConfirmResetRequest, encodeConfirmResetResponse, options...),
+		enrollTOTP:   grpctransport.NewServer(endpoints.EnrollTOTPEndpoint, decodeMFARequest, encodeEnrollTOTPResponse, options...),
+		confirmTOTP:  grpctransport.NewServer(endpoints.ConfirmTOTPEndpoint, decodeConfirmTOTPRequest, encodeRecoveryCodesResponse, options...),
+		recovery:     grpctransport.NewServer(endpoints.RecoveryEndpoint, decodeMFARequest, encodeRecoveryCodesResponse, options...),
+		disableMFA:   grpctransport.NewServer(endpoints.DisableMFAEndpoint, decodeMFARequest, encodeDisableMFAResponse, options...),
+		createAPIKey: grpctransport.NewServer(endpoints.CreateAPIKeyEndpoint, decodeCreateAPIKeyRequest, encodeCreateAPIKeyResponse, options...),
+		listAPIKeys:  grpctransport.NewServer(endpoints.ListAPIKeysEndpoint, decodeListAPIKeysRequest, encodeListAPIKeysResponse, options...),
+		revokeAPIKey: grpctransport.NewServer(endpoints.RevokeAPIKeyEndpoint, decodeRevokeAPIKeyRequest, encodeRevokeAPIKeyResponse, options...),
+		authAPIKey:   grpctransport.NewServer(endpoints.AuthAPIKeyEndpoint, decodeAuthenticateAPIKeyRequest, encodeAuthenticateAPIKeyResponse, options...),
+	}
+}
+
+func (a grpcAuthServer) Token(ctx context.Context, req *proto.TokenRequest) (*proto.TokenResponse, error) {
+
+	_, grpcResponse, err := a.token.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.TokenResponse), nil
+}
+
+func (a grpcAuthServer) Revoke(ctx context.Context, req *proto.RevokeRequest) (*proto.RevokeResponse, error) {
+
+	_, grpcResponse, err := a.revoke.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.RevokeResponse), nil
+}
+
+func (a grpcAuthServer) SetPassword(ctx context.Context, req *proto.SetPasswordRequest) (*proto.SetPasswordResponse, error) {
+
+	_, grpcResponse, err := a.setPassword.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.SetPasswordResponse), nil
+}
+
+func (a grpcAuthServer) SetRoles(ctx context.Context, req *proto.SetRolesRequest) (*proto.SetRolesResponse, error) {
+
+	_, grpcResponse, err := a.setRoles.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.SetRolesResponse), nil
+}
+
+func (a grpcAuthServer) RequestPasswordReset(ctx context.Context, req *proto.PasswordResetRequest) (*proto.PasswordResetResponse, error) {
+
+	_, grpcResponse, err := a.requestReset.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.PasswordResetResponse), nil
+}
+
+func (a grpcAuthServer) ConfirmPasswordReset(ctx context.Context, req *proto.ConfirmPasswordResetRequest) (*proto.ConfirmPasswordResetResponse, error) {
+
+	_, grpcResponse, err := a.confirmReset.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.ConfirmPasswordResetResponse), nil
+}
+
+func (a grpcAuthServer) EnrollTOTP(ctx context.Context, req *proto.MFARequest) (*proto.EnrollTOTPResponse, error) {
+
+	_, grpcResponse, err := a.enrollTOTP.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.EnrollTOTPResponse), nil
+}
+
+func (a grpcAuthServer) ConfirmTOTP(ctx context.Context, req *proto.ConfirmTOTPRequest) (*proto.RecoveryCodesResponse, error) {
+
+	_, grpcResponse, err := a.confirmTOTP.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.RecoveryCodesResponse), nil
+}
+
+func (a grpcAuthServer) RegenerateRecoveryCodes(ctx context.Context, req *proto.MFARequest) (*proto.RecoveryCodesResponse, error) {
+
+	_, grpcResponse, err := a.recovery.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.RecoveryCodesResponse), nil
+}
+
+func (a grpcAuthServer) DisableMFA(ctx context.Context, req *proto.MFARequest) (*proto.DisableMFAResponse, error) {
+
+	_, grpcResponse, err := a.disableMFA.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.DisableMFAResponse), nil
+}
+
+func (a grpcAuthServer) CreateAPIKey(ctx context.Context, req *proto.CreateAPIKeyRequest) (*proto.CreateAPIKeyResponse, error) {
+
+	_, grpcResponse, err := a.createAPIKey.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.CreateAPIKeyResponse), nil
+}
+
+func (a grpcAuthServer) ListAPIKeys(ctx context.Context, req *proto.ListAPIKeysRequest) (*proto.ListAPIKeysResponse, error) {
+
+	_, grpcResponse, err := a.listAPIKeys.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.ListAPIKeysResponse), nil
+}
+
+func (a grpcAuthServer) RevokeAPIKey(ctx context.Context, req *proto.APIKeyId) (*proto.RevokeAPIKeyResponse, error) {
+
+	_, grpcResponse, err := a.revokeAPIKey.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.RevokeAPIKeyResponse), nil
+}
+
+func (a grpcAuthServer) AuthenticateAPIKey(ctx context.Context, req *proto.AuthenticateAPIKeyRequest) (*proto.AuthenticateAPIKeyResponse, error) {
+
+	_, grpcResponse, err := a.authAPIKey.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.AuthenticateAPIKeyResponse), nil
+}
+
+func decodeTokenRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.TokenRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return tokenRequest{
+		GrantType:    reqData.GrantType,
+		Email:        reqData.Email,
+		Password:     reqData.Password,
+		RefreshToken: reqData.RefreshToken,
+		MFAToken:     reqData.MfaToken,
+		Code:         reqData.Code,
+	}, nil
+}
+
+// encodeTokenResponse returns the encoder of the token responses, the
+// lifetime of the access token is counted from the time of the clock.
+func encodeTokenResponse(clock domain.Clock) grpctransport.EncodeResponseFunc {
+	return func(ctx context.Context, resp interface{}) (interface{}, error) {
+		respData, validCast := resp.(tokenResponse)
+
+		if !validCast {
+			return nil, errors.New("invalid input data to encode")
+		}
+
+		if respData.Error != nil {
+			return &proto.TokenResponse{Code: authCode(respData.Error)}, nil
+		}
+
+		if respData.Tokens.MFAToken != "" {
+			return &proto.TokenResponse{Code: proto.CodeResult_OK, MfaToken: respData.Tokens.MFAToken}, nil
+		}
+
+		return &proto.TokenResponse{
+			Code:         proto.CodeResult_OK,
+			AccessToken:  respData.Tokens.AccessToken,
+			TokenType:    respData.Tokens.TokenType,
+			ExpiresIn:    int64(respData.Tokens.ExpiresAt.Sub(clock.Now()) / time.Second),
+			RefreshToken: respData.Tokens.RefreshToken,
+		}, nil
+	}
+}
+
+func decodeRevokeRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.RevokeRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return revokeRequest{RefreshToken: reqData.RefreshToken}, nil
+}
+
+func encodeRevokeResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(revokeResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.RevokeResponse{Code: authCode(respData.Error)}, nil
+	}
+
+	return &proto.RevokeResponse{Code: proto.CodeResult_OK}, nil
+}
+
+func decodeSetPasswordRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.SetPasswordRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return setPasswordRequest{Email: reqData.Email, Password: reqData.Password}, nil
+}
+
+func encodeSetPasswordResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(setPasswordResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		response := &proto.SetPasswordResponse{Code: authCode(respData.Error)}
+		// The policy violation is told back so the user can pick another password.
+		if errors.Is(respData.Error, auth.ErrWeakPassword) {
+			response.Message = respData.Error.Error()
+		}
+		return response, nil
+	}
+
+	return &proto.SetPasswordResponse{Code: proto.CodeResult_OK}, nil
+}
+
+func decodeSetRolesRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.SetRolesRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return setRolesRequest{Email: reqData.Email, Roles: reqData.Roles}, nil
+}
+
+func encodeSetRolesResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(setRolesResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.SetRolesResponse{Code: authCode(respData.Error)}, nil
+	}
+
+	return &proto.SetRolesResponse{Code: proto.CodeResult_OK}, nil
+}
+
+func decodeRequestResetRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.PasswordResetRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return requestResetRequest{Email: reqData.Email}, nil
+}
+
+func encodeRequestResetResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(requestResetResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.PasswordResetResponse{Code: authCode(respData.Error)}, nil
+	}
+
+	return &proto.PasswordResetResponse{Code: proto.CodeResult_OK}, nil
+}
+
+func decodeConfirmResetRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.ConfirmPasswordResetRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return confirmResetRequest{Token: reqData.Token, Password: reqData.Password}, nil
+}
+
+func encodeConfirmResetResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(confirmResetResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		response := &proto.ConfirmPasswordResetResponse{Code: authCode(respData.Error)}
+		if errors.Is(respData.Error, auth.ErrWeakPassword) {
+			response.Message = respData.Error.Error()
+		}
+		return response, nil
+	}
+
+	return &proto.ConfirmPasswordResetResponse{Code: proto.CodeResult_OK}, nil
+}
+
+func decodeMFARequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.MFARequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return mfaRequest{Email: reqData.Email}, nil
+}
+
+func encodeEnrollTOTPResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(enrollTOTPResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.EnrollTOTPResponse{Code: authCode(respData.Error)}, nil
+	}
+
+	return &proto.EnrollTOTPResponse{
+		Code:   proto.CodeResult_OK,
+		Secret: respData.Enrollment.Secret,
+		Uri:    respData.Enrollment.URI,
+	}, nil
+}
+
+func decodeConfirmTOTPRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.ConfirmTOTPRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return confirmTOTPRequest{Email: reqData.Email, Code: reqData.Code}, nil
+}
+
+func encodeRecoveryCodesResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(recoveryCodesResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.RecoveryCodesResponse{Code: authCode(respData.Error)}, nil
+	}
+
+	return &proto.RecoveryCodesResponse{Code: proto.CodeResult_OK, RecoveryCodes: respData.Codes}, nil
+}
+
+func encodeDisableMFAResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(disableMFAResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.DisableMFAResponse{Code: authCode(respData.Error)}, nil
+	}
+
+	return &proto.DisableMFAResponse{Code: proto.CodeResult_OK}, nil
+}
+
+func decodeCreateAPIKeyRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.CreateAPIKeyRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	request := createAPIKeyRequest{Name: reqData.Name, Scopes: reqData.Scopes}
+
+	if reqData.ExpiresAt != nil {
+		expiresAt := reqData.ExpiresAt.AsTime()
+		request.ExpiresAt = &expiresAt
+	}
+
+	return request, nil
+}
+
+func encodeCreateAPIKeyResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(createAPIKeyResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.CreateAPIKeyResponse{Code: authCode(respData.Error)}, nil
+	}
+
+	return &proto.CreateAPIKeyResponse{
+		Code:   proto.CodeResult_OK,
+		ApiKey: mappers.ToGrpcAPIKey(respData.APIKey),
+		Key:    respData.Key,
+	}, nil
+}
+
+func decodeListAPIKeysRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	if _, validCast := req.(*proto.ListAPIKeysRequest); !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return listAPIKeysRequest{}, nil
+}
+
+func encodeListAPIKeysResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(listAPIKeysResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.ListAPIKeysResponse{Code: authCode(respData.Error)}, nil
+	}
+
+	response := &proto.ListAPIKeysResponse{Code: proto.CodeResult_OK, ApiKeys: []*proto.APIKey{}}
+
+	for _, key := range respData.APIKeys {
+		response.ApiKeys = append(response.ApiKeys, mappers.ToGrpcAPIKey(key))
+	}
+
+	return response, nil
+}
+
+func decodeRevokeAPIKeyRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.APIKeyId)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return revokeAPIKeyRequest{ID: reqData.Id}, nil
+}
+
+func encodeRevokeAPIKeyResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(revokeAPIKeyResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.RevokeAPIKeyResponse{Code: authCode(respData.Error)}, nil
+	}
+
+	return &proto.RevokeAPIKeyResponse{Code: proto.CodeResult_OK}, nil
+}
+
+func decodeAuthenticateAPIKeyRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.AuthenticateAPIKeyRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return authenticateAPIKeyRequest{Key: reqData.Key}, nil
+}
+
+func encodeAuthenticateAPIKeyResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(authenticateAPIKeyResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.AuthenticateAPIKeyResponse{Code: authCode(respData.Error)}, nil
+	}
+
+	return &proto.AuthenticateAPIKeyResponse{Code: proto.CodeResult_OK, ApiKey: mappers.ToGrpcAPIKey(respData.APIKey)}, nil
+}
+
+// authCode translates the errors of the auth service into the result code
+// of the response. Unknown emails and wrong passwords share a code so the
+// response does not tell which users exist.
+func authCode(err error) proto.CodeResult {
+	if domain.IsUserErrorType(domain.ERRFORBIDDEN, err) {
+		return proto.CodeResult_FORBIDDEN
+	}
+	if domain.IsUserErrorType(domain.ERRNOTFOUND, err) {
+		return proto.CodeResult_NOTFOUND
+	}
+	if errors.Is(err, auth.ErrWeakPassword) {
+		return proto.CodeResult_INVALIDINPUT
+	}
+	switch err {
+	case auth.ErrInvalidCredentials, auth.ErrInvalidRefreshToken, auth.ErrAccountInactive,
+		auth.ErrInvalidMFAToken, auth.ErrInvalidMFACode, auth.ErrInvalidAPIKey:
+		return proto.CodeResult_UNAUTHENTICATED
+	case auth.ErrAccountLocked:
+		return proto.CodeResult_LOCKED
+	case auth.ErrMFAAlreadyEnabled:
+		return proto.CodeResult_CONFLICT
+	case auth.ErrAPIKeyNotFound:
+		return proto.CodeResult_NOTFOUND
+	case errUnsupportedGrant, auth.ErrInvalidResetToken, auth.ErrMFANotEnabled, auth.ErrMFADisabled,
+		auth.ErrInvalidAPIKeyRequest, auth.ErrAPIKeysDisabled:
+		return proto.CodeResult_INVALIDINPUT
+	case auth.ErrTooManyRequests:
+		return proto.CodeResult_TOOMANYREQUESTS
+	default:
+		return proto.CodeResult_FAILED
+	}
+}
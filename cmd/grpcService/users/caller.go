@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	domain "github.com/casmelad/GlobantPOC/pkg/users"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	callerSubjectMetadataKey = "x-caller-subject"
+	callerRolesMetadataKey   = "x-caller-roles"
+	callerAMRMetadataKey     = "x-caller-amr"
+	requestIDMetadataKey     = "x-request-id"
+	transportMetadataKey     = "x-source-transport"
+	clientAddressMetadataKey = "x-client-address"
+)
+
+// callerFromMetadata is a transport/grpc.ServerRequestFunc that moves the
+// identity of the authenticated caller, forwarded by the REST gateway as
+// metadata, into the request context used by the domain service. The
+// GatewayAuthenticator drops that metadata unless the gateway signed it.
+func callerFromMetadata(ctx context.Context, md metadata.MD) context.Context {
+	subjects := md.Get(callerSubjectMetadataKey)
+	if len(subjects) == 0 || subjects[0] == "" {
+		return ctx
+	}
+
+	caller := domain.Caller{
+		Subject: subjects[0],
+		Roles:   splitMetadata(md.Get(callerRolesMetadataKey)),
+		Methods: splitMetadata(md.Get(callerAMRMetadataKey)),
+	}
+
+	return domain.WithCaller(ctx, caller)
+}
+
+// splitMetadata reads the comma separated values of a metadata key.
+func splitMetadata(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+// requestFromMetadata is a transport/grpc.ServerRequestFunc that moves the id
+// of the request, the transport it came from and the address of the client
+// into the request context. Requests without a forwarded transport were made
+// directly over gRPC, their client is the peer of the connection.
+func requestFromMetadata(ctx context.Context, md metadata.MD) context.Context {
+	if ids := md.Get(requestIDMetadataKey); len(ids) > 0 && ids[0] != "" {
+		ctx = domain.WithRequestID(ctx, ids[0])
+	}
+
+	transport := domain.TransportGRPC
+
+	if transports := md.Get(transportMetadataKey); len(transports) > 0 && transports[0] != "" {
+		transport = transports[0]
+	}
+
+	if addresses := md.Get(clientAddressMetadataKey); transport != domain.TransportGRPC && len(addresses) > 0 && addresses[0] != "" {
+		ctx = domain.WithClientAddress(ctx, addresses[0])
+	} else if p, ok := peer.FromContext(ctx); ok {
+		ctx = domain.WithClientAddress(ctx, hostOf(p.Addr.String()))
+	}
+
+	return domain.WithTransport(ctx, transport)
+}
+
+// hostOf strips the port from a network address.
+func hostOf(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
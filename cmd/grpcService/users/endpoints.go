@@ -3,8 +3,8 @@ package grpc
 import (
 	"context"
 	"errors"
-	"fmt"
 
+	"github.com/casmelad/GlobantPOC/pkg/audit"
 	domain "github.com/casmelad/GlobantPOC/pkg/users"
 	"github.com/go-kit/kit/endpoint"
 )
@@ -15,15 +15,37 @@ type grpcUserServerEndpoints struct {
 	UpdateUserEndpoint     endpoint.Endpoint
 	DeleteUserEndpoint     endpoint.Endpoint
 	GetAllUsersEndpoint    endpoint.Endpoint
+	RestoreUserEndpoint    endpoint.Endpoint
+	PurgeUserEndpoint      endpoint.Endpoint
+	UserHistoryEndpoint    endpoint.Endpoint
+	RequestEmailEndpoint   endpoint.Endpoint
+	ConfirmEmailEndpoint   endpoint.Endpoint
+	SendVerifyEndpoint     endpoint.Endpoint
+	VerifyEmailEndpoint    endpoint.Endpoint
+	SuspendEndpoint        endpoint.Endpoint
+	ReactivateEndpoint     endpoint.Endpoint
+	DeactivateEndpoint     endpoint.Endpoint
 }
 
-func NewGrpcUsersServer(s domain.Service) *grpcUserServerEndpoints {
+func NewGrpcUsersServer(s domain.Service, h audit.Historian, middleware ...endpoint.Middleware) *grpcUserServerEndpoints {
+	mw := chain(middleware)
+
 	return &grpcUserServerEndpoints{
-		CreateUserEndpoint:     MakePostUserEndpoint(s),
-		GetUserByEmailEndpoint: MakeGetUserEndpoint(s),
-		UpdateUserEndpoint:     MakeUpdateUserEndpoint(s),
-		DeleteUserEndpoint:     MakeDeleteUserEndpoint(s),
-		GetAllUsersEndpoint:    MakeGetAllUsersEndpoint(s),
+		CreateUserEndpoint:     mw(MakePostUserEndpoint(s)),
+		GetUserByEmailEndpoint: mw(MakeGetUserEndpoint(s)),
+		UpdateUserEndpoint:     mw(MakeUpdateUserEndpoint(s)),
+		DeleteUserEndpoint:     mw(MakeDeleteUserEndpoint(s)),
+		GetAllUsersEndpoint:    mw(MakeGetAllUsersEndpoint(s)),
+		RestoreUserEndpoint:    mw(MakeRestoreUserEndpoint(s)),
+		PurgeUserEndpoint:      mw(MakePurgeUserEndpoint(s)),
+		UserHistoryEndpoint:    mw(MakeUserHistoryEndpoint(h)),
+		RequestEmailEndpoint:   mw(MakeRequestEmailChangeEndpoint(s)),
+		ConfirmEmailEndpoint:   mw(MakeConfirmEmailChangeEndpoint(s)),
+		SendVerifyEndpoint:     mw(MakeSendVerificationEndpoint(s)),
+		VerifyEmailEndpoint:    mw(MakeVerifyEmailEndpoint(s)),
+		SuspendEndpoint:        mw(MakeChangeStatusEndpoint(s.Suspend)),
+		ReactivateEndpoint:     mw(MakeChangeStatusEndpoint(s.Reactivate)),
+		DeactivateEndpoint:     mw(MakeChangeStatusEndpoint(s.Deactivate)),
 	}
 }
 
@@ -38,8 +60,6 @@ func MakePostUserEndpoint(s domain.Service) endpoint.Endpoint {
 
 		usrID, err := s.Create(ctx, usr)
 
-		fmt.Println("Cool", postUserResponse{Id: usrID, Error: err})
-
 		return postUserResponse{Id: usrID, Error: err}, nil
 	}
 }
@@ -54,23 +74,26 @@ func MakeGetUserEndpoint(s domain.Service) endpoint.Endpoint {
 
 		usr, err := s.GetByEmail(ctx, reqData.Email)
 
-		return getUserResponse{User: User{Id: int32(usr.ID), Email: usr.Email, Name: usr.Name, LastName: usr.LastName}}, nil
+		return getUserResponse{User: newUser(usr)}, nil
 	}
 }
 
 func MakeGetAllUsersEndpoint(s domain.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 
-		usrs, err := s.GetAll(ctx)
+		reqData, validCast := request.(GetAllUsersRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+
+		usrs, err := s.GetAll(ctx, domain.Filter{IncludeDeleted: reqData.IncludeDeleted, Status: domain.AccountStatus(reqData.Status)})
 
 		responseData := getAllUsersResponse{Users: []User{}}
 
 		for _, usr := range usrs {
-			responseData.Users = append(responseData.Users, User{Id: int32(usr.ID), Email: usr.Email, Name: usr.Name, LastName: usr.LastName})
+			responseData.Users = append(responseData.Users, newUser(usr))
 		}
 
-		fmt.Println("users", responseData)
-
 		return responseData, nil
 	}
 }
@@ -78,24 +101,30 @@ func MakeGetAllUsersEndpoint(s domain.Service) endpoint.Endpoint {
 func MakeUpdateUserEndpoint(s domain.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 
-		fmt.Println("Llega a endpoint")
 		reqData, validCast := request.(updateUserRequest)
 
-		fmt.Println(reqData)
-
 		if !validCast {
 			return nil, errors.New("invalid request type")
 		}
 
-		usr := domain.User{Email: reqData.Email, Name: reqData.Name, LastName: reqData.LastName}
+		usr := domain.User{Email: reqData.Email, Name: reqData.Name, LastName: reqData.LastName, Version: int(reqData.Version)}
 
 		if err != nil {
 			return nil, errors.New("invalid object cast")
 		}
 
-		err = s.Update(ctx, usr)
+		if reqData.Paths != nil {
+			err = s.Patch(ctx, domain.Patch{Email: reqData.Email, Version: int(reqData.Version), Paths: reqData.Paths, Name: reqData.Name, LastName: reqData.LastName})
+		} else {
+			err = s.Update(ctx, usr)
+		}
+
+		if err != nil {
+			return updateUserResponse{Error: err}, nil
+		}
 
-		return updateUserResponse{Error: err}, nil
+		//The update only succeeds on the version the caller read, every write increments it.
+		return updateUserResponse{Version: reqData.Version + 1}, nil
 	}
 }
 
@@ -107,8 +136,128 @@ func MakeDeleteUserEndpoint(s domain.Service) endpoint.Endpoint {
 			return nil, errors.New("invalid request type")
 		}
 
-		err = s.Delete(ctx, int(reqData.Id))
+		err = s.Delete(ctx, int(reqData.Id), int(reqData.Version))
 
 		return deleteUserResponse{Error: err}, nil
 	}
 }
+
+func MakeRestoreUserEndpoint(s domain.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(restoreUserRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		err = s.Restore(ctx, int(reqData.Id))
+
+		return restoreUserResponse{Error: err}, nil
+	}
+}
+
+func MakePurgeUserEndpoint(s domain.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(purgeUserRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		err = s.Purge(ctx, int(reqData.Id))
+
+		return purgeUserResponse{Error: err}, nil
+	}
+}
+
+func MakeUserHistoryEndpoint(h audit.Historian) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(historyRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		entries, err := h.History(ctx, int(reqData.UserID), audit.Query{From: reqData.From, To: reqData.To, Actor: reqData.Actor})
+
+		return historyResponse{Entries: entries, Error: err}, nil
+	}
+}
+
+func MakeRequestEmailChangeEndpoint(s domain.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(emailChangeRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		err = s.RequestEmailChange(ctx, reqData.Email, reqData.NewEmail)
+
+		return emailChangeResponse{Error: err}, nil
+	}
+}
+
+func MakeConfirmEmailChangeEndpoint(s domain.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(confirmEmailChangeRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		change, err := s.ConfirmEmailChange(ctx, reqData.Token)
+
+		if err != nil {
+			return confirmEmailChangeResponse{Error: err}, nil
+		}
+
+		usr, err := s.GetByEmail(ctx, change.NewEmail)
+
+		return confirmEmailChangeResponse{User: newUser(usr), Error: err}, nil
+	}
+}
+
+func MakeSendVerificationEndpoint(s domain.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(sendVerificationRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		err = s.SendVerification(ctx, reqData.Email)
+
+		return sendVerificationResponse{Error: err}, nil
+	}
+}
+
+func MakeVerifyEmailEndpoint(s domain.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(verifyEmailRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		usr, err := s.VerifyEmail(ctx, reqData.Token)
+
+		return verifyEmailResponse{User: newUser(usr), Error: err}, nil
+	}
+}
+
+// MakeChangeStatusEndpoint serves the status transitions, they all take the
+// id of the user and differ only in the service method they call.
+func MakeChangeStatusEndpoint(transition func(context.Context, int) error) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(changeStatusRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		err = transition(ctx, int(reqData.Id))
+
+		return changeStatusResponse{Error: err}, nil
+	}
+}
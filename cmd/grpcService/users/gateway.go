@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/casmelad/GlobantPOC/pkg/gateway"
+	domain "github.com/casmelad/GlobantPOC/pkg/users"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// GatewayAuthenticator keeps the caller, the transport and the client address
+// forwarded in the metadata of a call only when the REST gateway signed them
+// for that method with the secret both share, and the signature was not used
+// before. Any peer can send that metadata, so the unsigned or replayed ones
+// are dropped and the call is served as an anonymous direct gRPC call. It has
+// to run before the go-kit interceptor so that callerFromMetadata and
+// requestFromMetadata only see trusted metadata.
+type GatewayAuthenticator struct {
+	secret []byte
+	clock  domain.Clock
+	replay *gateway.ReplayCache
+}
+
+// NewGatewayAuthenticator returns a GatewayAuthenticator type pointer, none of
+// the forwarded metadata is trusted when the secret is empty.
+func NewGatewayAuthenticator(secret string, clock domain.Clock) *GatewayAuthenticator {
+	return &GatewayAuthenticator{
+		secret: []byte(secret),
+		clock:  clock,
+		replay: gateway.NewReplayCache(),
+	}
+}
+
+// UnaryInterceptor drops the forwarded metadata of the unary calls that are
+// not signed by the gateway.
+func (g *GatewayAuthenticator) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(g.verify(ctx, info.FullMethod), req)
+}
+
+// StreamInterceptor is the UnaryInterceptor of the streams.
+func (g *GatewayAuthenticator) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, gatewayStream{ServerStream: ss, ctx: g.verify(ss.Context(), info.FullMethod)})
+}
+
+type gatewayStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s gatewayStream) Context() context.Context {
+	return s.ctx
+}
+
+// verify returns the context with its incoming metadata stripped of the
+// forwarded keys, unless their signature is valid for the method and seen
+// for the first time.
+func (g *GatewayAuthenticator) verify(ctx context.Context, method string) context.Context {
+
+	md, ok := metadata.FromIncomingContext(ctx)
+
+	if !ok {
+		return ctx
+	}
+
+	now := g.clock.Now()
+
+	if gateway.Verify(g.secret, method, md, now) && !g.replay.Seen(gateway.Nonce(md), now) {
+		return ctx
+	}
+
+	return metadata.NewIncomingContext(ctx, gateway.Strip(md))
+}
@@ -0,0 +1,124 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/gateway"
+	entities "github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+var gatewayNow = time.Date(2021, 11, 3, 10, 0, 0, 0, time.UTC)
+
+const gatewayMethod = "/users.Users/Delete"
+
+func forwardedMetadata() metadata.MD {
+	return metadata.Pairs(
+		callerSubjectMetadataKey, "7",
+		callerRolesMetadataKey, "admin",
+		callerAMRMetadataKey, "pwd,otp",
+		transportMetadataKey, entities.TransportREST,
+		clientAddressMetadataKey, "192.0.2.1")
+}
+
+func signedMetadata(secret string, method string) metadata.MD {
+	md := forwardedMetadata()
+	pairs, _ := gateway.SignMetadata([]byte(secret), method, md, gatewayNow)
+	return metadata.Join(md, metadata.Pairs(pairs...))
+}
+
+// interceptedContext returns the request context the go-kit handlers see
+// after the authenticator and the ServerBefore functions ran.
+func interceptedContext(secret string, md metadata.MD) context.Context {
+	return interceptedBy(NewGatewayAuthenticator(secret, fixedClock{now: gatewayNow}), md)
+}
+
+func interceptedBy(authenticator *GatewayAuthenticator, md metadata.MD) context.Context {
+	var result context.Context
+	authenticator.UnaryInterceptor(metadata.NewIncomingContext(context.Background(), md), nil, &grpc.UnaryServerInfo{FullMethod: gatewayMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		incoming, _ := metadata.FromIncomingContext(ctx)
+		result = requestFromMetadata(callerFromMetadata(ctx, incoming), incoming)
+		return nil, nil
+	})
+	return result
+}
+
+func Test_GatewayAuthenticator_SignedMetadata_IsTrusted(t *testing.T) {
+	//Arrange
+	md := signedMetadata("shared-secret", gatewayMethod)
+	//Act
+	ctx := interceptedContext("shared-secret", md)
+	//Assert
+	caller, ok := entities.CallerFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "7", caller.Subject)
+	assert.Equal(t, []string{"admin"}, caller.Roles)
+	assert.Equal(t, "192.0.2.1", entities.ClientAddressFromContext(ctx))
+	assert.Equal(t, entities.TransportREST, entities.TransportFromContext(ctx))
+}
+
+func Test_GatewayAuthenticator_UnsignedMetadata_IsDropped(t *testing.T) {
+	//Arrange
+	md := forwardedMetadata()
+	//Act
+	ctx := interceptedContext("shared-secret", md)
+	//Assert
+	_, ok := entities.CallerFromContext(ctx)
+	assert.False(t, ok)
+	assert.Empty(t, entities.ClientAddressFromContext(ctx))
+	assert.Equal(t, entities.TransportGRPC, entities.TransportFromContext(ctx))
+}
+
+func Test_GatewayAuthenticator_SignedWithOtherSecret_IsDropped(t *testing.T) {
+	//Arrange
+	md := signedMetadata("guessed-secret", gatewayMethod)
+	//Act
+	ctx := interceptedContext("shared-secret", md)
+	//Assert
+	_, ok := entities.CallerFromContext(ctx)
+	assert.False(t, ok)
+}
+
+func Test_GatewayAuthenticator_NoSecret_DropsSignedMetadata(t *testing.T) {
+	//Arrange
+	md := signedMetadata("shared-secret", gatewayMethod)
+	//Act
+	ctx := interceptedContext("", md)
+	//Assert
+	_, ok := entities.CallerFromContext(ctx)
+	assert.False(t, ok)
+}
+
+func Test_GatewayAuthenticator_SignedForOtherMethod_IsDropped(t *testing.T) {
+	//Arrange
+	md := signedMetadata("shared-secret", "/users.Users/GetUser")
+	//Act
+	ctx := interceptedContext("shared-secret", md)
+	//Assert
+	_, ok := entities.CallerFromContext(ctx)
+	assert.False(t, ok)
+}
+
+func Test_GatewayAuthenticator_ReplayedSignature_IsDropped(t *testing.T) {
+	//Arrange
+	authenticator := NewGatewayAuthenticator("shared-secret", fixedClock{now: gatewayNow})
+	md := signedMetadata("shared-secret", gatewayMethod)
+	interceptedBy(authenticator, md)
+	//Act
+	ctx := interceptedBy(authenticator, md)
+	//Assert
+	_, ok := entities.CallerFromContext(ctx)
+	assert.False(t, ok)
+}
@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/casmelad/GlobantPOC/pkg/instrumentation"
+	"github.com/go-kit/kit/endpoint"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+)
+
+// InstrumentingMiddleware returns the endpoint middleware recording the RED
+// metrics of the gRPC methods. It relies on the go-kit interceptor to find
+// the method being served.
+func InstrumentingMiddleware(m instrumentation.RED) endpoint.Middleware {
+	return instrumentation.EndpointMiddleware(m, methodFromContext, responseError)
+}
+
+// chain composes the middlewares given to the endpoint constructors, the
+// first one being the outermost.
+func chain(middleware []endpoint.Middleware) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			next = middleware[i](next)
+		}
+		return next
+	}
+}
+
+func methodFromContext(ctx context.Context) string {
+	method, _ := ctx.Value(grpctransport.ContextKeyRequestMethod).(string)
+	return method
+}
+
+// responseError returns the domain error the endpoint responses carry in
+// their Error field, the endpoints themselves only fail on bad requests.
+func responseError(response interface{}) error {
+
+	value := reflect.Indirect(reflect.ValueOf(response))
+
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	field := value.FieldByName("Error")
+
+	if !field.IsValid() || field.Kind() != reflect.Interface || field.IsNil() {
+		return nil
+	}
+
+	err, _ := field.Interface().(error)
+
+	return err
+}
@@ -0,0 +1,32 @@
+package grpc
+
+import (
+	"github.com/casmelad/GlobantPOC/pkg/logging"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+
+	proto "github.com/casmelad/GlobantPOC/api/users/v1"
+)
+
+// LoggingMiddleware returns the endpoint middleware logging every call to
+// the gRPC methods, with the request and trace ids.
+func LoggingMiddleware(logger log.Logger) endpoint.Middleware {
+	return logging.EndpointMiddleware(logger, logging.Endpoint{
+		Operation: methodFromContext,
+		Failed:    responseError,
+		Classify:  errorClass,
+	})
+}
+
+// errorClass tells the errors the caller has to fix apart from the server
+// failures, using the result codes the responses are encoded with.
+func errorClass(err error) string {
+
+	for _, code := range []proto.CodeResult{statusCode(err), emailChangeCode(err), authCode(err), webhookCode(err)} {
+		if code != proto.CodeResult_FAILED {
+			return logging.ErrorClassClient
+		}
+	}
+
+	return logging.ErrorClass(err)
+}
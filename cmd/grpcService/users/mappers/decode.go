@@ -1,27 +1,210 @@
 package mappers
 
 import (
-	proto "github.com/casmelad/GlobantPOC/cmd/grpcService/users/proto"
+	"time"
+
+	proto "github.com/casmelad/GlobantPOC/api/users/v1"
+	"github.com/casmelad/GlobantPOC/pkg/audit"
+	"github.com/casmelad/GlobantPOC/pkg/auth"
+	"github.com/casmelad/GlobantPOC/pkg/events"
 	domain "github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/casmelad/GlobantPOC/pkg/webhooks"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 //ToDomainUser maps a grpc user to domain user
-func ToDomainUser(userToMap proto.User) (domain.User, error) {
-	return domain.User{
-		ID:       int(userToMap.Id),
-		Email:    userToMap.Email,
-		Name:     userToMap.Name,
-		LastName: userToMap.LastName,
-	}, nil
+func ToDomainUser(userToMap *proto.User) (domain.User, error) {
+	usr := domain.User{
+		ID:        int(userToMap.Id),
+		Email:     userToMap.Email,
+		Name:      userToMap.Name,
+		LastName:  userToMap.LastName,
+		Version:   int(userToMap.Version),
+		CreatedAt: ToTime(userToMap.CreatedAt),
+		UpdatedAt: ToTime(userToMap.UpdatedAt),
+		CreatedBy: userToMap.CreatedBy,
+		UpdatedBy: userToMap.UpdatedBy,
+		Status:    domain.AccountStatus(userToMap.Status),
+	}
+
+	if userToMap.DeletedAt != nil {
+		deletedAt := userToMap.DeletedAt.AsTime()
+		usr.DeletedAt = &deletedAt
+	}
+
+	return usr, nil
 }
 
 //ToGrpcUser maps a domain user to a grpc user
-func ToGrpcUser(userToMap domain.User) (proto.User, error) {
-	return proto.User{
-		Id:       int32(userToMap.ID),
-		Email:    userToMap.Email,
-		Name:     userToMap.Name,
-		LastName: userToMap.LastName,
+func ToGrpcUser(userToMap domain.User) (*proto.User, error) {
+	usr := &proto.User{
+		Id:        int32(userToMap.ID),
+		Email:     userToMap.Email,
+		Name:      userToMap.Name,
+		LastName:  userToMap.LastName,
+		Version:   int32(userToMap.Version),
+		CreatedAt: ToTimestamp(userToMap.CreatedAt),
+		UpdatedAt: ToTimestamp(userToMap.UpdatedAt),
+		CreatedBy: userToMap.CreatedBy,
+		UpdatedBy: userToMap.UpdatedBy,
+		Status:    string(userToMap.Status),
+	}
+
+	if userToMap.DeletedAt != nil {
+		usr.DeletedAt = timestamppb.New(*userToMap.DeletedAt)
+	}
+
+	return usr, nil
+
+}
+
+//ToTime maps a grpc timestamp to a time, a missing timestamp maps to the zero time
+func ToTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+//ToTimestamp maps a time to a grpc timestamp, the zero time maps to a missing timestamp
+func ToTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+//ToGrpcAuditEntry maps an audit entry to a grpc audit entry
+func ToGrpcAuditEntry(entryToMap audit.Entry) *proto.AuditEntry {
+	entry := &proto.AuditEntry{
+		Id:         int32(entryToMap.ID),
+		UserId:     int32(entryToMap.UserID),
+		Action:     string(entryToMap.Action),
+		Changes:    []*proto.FieldChange{},
+		Actor:      entryToMap.Actor,
+		RequestId:  entryToMap.RequestID,
+		Transport:  entryToMap.Transport,
+		OccurredAt: ToTimestamp(entryToMap.OccurredAt),
+	}
+
+	for _, c := range entryToMap.Changes {
+		entry.Changes = append(entry.Changes, &proto.FieldChange{Field: c.Field, Before: c.Before, After: c.After})
+	}
+
+	return entry
+}
+
+//ToGrpcUserEvent maps an outbox message to a grpc user event
+func ToGrpcUserEvent(m events.Message) (*proto.UserEvent, error) {
+	event, err := events.Decode(m)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var usr domain.User
+
+	switch e := event.(type) {
+	case domain.UserCreated:
+		usr = e.User
+	case domain.UserUpdated:
+		usr = e.User
+	case domain.UserDeleted:
+		usr = e.User
+	case domain.UserPurged:
+		usr = domain.User{ID: e.ID}
+	}
+
+	grpcUser, err := ToGrpcUser(usr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.UserEvent{
+		Sequence:   m.Position,
+		Type:       string(m.Type),
+		User:       grpcUser,
+		OccurredAt: ToTimestamp(m.OccurredAt),
 	}, nil
+}
+
+//ToDomainSubscription maps a grpc webhook subscription to a webhooks subscription
+func ToDomainSubscription(subToMap *proto.WebhookSubscription) webhooks.Subscription {
+	sub := webhooks.Subscription{
+		ID:         int(subToMap.Id),
+		URL:        subToMap.Url,
+		EventTypes: []domain.EventType{},
+		Secret:     subToMap.Secret,
+		CreatedAt:  ToTime(subToMap.CreatedAt),
+		CreatedBy:  subToMap.CreatedBy,
+	}
+
+	for _, t := range subToMap.EventTypes {
+		sub.EventTypes = append(sub.EventTypes, domain.EventType(t))
+	}
+
+	return sub
+}
+
+//ToGrpcSubscription maps a webhooks subscription to a grpc webhook subscription, the secret is not mapped
+func ToGrpcSubscription(subToMap webhooks.Subscription) *proto.WebhookSubscription {
+	sub := &proto.WebhookSubscription{
+		Id:         int32(subToMap.ID),
+		Url:        subToMap.URL,
+		EventTypes: []string{},
+		CreatedAt:  ToTimestamp(subToMap.CreatedAt),
+		CreatedBy:  subToMap.CreatedBy,
+	}
+
+	for _, t := range subToMap.EventTypes {
+		sub.EventTypes = append(sub.EventTypes, string(t))
+	}
+
+	return sub
+}
+
+//ToGrpcDelivery maps a webhooks delivery to a grpc webhook delivery
+func ToGrpcDelivery(deliveryToMap webhooks.Delivery) *proto.WebhookDelivery {
+	delivery := &proto.WebhookDelivery{
+		Id:             int32(deliveryToMap.ID),
+		SubscriptionId: int32(deliveryToMap.SubscriptionID),
+		Sequence:       deliveryToMap.Sequence,
+		EventType:      string(deliveryToMap.EventType),
+		Status:         string(deliveryToMap.Status),
+		Attempts:       []*proto.DeliveryAttempt{},
+		NextAttemptAt:  ToTimestamp(deliveryToMap.NextAttemptAt),
+		CreatedAt:      ToTimestamp(deliveryToMap.CreatedAt),
+	}
+
+	for _, a := range deliveryToMap.Attempts {
+		delivery.Attempts = append(delivery.Attempts, &proto.DeliveryAttempt{At: ToTimestamp(a.At), StatusCode: int32(a.StatusCode), Error: a.Error})
+	}
+
+	return delivery
+}
+
+//ToGrpcAPIKey maps an API key to a grpc API key, the hash of its secret is not mapped
+func ToGrpcAPIKey(keyToMap auth.APIKey) *proto.APIKey {
+	key := &proto.APIKey{
+		Id:        keyToMap.ID,
+		Name:      keyToMap.Name,
+		Scopes:    keyToMap.Scopes,
+		CreatedBy: keyToMap.CreatedBy,
+		CreatedAt: ToTimestamp(keyToMap.CreatedAt),
+	}
+
+	if keyToMap.ExpiresAt != nil {
+		key.ExpiresAt = timestamppb.New(*keyToMap.ExpiresAt)
+	}
+
+	if keyToMap.LastUsedAt != nil {
+		key.LastUsedAt = timestamppb.New(*keyToMap.LastUsedAt)
+	}
+
+	if keyToMap.RevokedAt != nil {
+		key.RevokedAt = timestamppb.New(*keyToMap.RevokedAt)
+	}
 
+	return key
 }
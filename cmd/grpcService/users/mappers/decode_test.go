@@ -1,16 +1,19 @@
 package mappers
 
 import (
+	"encoding/json"
 	"testing"
 
-	proto "github.com/casmelad/GlobantPOC/cmd/grpcService/users/proto"
+	proto "github.com/casmelad/GlobantPOC/api/users/v1"
+	"github.com/casmelad/GlobantPOC/pkg/events"
 	domain "github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/casmelad/GlobantPOC/pkg/webhooks"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_ToDomainUser_ResultOk(t *testing.T) {
 	//Arrange
-	toMap := proto.User{Id: 999999}
+	toMap := &proto.User{Id: 999999}
 	expectedResult := domain.User{ID: 999999}
 
 	//Act
@@ -25,7 +28,7 @@ func Test_ToGrpcUser_ResultOk(t *testing.T) {
 
 	//Arrange
 	toMap := domain.User{ID: 999999}
-	expectedResult := proto.User{Id: 999999}
+	expectedResult := &proto.User{Id: 999999}
 
 	//Act
 	result, err := ToGrpcUser(toMap)
@@ -34,3 +37,39 @@ func Test_ToGrpcUser_ResultOk(t *testing.T) {
 	assert.Equal(t, expectedResult, result)
 	assert.Nil(t, err)
 }
+
+func Test_ToGrpcUserEvent_ResultOk(t *testing.T) {
+
+	//Arrange
+	payload, _ := json.Marshal(domain.UserUpdated{User: domain.User{ID: 999999, Email: "test@gmail.com"}})
+	toMap := events.Message{Sequence: 3, Position: 7, Type: domain.UserUpdatedEvent, UserID: 999999, Payload: payload}
+
+	//Act
+	result, err := ToGrpcUserEvent(toMap)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), result.Sequence)
+	assert.Equal(t, "user.updated", result.Type)
+	assert.Equal(t, int32(999999), result.User.Id)
+	assert.Equal(t, "test@gmail.com", result.User.Email)
+}
+
+func Test_ToGrpcUserEvent_UnknownType_ReturnsError(t *testing.T) {
+
+	//Act
+	_, err := ToGrpcUserEvent(events.Message{Type: "user.renamed"})
+
+	//Assert
+	assert.Equal(t, events.ErrUnknownEventType, err)
+}
+
+func Test_ToGrpcSubscription_DoesNotMapSecret(t *testing.T) {
+	//Arrange
+	sub := webhooks.Subscription{ID: 1, URL: "https://partner.example.com/hooks", EventTypes: []domain.EventType{domain.UserCreatedEvent}, Secret: "0123456789abcdef"}
+	//Act
+	result := ToGrpcSubscription(sub)
+	//Assert
+	assert.Equal(t, "", result.Secret)
+	assert.Equal(t, []string{"user.created"}, result.EventTypes)
+}
@@ -0,0 +1,28 @@
+package grpc
+
+import (
+	"errors"
+
+	proto "github.com/casmelad/GlobantPOC/api/users/v1"
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	domain "github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+// errorDetails describes the error a result failed with, with the given code
+// of the catalogue shared with the REST gateway. The validation errors list
+// every field that is not valid.
+func errorDetails(err error, code problems.Code) *proto.ErrorDetails {
+
+	details := &proto.ErrorDetails{Code: string(code), Detail: err.Error()}
+
+	var validation domain.ValidationError
+
+	if errors.As(err, &validation) {
+		details.Code = string(problems.CodeValidationFailed)
+		for _, v := range validation.Violations {
+			details.Violations = append(details.Violations, &proto.FieldViolation{Field: v.Field, Rule: v.Rule, Description: v.Description})
+		}
+	}
+
+	return details
+}
@@ -0,0 +1,98 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDUnaryInterceptor makes sure every call has a request id: the one
+// forwarded by the REST gateway or a generated one. It is returned in the
+// response header, and in the details of the error the call fails with, if
+// any. It has to run before the go-kit interceptor so that the id is moved
+// into the request context by requestFromMetadata.
+func RequestIDUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+	ctx, id := requestIDToIncoming(ctx)
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadataKey, id))
+
+	resp, err := handler(ctx, req)
+
+	return resp, withRequestInfo(err, id)
+}
+
+// RequestIDStreamInterceptor is the RequestIDUnaryInterceptor of the streams.
+func RequestIDStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+	ctx, id := requestIDToIncoming(ss.Context())
+	ss.SetHeader(metadata.Pairs(requestIDMetadataKey, id))
+
+	return withRequestInfo(handler(srv, requestIDStream{ServerStream: ss, ctx: ctx}), id)
+}
+
+type requestIDStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s requestIDStream) Context() context.Context {
+	return s.ctx
+}
+
+// requestIDToIncoming returns the id of the request found in the incoming
+// metadata, a generated one is added to it when missing.
+func requestIDToIncoming(ctx context.Context) (context.Context, string) {
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if ids := md.Get(requestIDMetadataKey); len(ids) > 0 && ids[0] != "" {
+		return ctx, ids[0]
+	}
+
+	id := uuid.New().String()
+	md = md.Copy()
+	md.Set(requestIDMetadataKey, id)
+
+	return metadata.NewIncomingContext(ctx, md), id
+}
+
+// withRequestInfo adds the id of the request to the details of the status
+// of err, so that the failure can be found in the logs of both services,
+// along with its code of the catalogue shared with the REST gateway unless
+// the status already carries it.
+func withRequestInfo(err error, id string) error {
+
+	if err == nil {
+		return nil
+	}
+
+	st := status.Convert(err)
+
+	withDetails, detailsErr := st.WithDetails(&errdetails.RequestInfo{RequestId: id})
+
+	if detailsErr == nil && !hasErrorInfo(st) {
+		withDetails, detailsErr = withDetails.WithDetails(problems.ErrorInfo(problems.FromStatus(st)))
+	}
+
+	if detailsErr != nil {
+		return err
+	}
+
+	return withDetails.Err()
+}
+
+// hasErrorInfo reports whether the status has the ErrorInfo of a catalogue
+// code among its details.
+func hasErrorInfo(st *status.Status) bool {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == problems.Domain {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	entities "github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func requestIDOf(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return entities.RequestIDFromContext(requestFromMetadata(ctx, md))
+}
+
+func Test_RequestIDUnaryInterceptor_ForwardedID_InContext(t *testing.T) {
+	//Arrange
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDMetadataKey, "client-42"))
+	var id string
+	//Act
+	RequestIDUnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		id = requestIDOf(ctx)
+		return nil, nil
+	})
+	//Assert
+	assert.Equal(t, "client-42", id)
+}
+
+func Test_RequestIDUnaryInterceptor_NoID_UUIDGenerated(t *testing.T) {
+	//Arrange
+	var id string
+	//Act
+	RequestIDUnaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		id = requestIDOf(ctx)
+		return nil, nil
+	})
+	//Assert
+	_, err := uuid.Parse(id)
+	assert.Nil(t, err)
+}
+
+func Test_RequestIDUnaryInterceptor_Error_RequestInfoInDetails(t *testing.T) {
+	//Arrange
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDMetadataKey, "client-42"))
+	//Act
+	_, err := RequestIDUnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.New("invalid input data")
+	})
+	//Assert
+	st := status.Convert(err)
+	assert.Equal(t, codes.Unknown, st.Code())
+	assert.Equal(t, "invalid input data", st.Message())
+	assert.Len(t, st.Details(), 2)
+	info, ok := st.Details()[0].(*errdetails.RequestInfo)
+	assert.True(t, ok)
+	assert.Equal(t, "client-42", info.RequestId)
+	assert.Equal(t, problems.CodeInternal, problems.FromStatus(st))
+}
+
+func Test_RequestIDUnaryInterceptor_StatusError_CodeInErrorInfo(t *testing.T) {
+	//Act
+	_, err := RequestIDUnaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	})
+	//Assert
+	st := status.Convert(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+	info, ok := st.Details()[1].(*errdetails.ErrorInfo)
+	assert.True(t, ok)
+	assert.Equal(t, string(problems.CodeNotFound), info.Reason)
+	assert.Equal(t, problems.Domain, info.Domain)
+}
@@ -1,15 +1,25 @@
 package grpc
 
+import (
+	"time"
+
+	domain "github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/casmelad/GlobantPOC/pkg/webhooks"
+)
+
 type postUserRequest struct {
 	User `json:"user,omitempty"`
 }
 
 type updateUserRequest struct {
 	User `json:"user,omitempty"`
+	//The fields to change, nil replaces the whole user
+	Paths []string `json:"paths,omitempty"`
 }
 
 type deleteUserRequest struct {
-	Id int32 `json:"id,omitempty"`
+	Id      int32 `json:"id,omitempty"`
+	Version int32 `json:"version,omitempty"`
 }
 
 type getUserRequest struct {
@@ -17,6 +27,52 @@ type getUserRequest struct {
 }
 
 type GetAllUsersRequest struct {
+	IncludeDeleted bool   `json:"include_deleted,omitempty"`
+	Status         string `json:"status,omitempty"`
+}
+
+type listUsersRequest struct {
+	Filter domain.Filter
+	//The most users of the page
+	PageSize int
+	//The users up to this id are in the previous pages
+	After int
+}
+
+type restoreUserRequest struct {
+	Id int32 `json:"id,omitempty"`
+}
+
+type purgeUserRequest struct {
+	Id int32 `json:"id,omitempty"`
+}
+
+type historyRequest struct {
+	UserID int32
+	From   time.Time
+	To     time.Time
+	Actor  string
+}
+
+type emailChangeRequest struct {
+	Email    string
+	NewEmail string
+}
+
+type confirmEmailChangeRequest struct {
+	Token string
+}
+
+type sendVerificationRequest struct {
+	Email string
+}
+
+type verifyEmailRequest struct {
+	Token string
+}
+
+type changeStatusRequest struct {
+	Id int32
 }
 
 type User struct {
@@ -28,4 +84,109 @@ type User struct {
 	Name string `json:"name,omitempty"`
 	//The user last name
 	LastName string `json:"last_name,omitempty"`
+	//The version of the stored user
+	Version int32 `json:"version,omitempty"`
+	//When the user was created
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	//When the user was last changed
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	//Who created the user
+	CreatedBy string `json:"created_by,omitempty"`
+	//Who last changed the user
+	UpdatedBy string `json:"updated_by,omitempty"`
+	//When the user was soft deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	//The stage of the account lifecycle
+	Status string `json:"status,omitempty"`
+}
+
+func newUser(usr domain.User) User {
+	return User{
+		Id:        int32(usr.ID),
+		Email:     usr.Email,
+		Name:      usr.Name,
+		LastName:  usr.LastName,
+		Version:   int32(usr.Version),
+		CreatedAt: usr.CreatedAt,
+		UpdatedAt: usr.UpdatedAt,
+		CreatedBy: usr.CreatedBy,
+		UpdatedBy: usr.UpdatedBy,
+		DeletedAt: usr.DeletedAt,
+		Status:    string(usr.Status),
+	}
+}
+
+type subscribeRequest struct {
+	Subscription webhooks.Subscription
+}
+
+type subscriptionRequest struct {
+	Id int32
+}
+
+type listSubscriptionsRequest struct{}
+
+type unsubscribeRequest struct {
+	Id int32
+}
+
+type deliveriesRequest struct {
+	Query webhooks.DeliveryQuery
+}
+
+type tokenRequest struct {
+	GrantType    string
+	Email        string
+	Password     string
+	RefreshToken string
+	MFAToken     string
+	Code         string
+}
+
+type revokeRequest struct {
+	RefreshToken string
+}
+
+type setPasswordRequest struct {
+	Email    string
+	Password string
+}
+
+type setRolesRequest struct {
+	Email string
+	Roles []string
+}
+
+type requestResetRequest struct {
+	Email string
+}
+
+type confirmResetRequest struct {
+	Token    string
+	Password string
+}
+
+type mfaRequest struct {
+	Email string
+}
+
+type confirmTOTPRequest struct {
+	Email string
+	Code  string
+}
+
+type createAPIKeyRequest struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+type listAPIKeysRequest struct{}
+
+type revokeAPIKeyRequest struct {
+	ID string
+}
+
+type authenticateAPIKeyRequest struct {
+	Key string
 }
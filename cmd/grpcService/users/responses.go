@@ -1,5 +1,11 @@
 package grpc
 
+import (
+	"github.com/casmelad/GlobantPOC/pkg/audit"
+	"github.com/casmelad/GlobantPOC/pkg/auth"
+	"github.com/casmelad/GlobantPOC/pkg/webhooks"
+)
+
 type postUserResponse struct {
 	Error error
 	Id    int
@@ -13,10 +19,143 @@ type getAllUsersResponse struct {
 	Users []User
 }
 
-type updateUserResponse struct {
+type userResponse struct {
+	User  User
+	Error error
+}
+
+type listUsersResponse struct {
+	Users []User
+	//The id of the last user of the page, 0 on the last page
+	Next  int
+	Total int
 	Error error
 }
 
+type updateUserResponse struct {
+	//The version of the updated user
+	Version int32
+	Error   error
+}
+
 type deleteUserResponse struct {
 	Error error
 }
+
+type restoreUserResponse struct {
+	Error error
+}
+
+type purgeUserResponse struct {
+	Error error
+}
+
+type historyResponse struct {
+	Entries []audit.Entry
+	Error   error
+}
+
+type emailChangeResponse struct {
+	Error error
+}
+
+type confirmEmailChangeResponse struct {
+	User  User
+	Error error
+}
+
+type sendVerificationResponse struct {
+	Error error
+}
+
+type verifyEmailResponse struct {
+	User  User
+	Error error
+}
+
+type changeStatusResponse struct {
+	Error error
+}
+
+type subscribeResponse struct {
+	Subscription webhooks.Subscription
+	Error        error
+}
+
+type subscriptionResponse struct {
+	Subscription webhooks.Subscription
+	Error        error
+}
+
+type listSubscriptionsResponse struct {
+	Subscriptions []webhooks.Subscription
+	Error         error
+}
+
+type unsubscribeResponse struct {
+	Error error
+}
+
+type deliveriesResponse struct {
+	Deliveries []webhooks.Delivery
+	Error      error
+}
+
+type tokenResponse struct {
+	Tokens auth.Tokens
+	Error  error
+}
+
+type revokeResponse struct {
+	Error error
+}
+
+type setPasswordResponse struct {
+	Error error
+}
+
+type setRolesResponse struct {
+	Error error
+}
+
+type requestResetResponse struct {
+	Error error
+}
+
+type confirmResetResponse struct {
+	Error error
+}
+
+type enrollTOTPResponse struct {
+	Enrollment auth.Enrollment
+	Error      error
+}
+
+type recoveryCodesResponse struct {
+	Codes []string
+	Error error
+}
+
+type disableMFAResponse struct {
+	Error error
+}
+
+type createAPIKeyResponse struct {
+	APIKey auth.APIKey
+	Key    string
+	Error  error
+}
+
+type listAPIKeysResponse struct {
+	APIKeys []auth.APIKey
+	Error   error
+}
+
+type revokeAPIKeyResponse struct {
+	Error error
+}
+
+type authenticateAPIKeyResponse struct {
+	APIKey auth.APIKey
+	Error  error
+}
@@ -3,32 +3,56 @@ package grpc
 import (
 	"context"
 	"errors"
-	"fmt"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/tracing/zipkin"
 
-	"github.com/go-kit/kit/transport"
 	grpctransport "github.com/go-kit/kit/transport/grpc"
 	stdopentracing "github.com/opentracing/opentracing-go"
 	stdzipkin "github.com/openzipkin/zipkin-go"
 
-	proto "github.com/casmelad/GlobantPOC/cmd/grpcService/users/proto"
+	proto "github.com/casmelad/GlobantPOC/api/users/v1"
+	"github.com/casmelad/GlobantPOC/cmd/grpcService/users/mappers"
+	"github.com/casmelad/GlobantPOC/pkg/events"
+	"github.com/casmelad/GlobantPOC/pkg/logging"
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	domain "github.com/casmelad/GlobantPOC/pkg/users"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type grpcUserServer struct {
 	proto.UsersServer
-	getUser     grpctransport.Handler
-	create      grpctransport.Handler
-	getAllUsers grpctransport.Handler
-	update      grpctransport.Handler
-	delete      grpctransport.Handler
+	getUser      grpctransport.Handler
+	create       grpctransport.Handler
+	getAllUsers  grpctransport.Handler
+	update       grpctransport.Handler
+	delete       grpctransport.Handler
+	restore      grpctransport.Handler
+	purge        grpctransport.Handler
+	history      grpctransport.Handler
+	requestEmail grpctransport.Handler
+	confirmEmail grpctransport.Handler
+	sendVerify   grpctransport.Handler
+	verifyEmail  grpctransport.Handler
+	suspend      grpctransport.Handler
+	reactivate   grpctransport.Handler
+	deactivate   grpctransport.Handler
+	feed         changeFeed
 }
 
-func NewGrpcUserServer(endpoints grpcUserServerEndpoints, otTracer stdopentracing.Tracer, zipkinTracer *stdzipkin.Tracer, logger log.Logger) proto.UsersServer {
+// changeFeed streams the user events to the WatchUsers subscribers.
+type changeFeed interface {
+	Head(ctx context.Context) (int64, error)
+	Watch(ctx context.Context, after int64, send func(events.Message) error) error
+}
+
+func NewGrpcUserServer(endpoints grpcUserServerEndpoints, feed changeFeed, otTracer stdopentracing.Tracer, zipkinTracer *stdzipkin.Tracer, logger log.Logger) proto.UsersServer {
 
 	options := []grpctransport.ServerOption{
-		grpctransport.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		grpctransport.ServerErrorHandler(logging.NewErrorHandler(logger)),
+		grpctransport.ServerBefore(callerFromMetadata, requestFromMetadata),
 	}
 
 	if zipkinTracer != nil {
@@ -45,12 +69,23 @@ func NewGrpcUserServer(endpoints grpcUserServerEndpoints, otTracer stdopentracin
 	}
 
 	server := &grpcUserServer{
-
-		create:      grpctransport.NewServer(endpoints.CreateUserEndpoint, decodeCreateUserRequest, encodeCreateUserResponse, options...),
-		getUser:     grpctransport.NewServer(endpoints.GetUserByEmailEndpoint, decodeGetUserRequest, encodeGetUserResponse, options...),
-		getAllUsers: grpctransport.NewServer(endpoints.GetAllUsersEndpoint, decodeGetAllUsersRequest, encodeGetAllUsersResponse, options...),
-		update:      grpctransport.NewServer(endpoints.UpdateUserEndpoint, decodeUpdateUserRequest, encodeUpdateUserResponse, options...),
-		delete:      grpctransport.NewServer(endpoints.DeleteUserEndpoint, decodeDeleteUserRequest, encodeDeleteUserResponse, options...),
+		feed: feed,
+
+		create:       grpctransport.NewServer(endpoints.CreateUserEndpoint, decodeCreateUserRequest, encodeCreateUserResponse, options...),
+		getUser:      grpctransport.NewServer(endpoints.GetUserByEmailEndpoint, decodeGetUserRequest, encodeGetUserResponse, options...),
+		getAllUsers:  grpctransport.NewServer(endpoints.GetAllUsersEndpoint, decodeGetAllUsersRequest, encodeGetAllUsersResponse, options...),
+		update:       grpctransport.NewServer(endpoints.UpdateUserEndpoint, decodeUpdateUserRequest, encodeUpdateUserResponse, options...),
+		delete:       grpctransport.NewServer(endpoints.DeleteUserEndpoint, decodeDeleteUserRequest, encodeDeleteUserResponse, options...),
+		restore:      grpctransport.NewServer(endpoints.RestoreUserEndpoint, decodeRestoreUserRequest, encodeRestoreUserResponse, options...),
+		purge:        grpctransport.NewServer(endpoints.PurgeUserEndpoint, decodePurgeUserRequest, encodePurgeUserResponse, options...),
+		history:      grpctransport.NewServer(endpoints.UserHistoryEndpoint, decodeUserHistoryRequest, encodeUserHistoryResponse, options...),
+		requestEmail: grpctransport.NewServer(endpoints.RequestEmailEndpoint, decodeEmailChangeRequest, encodeEmailChangeResponse, options...),
+		confirmEmail: grpctransport.NewServer(endpoints.ConfirmEmailEndpoint, decodeConfirmEmailChangeRequest, encodeConfirmEmailChangeResponse, options...),
+		sendVerify:   grpctransport.NewServer(endpoints.SendVerifyEndpoint, decodeSendVerificationRequest, encodeSendVerificationResponse, options...),
+		verifyEmail:  grpctransport.NewServer(endpoints.VerifyEmailEndpoint, decodeVerifyEmailRequest, encodeVerifyEmailResponse, options...),
+		suspend:      grpctransport.NewServer(endpoints.SuspendEndpoint, decodeChangeStatusRequest, encodeChangeStatusResponse, options...),
+		reactivate:   grpctransport.NewServer(endpoints.ReactivateEndpoint, decodeChangeStatusRequest, encodeChangeStatusResponse, options...),
+		deactivate:   grpctransport.NewServer(endpoints.DeactivateEndpoint, decodeChangeStatusRequest, encodeChangeStatusResponse, options...),
 	}
 
 	return server
@@ -67,8 +102,6 @@ func (u grpcUserServer) Create(ctx context.Context, user *proto.CreateUserReques
 
 	_, grpcResponse, err := u.create.ServeGRPC(ctx, user)
 
-	fmt.Println("xvbxbbcbcvbc", grpcResponse)
-
 	return grpcResponse.(*proto.CreateUserResponse), err
 
 }
@@ -82,20 +115,118 @@ func (u grpcUserServer) GetAllUsers(ctx context.Context, filters *proto.Filters)
 
 func (u grpcUserServer) Update(ctx context.Context, userInfo *proto.UpdateUserRequest) (*proto.UpdateUserResponse, error) {
 
-	fmt.Println("Update method", u.update)
-
 	_, grpcResponse, err := u.update.ServeGRPC(ctx, userInfo)
 
 	return grpcResponse.(*proto.UpdateUserResponse), err
 }
 
-func (u grpcUserServer) Delete(ctx context.Context, userId *proto.Id) (*proto.DeleteUserResponse, error) {
+func (u grpcUserServer) Delete(ctx context.Context, userInfo *proto.DeleteUserRequest) (*proto.DeleteUserResponse, error) {
 
-	ctx, grpcResponse, err := u.delete.ServeGRPC(ctx, userId)
+	ctx, grpcResponse, err := u.delete.ServeGRPC(ctx, userInfo)
 
 	return grpcResponse.(*proto.DeleteUserResponse), err
 }
 
+func (u grpcUserServer) Restore(ctx context.Context, userId *proto.Id) (*proto.RestoreUserResponse, error) {
+
+	_, grpcResponse, err := u.restore.ServeGRPC(ctx, userId)
+
+	return grpcResponse.(*proto.RestoreUserResponse), err
+}
+
+func (u grpcUserServer) Purge(ctx context.Context, userId *proto.Id) (*proto.PurgeUserResponse, error) {
+
+	_, grpcResponse, err := u.purge.ServeGRPC(ctx, userId)
+
+	return grpcResponse.(*proto.PurgeUserResponse), err
+}
+
+func (u grpcUserServer) History(ctx context.Context, req *proto.HistoryRequest) (*proto.HistoryResponse, error) {
+
+	_, grpcResponse, err := u.history.ServeGRPC(ctx, req)
+
+	return grpcResponse.(*proto.HistoryResponse), err
+}
+
+func (u grpcUserServer) RequestEmailChange(ctx context.Context, req *proto.EmailChangeRequest) (*proto.EmailChangeResponse, error) {
+
+	_, grpcResponse, err := u.requestEmail.ServeGRPC(ctx, req)
+
+	return grpcResponse.(*proto.EmailChangeResponse), err
+}
+
+func (u grpcUserServer) ConfirmEmailChange(ctx context.Context, req *proto.ConfirmEmailChangeRequest) (*proto.ConfirmEmailChangeResponse, error) {
+
+	_, grpcResponse, err := u.confirmEmail.ServeGRPC(ctx, req)
+
+	return grpcResponse.(*proto.ConfirmEmailChangeResponse), err
+}
+
+func (u grpcUserServer) SendVerification(ctx context.Context, req *proto.EmailAddress) (*proto.SendVerificationResponse, error) {
+
+	_, grpcResponse, err := u.sendVerify.ServeGRPC(ctx, req)
+
+	return grpcResponse.(*proto.SendVerificationResponse), err
+}
+
+func (u grpcUserServer) VerifyEmail(ctx context.Context, req *proto.VerifyEmailRequest) (*proto.VerifyEmailResponse, error) {
+
+	_, grpcResponse, err := u.verifyEmail.ServeGRPC(ctx, req)
+
+	return grpcResponse.(*proto.VerifyEmailResponse), err
+}
+
+func (u grpcUserServer) Suspend(ctx context.Context, userId *proto.Id) (*proto.ChangeStatusResponse, error) {
+
+	_, grpcResponse, err := u.suspend.ServeGRPC(ctx, userId)
+
+	return grpcResponse.(*proto.ChangeStatusResponse), err
+}
+
+func (u grpcUserServer) Reactivate(ctx context.Context, userId *proto.Id) (*proto.ChangeStatusResponse, error) {
+
+	_, grpcResponse, err := u.reactivate.ServeGRPC(ctx, userId)
+
+	return grpcResponse.(*proto.ChangeStatusResponse), err
+}
+
+func (u grpcUserServer) Deactivate(ctx context.Context, userId *proto.Id) (*proto.ChangeStatusResponse, error) {
+
+	_, grpcResponse, err := u.deactivate.ServeGRPC(ctx, userId)
+
+	return grpcResponse.(*proto.ChangeStatusResponse), err
+}
+
+// WatchUsers is not served through a go-kit endpoint, go-kit has no support
+// for server streams. A watcher that falls behind gets ResourceExhausted and
+// is expected to resume from the last sequence it received.
+func (u grpcUserServer) WatchUsers(req *proto.WatchUsersRequest, stream proto.Users_WatchUsersServer) error {
+
+	after := req.AfterSequence
+
+	if req.FromHead {
+		head, err := u.feed.Head(stream.Context())
+		if err != nil {
+			return err
+		}
+		after = head
+	}
+
+	err := u.feed.Watch(stream.Context(), after, func(m events.Message) error {
+		event, err := mappers.ToGrpcUserEvent(m)
+		if err != nil {
+			return err
+		}
+		return stream.Send(event)
+	})
+
+	if err == events.ErrFeedOverflow {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	return err
+}
+
 // decodeGRPCSumRequest is a transport/grpc.DecodeRequestFunc that converts a
 // gRPC sum request to a user-domain sum request. Primarily useful in a server.
 func decodeCreateUserRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
@@ -118,9 +249,9 @@ func encodeCreateUserResponse(ctx context.Context, resp interface{}) (interface{
 
 	if respData.Error != nil {
 		if respData.Error.Error() == "user already exists" {
-			return &proto.CreateUserResponse{Code: proto.CodeResult_FAILED}, nil
+			return &proto.CreateUserResponse{Code: proto.CodeResult_FAILED, Details: errorDetails(respData.Error, problems.CodeAlreadyExists)}, nil
 		} else {
-			return &proto.CreateUserResponse{Code: proto.CodeResult_INVALIDINPUT}, nil
+			return &proto.CreateUserResponse{Code: proto.CodeResult_INVALIDINPUT, Details: errorDetails(respData.Error, problems.CodeInternal)}, nil
 		}
 	}
 
@@ -142,18 +273,19 @@ func encodeGetUserResponse(ctx context.Context, resp interface{}) (interface{},
 	if !validCast {
 		return nil, errors.New("invalid input data to encode")
 	}
-	usr := proto.User{Id: respData.Id, Name: respData.Name, Email: respData.Email, LastName: respData.LastName}
+	if respData.Id == 0 {
+		return &proto.GetUserResponse{User: &proto.User{}}, nil
+	}
 
-	return &proto.GetUserResponse{User: &usr}, nil
+	return &proto.GetUserResponse{User: toProtoUser(respData.User)}, nil
 }
 
-// decodeGetAllUsersRequest : param Filters is not being used yet
 func decodeGetAllUsersRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
-	_, validCast := grpcReq.(*proto.Filters)
+	reqData, validCast := grpcReq.(*proto.Filters)
 	if !validCast {
 		return nil, errors.New("invalid input data decode")
 	}
-	return getUserRequest{}, nil
+	return GetAllUsersRequest{IncludeDeleted: reqData.IncludeDeleted, Status: reqData.Status}, nil
 }
 
 func encodeGetAllUsersResponse(ctx context.Context, resp interface{}) (interface{}, error) {
@@ -165,9 +297,7 @@ func encodeGetAllUsersResponse(ctx context.Context, resp interface{}) (interface
 	response := &proto.GetAllUsersResponse{Users: []*proto.User{}}
 
 	for _, usr := range respData.Users {
-		pbUser := proto.User{Id: usr.Id, Name: usr.Name, Email: usr.Email, LastName: usr.LastName}
-
-		response.Users = append(response.Users, &pbUser)
+		response.Users = append(response.Users, toProtoUser(usr))
 	}
 
 	return response, nil
@@ -177,57 +307,60 @@ func decodeUpdateUserRequest(ctx context.Context, grpcReq interface{}) (interfac
 
 	reqData, validCast := grpcReq.(*proto.UpdateUserRequest)
 
-	fmt.Println("decode", reqData)
-
 	if !validCast {
 		return nil, errors.New("invalid input data to decode")
 	}
 
-	usr := User{Id: reqData.User.Id, Email: reqData.User.Email, Name: reqData.User.Name, LastName: reqData.User.LastName}
+	usr := User{Id: reqData.User.Id, Email: reqData.User.Email, Name: reqData.User.Name, LastName: reqData.User.LastName, Version: reqData.Version}
 
-	fmt.Println("user", usr)
+	request := updateUserRequest{User: usr}
 
-	return updateUserRequest{User: usr}, nil
+	if reqData.UpdateMask != nil {
+		request.Paths = append([]string{}, reqData.UpdateMask.Paths...)
+	}
+
+	return request, nil
 }
 
 func encodeUpdateUserResponse(ctx context.Context, resp interface{}) (interface{}, error) {
 
-	fmt.Println("encode", resp)
 	respData, validCast := resp.(updateUserResponse)
 
-	fmt.Println("encode 2", respData)
-
 	if !validCast {
 		return nil, errors.New("invalid input data to encode")
 	}
 
 	if respData.Error != nil {
+		if domain.IsUserErrorType(domain.ERRVERSIONCONFLICT, respData.Error) {
+			return &proto.UpdateUserResponse{Code: proto.CodeResult_CONFLICT, Details: errorDetails(respData.Error, problems.CodeVersionConflict)}, nil
+		}
+		if domain.IsUserErrorType(domain.ERRVERSIONREQUIRED, respData.Error) {
+			return &proto.UpdateUserResponse{Code: proto.CodeResult_PRECONDITIONREQUIRED, Details: errorDetails(respData.Error, problems.CodePreconditionRequired)}, nil
+		}
 		switch respData.Error.Error() {
 		case "user not found":
-			return &proto.UpdateUserResponse{Code: proto.CodeResult_NOTFOUND}, nil
+			return &proto.UpdateUserResponse{Code: proto.CodeResult_NOTFOUND, Details: errorDetails(respData.Error, problems.CodeNotFound)}, nil
 		case "cannot update the user information":
-			return &proto.UpdateUserResponse{Code: proto.CodeResult_FAILED}, nil
+			return &proto.UpdateUserResponse{Code: proto.CodeResult_FAILED, Details: errorDetails(respData.Error, problems.CodeInternal)}, nil
 		default:
-			return &proto.UpdateUserResponse{Code: proto.CodeResult_INVALIDINPUT}, nil
+			return &proto.UpdateUserResponse{Code: proto.CodeResult_INVALIDINPUT, Details: errorDetails(respData.Error, problems.CodeValidationFailed)}, nil
 		}
 	}
 
-	return &proto.UpdateUserResponse{Code: proto.CodeResult_OK}, nil
+	return &proto.UpdateUserResponse{Code: proto.CodeResult_OK, Version: respData.Version}, nil
 }
 
 func decodeDeleteUserRequest(ctx context.Context, req interface{}) (interface{}, error) {
-	fmt.Println(req)
-	reqData, validCast := req.(*proto.Id)
+	reqData, validCast := req.(*proto.DeleteUserRequest)
 
 	if !validCast {
 		return nil, errors.New("invalid input data to decode")
 	}
 
-	return deleteUserRequest{Id: reqData.Value}, nil
+	return deleteUserRequest{Id: reqData.Id, Version: reqData.Version}, nil
 }
 
 func encodeDeleteUserResponse(ctx context.Context, resp interface{}) (interface{}, error) {
-	fmt.Println(resp)
 	respData, validCast := resp.(deleteUserResponse)
 
 	if !validCast {
@@ -235,6 +368,15 @@ func encodeDeleteUserResponse(ctx context.Context, resp interface{}) (interface{
 	}
 
 	if respData.Error != nil {
+		if domain.IsUserErrorType(domain.ERRFORBIDDEN, respData.Error) {
+			return &proto.DeleteUserResponse{Code: proto.CodeResult_FORBIDDEN}, nil
+		}
+		if domain.IsUserErrorType(domain.ERRVERSIONCONFLICT, respData.Error) {
+			return &proto.DeleteUserResponse{Code: proto.CodeResult_CONFLICT}, nil
+		}
+		if domain.IsUserErrorType(domain.ERRVERSIONREQUIRED, respData.Error) {
+			return &proto.DeleteUserResponse{Code: proto.CodeResult_PRECONDITIONREQUIRED}, nil
+		}
 		errorMessage := respData.Error.Error()
 		switch errorMessage {
 		case "user not found":
@@ -248,3 +390,310 @@ func encodeDeleteUserResponse(ctx context.Context, resp interface{}) (interface{
 
 	return &proto.DeleteUserResponse{Code: proto.CodeResult_OK}, nil
 }
+
+func decodeRestoreUserRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.Id)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return restoreUserRequest{Id: reqData.Value}, nil
+}
+
+func encodeRestoreUserResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(restoreUserResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		if domain.IsUserErrorType(domain.ERRFORBIDDEN, respData.Error) {
+			return &proto.RestoreUserResponse{Code: proto.CodeResult_FORBIDDEN}, nil
+		}
+		if domain.IsUserErrorType(domain.ERRINVALIDDATA, respData.Error) {
+			return &proto.RestoreUserResponse{Code: proto.CodeResult_INVALIDINPUT}, nil
+		}
+		switch respData.Error.Error() {
+		case "user not found":
+			return &proto.RestoreUserResponse{Code: proto.CodeResult_NOTFOUND}, nil
+		case "invalid id":
+			return &proto.RestoreUserResponse{Code: proto.CodeResult_INVALIDINPUT}, nil
+		default:
+			return &proto.RestoreUserResponse{Code: proto.CodeResult_FAILED}, nil
+		}
+	}
+
+	return &proto.RestoreUserResponse{Code: proto.CodeResult_OK}, nil
+}
+
+func decodePurgeUserRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.Id)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return purgeUserRequest{Id: reqData.Value}, nil
+}
+
+func encodePurgeUserResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(purgeUserResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		if domain.IsUserErrorType(domain.ERRFORBIDDEN, respData.Error) {
+			return &proto.PurgeUserResponse{Code: proto.CodeResult_FORBIDDEN}, nil
+		}
+		if domain.IsUserErrorType(domain.ERRINVALIDDATA, respData.Error) {
+			return &proto.PurgeUserResponse{Code: proto.CodeResult_INVALIDINPUT}, nil
+		}
+		switch respData.Error.Error() {
+		case "user not found":
+			return &proto.PurgeUserResponse{Code: proto.CodeResult_NOTFOUND}, nil
+		case "invalid id":
+			return &proto.PurgeUserResponse{Code: proto.CodeResult_INVALIDINPUT}, nil
+		default:
+			return &proto.PurgeUserResponse{Code: proto.CodeResult_FAILED}, nil
+		}
+	}
+
+	return &proto.PurgeUserResponse{Code: proto.CodeResult_OK}, nil
+}
+
+func toProtoUser(usr User) *proto.User {
+	pbUser := &proto.User{
+		Id:        usr.Id,
+		Name:      usr.Name,
+		Email:     usr.Email,
+		LastName:  usr.LastName,
+		Version:   usr.Version,
+		CreatedAt: mappers.ToTimestamp(usr.CreatedAt),
+		UpdatedAt: mappers.ToTimestamp(usr.UpdatedAt),
+		CreatedBy: usr.CreatedBy,
+		UpdatedBy: usr.UpdatedBy,
+		Status:    usr.Status,
+	}
+
+	if usr.DeletedAt != nil {
+		pbUser.DeletedAt = timestamppb.New(*usr.DeletedAt)
+	}
+
+	return pbUser
+}
+
+func decodeUserHistoryRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.HistoryRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return historyRequest{
+		UserID: reqData.UserId,
+		From:   mappers.ToTime(reqData.From),
+		To:     mappers.ToTime(reqData.To),
+		Actor:  reqData.Actor,
+	}, nil
+}
+
+func encodeUserHistoryResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(historyResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		if domain.IsUserErrorType(domain.ERRFORBIDDEN, respData.Error) {
+			return &proto.HistoryResponse{Code: proto.CodeResult_FORBIDDEN}, nil
+		}
+		switch respData.Error.Error() {
+		case "invalid id":
+			return &proto.HistoryResponse{Code: proto.CodeResult_INVALIDINPUT}, nil
+		default:
+			return &proto.HistoryResponse{Code: proto.CodeResult_FAILED}, nil
+		}
+	}
+
+	response := &proto.HistoryResponse{Code: proto.CodeResult_OK, Entries: []*proto.AuditEntry{}}
+
+	for _, e := range respData.Entries {
+		response.Entries = append(response.Entries, mappers.ToGrpcAuditEntry(e))
+	}
+
+	return response, nil
+}
+
+func decodeEmailChangeRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.EmailChangeRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return emailChangeRequest{Email: reqData.Email, NewEmail: reqData.NewEmail}, nil
+}
+
+func encodeEmailChangeResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(emailChangeResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	return &proto.EmailChangeResponse{Code: emailChangeCode(respData.Error)}, nil
+}
+
+func decodeConfirmEmailChangeRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.ConfirmEmailChangeRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return confirmEmailChangeRequest{Token: reqData.Token}, nil
+}
+
+func encodeConfirmEmailChangeResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(confirmEmailChangeResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.ConfirmEmailChangeResponse{Code: emailChangeCode(respData.Error)}, nil
+	}
+
+	return &proto.ConfirmEmailChangeResponse{Code: proto.CodeResult_OK, User: toProtoUser(respData.User)}, nil
+}
+
+// emailChangeCode maps the errors of the change email flow to a result code.
+func emailChangeCode(err error) proto.CodeResult {
+
+	if err == nil {
+		return proto.CodeResult_OK
+	}
+
+	if err == domain.ErrInvalidToken || domain.IsUserErrorType(domain.ERRINVALIDDATA, err) {
+		return proto.CodeResult_INVALIDINPUT
+	}
+
+	if domain.IsUserErrorType(domain.ERRVERSIONCONFLICT, err) {
+		return proto.CodeResult_CONFLICT
+	}
+
+	if domain.IsUserErrorType(domain.ERRFORBIDDEN, err) {
+		return proto.CodeResult_FORBIDDEN
+	}
+
+	switch err.Error() {
+	case "user not found":
+		return proto.CodeResult_NOTFOUND
+	case "user already exists":
+		return proto.CodeResult_CONFLICT
+	case "Email is not valid":
+		return proto.CodeResult_INVALIDINPUT
+	default:
+		return proto.CodeResult_FAILED
+	}
+}
+
+func decodeSendVerificationRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.EmailAddress)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return sendVerificationRequest{Email: reqData.Value}, nil
+}
+
+func encodeSendVerificationResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(sendVerificationResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	return &proto.SendVerificationResponse{Code: statusCode(respData.Error)}, nil
+}
+
+func decodeVerifyEmailRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.VerifyEmailRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return verifyEmailRequest{Token: reqData.Token}, nil
+}
+
+func encodeVerifyEmailResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(verifyEmailResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.VerifyEmailResponse{Code: statusCode(respData.Error)}, nil
+	}
+
+	return &proto.VerifyEmailResponse{Code: proto.CodeResult_OK, User: toProtoUser(respData.User)}, nil
+}
+
+func decodeChangeStatusRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.Id)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return changeStatusRequest{Id: reqData.Value}, nil
+}
+
+func encodeChangeStatusResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(changeStatusResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	return &proto.ChangeStatusResponse{Code: statusCode(respData.Error)}, nil
+}
+
+// statusCode maps the errors of the account lifecycle to a result code, a
+// transition the current status does not allow is a conflict.
+func statusCode(err error) proto.CodeResult {
+
+	if err == nil {
+		return proto.CodeResult_OK
+	}
+
+	if domain.IsUserErrorType(domain.ERRFORBIDDEN, err) {
+		return proto.CodeResult_FORBIDDEN
+	}
+
+	if domain.IsUserErrorType(domain.ERRINVALIDDATA, err) || domain.IsUserErrorType(domain.ERRVERSIONCONFLICT, err) {
+		return proto.CodeResult_CONFLICT
+	}
+
+	if err == domain.ErrInvalidToken {
+		return proto.CodeResult_INVALIDINPUT
+	}
+
+	switch err.Error() {
+	case "user not found":
+		return proto.CodeResult_NOTFOUND
+	case "invalid id":
+		return proto.CodeResult_INVALIDINPUT
+	default:
+		return proto.CodeResult_FAILED
+	}
+}
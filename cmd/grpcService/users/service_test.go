@@ -6,8 +6,10 @@ import (
 	"os"
 	"testing"
 
+	proto "github.com/casmelad/GlobantPOC/api/users/v1"
 	mappers "github.com/casmelad/GlobantPOC/cmd/grpcService/users/mappers"
-	proto "github.com/casmelad/GlobantPOC/cmd/grpcService/users/proto"
+	"github.com/casmelad/GlobantPOC/pkg/audit"
+	"github.com/casmelad/GlobantPOC/pkg/problems"
 	entities "github.com/casmelad/GlobantPOC/pkg/users"
 	"github.com/go-kit/log"
 	stdopentracing "github.com/opentracing/opentracing-go"
@@ -26,34 +28,89 @@ type applicationServiceMock struct {
 	mock.Mock
 }
 
-func (r applicationServiceMock) Create(ctx context.Context, u entities.User) (int, error) {
+func (r *applicationServiceMock) Create(ctx context.Context, u entities.User) (int, error) {
 	args := r.Called(ctx, u)
 	return args.Int(0), args.Error(1)
 }
 
-func (r applicationServiceMock) GetByEmail(ctx context.Context, email string) (entities.User, error) {
+func (r *applicationServiceMock) GetByEmail(ctx context.Context, email string) (entities.User, error) {
 	args := r.Called(ctx, email)
 	return args.Get(0).(entities.User), args.Error(1)
 }
 
-func (r applicationServiceMock) GetAll(ctx context.Context) ([]entities.User, error) {
-	args := r.Called(ctx)
+func (r *applicationServiceMock) GetAll(ctx context.Context, filter entities.Filter) ([]entities.User, error) {
+	args := r.Called(ctx, filter)
 	return args.Get(0).([]entities.User), args.Error(1)
 }
 
-func (r applicationServiceMock) Update(ctx context.Context, u entities.User) error {
+func (r *applicationServiceMock) Update(ctx context.Context, u entities.User) error {
 	args := r.Called(ctx, u)
 	return args.Error(0)
 }
 
-func (r applicationServiceMock) Delete(ctx context.Context, id int) error {
+func (r *applicationServiceMock) Patch(ctx context.Context, p entities.Patch) error {
+	args := r.Called(ctx, p)
+	return args.Error(0)
+}
+
+func (r *applicationServiceMock) Delete(ctx context.Context, id int, version int) error {
+	args := r.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (r *applicationServiceMock) Restore(ctx context.Context, id int) error {
 	args := r.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (r *applicationServiceMock) Purge(ctx context.Context, id int) error {
+	args := r.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (r *applicationServiceMock) RequestEmailChange(ctx context.Context, email string, newEmail string) error {
+	args := r.Called(ctx, email, newEmail)
+	return args.Error(0)
+}
+
+func (r *applicationServiceMock) ConfirmEmailChange(ctx context.Context, token string) (entities.EmailChange, error) {
+	args := r.Called(ctx, token)
+	return args.Get(0).(entities.EmailChange), args.Error(1)
+}
+
+func (r *applicationServiceMock) SendVerification(ctx context.Context, email string) error {
+	args := r.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (r *applicationServiceMock) VerifyEmail(ctx context.Context, token string) (entities.User, error) {
+	args := r.Called(ctx, token)
+	return args.Get(0).(entities.User), args.Error(1)
+}
+
+func (r *applicationServiceMock) Suspend(ctx context.Context, id int) error {
+	args := r.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (r *applicationServiceMock) Reactivate(ctx context.Context, id int) error {
+	args := r.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (r *applicationServiceMock) Deactivate(ctx context.Context, id int) error {
+	args := r.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (r *applicationServiceMock) History(ctx context.Context, id int, q audit.Query) ([]audit.Entry, error) {
+	args := r.Called(ctx, id, q)
+	return args.Get(0).([]audit.Entry), args.Error(1)
+}
+
 var emailAddress string = "test@gmail.com"
 var ctx context.Context = context.Background()
-var applicationService applicationServiceMock = applicationServiceMock{}
+var applicationService *applicationServiceMock = &applicationServiceMock{}
 var logger log.Logger = log.NewLogfmtLogger(os.Stderr)
 
 var zipkinTracer *zipkin.Tracer
@@ -61,34 +118,35 @@ var zipkinTracer *zipkin.Tracer
 // Determine which OpenTracing tracer to use. We'll pass the tracer to all the
 // components that use it, as a dependency.
 var tracer stdopentracing.Tracer
-var endpoints = NewGrpcUsersServer(applicationService)
-var grpcService proto.UsersServer = NewGrpcUserServer(*endpoints, tracer, zipkinTracer, logger)
+var endpoints = NewGrpcUsersServer(applicationService, applicationService)
+var grpcService proto.UsersServer = NewGrpcUserServer(*endpoints, nil, tracer, zipkinTracer, logger)
 
 func Test_GetUser_ValidEmail_ReturnsUser(t *testing.T) {
 	//Arrange
-	expectedValue := proto.User{Email: emailAddress}
-	applicationService.On("GetByEmail", expectedValue.Email).Return(entities.User{Email: expectedValue.Email}, nil).Once()
+	applicationService.On("GetByEmail", mock.Anything, emailAddress).Return(entities.User{ID: 1, Email: emailAddress}, nil).Once()
 	//Act
 	result, err := grpcService.GetUser(ctx, &proto.EmailAddress{Value: emailAddress})
 	//Assert
 	assert.Nil(t, err)
-	assert.Equal(t, &proto.GetUserResponse{User: &expectedValue}, result)
+	assert.Equal(t, int32(1), result.User.Id)
+	assert.Equal(t, emailAddress, result.User.Email)
 	applicationService.AssertExpectations(t)
 }
 
 func Test_GetUser_InvalidEmail_ReturnsNotFoundError(t *testing.T) {
 	//Arrange
-	applicationService.On("GetByEmail", emailAddress).Return(entities.User{}, errors.New("user not found")).Once()
+	applicationService.On("GetByEmail", mock.Anything, emailAddress).Return(entities.User{}, errors.New("user not found")).Once()
 	//Act
-	_, err := grpcService.GetUser(ctx, &proto.EmailAddress{Value: emailAddress})
+	result, err := grpcService.GetUser(ctx, &proto.EmailAddress{Value: emailAddress})
 	//Assert
-	assert.NotNil(t, err)
+	assert.Nil(t, err)
+	assert.Zero(t, result.User.Id)
 	applicationService.AssertExpectations(t)
 }
 
 func Test_GetAll_ReturnsNoError(t *testing.T) {
 	//Arrange
-	applicationService.On("GetAll").Return([]entities.User{{}}, nil).Once()
+	applicationService.On("GetAll", mock.Anything, mock.Anything).Return([]entities.User{{}}, nil).Once()
 	//Act
 	users, err := grpcService.GetAllUsers(ctx, &proto.Filters{})
 	//Assert
@@ -100,8 +158,8 @@ func Test_GetAll_ReturnsNoError(t *testing.T) {
 func Test_Create_ValidData_ReturnsNoError(t *testing.T) {
 	//Arrange
 	userToCreate := proto.User{}
-	mappedUser, _ := mappers.ToDomainUser(userToCreate)
-	applicationService.On("Create", mappedUser).Return(1, nil).Once()
+	mappedUser, _ := mappers.ToDomainUser(&userToCreate)
+	applicationService.On("Create", mock.Anything, mappedUser).Return(1, nil).Once()
 	//Act
 	result, err := grpcService.Create(ctx, &proto.CreateUserRequest{User: &userToCreate})
 	//Arrange
@@ -113,12 +171,12 @@ func Test_Create_ValidData_ReturnsNoError(t *testing.T) {
 func Test_Create_InvalidData_ReturnsInvalidDataError(t *testing.T) {
 	//Arrange
 	userToCreate := proto.User{}
-	mappedUser, _ := mappers.ToDomainUser(userToCreate)
-	applicationService.On("Create", mappedUser).Return(0, errors.New("some data is missing")).Once()
+	mappedUser, _ := mappers.ToDomainUser(&userToCreate)
+	applicationService.On("Create", mock.Anything, mappedUser).Return(0, errors.New("some data is missing")).Once()
 	//Act
 	result, err := grpcService.Create(ctx, &proto.CreateUserRequest{User: &userToCreate})
 	//Arrange
-	assert.NotNil(t, err)
+	assert.Nil(t, err)
 	assert.Equal(t, proto.CodeResult_INVALIDINPUT, result.Code)
 	applicationService.AssertExpectations(t)
 }
@@ -126,12 +184,12 @@ func Test_Create_InvalidData_ReturnsInvalidDataError(t *testing.T) {
 func Test_Create_DuplicatedData_ReturnsAlreadyExistsError(t *testing.T) {
 	//Arrange
 	userToCreate := proto.User{}
-	mappedUser, _ := mappers.ToDomainUser(userToCreate)
-	applicationService.On("Create", mappedUser).Return(0, errors.New("user already exists")).Once()
+	mappedUser, _ := mappers.ToDomainUser(&userToCreate)
+	applicationService.On("Create", mock.Anything, mappedUser).Return(0, errors.New("user already exists")).Once()
 	//Act
 	result, err := grpcService.Create(ctx, &proto.CreateUserRequest{User: &userToCreate})
 	//Arrange
-	assert.NotNil(t, err)
+	assert.Nil(t, err)
 	assert.Equal(t, proto.CodeResult_FAILED, result.Code)
 	applicationService.AssertExpectations(t)
 }
@@ -139,8 +197,8 @@ func Test_Create_DuplicatedData_ReturnsAlreadyExistsError(t *testing.T) {
 func Test_Update_ValidData_ReturnsNoError(t *testing.T) {
 	//Arrange
 	userToCreate := proto.User{}
-	mappedUser, _ := mappers.ToDomainUser(userToCreate)
-	applicationService.On("Update", mappedUser).Return(nil).Once()
+	mappedUser, _ := mappers.ToDomainUser(&userToCreate)
+	applicationService.On("Update", mock.Anything, mappedUser).Return(nil).Once()
 	//Act
 	result, err := grpcService.Update(ctx, &proto.UpdateUserRequest{User: &userToCreate})
 	//Arrange
@@ -152,12 +210,12 @@ func Test_Update_ValidData_ReturnsNoError(t *testing.T) {
 func Test_Update_InvalidUserData_ReturnsNotFoundError(t *testing.T) {
 	//Arrange
 	userToCreate := proto.User{}
-	mappedUser, _ := mappers.ToDomainUser(userToCreate)
-	applicationService.On("Update", mappedUser).Return(errors.New("user not found")).Once()
+	mappedUser, _ := mappers.ToDomainUser(&userToCreate)
+	applicationService.On("Update", mock.Anything, mappedUser).Return(errors.New("user not found")).Once()
 	//Act
 	result, err := grpcService.Update(ctx, &proto.UpdateUserRequest{User: &userToCreate})
 	//Arrange
-	assert.NotNil(t, err)
+	assert.Nil(t, err)
 	assert.Equal(t, proto.CodeResult_NOTFOUND, result.Code)
 	applicationService.AssertExpectations(t)
 }
@@ -165,12 +223,12 @@ func Test_Update_InvalidUserData_ReturnsNotFoundError(t *testing.T) {
 func Test_Update_InvalidUserData_ReturnsInvalidInputError(t *testing.T) {
 	//Arrange
 	userToCreate := proto.User{}
-	mappedUser, _ := mappers.ToDomainUser(userToCreate)
-	applicationService.On("Update", mappedUser).Return(errors.New("invalid input")).Once()
+	mappedUser, _ := mappers.ToDomainUser(&userToCreate)
+	applicationService.On("Update", mock.Anything, mappedUser).Return(errors.New("invalid input")).Once()
 	//Act
 	result, err := grpcService.Update(ctx, &proto.UpdateUserRequest{User: &userToCreate})
 	//Arrange
-	assert.NotNil(t, err)
+	assert.Nil(t, err)
 	assert.Equal(t, proto.CodeResult_INVALIDINPUT, result.Code)
 	applicationService.AssertExpectations(t)
 }
@@ -178,45 +236,114 @@ func Test_Update_InvalidUserData_ReturnsInvalidInputError(t *testing.T) {
 func Test_Update_InvalidUserData_ReturnsFailedError(t *testing.T) {
 	//Arrange
 	userToCreate := proto.User{}
-	mappedUser, _ := mappers.ToDomainUser(userToCreate)
-	applicationService.On("Update", mappedUser).Return(errors.New("cannot update the user")).Once()
+	mappedUser, _ := mappers.ToDomainUser(&userToCreate)
+	applicationService.On("Update", mock.Anything, mappedUser).Return(errors.New("cannot update the user information")).Once()
 	//Act
 	result, err := grpcService.Update(ctx, &proto.UpdateUserRequest{User: &userToCreate})
 	//Arrange
-	assert.NotNil(t, err)
+	assert.Nil(t, err)
 	assert.Equal(t, proto.CodeResult_FAILED, result.Code)
 	applicationService.AssertExpectations(t)
 }
 
+func Test_Update_NoVersion_ReturnsPreconditionRequiredError(t *testing.T) {
+	//Arrange
+	userToCreate := proto.User{}
+	mappedUser, _ := mappers.ToDomainUser(&userToCreate)
+	applicationService.On("Update", mock.Anything, mappedUser).Return(entities.UserError(entities.ERRVERSIONREQUIRED)).Once()
+	//Act
+	result, err := grpcService.Update(ctx, &proto.UpdateUserRequest{User: &userToCreate})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, proto.CodeResult_PRECONDITIONREQUIRED, result.Code)
+	assert.Equal(t, string(problems.CodePreconditionRequired), result.Details.Code)
+	applicationService.AssertExpectations(t)
+}
+
+func Test_Delete_NoVersion_ReturnsPreconditionRequiredError(t *testing.T) {
+	//Arrange
+	applicationService.On("Delete", mock.Anything, 1, 0).Return(entities.UserError(entities.ERRVERSIONREQUIRED)).Once()
+	//Act
+	result, err := grpcService.Delete(ctx, &proto.DeleteUserRequest{Id: 1})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, proto.CodeResult_PRECONDITIONREQUIRED, result.Code)
+	applicationService.AssertExpectations(t)
+}
+
 func Test_Delete_InvalidId_ReturnsNotFoundError(t *testing.T) {
 	//Arrange
-	applicationService.On("Delete", 1).Return(errors.New("user not found")).Once()
+	applicationService.On("Delete", mock.Anything, 1, 1).Return(errors.New("user not found")).Once()
 	//Act
-	result, err := grpcService.Delete(ctx, &proto.Id{Value: 1})
+	result, err := grpcService.Delete(ctx, &proto.DeleteUserRequest{Id: 1, Version: 1})
 	//Assert
-	assert.NotNil(t, err)
+	assert.Nil(t, err)
 	assert.Equal(t, proto.CodeResult_NOTFOUND, result.Code)
 	applicationService.AssertExpectations(t)
 }
 
 func Test_Delete_IdZero_ReturnsInvalidInputError(t *testing.T) {
 	//Arrange
-	applicationService.On("Delete", 0).Return(errors.New("invalid id")).Once()
+	applicationService.On("Delete", mock.Anything, 0, 1).Return(errors.New("invalid id")).Once()
 	//Act
-	result, err := grpcService.Delete(ctx, &proto.Id{Value: 0})
+	result, err := grpcService.Delete(ctx, &proto.DeleteUserRequest{Id: 0, Version: 1})
 	//Assert
-	assert.NotNil(t, err)
+	assert.Nil(t, err)
 	assert.Equal(t, proto.CodeResult_INVALIDINPUT, result.Code)
 	applicationService.AssertExpectations(t)
 }
 
 func Test_Delete_InternalError_ReturnsError(t *testing.T) {
 	//Arrange
-	applicationService.On("Delete", 1).Return(errors.New("user was not removed")).Once()
+	applicationService.On("Delete", mock.Anything, 1, 1).Return(errors.New("user was not removed")).Once()
 	//Act
-	result, err := grpcService.Delete(ctx, &proto.Id{Value: 1})
+	result, err := grpcService.Delete(ctx, &proto.DeleteUserRequest{Id: 1, Version: 1})
 	//Assert
-	assert.NotNil(t, err)
+	assert.Nil(t, err)
 	assert.Equal(t, proto.CodeResult_FAILED, result.Code)
 	applicationService.AssertExpectations(t)
 }
+
+func Test_Delete_AdminWithoutSecondFactor_ReturnsForbiddenError(t *testing.T) {
+	//Arrange
+	applicationService.On("Delete", mock.Anything, 1, 1).Return(entities.UserError(entities.ERRFORBIDDEN)).Once()
+	//Act
+	result, err := grpcService.Delete(ctx, &proto.DeleteUserRequest{Id: 1, Version: 1})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, proto.CodeResult_FORBIDDEN, result.Code)
+	applicationService.AssertExpectations(t)
+}
+
+func Test_Restore_NotAdmin_ReturnsForbiddenError(t *testing.T) {
+	//Arrange
+	applicationService.On("Restore", mock.Anything, 1).Return(entities.UserError(entities.ERRFORBIDDEN)).Once()
+	//Act
+	result, err := grpcService.Restore(ctx, &proto.Id{Value: 1})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, proto.CodeResult_FORBIDDEN, result.Code)
+	applicationService.AssertExpectations(t)
+}
+
+func Test_History_NotAdmin_ReturnsForbiddenError(t *testing.T) {
+	//Arrange
+	applicationService.On("History", mock.Anything, 1, audit.Query{}).Return([]audit.Entry(nil), entities.UserError(entities.ERRFORBIDDEN)).Once()
+	//Act
+	result, err := grpcService.History(ctx, &proto.HistoryRequest{UserId: 1})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, proto.CodeResult_FORBIDDEN, result.Code)
+	applicationService.AssertExpectations(t)
+}
+
+func Test_RequestEmailChange_OtherUser_ReturnsForbiddenError(t *testing.T) {
+	//Arrange
+	applicationService.On("RequestEmailChange", mock.Anything, emailAddress, "new@gmail.com").Return(entities.UserError(entities.ERRFORBIDDEN)).Once()
+	//Act
+	result, err := grpcService.RequestEmailChange(ctx, &proto.EmailChangeRequest{Email: emailAddress, NewEmail: "new@gmail.com"})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, proto.CodeResult_FORBIDDEN, result.Code)
+	applicationService.AssertExpectations(t)
+}
@@ -0,0 +1,574 @@
+package grpc
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/tracing/zipkin"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	stdzipkin "github.com/openzipkin/zipkin-go"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	usersv2 "github.com/casmelad/GlobantPOC/api/users/v2"
+	"github.com/casmelad/GlobantPOC/pkg/logging"
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	domain "github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+const (
+	// defaultPageSize is the size of the pages of ListUsers when the request
+	// does not set one.
+	defaultPageSize = 50
+	// maxPageSize is the largest page of ListUsers, the larger sizes are
+	// lowered to it.
+	maxPageSize = 1000
+)
+
+// accountStatuses are the domain statuses of the v2 status enum.
+var accountStatuses = map[usersv2.Status]domain.AccountStatus{
+	usersv2.Status_STATUS_PENDING:     domain.AccountPending,
+	usersv2.Status_STATUS_ACTIVE:      domain.AccountActive,
+	usersv2.Status_STATUS_SUSPENDED:   domain.AccountSuspended,
+	usersv2.Status_STATUS_DEACTIVATED: domain.AccountDeactivated,
+}
+
+type grpcUserV2ServerEndpoints struct {
+	GetUserEndpoint    endpoint.Endpoint
+	ListUsersEndpoint  endpoint.Endpoint
+	CreateUserEndpoint endpoint.Endpoint
+	UpdateUserEndpoint endpoint.Endpoint
+	DeleteUserEndpoint endpoint.Endpoint
+}
+
+func NewGrpcUsersV2Endpoints(s domain.Service, middleware ...endpoint.Middleware) *grpcUserV2ServerEndpoints {
+	mw := chain(middleware)
+
+	return &grpcUserV2ServerEndpoints{
+		GetUserEndpoint:    mw(MakeGetUserV2Endpoint(s)),
+		ListUsersEndpoint:  mw(MakeListUsersEndpoint(s)),
+		CreateUserEndpoint: mw(MakeCreateUserV2Endpoint(s)),
+		UpdateUserEndpoint: mw(MakeUpdateUserV2Endpoint(s)),
+		DeleteUserEndpoint: mw(MakeDeleteUserEndpoint(s)),
+	}
+}
+
+func MakeGetUserV2Endpoint(s domain.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(getUserRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		usr, err := s.GetByEmail(ctx, reqData.Email)
+
+		return userResponse{User: newUser(usr), Error: err}, nil
+	}
+}
+
+func MakeListUsersEndpoint(s domain.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(listUsersRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		usrs, err := s.GetAll(ctx, reqData.Filter)
+
+		if err != nil {
+			return listUsersResponse{Error: err}, nil
+		}
+
+		sort.Slice(usrs, func(i, j int) bool { return usrs[i].ID < usrs[j].ID })
+
+		page := listUsersResponse{Users: []User{}, Total: len(usrs)}
+
+		for _, usr := range usrs {
+			if usr.ID <= reqData.After {
+				continue
+			}
+			if len(page.Users) == reqData.PageSize {
+				page.Next = int(page.Users[len(page.Users)-1].Id)
+				break
+			}
+			page.Users = append(page.Users, newUser(usr))
+		}
+
+		return page, nil
+	}
+}
+
+// MakeCreateUserV2Endpoint creates the user and reads it back, so that the
+// call returns it as it was stored.
+func MakeCreateUserV2Endpoint(s domain.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(postUserRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		if _, err := s.Create(ctx, domain.User{Email: reqData.Email, Name: reqData.Name, LastName: reqData.LastName}); err != nil {
+			return userResponse{Error: err}, nil
+		}
+
+		usr, err := s.GetByEmail(ctx, reqData.Email)
+
+		return userResponse{User: newUser(usr), Error: err}, nil
+	}
+}
+
+// MakeUpdateUserV2Endpoint changes the user and reads it back, so that the
+// call returns it with its new version.
+func MakeUpdateUserV2Endpoint(s domain.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(updateUserRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		if reqData.Paths != nil {
+			err = s.Patch(ctx, domain.Patch{Email: reqData.Email, Version: int(reqData.Version), Paths: reqData.Paths, Name: reqData.Name, LastName: reqData.LastName})
+		} else {
+			err = s.Update(ctx, domain.User{Email: reqData.Email, Name: reqData.Name, LastName: reqData.LastName, Version: int(reqData.Version)})
+		}
+
+		if err != nil {
+			return userResponse{Error: err}, nil
+		}
+
+		usr, err := s.GetByEmail(ctx, reqData.Email)
+
+		return userResponse{User: newUser(usr), Error: err}, nil
+	}
+}
+
+type grpcUserV2Server struct {
+	usersv2.UsersServer
+	getUser    grpctransport.Handler
+	listUsers  grpctransport.Handler
+	createUser grpctransport.Handler
+	updateUser grpctransport.Handler
+	deleteUser grpctransport.Handler
+}
+
+// NewGrpcUserV2Server returns the server of the v2 users API. Unlike the v1
+// server its calls fail with the status code of the error, which carries the
+// code of the catalogue shared with the REST gateway.
+func NewGrpcUserV2Server(endpoints grpcUserV2ServerEndpoints, zipkinTracer *stdzipkin.Tracer, logger log.Logger) usersv2.UsersServer {
+
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorHandler(logging.NewErrorHandler(logger)),
+		grpctransport.ServerBefore(callerFromMetadata, requestFromMetadata),
+	}
+
+	if zipkinTracer != nil {
+		options = append(options, zipkin.GRPCServerTrace(zipkinTracer))
+	}
+
+	return &grpcUserV2Server{
+		getUser:    grpctransport.NewServer(endpoints.GetUserEndpoint, decodeGetUserV2Request, encodeUserV2Response, options...),
+		listUsers:  grpctransport.NewServer(endpoints.ListUsersEndpoint, decodeListUsersRequest, encodeListUsersResponse, options...),
+		createUser: grpctransport.NewServer(endpoints.CreateUserEndpoint, decodeCreateUserV2Request, encodeUserV2Response, options...),
+		updateUser: grpctransport.NewServer(endpoints.UpdateUserEndpoint, decodeUpdateUserV2Request, encodeUserV2Response, options...),
+		deleteUser: grpctransport.NewServer(endpoints.DeleteUserEndpoint, decodeDeleteUserV2Request, encodeDeleteUserV2Response, options...),
+	}
+}
+
+func (u grpcUserV2Server) GetUser(ctx context.Context, req *usersv2.GetUserRequest) (*usersv2.User, error) {
+
+	_, grpcResponse, err := u.getUser.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return applyReadMask(grpcResponse.(*usersv2.User), req.ReadMask), nil
+}
+
+func (u grpcUserV2Server) ListUsers(ctx context.Context, req *usersv2.ListUsersRequest) (*usersv2.ListUsersResponse, error) {
+
+	_, grpcResponse, err := u.listUsers.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	response := grpcResponse.(*usersv2.ListUsersResponse)
+
+	for _, user := range response.Users {
+		applyReadMask(user, req.ReadMask)
+	}
+
+	return response, nil
+}
+
+func (u grpcUserV2Server) CreateUser(ctx context.Context, req *usersv2.CreateUserRequest) (*usersv2.User, error) {
+
+	_, grpcResponse, err := u.createUser.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*usersv2.User), nil
+}
+
+func (u grpcUserV2Server) UpdateUser(ctx context.Context, req *usersv2.UpdateUserRequest) (*usersv2.User, error) {
+
+	_, grpcResponse, err := u.updateUser.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*usersv2.User), nil
+}
+
+func (u grpcUserV2Server) DeleteUser(ctx context.Context, req *usersv2.DeleteUserRequest) (*emptypb.Empty, error) {
+
+	_, grpcResponse, err := u.deleteUser.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*emptypb.Empty), nil
+}
+
+func decodeGetUserV2Request(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*usersv2.GetUserRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	if err := validReadMask(reqData.ReadMask); err != nil {
+		return nil, err
+	}
+
+	return getUserRequest{Email: reqData.Email}, nil
+}
+
+func decodeListUsersRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*usersv2.ListUsersRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	if err := validReadMask(reqData.ReadMask); err != nil {
+		return nil, err
+	}
+
+	request := listUsersRequest{
+		Filter:   domain.Filter{IncludeDeleted: reqData.ShowDeleted, Status: accountStatuses[reqData.Status]},
+		PageSize: int(reqData.PageSize),
+	}
+
+	switch {
+	case reqData.PageSize < 0:
+		return nil, badRequest("page_size", "min", "page_size can not be negative")
+	case reqData.PageSize == 0:
+		request.PageSize = defaultPageSize
+	case reqData.PageSize > maxPageSize:
+		request.PageSize = maxPageSize
+	}
+
+	after, err := pageAfter(reqData.PageToken)
+
+	if err != nil {
+		return nil, badRequest("page_token", "format", "page_token is not the token of a page")
+	}
+
+	request.After = after
+
+	return request, nil
+}
+
+func encodeListUsersResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(listUsersResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return nil, statusError(respData.Error)
+	}
+
+	response := &usersv2.ListUsersResponse{Users: []*usersv2.User{}, NextPageToken: pageToken(respData.Next), TotalSize: int32(respData.Total)}
+
+	for _, usr := range respData.Users {
+		response.Users = append(response.Users, toV2User(usr))
+	}
+
+	return response, nil
+}
+
+func decodeCreateUserV2Request(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*usersv2.CreateUserRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	if reqData.User == nil {
+		return nil, badRequest("user", "required", "user is required")
+	}
+
+	return postUserRequest{User: User{Email: reqData.User.Email, Name: reqData.User.Name, LastName: reqData.User.LastName}}, nil
+}
+
+func decodeUpdateUserV2Request(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*usersv2.UpdateUserRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	switch {
+	case reqData.User == nil:
+		return nil, badRequest("user", "required", "user is required")
+	case reqData.User.Version <= 0:
+		return nil, badRequest("user.version", "required", "user.version is required")
+	}
+
+	request := updateUserRequest{User: User{Email: reqData.User.Email, Name: reqData.User.Name, LastName: reqData.User.LastName, Version: reqData.User.Version}}
+
+	if reqData.UpdateMask != nil {
+		for _, path := range reqData.UpdateMask.Paths {
+			if path != domain.NamePath && path != domain.LastNamePath {
+				return nil, badRequest("update_mask", "oneof", fmt.Sprintf("%s can not be updated", path))
+			}
+		}
+		request.Paths = append([]string{}, reqData.UpdateMask.Paths...)
+	}
+
+	return request, nil
+}
+
+// encodeUserV2Response encodes the user of the reads and the writes, the
+// read masks are applied by the calls that take one.
+func encodeUserV2Response(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(userResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return nil, statusError(respData.Error)
+	}
+
+	return toV2User(respData.User), nil
+}
+
+func decodeDeleteUserV2Request(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*usersv2.DeleteUserRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return deleteUserRequest{Id: reqData.Id, Version: reqData.Version}, nil
+}
+
+func encodeDeleteUserV2Response(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(deleteUserResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return nil, statusError(respData.Error)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func toV2User(usr User) *usersv2.User {
+
+	user := &usersv2.User{
+		Id:        usr.Id,
+		Email:     usr.Email,
+		Name:      usr.Name,
+		LastName:  usr.LastName,
+		Version:   usr.Version,
+		CreatedBy: usr.CreatedBy,
+		UpdatedBy: usr.UpdatedBy,
+	}
+
+	for s, status := range accountStatuses {
+		if string(status) == usr.Status {
+			user.Status = s
+		}
+	}
+
+	user.CreateTime = timestamp(usr.CreatedAt)
+	user.UpdateTime = timestamp(usr.UpdatedAt)
+
+	if usr.DeletedAt != nil {
+		user.DeleteTime = timestamp(*usr.DeletedAt)
+	}
+
+	return user
+}
+
+// timestamp returns nil for the zero time, so that it is left unset.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// validReadMask fails with INVALID_ARGUMENT when the mask names a field the
+// user does not have.
+func validReadMask(mask *fieldmaskpb.FieldMask) error {
+	if mask == nil || mask.IsValid(&usersv2.User{}) {
+		return nil
+	}
+	return badRequest("read_mask", "oneof", "read_mask names a field the user does not have")
+}
+
+// applyReadMask clears the fields of the user that are not in the mask, a
+// nil mask keeps every field.
+func applyReadMask(user *usersv2.User, mask *fieldmaskpb.FieldMask) *usersv2.User {
+
+	if mask == nil {
+		return user
+	}
+
+	keep := map[string]bool{}
+
+	for _, path := range mask.Paths {
+		keep[path] = true
+	}
+
+	msg := user.ProtoReflect()
+
+	msg.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if !keep[string(fd.Name())] {
+			msg.Clear(fd)
+		}
+		return true
+	})
+
+	return user
+}
+
+// pageToken returns the opaque token of the page that starts after the user
+// with the given id, an empty token when there is no such page.
+func pageToken(after int) string {
+	if after == 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(after)))
+}
+
+// pageAfter returns the id the page of the token starts after.
+func pageAfter(token string) (int, error) {
+
+	if token == "" {
+		return 0, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+
+	if err != nil {
+		return 0, err
+	}
+
+	after, err := strconv.Atoi(string(data))
+
+	if err != nil || after <= 0 {
+		return 0, errors.New("invalid page token")
+	}
+
+	return after, nil
+}
+
+// badRequest is the INVALID_ARGUMENT status of a field of the request that
+// is not valid.
+func badRequest(field, rule, description string) error {
+	return withDetails(status.New(problems.Lookup(problems.CodeBadRequest).GRPC, description), problems.CodeBadRequest,
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: description}}})
+}
+
+// statusError converts the errors of the users service into the status the
+// v2 calls fail with: the gRPC code of the catalogue error they are
+// translated to, along with its ErrorInfo and the violations of the fields
+// that are not valid. The unexpected errors do not describe themselves.
+func statusError(err error) error {
+
+	var validation domain.ValidationError
+
+	if errors.As(err, &validation) {
+		violations := &errdetails.BadRequest{}
+		for _, v := range validation.Violations {
+			violations.FieldViolations = append(violations.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: v.Field, Description: v.Description})
+		}
+		return withDetails(status.New(problems.Lookup(problems.CodeValidationFailed).GRPC, err.Error()), problems.CodeValidationFailed, violations)
+	}
+
+	code := problems.CodeInternal
+
+	switch {
+	case domain.IsUserErrorType(domain.ERRFORBIDDEN, err):
+		code = problems.CodeForbidden
+	case domain.IsUserErrorType(domain.ERRVERSIONCONFLICT, err):
+		code = problems.CodeVersionConflict
+	case domain.IsUserErrorType(domain.ERRVERSIONREQUIRED, err):
+		code = problems.CodePreconditionRequired
+	case domain.IsUserErrorType(domain.ERRINVALIDDATA, err):
+		code = problems.CodeValidationFailed
+	case domain.IsUserErrorType(domain.ERRNOTFOUND, err), err.Error() == domain.USERNOTFOUND:
+		code = problems.CodeNotFound
+	case domain.IsUserErrorType(domain.ERRALREADYEXISTS, err), err.Error() == domain.USERALREADYEXISTS:
+		code = problems.CodeAlreadyExists
+	case err.Error() == "invalid id":
+		code = problems.CodeBadRequest
+	}
+
+	message := err.Error()
+
+	if code == problems.CodeInternal {
+		message = problems.Lookup(code).Title
+	}
+
+	return withDetails(status.New(problems.Lookup(code).GRPC, message), code)
+}
+
+// withDetails adds the ErrorInfo of code and the given details to st.
+func withDetails(st *status.Status, code problems.Code, details ...*errdetails.BadRequest) error {
+
+	withInfo, err := st.WithDetails(problems.ErrorInfo(code))
+
+	if err != nil {
+		return st.Err()
+	}
+
+	for _, d := range details {
+		if withDetail, err := withInfo.WithDetails(d); err == nil {
+			withInfo = withDetail
+		}
+	}
+
+	return withInfo.Err()
+}
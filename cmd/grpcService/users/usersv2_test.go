@@ -0,0 +1,159 @@
+package grpc
+
+import (
+	"errors"
+	"testing"
+
+	usersv2 "github.com/casmelad/GlobantPOC/api/users/v2"
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	entities "github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func newV2Server(service *applicationServiceMock) usersv2.UsersServer {
+	return NewGrpcUserV2Server(*NewGrpcUsersV2Endpoints(service), nil, logger)
+}
+
+// statusDetails returns the code of the status of err, its catalogue code
+// and the fields of its BadRequest detail.
+func statusDetails(err error) (codes.Code, problems.Code, []string) {
+	st := status.Convert(err)
+	var fields []string
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				fields = append(fields, v.Field)
+			}
+		}
+	}
+	return st.Code(), problems.FromStatus(st), fields
+}
+
+func Test_GetUserV2_UnknownEmail_ReturnsNotFound(t *testing.T) {
+	//Arrange
+	service := &applicationServiceMock{}
+	service.On("GetByEmail", mock.Anything, emailAddress).Return(entities.User{}, errors.New("user not found")).Once()
+	//Act
+	_, err := newV2Server(service).GetUser(ctx, &usersv2.GetUserRequest{Email: emailAddress})
+	//Assert
+	code, problem, _ := statusDetails(err)
+	assert.Equal(t, codes.NotFound, code)
+	assert.Equal(t, problems.CodeNotFound, problem)
+	service.AssertExpectations(t)
+}
+
+func Test_GetUserV2_ReadMask_ReturnsOnlyMaskedFields(t *testing.T) {
+	//Arrange
+	service := &applicationServiceMock{}
+	service.On("GetByEmail", mock.Anything, emailAddress).Return(entities.User{ID: 7, Email: emailAddress, Name: "Larry", LastName: "Page", Status: entities.AccountActive}, nil).Once()
+	//Act
+	user, err := newV2Server(service).GetUser(ctx, &usersv2.GetUserRequest{Email: emailAddress, ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"email", "status"}}})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, emailAddress, user.Email)
+	assert.Equal(t, usersv2.Status_STATUS_ACTIVE, user.Status)
+	assert.Empty(t, user.Name)
+	assert.Zero(t, user.Id)
+}
+
+func Test_GetUserV2_UnknownReadMaskField_ReturnsInvalidArgument(t *testing.T) {
+	//Arrange
+	service := &applicationServiceMock{}
+	//Act
+	_, err := newV2Server(service).GetUser(ctx, &usersv2.GetUserRequest{Email: emailAddress, ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"password"}}})
+	//Assert
+	code, _, fields := statusDetails(err)
+	assert.Equal(t, codes.InvalidArgument, code)
+	assert.Equal(t, []string{"read_mask"}, fields)
+	service.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+}
+
+func Test_ListUsers_PageSize_PagesThroughUsersById(t *testing.T) {
+	//Arrange
+	service := &applicationServiceMock{}
+	service.On("GetAll", mock.Anything, entities.Filter{}).Return([]entities.User{{ID: 3}, {ID: 1}, {ID: 2}}, nil).Twice()
+	server := newV2Server(service)
+	//Act
+	first, firstErr := server.ListUsers(ctx, &usersv2.ListUsersRequest{PageSize: 2})
+	second, secondErr := server.ListUsers(ctx, &usersv2.ListUsersRequest{PageSize: 2, PageToken: first.GetNextPageToken()})
+	//Assert
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Equal(t, []int32{1, 2}, []int32{first.Users[0].Id, first.Users[1].Id})
+	assert.NotEmpty(t, first.NextPageToken)
+	assert.Equal(t, int32(3), first.TotalSize)
+	assert.Len(t, second.Users, 1)
+	assert.Equal(t, int32(3), second.Users[0].Id)
+	assert.Empty(t, second.NextPageToken)
+}
+
+func Test_ListUsers_InvalidPageToken_ReturnsInvalidArgument(t *testing.T) {
+	//Arrange
+	service := &applicationServiceMock{}
+	//Act
+	_, err := newV2Server(service).ListUsers(ctx, &usersv2.ListUsersRequest{PageToken: "not a token"})
+	//Assert
+	code, _, fields := statusDetails(err)
+	assert.Equal(t, codes.InvalidArgument, code)
+	assert.Equal(t, []string{"page_token"}, fields)
+}
+
+func Test_CreateUserV2_InvalidData_ListsEveryField(t *testing.T) {
+	//Arrange
+	service := &applicationServiceMock{}
+	invalid := entities.ValidationError{Violations: []entities.FieldViolation{{Field: "name", Rule: "required"}, {Field: "lastname", Rule: "required"}}}
+	service.On("Create", mock.Anything, entities.User{Email: emailAddress}).Return(0, invalid).Once()
+	//Act
+	_, err := newV2Server(service).CreateUser(ctx, &usersv2.CreateUserRequest{User: &usersv2.User{Email: emailAddress}})
+	//Assert
+	code, problem, fields := statusDetails(err)
+	assert.Equal(t, codes.InvalidArgument, code)
+	assert.Equal(t, problems.CodeValidationFailed, problem)
+	assert.Equal(t, []string{"name", "lastname"}, fields)
+}
+
+func Test_UpdateUserV2_StaleVersion_ReturnsAborted(t *testing.T) {
+	//Arrange
+	service := &applicationServiceMock{}
+	patch := entities.Patch{Email: emailAddress, Version: 2, Paths: []string{entities.NamePath}, Name: "Larry"}
+	service.On("Patch", mock.Anything, patch).Return(entities.UserError(entities.ERRVERSIONCONFLICT)).Once()
+	//Act
+	_, err := newV2Server(service).UpdateUser(ctx, &usersv2.UpdateUserRequest{
+		User:       &usersv2.User{Email: emailAddress, Name: "Larry", Version: 2},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+	})
+	//Assert
+	code, problem, _ := statusDetails(err)
+	assert.Equal(t, codes.Aborted, code)
+	assert.Equal(t, problems.CodeVersionConflict, problem)
+	service.AssertExpectations(t)
+}
+
+func Test_UpdateUserV2_UpdatedUser_ReturnsNewVersion(t *testing.T) {
+	//Arrange
+	service := &applicationServiceMock{}
+	service.On("Update", mock.Anything, entities.User{Email: emailAddress, Name: "Larry", LastName: "Page", Version: 2}).Return(nil).Once()
+	service.On("GetByEmail", mock.Anything, emailAddress).Return(entities.User{Email: emailAddress, Name: "Larry", LastName: "Page", Version: 3}, nil).Once()
+	//Act
+	user, err := newV2Server(service).UpdateUser(ctx, &usersv2.UpdateUserRequest{User: &usersv2.User{Email: emailAddress, Name: "Larry", LastName: "Page", Version: 2}})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), user.Version)
+	service.AssertExpectations(t)
+}
+
+func Test_UpdateUserV2_MissingVersion_ReturnsInvalidArgument(t *testing.T) {
+	//Arrange
+	service := &applicationServiceMock{}
+	//Act
+	_, err := newV2Server(service).UpdateUser(ctx, &usersv2.UpdateUserRequest{User: &usersv2.User{Email: emailAddress, Name: "Larry"}})
+	//Assert
+	code, _, fields := statusDetails(err)
+	assert.Equal(t, codes.InvalidArgument, code)
+	assert.Equal(t, []string{"user.version"}, fields)
+}
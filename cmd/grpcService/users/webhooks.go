@@ -0,0 +1,353 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/tracing/zipkin"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	stdzipkin "github.com/openzipkin/zipkin-go"
+
+	proto "github.com/casmelad/GlobantPOC/api/users/v1"
+	"github.com/casmelad/GlobantPOC/cmd/grpcService/users/mappers"
+	"github.com/casmelad/GlobantPOC/pkg/logging"
+	domain "github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/casmelad/GlobantPOC/pkg/webhooks"
+)
+
+// webhookManager manages the webhook subscriptions, it is implemented by
+// webhooks.Service.
+type webhookManager interface {
+	Subscribe(context.Context, webhooks.Subscription) (webhooks.Subscription, error)
+	Subscription(context.Context, int) (webhooks.Subscription, error)
+	Subscriptions(context.Context) ([]webhooks.Subscription, error)
+	Unsubscribe(context.Context, int) error
+	Deliveries(context.Context, webhooks.DeliveryQuery) ([]webhooks.Delivery, error)
+}
+
+type grpcWebhookServerEndpoints struct {
+	SubscribeEndpoint         endpoint.Endpoint
+	GetSubscriptionEndpoint   endpoint.Endpoint
+	ListSubscriptionsEndpoint endpoint.Endpoint
+	UnsubscribeEndpoint       endpoint.Endpoint
+	DeliveriesEndpoint        endpoint.Endpoint
+}
+
+func NewGrpcWebhookEndpoints(s webhookManager, middleware ...endpoint.Middleware) *grpcWebhookServerEndpoints {
+	mw := chain(middleware)
+
+	return &grpcWebhookServerEndpoints{
+		SubscribeEndpoint:         mw(MakeSubscribeEndpoint(s)),
+		GetSubscriptionEndpoint:   mw(MakeGetSubscriptionEndpoint(s)),
+		ListSubscriptionsEndpoint: mw(MakeListSubscriptionsEndpoint(s)),
+		UnsubscribeEndpoint:       mw(MakeUnsubscribeEndpoint(s)),
+		DeliveriesEndpoint:        mw(MakeDeliveriesEndpoint(s)),
+	}
+}
+
+func MakeSubscribeEndpoint(s webhookManager) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(subscribeRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		sub, err := s.Subscribe(ctx, reqData.Subscription)
+
+		return subscribeResponse{Subscription: sub, Error: err}, nil
+	}
+}
+
+func MakeGetSubscriptionEndpoint(s webhookManager) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(subscriptionRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		sub, err := s.Subscription(ctx, int(reqData.Id))
+
+		return subscriptionResponse{Subscription: sub, Error: err}, nil
+	}
+}
+
+func MakeListSubscriptionsEndpoint(s webhookManager) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		if _, validCast := request.(listSubscriptionsRequest); !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		subs, err := s.Subscriptions(ctx)
+
+		return listSubscriptionsResponse{Subscriptions: subs, Error: err}, nil
+	}
+}
+
+func MakeUnsubscribeEndpoint(s webhookManager) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(unsubscribeRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		err = s.Unsubscribe(ctx, int(reqData.Id))
+
+		return unsubscribeResponse{Error: err}, nil
+	}
+}
+
+func MakeDeliveriesEndpoint(s webhookManager) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(deliveriesRequest)
+
+		if !validCast {
+			return nil, errors.New("invalid request type")
+		}
+
+		deliveries, err := s.Deliveries(ctx, reqData.Query)
+
+		return deliveriesResponse{Deliveries: deliveries, Error: err}, nil
+	}
+}
+
+type grpcWebhookServer struct {
+	proto.WebhooksServer
+	subscribe         grpctransport.Handler
+	getSubscription   grpctransport.Handler
+	listSubscriptions grpctransport.Handler
+	unsubscribe       grpctransport.Handler
+	deliveries        grpctransport.Handler
+}
+
+func NewGrpcWebhookServer(endpoints grpcWebhookServerEndpoints, zipkinTracer *stdzipkin.Tracer, logger log.Logger) proto.WebhooksServer {
+
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorHandler(logging.NewErrorHandler(logger)),
+		grpctransport.ServerBefore(callerFromMetadata, requestFromMetadata),
+	}
+
+	if zipkinTracer != nil {
+		options = append(options, zipkin.GRPCServerTrace(zipkinTracer))
+	}
+
+	return &grpcWebhookServer{
+		subscribe:         grpctransport.NewServer(endpoints.SubscribeEndpoint, decodeSubscribeRequest, encodeSubscribeResponse, options...),
+		getSubscription:   grpctransport.NewServer(endpoints.GetSubscriptionEndpoint, decodeSubscriptionRequest, encodeSubscriptionResponse, options...),
+		listSubscriptions: grpctransport.NewServer(endpoints.ListSubscriptionsEndpoint, decodeListSubscriptionsRequest, encodeListSubscriptionsResponse, options...),
+		unsubscribe:       grpctransport.NewServer(endpoints.UnsubscribeEndpoint, decodeUnsubscribeRequest, encodeUnsubscribeResponse, options...),
+		deliveries:        grpctransport.NewServer(endpoints.DeliveriesEndpoint, decodeDeliveriesRequest, encodeDeliveriesResponse, options...),
+	}
+}
+
+func (w grpcWebhookServer) Subscribe(ctx context.Context, req *proto.SubscribeRequest) (*proto.SubscribeResponse, error) {
+
+	_, grpcResponse, err := w.subscribe.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.SubscribeResponse), nil
+}
+
+func (w grpcWebhookServer) GetSubscription(ctx context.Context, id *proto.Id) (*proto.GetSubscriptionResponse, error) {
+
+	_, grpcResponse, err := w.getSubscription.ServeGRPC(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.GetSubscriptionResponse), nil
+}
+
+func (w grpcWebhookServer) ListSubscriptions(ctx context.Context, req *proto.ListSubscriptionsRequest) (*proto.ListSubscriptionsResponse, error) {
+
+	_, grpcResponse, err := w.listSubscriptions.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.ListSubscriptionsResponse), nil
+}
+
+func (w grpcWebhookServer) Unsubscribe(ctx context.Context, id *proto.Id) (*proto.UnsubscribeResponse, error) {
+
+	_, grpcResponse, err := w.unsubscribe.ServeGRPC(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.UnsubscribeResponse), nil
+}
+
+func (w grpcWebhookServer) Deliveries(ctx context.Context, req *proto.DeliveriesRequest) (*proto.DeliveriesResponse, error) {
+
+	_, grpcResponse, err := w.deliveries.ServeGRPC(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcResponse.(*proto.DeliveriesResponse), nil
+}
+
+func decodeSubscribeRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.SubscribeRequest)
+
+	if !validCast || reqData.Subscription == nil {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return subscribeRequest{Subscription: mappers.ToDomainSubscription(reqData.Subscription)}, nil
+}
+
+func encodeSubscribeResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(subscribeResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.SubscribeResponse{Code: webhookCode(respData.Error)}, nil
+	}
+
+	// The secret is handed back once, when the subscription is created.
+	sub := mappers.ToGrpcSubscription(respData.Subscription)
+	sub.Secret = respData.Subscription.Secret
+
+	return &proto.SubscribeResponse{Code: proto.CodeResult_OK, Subscription: sub}, nil
+}
+
+func decodeSubscriptionRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.Id)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return subscriptionRequest{Id: reqData.Value}, nil
+}
+
+func encodeSubscriptionResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(subscriptionResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.GetSubscriptionResponse{Code: webhookCode(respData.Error)}, nil
+	}
+
+	return &proto.GetSubscriptionResponse{Code: proto.CodeResult_OK, Subscription: mappers.ToGrpcSubscription(respData.Subscription)}, nil
+}
+
+func decodeListSubscriptionsRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	if _, validCast := req.(*proto.ListSubscriptionsRequest); !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return listSubscriptionsRequest{}, nil
+}
+
+func encodeListSubscriptionsResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(listSubscriptionsResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.ListSubscriptionsResponse{Code: webhookCode(respData.Error)}, nil
+	}
+
+	response := &proto.ListSubscriptionsResponse{Code: proto.CodeResult_OK, Subscriptions: []*proto.WebhookSubscription{}}
+
+	for _, sub := range respData.Subscriptions {
+		response.Subscriptions = append(response.Subscriptions, mappers.ToGrpcSubscription(sub))
+	}
+
+	return response, nil
+}
+
+func decodeUnsubscribeRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.Id)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return unsubscribeRequest{Id: reqData.Value}, nil
+}
+
+func encodeUnsubscribeResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(unsubscribeResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.UnsubscribeResponse{Code: webhookCode(respData.Error)}, nil
+	}
+
+	return &proto.UnsubscribeResponse{Code: proto.CodeResult_OK}, nil
+}
+
+func decodeDeliveriesRequest(ctx context.Context, req interface{}) (interface{}, error) {
+	reqData, validCast := req.(*proto.DeliveriesRequest)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to decode")
+	}
+
+	return deliveriesRequest{Query: webhooks.DeliveryQuery{
+		SubscriptionID: int(reqData.SubscriptionId),
+		Status:         webhooks.DeliveryStatus(reqData.Status),
+	}}, nil
+}
+
+func encodeDeliveriesResponse(ctx context.Context, resp interface{}) (interface{}, error) {
+	respData, validCast := resp.(deliveriesResponse)
+
+	if !validCast {
+		return nil, errors.New("invalid input data to encode")
+	}
+
+	if respData.Error != nil {
+		return &proto.DeliveriesResponse{Code: webhookCode(respData.Error)}, nil
+	}
+
+	response := &proto.DeliveriesResponse{Code: proto.CodeResult_OK, Deliveries: []*proto.WebhookDelivery{}}
+
+	for _, d := range respData.Deliveries {
+		response.Deliveries = append(response.Deliveries, mappers.ToGrpcDelivery(d))
+	}
+
+	return response, nil
+}
+
+// webhookCode translates the errors of the webhooks service into the result
+// code of the response.
+func webhookCode(err error) proto.CodeResult {
+	if domain.IsUserErrorType(domain.ERRFORBIDDEN, err) {
+		return proto.CodeResult_FORBIDDEN
+	}
+	switch err {
+	case webhooks.ErrSubscriptionNotFound:
+		return proto.CodeResult_NOTFOUND
+	case webhooks.ErrInvalidSubscription, webhooks.ErrInvalidDeliveryQuery:
+		return proto.CodeResult_INVALIDINPUT
+	default:
+		return proto.CodeResult_FAILED
+	}
+}
@@ -1,16 +1,26 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/casmelad/GlobantPOC/cmd/restService/users"
+	"github.com/casmelad/GlobantPOC/pkg/instrumentation"
+	"github.com/casmelad/GlobantPOC/pkg/logging"
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/tracing"
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	zipkinhttp "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	glog "google.golang.org/grpc/grpclog"
 )
 
@@ -23,34 +33,110 @@ func init() {
 func main() {
 
 	cfg := config{}
-
-	if err := env.Parse(&cfg); err != nil {
-		fmt.Printf("%+v\n", err)
-	}
+	cfgErr := env.Parse(&cfg)
 
 	var (
 		httpAddr = flag.String("http.addr", ":8080", "HTTP listen address")
 	)
 	flag.Parse()
 
-	var logger log.Logger
-	{
-		logger = log.NewLogfmtLogger(os.Stderr)
-		logger = log.With(logger, "ts", log.DefaultTimestampUTC)
-		logger = log.With(logger, "caller", log.DefaultCaller)
+	logger, err := logging.New(os.Stderr, logging.Config{Level: cfg.LogLevel, Format: cfg.LogFormat})
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if cfgErr != nil {
+		level.Error(logger).Log("err", cfgErr)
 	}
 
+	if _, err := os.Stat(cfg.PublicKeyFile); err != nil {
+		level.Warn(logger).Log("msg", "AUTH_PUBLIC_KEY_FILE can not be read, the bearer tokens are rejected", "err", err)
+	}
+
+	users.EventsHeartbeat = cfg.EventsHeartbeat
+	users.PublicKeyFile = cfg.PublicKeyFile
+
+	rateLimits, err := users.ParseRateLimitRules(cfg.RateLimits)
+
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+
+	zipkinTracer, closeTracer, err := tracing.Setup(context.Background(), tracing.Settings{
+		Provider: cfg.TraceProvider,
+		Zipkin: tracing.Config{
+			URL:         cfg.TraceURL,
+			ServiceName: cfg.TraceService,
+			HostPort:    cfg.TraceHost + *httpAddr,
+			SampleRate:  cfg.TraceSampleRate,
+		},
+		OTel: tracing.OTelConfig{
+			Exporter:    cfg.TraceExporter,
+			Endpoint:    cfg.TraceEndpoint,
+			Insecure:    cfg.TraceInsecure,
+			File:        cfg.TraceFile,
+			ServiceName: cfg.TraceService,
+			SampleRate:  cfg.TraceSampleRate,
+		},
+	})
+
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+
+	defer closeTracer()
+
+	instrumenting := users.InstrumentingMiddleware(instrumentation.NewRED("users", "http"))
+	logged := users.LoggingMiddleware(log.With(logger, "component", "endpoint"))
+
 	var h http.Handler
 	{
-		h = users.MakeHTTPHandler(users.UserProxy{}, log.With(logger, "component", "HTTP"))
+		mux := http.NewServeMux()
+		mux.Handle(users.UsersBaseUri, users.MakeHTTPHandler(users.UserProxy{}, log.With(logger, "component", "HTTP"), instrumenting, logged))
+		mux.Handle(users.AuthBaseUri, users.MakeAuthHTTPHandler(users.AuthProxy{}, log.With(logger, "component", "HTTP"), instrumenting, logged))
+		mux.Handle(users.WebhooksBaseUri, users.MakeWebhooksHTTPHandler(users.WebhookProxy{}, log.With(logger, "component", "HTTP"), instrumenting, logged))
+		docs := users.MakeOpenAPIHandler()
+		mux.Handle(users.OpenAPIDocument, docs)
+		mux.Handle(users.APIDocs, docs)
+
+		// The routes the hand-written handlers do not serve are transcoded
+		// into calls of the annotated RPCs, as the ones of the v2 API.
+		if cfg.Transcoding {
+			transcoder, err := users.MakeTranscodingHandler(cfg.MaxBodyBytes)
+			if err != nil {
+				level.Error(logger).Log("err", err)
+				os.Exit(1)
+			}
+			for _, route := range transcoder.Routes() {
+				level.Debug(logger).Log("transcoding", route)
+			}
+			mux.Handle("/", transcoder)
+		}
+
+		h = mux
 	}
 
-	handler := users.UUIDContextMiddleware(h)
+	validator, err := users.NewRequestValidator(cfg.MaxBodyBytes)
+
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+
+	handler := validator.Middleware(h)
+	handler = users.NewRateLimiter(rateLimits, memory.NewInMemoryRateLimitStore()).Middleware(handler)
 	handler = users.AuthenticationMiddleware(handler)
+	handler = users.UUIDContextMiddleware(handler)
+	handler = zipkinhttp.NewServerMiddleware(zipkinTracer)(handler)
+	handler = otelhttp.NewHandler(handler, cfg.TraceService)
 
 	errs := make(chan error)
 	go func() {
-		c := make(chan os.Signal)
+		c := make(chan os.Signal, 1)
 		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
 		errs <- fmt.Errorf("%s", <-c)
 	}()
@@ -60,13 +146,39 @@ func main() {
 		errs <- http.ListenAndServe(*httpAddr, handler)
 	}()
 
+	if cfg.MetricsAddr != "" {
+		go func() {
+			metrics := http.NewServeMux()
+			metrics.Handle("/metrics", promhttp.Handler())
+			logger.Log("transport", "HTTP", "metrics", cfg.MetricsAddr)
+			errs <- http.ListenAndServe(cfg.MetricsAddr, metrics)
+		}()
+	}
+
 	logger.Log("exit", <-errs)
 
 }
 
 type config struct {
-	Port         int    `env:"RESTSERVER_PORT" envDefault:"8000"`
-	Hosts        string `env:"RESTSERVER_HOSTS" envDefault:":"`
-	WriteTimeout int    `env:"RESTSERVER_WRITETIMEOUT" envDefault:"15"`
-	ReadTimeout  int    `env:"RESTSERVER_READTIMEOUT" envDefault:"15"`
+	Port            int           `env:"RESTSERVER_PORT" envDefault:"8000"`
+	Hosts           string        `env:"RESTSERVER_HOSTS" envDefault:":"`
+	WriteTimeout    int           `env:"RESTSERVER_WRITETIMEOUT" envDefault:"15"`
+	ReadTimeout     int           `env:"RESTSERVER_READTIMEOUT" envDefault:"15"`
+	EventsHeartbeat time.Duration `env:"RESTSERVER_EVENTS_HEARTBEAT" envDefault:"15s"`
+	PublicKeyFile   string        `env:"AUTH_PUBLIC_KEY_FILE" envDefault:"/home/adrian.castan/cert/id_rsa.pub"`
+	RateLimits      string        `env:"RESTSERVER_RATE_LIMITS" envDefault:"POST /users/ 30/1m; * /auth/token 10/1m 20"`
+	MetricsAddr     string        `env:"RESTSERVER_METRICS_ADDR" envDefault:":9101"`
+	MaxBodyBytes    int64         `env:"RESTSERVER_MAX_BODY_BYTES" envDefault:"1048576"`
+	Transcoding     bool          `env:"RESTSERVER_TRANSCODING" envDefault:"true"`
+	TraceURL        string        `env:"TRACING_ZIPKIN_URL"`
+	TraceService    string        `env:"TRACING_SERVICE_NAME" envDefault:"users-rest"`
+	TraceHost       string        `env:"TRACING_HOST" envDefault:"localhost"`
+	TraceSampleRate float64       `env:"TRACING_SAMPLE_RATE" envDefault:"1"`
+	TraceProvider   string        `env:"TRACING_PROVIDER" envDefault:"zipkin"`
+	TraceExporter   string        `env:"TRACING_OTEL_EXPORTER" envDefault:"otlp"`
+	TraceEndpoint   string        `env:"TRACING_OTLP_ENDPOINT" envDefault:"localhost:4317"`
+	TraceInsecure   bool          `env:"TRACING_OTLP_INSECURE" envDefault:"true"`
+	TraceFile       string        `env:"TRACING_OTEL_FILE"`
+	LogLevel        string        `env:"LOG_LEVEL" envDefault:"info"`
+	LogFormat       string        `env:"LOG_FORMAT" envDefault:"logfmt"`
 }
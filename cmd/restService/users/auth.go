@@ -0,0 +1,469 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	proto "github.com/casmelad/GlobantPOC/api/users/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// PasswordGrant issues the tokens of a user with its email and password.
+	PasswordGrant = "password"
+	// RefreshTokenGrant issues new tokens with a refresh token, which is
+	// rotated.
+	RefreshTokenGrant = "refresh_token"
+	// MFAGrant issues the tokens of a user with the MFA token returned by the
+	// password grant and a TOTP or recovery code.
+	MFAGrant = "mfa"
+)
+
+// GrpcAuthProxy logs the users in and manages their credentials through the
+// gRPC service.
+type GrpcAuthProxy interface {
+	Token(context.Context, TokenRequest) (Tokens, error)
+	Revoke(context.Context, string) error
+	SetPassword(context.Context, string, string) error
+	SetRoles(context.Context, string, []string) error
+	RequestPasswordReset(context.Context, string) error
+	ConfirmPasswordReset(context.Context, string, string) error
+	EnrollTOTP(context.Context, string) (Enrollment, error)
+	ConfirmTOTP(context.Context, string, string) ([]string, error)
+	RegenerateRecoveryCodes(context.Context, string) ([]string, error)
+	DisableMFA(context.Context, string) error
+	CreateAPIKey(context.Context, NewAPIKey) (APIKey, error)
+	ListAPIKeys(context.Context) ([]APIKey, error)
+	RevokeAPIKey(context.Context, string) error
+	APIKeyAuthenticator
+}
+
+// APIKeyAuthenticator checks the API keys sent in the APIKeyHeader.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(context.Context, string) (APIKey, error)
+}
+
+// TokenRequest asks for the tokens of a user, with its email and password for
+// the password grant, with a refresh token for the refresh_token grant or with
+// an MFA token and a code for the mfa grant.
+type TokenRequest struct {
+	GrantType    string `json:"grant_type"`
+	Email        string `json:"email,omitempty"`
+	Password     string `json:"password,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+	Code         string `json:"code,omitempty"`
+}
+
+// Tokens are the tokens handed to a user, the access token is sent in the
+// Authorization header until it expires and then exchanged, with the refresh
+// token, for new ones. A user with a second factor gets only an MFA token
+// from the password grant, to be sent with a code in the mfa grant.
+type Tokens struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	TokenType    string `json:"token_type,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+}
+
+// Enrollment is the TOTP secret of a user, to be added to an authenticator
+// app by hand or by scanning the URI as a QR code.
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// NewAPIKey asks for an API key for a client that can not log in
+// interactively, such as a batch job.
+type NewAPIKey struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKey is the credential of a client that can not log in interactively,
+// its scopes are the roles it acts with. The key is only returned when it is
+// created.
+type APIKey struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Key        string     `json:"key,omitempty"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type AuthProxy struct{}
+
+func (ap AuthProxy) Token(ctx context.Context, req TokenRequest) (Tokens, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	result, errorFromCall := c.Token(serverCon.context, &proto.TokenRequest{
+		GrantType:    req.GrantType,
+		Email:        req.Email,
+		Password:     req.Password,
+		RefreshToken: req.RefreshToken,
+		MfaToken:     req.MFAToken,
+		Code:         req.Code,
+	})
+
+	if errorFromCall != nil {
+		return Tokens{}, errorFromCall
+	}
+
+	if err := errorFromCode(result.Code); err != nil {
+		return Tokens{}, err
+	}
+
+	return Tokens{
+		AccessToken:  result.AccessToken,
+		TokenType:    result.TokenType,
+		ExpiresIn:    result.ExpiresIn,
+		RefreshToken: result.RefreshToken,
+		MFAToken:     result.MfaToken,
+	}, nil
+}
+
+func (ap AuthProxy) Revoke(ctx context.Context, refreshToken string) error {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	result, errorFromCall := c.Revoke(serverCon.context, &proto.RevokeRequest{RefreshToken: refreshToken})
+
+	if errorFromCall != nil {
+		return errorFromCall
+	}
+
+	return errorFromCode(result.Code)
+}
+
+func (ap AuthProxy) SetPassword(ctx context.Context, email string, password string) error {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	result, errorFromCall := c.SetPassword(serverCon.context, &proto.SetPasswordRequest{Email: email, Password: password})
+
+	if errorFromCall != nil {
+		return errorFromCall
+	}
+
+	return passwordError(result.Code, result.Message)
+}
+
+func (ap AuthProxy) SetRoles(ctx context.Context, email string, roles []string) error {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	result, errorFromCall := c.SetRoles(serverCon.context, &proto.SetRolesRequest{Email: email, Roles: roles})
+
+	if errorFromCall != nil {
+		return errorFromCall
+	}
+
+	return errorFromCode(result.Code)
+}
+
+// RequestPasswordReset sends a reset token to the email of the user, the
+// result does not tell whether the email belongs to a user.
+func (ap AuthProxy) RequestPasswordReset(ctx context.Context, email string) error {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	result, errorFromCall := c.RequestPasswordReset(serverCon.context, &proto.PasswordResetRequest{Email: email})
+
+	if errorFromCall != nil {
+		return errorFromCall
+	}
+
+	return errorFromCode(result.Code)
+}
+
+// ConfirmPasswordReset replaces the password of the user the token was sent
+// to.
+func (ap AuthProxy) ConfirmPasswordReset(ctx context.Context, token string, password string) error {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	result, errorFromCall := c.ConfirmPasswordReset(serverCon.context, &proto.ConfirmPasswordResetRequest{Token: token, Password: password})
+
+	if errorFromCall != nil {
+		return errorFromCall
+	}
+
+	return passwordError(result.Code, result.Message)
+}
+
+// EnrollTOTP starts the TOTP enrolment of the user.
+func (ap AuthProxy) EnrollTOTP(ctx context.Context, email string) (Enrollment, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	result, errorFromCall := c.EnrollTOTP(serverCon.context, &proto.MFARequest{Email: email})
+
+	if errorFromCall != nil {
+		return Enrollment{}, errorFromCall
+	}
+
+	if err := errorFromCode(result.Code); err != nil {
+		return Enrollment{}, err
+	}
+
+	return Enrollment{Secret: result.Secret, URI: result.Uri}, nil
+}
+
+// ConfirmTOTP enables the second factor of the user and returns its recovery
+// codes.
+func (ap AuthProxy) ConfirmTOTP(ctx context.Context, email string, code string) ([]string, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	result, errorFromCall := c.ConfirmTOTP(serverCon.context, &proto.ConfirmTOTPRequest{Email: email, Code: code})
+
+	if errorFromCall != nil {
+		return nil, errorFromCall
+	}
+
+	if err := errorFromCode(result.Code); err != nil {
+		return nil, err
+	}
+
+	return result.RecoveryCodes, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user.
+func (ap AuthProxy) RegenerateRecoveryCodes(ctx context.Context, email string) ([]string, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	result, errorFromCall := c.RegenerateRecoveryCodes(serverCon.context, &proto.MFARequest{Email: email})
+
+	if errorFromCall != nil {
+		return nil, errorFromCall
+	}
+
+	if err := errorFromCode(result.Code); err != nil {
+		return nil, err
+	}
+
+	return result.RecoveryCodes, nil
+}
+
+// DisableMFA removes the second factor of the user.
+func (ap AuthProxy) DisableMFA(ctx context.Context, email string) error {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	result, errorFromCall := c.DisableMFA(serverCon.context, &proto.MFARequest{Email: email})
+
+	if errorFromCall != nil {
+		return errorFromCall
+	}
+
+	return errorFromCode(result.Code)
+}
+
+// CreateAPIKey creates an API key, the key is only returned here.
+func (ap AuthProxy) CreateAPIKey(ctx context.Context, req NewAPIKey) (APIKey, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	request := &proto.CreateAPIKeyRequest{Name: req.Name, Scopes: req.Scopes}
+
+	if req.ExpiresAt != nil {
+		request.ExpiresAt = timestamppb.New(*req.ExpiresAt)
+	}
+
+	result, errorFromCall := c.CreateAPIKey(serverCon.context, request)
+
+	if errorFromCall != nil {
+		return APIKey{}, errorFromCall
+	}
+
+	if err := errorFromCode(result.Code); err != nil {
+		return APIKey{}, err
+	}
+
+	key := apiKeyFromProto(result.ApiKey)
+	key.Key = result.Key
+
+	return key, nil
+}
+
+// ListAPIKeys gets every API key, revoked ones included.
+func (ap AuthProxy) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	result, errorFromCall := c.ListAPIKeys(serverCon.context, &proto.ListAPIKeysRequest{})
+
+	if errorFromCall != nil {
+		return []APIKey{}, errorFromCall
+	}
+
+	if err := errorFromCode(result.Code); err != nil {
+		return []APIKey{}, err
+	}
+
+	keys := []APIKey{}
+
+	for _, k := range result.ApiKeys {
+		keys = append(keys, apiKeyFromProto(k))
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey stops accepting an API key.
+func (ap AuthProxy) RevokeAPIKey(ctx context.Context, id string) error {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	result, errorFromCall := c.RevokeAPIKey(serverCon.context, &proto.APIKeyId{Id: id})
+
+	if errorFromCall != nil {
+		return errorFromCall
+	}
+
+	return errorFromCode(result.Code)
+}
+
+// AuthenticateAPIKey checks an API key and returns it.
+func (ap AuthProxy) AuthenticateAPIKey(ctx context.Context, key string) (APIKey, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.auth
+	result, errorFromCall := c.AuthenticateAPIKey(serverCon.context, &proto.AuthenticateAPIKeyRequest{Key: key})
+
+	if errorFromCall != nil {
+		return APIKey{}, errorFromCall
+	}
+
+	if err := errorFromCode(result.Code); err != nil {
+		return APIKey{}, err
+	}
+
+	return apiKeyFromProto(result.ApiKey), nil
+}
+
+func apiKeyFromProto(k *proto.APIKey) APIKey {
+	key := APIKey{
+		Id:        k.GetId(),
+		Name:      k.GetName(),
+		Scopes:    k.GetScopes(),
+		CreatedBy: k.GetCreatedBy(),
+		CreatedAt: timeFromProto(k.GetCreatedAt()),
+	}
+	if k.GetExpiresAt() != nil {
+		expiresAt := k.ExpiresAt.AsTime()
+		key.ExpiresAt = &expiresAt
+	}
+	if k.GetLastUsedAt() != nil {
+		lastUsedAt := k.LastUsedAt.AsTime()
+		key.LastUsedAt = &lastUsedAt
+	}
+	if k.GetRevokedAt() != nil {
+		revokedAt := k.RevokedAt.AsTime()
+		key.RevokedAt = &revokedAt
+	}
+	return key
+}
+
+// passwordError translates the result code of a password change, an invalid
+// input with a message is a password rejected by the policy.
+func passwordError(code proto.CodeResult, message string) error {
+	if code == proto.CodeResult_INVALIDINPUT && message != "" {
+		return fmt.Errorf("%w: %s", ErrWeakPassword, strings.TrimPrefix(message, ErrWeakPassword.Error()+": "))
+	}
+	return errorFromCode(code)
+}
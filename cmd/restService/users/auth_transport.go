@@ -0,0 +1,611 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/casmelad/GlobantPOC/pkg/logging"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+type AuthEndpoints struct {
+	TokenEndpoint        endpoint.Endpoint
+	RevokeEndpoint       endpoint.Endpoint
+	SetPasswordEndpoint  endpoint.Endpoint
+	SetRolesEndpoint     endpoint.Endpoint
+	RequestResetEndpoint endpoint.Endpoint
+	ConfirmResetEndpoint endpoint.Endpoint
+	EnrollTOTPEndpoint   endpoint.Endpoint
+	ConfirmTOTPEndpoint  endpoint.Endpoint
+	RecoveryEndpoint     endpoint.Endpoint
+	DisableMFAEndpoint   endpoint.Endpoint
+	CreateAPIKeyEndpoint endpoint.Endpoint
+	ListAPIKeysEndpoint  endpoint.Endpoint
+	RevokeAPIKeyEndpoint endpoint.Endpoint
+}
+
+func MakeAuthEndpoints(s GrpcAuthProxy, middleware ...endpoint.Middleware) AuthEndpoints {
+	mw := chain(middleware)
+
+	return AuthEndpoints{
+		TokenEndpoint:        mw(MakeTokenEndpoint(s)),
+		RevokeEndpoint:       mw(MakeRevokeEndpoint(s)),
+		SetPasswordEndpoint:  mw(MakeSetPasswordEndpoint(s)),
+		SetRolesEndpoint:     mw(MakeSetRolesEndpoint(s)),
+		RequestResetEndpoint: mw(MakeRequestResetEndpoint(s)),
+		ConfirmResetEndpoint: mw(MakeConfirmResetEndpoint(s)),
+		EnrollTOTPEndpoint:   mw(MakeEnrollTOTPEndpoint(s)),
+		ConfirmTOTPEndpoint:  mw(MakeConfirmTOTPEndpoint(s)),
+		RecoveryEndpoint:     mw(MakeRecoveryCodesEndpoint(s)),
+		DisableMFAEndpoint:   mw(MakeDisableMFAEndpoint(s)),
+		CreateAPIKeyEndpoint: mw(MakeCreateAPIKeyEndpoint(s)),
+		ListAPIKeysEndpoint:  mw(MakeListAPIKeysEndpoint(s)),
+		RevokeAPIKeyEndpoint: mw(MakeRevokeAPIKeyEndpoint(s)),
+	}
+}
+
+type tokenRequest struct {
+	Request TokenRequest
+}
+
+type revokeRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type setPasswordRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type setRolesRequest struct {
+	Email string   `json:"email"`
+	Roles []string `json:"roles"`
+}
+
+type requestResetRequest struct {
+	Email string `json:"email"`
+}
+
+type confirmResetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type mfaRequest struct {
+	Email string `json:"email"`
+}
+
+type confirmTOTPRequest struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+type createAPIKeyRequest struct {
+	Request NewAPIKey
+}
+
+type listAPIKeysRequest struct{}
+
+type revokeAPIKeyRequest struct {
+	ID string
+}
+
+type tokenResponse struct {
+	Err error `json:"err,omitempty"`
+	Tokens
+}
+
+// Headers keeps the tokens out of the caches, as RFC 6749 requires.
+func (r tokenResponse) Headers() http.Header {
+	return http.Header{"Cache-Control": []string{"no-store"}, "Pragma": []string{"no-cache"}}
+}
+
+type revokeResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+type setPasswordResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+type setRolesResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+type requestResetResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+// StatusCode is 202 whether the email belongs to a user or not.
+func (r requestResetResponse) StatusCode() int {
+	return http.StatusAccepted
+}
+
+type confirmResetResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+type enrollTOTPResponse struct {
+	Err error `json:"err,omitempty"`
+	Enrollment
+}
+
+// Headers keeps the TOTP secret out of the caches.
+func (r enrollTOTPResponse) Headers() http.Header {
+	return http.Header{"Cache-Control": []string{"no-store"}}
+}
+
+type recoveryCodesResponse struct {
+	Err           error    `json:"err,omitempty"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// Headers keeps the recovery codes out of the caches.
+func (r recoveryCodesResponse) Headers() http.Header {
+	return http.Header{"Cache-Control": []string{"no-store"}}
+}
+
+type disableMFAResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+type createAPIKeyResponse struct {
+	Err    error  `json:"err,omitempty"`
+	Href   string `json:"href,omitempty"`
+	APIKey APIKey `json:"api_key"`
+}
+
+// StatusCode is 201, the key was created.
+func (r createAPIKeyResponse) StatusCode() int {
+	return http.StatusCreated
+}
+
+// Headers keeps the key out of the caches.
+func (r createAPIKeyResponse) Headers() http.Header {
+	return http.Header{"Cache-Control": []string{"no-store"}}
+}
+
+type listAPIKeysResponse struct {
+	Err     error    `json:"err,omitempty"`
+	APIKeys []APIKey `json:"api_keys"`
+}
+
+type revokeAPIKeyResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+// MakeTokenEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeTokenEndpoint(s GrpcAuthProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(tokenRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		tokens, e := s.Token(ctx, reqData.Request)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return tokenResponse{Tokens: tokens}, nil
+	}
+}
+
+// MakeRevokeEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeRevokeEndpoint(s GrpcAuthProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(revokeRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		e := s.Revoke(ctx, reqData.RefreshToken)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return revokeResponse{}, nil
+	}
+}
+
+// MakeSetPasswordEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeSetPasswordEndpoint(s GrpcAuthProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(setPasswordRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		e := s.SetPassword(ctx, reqData.Email, reqData.Password)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return setPasswordResponse{}, nil
+	}
+}
+
+// MakeSetRolesEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeSetRolesEndpoint(s GrpcAuthProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(setRolesRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		e := s.SetRoles(ctx, reqData.Email, reqData.Roles)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return setRolesResponse{}, nil
+	}
+}
+
+// MakeRequestResetEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeRequestResetEndpoint(s GrpcAuthProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(requestResetRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		e := s.RequestPasswordReset(ctx, reqData.Email)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return requestResetResponse{}, nil
+	}
+}
+
+// MakeConfirmResetEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeConfirmResetEndpoint(s GrpcAuthProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(confirmResetRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		e := s.ConfirmPasswordReset(ctx, reqData.Token, reqData.Password)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return confirmResetResponse{}, nil
+	}
+}
+
+// MakeEnrollTOTPEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeEnrollTOTPEndpoint(s GrpcAuthProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(mfaRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		enrollment, e := s.EnrollTOTP(ctx, reqData.Email)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return enrollTOTPResponse{Enrollment: enrollment}, nil
+	}
+}
+
+// MakeConfirmTOTPEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeConfirmTOTPEndpoint(s GrpcAuthProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(confirmTOTPRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		codes, e := s.ConfirmTOTP(ctx, reqData.Email, reqData.Code)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return recoveryCodesResponse{RecoveryCodes: codes}, nil
+	}
+}
+
+// MakeRecoveryCodesEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeRecoveryCodesEndpoint(s GrpcAuthProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(mfaRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		codes, e := s.RegenerateRecoveryCodes(ctx, reqData.Email)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return recoveryCodesResponse{RecoveryCodes: codes}, nil
+	}
+}
+
+// MakeDisableMFAEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeDisableMFAEndpoint(s GrpcAuthProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(mfaRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		e := s.DisableMFA(ctx, reqData.Email)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return disableMFAResponse{}, nil
+	}
+}
+
+// MakeCreateAPIKeyEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeCreateAPIKeyEndpoint(s GrpcAuthProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(createAPIKeyRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		key, e := s.CreateAPIKey(ctx, reqData.Request)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return createAPIKeyResponse{Href: fmt.Sprintf("%s/%s", AuthAPIKeys, key.Id), APIKey: key}, nil
+	}
+}
+
+// MakeListAPIKeysEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeListAPIKeysEndpoint(s GrpcAuthProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		if _, validCast := request.(listAPIKeysRequest); !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		keys, e := s.ListAPIKeys(ctx)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return listAPIKeysResponse{APIKeys: keys}, nil
+	}
+}
+
+// MakeRevokeAPIKeyEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeRevokeAPIKeyEndpoint(s GrpcAuthProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(revokeAPIKeyRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		e := s.RevokeAPIKey(ctx, reqData.ID)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return revokeAPIKeyResponse{}, nil
+	}
+}
+
+// MakeAuthHTTPHandler mounts the login and credential endpoints into an
+// http.Handler.
+func MakeAuthHTTPHandler(s GrpcAuthProxy, logger log.Logger, middleware ...endpoint.Middleware) http.Handler {
+	r := mux.NewRouter()
+	e := MakeAuthEndpoints(s, middleware...)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorHandler(logging.NewErrorHandler(logger)),
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(clientAddressToContext),
+		httptransport.ServerBefore(routeToContext),
+	}
+
+	r.Methods(http.MethodPost).Path(AuthToken).Handler(httptransport.NewServer(
+		e.TokenEndpoint,
+		decodeTokenRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodPost).Path(AuthRevoke).Handler(httptransport.NewServer(
+		e.RevokeEndpoint,
+		decodeRevokeRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodPut).Path(AuthPassword).Handler(httptransport.NewServer(
+		e.SetPasswordEndpoint,
+		decodeSetPasswordRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodPut).Path(AuthRoles).Handler(httptransport.NewServer(
+		e.SetRolesEndpoint,
+		decodeSetRolesRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods(http.MethodPost).Path(AuthReset).Handler(httptransport.NewServer(
+		e.RequestResetEndpoint,
+		decodeRequestResetRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodPost).Path(AuthConfirm).Handler(httptransport.NewServer(
+		e.ConfirmResetEndpoint,
+		decodeConfirmResetRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods(http.MethodPost).Path(AuthTOTP).Handler(httptransport.NewServer(
+		e.EnrollTOTPEndpoint,
+		decodeMFARequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodPost).Path(AuthTOTPConfirm).Handler(httptransport.NewServer(
+		e.ConfirmTOTPEndpoint,
+		decodeConfirmTOTPRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodPost).Path(AuthRecovery).Handler(httptransport.NewServer(
+		e.RecoveryEndpoint,
+		decodeMFARequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodPost).Path(AuthDisableMFA).Handler(httptransport.NewServer(
+		e.DisableMFAEndpoint,
+		decodeMFARequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods(http.MethodPost).Path(AuthAPIKeys).Handler(httptransport.NewServer(
+		e.CreateAPIKeyEndpoint,
+		decodeCreateAPIKeyRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodGet).Path(AuthAPIKeys).Handler(httptransport.NewServer(
+		e.ListAPIKeysEndpoint,
+		decodeListAPIKeysRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodDelete).Path(AuthAPIKey).Handler(httptransport.NewServer(
+		e.RevokeAPIKeyEndpoint,
+		decodeRevokeAPIKeyRequest,
+		encodeResponse,
+		options...,
+	))
+
+	return r
+}
+
+func decodeTokenRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req tokenRequest
+	if e := json.NewDecoder(r.Body).Decode(&req.Request); e != nil {
+		return nil, ErrInvalidInput
+	}
+	switch req.Request.GrantType {
+	case PasswordGrant:
+		if req.Request.Email == "" || req.Request.Password == "" {
+			return nil, ErrInvalidInput
+		}
+	case RefreshTokenGrant:
+		if req.Request.RefreshToken == "" {
+			return nil, ErrInvalidInput
+		}
+	case MFAGrant:
+		if req.Request.MFAToken == "" || req.Request.Code == "" {
+			return nil, ErrInvalidInput
+		}
+	default:
+		return nil, ErrInvalidInput
+	}
+	return req, nil
+}
+
+func decodeRevokeRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req revokeRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil || req.RefreshToken == "" {
+		return nil, ErrInvalidInput
+	}
+	return req, nil
+}
+
+func decodeSetPasswordRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req setPasswordRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil || req.Email == "" {
+		return nil, ErrInvalidInput
+	}
+	return req, nil
+}
+
+func decodeSetRolesRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req setRolesRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil || req.Email == "" {
+		return nil, ErrInvalidInput
+	}
+	return req, nil
+}
+
+func decodeRequestResetRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req requestResetRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil || req.Email == "" {
+		return nil, ErrInvalidInput
+	}
+	return req, nil
+}
+
+func decodeConfirmResetRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req confirmResetRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil || req.Token == "" || req.Password == "" {
+		return nil, ErrInvalidInput
+	}
+	return req, nil
+}
+
+func decodeMFARequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req mfaRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil || req.Email == "" {
+		return nil, ErrInvalidInput
+	}
+	return req, nil
+}
+
+func decodeConfirmTOTPRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req confirmTOTPRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil || req.Email == "" || req.Code == "" {
+		return nil, ErrInvalidInput
+	}
+	return req, nil
+}
+
+func decodeCreateAPIKeyRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req createAPIKeyRequest
+	if e := json.NewDecoder(r.Body).Decode(&req.Request); e != nil || req.Request.Name == "" || len(req.Request.Scopes) == 0 {
+		return nil, ErrInvalidInput
+	}
+	return req, nil
+}
+
+func decodeListAPIKeysRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return listAPIKeysRequest{}, nil
+}
+
+func decodeRevokeAPIKeyRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	id := mux.Vars(r)[APIKeyID]
+	if id == "" {
+		return nil, ErrBadRouting
+	}
+	return revokeAPIKeyRequest{ID: id}, nil
+}
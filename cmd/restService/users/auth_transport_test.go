@@ -0,0 +1,292 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type authProxyStub struct {
+	requested TokenRequest
+	address   string
+	revoked   string
+	err       error
+}
+
+func (p *authProxyStub) Token(ctx context.Context, req TokenRequest) (Tokens, error) {
+	p.requested = req
+	return Tokens{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900, RefreshToken: "refresh"}, p.err
+}
+
+func (p *authProxyStub) Revoke(ctx context.Context, refreshToken string) error {
+	return p.err
+}
+
+func (p *authProxyStub) SetPassword(ctx context.Context, email string, password string) error {
+	return p.err
+}
+
+func (p *authProxyStub) SetRoles(ctx context.Context, email string, roles []string) error {
+	return p.err
+}
+
+func (p *authProxyStub) RequestPasswordReset(ctx context.Context, email string) error {
+	p.address, _ = ctx.Value(clientAddressContextKey{}).(string)
+	return p.err
+}
+
+func (p *authProxyStub) ConfirmPasswordReset(ctx context.Context, token string, password string) error {
+	return p.err
+}
+
+func (p *authProxyStub) EnrollTOTP(ctx context.Context, email string) (Enrollment, error) {
+	return Enrollment{Secret: "JBSWY3DPEHPK3PXP", URI: "otpauth://totp/users:jane@example.com?secret=JBSWY3DPEHPK3PXP"}, p.err
+}
+
+func (p *authProxyStub) ConfirmTOTP(ctx context.Context, email string, code string) ([]string, error) {
+	return []string{"aaaa-bbbb-cccc-dddd"}, p.err
+}
+
+func (p *authProxyStub) RegenerateRecoveryCodes(ctx context.Context, email string) ([]string, error) {
+	return []string{"aaaa-bbbb-cccc-dddd"}, p.err
+}
+
+func (p *authProxyStub) DisableMFA(ctx context.Context, email string) error {
+	return p.err
+}
+
+func (p *authProxyStub) CreateAPIKey(ctx context.Context, req NewAPIKey) (APIKey, error) {
+	return APIKey{Id: "0123456789abcdef", Name: req.Name, Scopes: req.Scopes, Key: "0123456789abcdef.secret"}, p.err
+}
+
+func (p *authProxyStub) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	return []APIKey{{Id: "0123456789abcdef", Name: "nightly-export", Scopes: []string{"admin"}}}, p.err
+}
+
+func (p *authProxyStub) RevokeAPIKey(ctx context.Context, id string) error {
+	p.revoked = id
+	return p.err
+}
+
+func (p *authProxyStub) AuthenticateAPIKey(ctx context.Context, key string) (APIKey, error) {
+	return APIKey{Id: "0123456789abcdef", Scopes: []string{"admin"}}, p.err
+}
+
+func serveAuth(proxy GrpcAuthProxy, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	MakeAuthHTTPHandler(proxy, log.NewNopLogger()).ServeHTTP(w, r)
+	return w
+}
+
+func Test_Auth_Token_PasswordGrant_ReturnsTokens(t *testing.T) {
+	//Arrange
+	proxy := &authProxyStub{}
+	r := httptest.NewRequest(http.MethodPost, AuthToken, strings.NewReader(`{"grant_type":"password","email":"jane@example.com","password":"Correct-Horse-42"}`))
+	//Act
+	w := serveAuth(proxy, r)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "jane@example.com", proxy.requested.Email)
+	var body Tokens
+	json.NewDecoder(w.Body).Decode(&body)
+	assert.Equal(t, Tokens{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900, RefreshToken: "refresh"}, body)
+}
+
+var tokenRequestTestCases []struct {
+	name string
+	body string
+	err  error
+	code int
+} = []struct {
+	name string
+	body string
+	err  error
+	code int
+}{
+	{"unknown grant", `{"grant_type":"client_credentials"}`, nil, http.StatusUnprocessableEntity},
+	{"password grant without password", `{"grant_type":"password","email":"jane@example.com"}`, nil, http.StatusUnprocessableEntity},
+	{"refresh grant without token", `{"grant_type":"refresh_token"}`, nil, http.StatusUnprocessableEntity},
+	{"wrong password", `{"grant_type":"password","email":"jane@example.com","password":"x"}`, ErrUnauthorized, http.StatusUnauthorized},
+	{"locked account", `{"grant_type":"password","email":"jane@example.com","password":"x"}`, ErrLocked, http.StatusLocked},
+	{"rotated refresh token", `{"grant_type":"refresh_token","refresh_token":"used"}`, ErrUnauthorized, http.StatusUnauthorized},
+	{"mfa grant without code", `{"grant_type":"mfa","mfa_token":"challenge"}`, nil, http.StatusUnprocessableEntity},
+	{"wrong mfa code", `{"grant_type":"mfa","mfa_token":"challenge","code":"000000"}`, ErrUnauthorized, http.StatusUnauthorized},
+}
+
+func TestCases_Auth_Token(t *testing.T) {
+	for _, tc := range tokenRequestTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Arrange
+			r := httptest.NewRequest(http.MethodPost, AuthToken, strings.NewReader(tc.body))
+			//Act
+			w := serveAuth(&authProxyStub{err: tc.err}, r)
+			//Assert
+			assert.Equal(t, tc.code, w.Code)
+		})
+	}
+}
+
+func Test_Auth_SetPassword_WeakPassword_ReturnsReason(t *testing.T) {
+	//Arrange
+	proxy := &authProxyStub{err: fmt.Errorf("%w: it must have at least 12 characters", ErrWeakPassword)}
+	r := httptest.NewRequest(http.MethodPut, AuthPassword, strings.NewReader(`{"email":"jane@example.com","password":"short"}`))
+	//Act
+	w := serveAuth(proxy, r)
+	//Assert
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "at least 12 characters")
+}
+
+func Test_Auth_RequestPasswordReset_AcceptsAndForwardsTheClientAddress(t *testing.T) {
+	//Arrange
+	proxy := &authProxyStub{}
+	r := httptest.NewRequest(http.MethodPost, AuthReset, strings.NewReader(`{"email":"jane@example.com"}`))
+	r.RemoteAddr = "192.0.2.1:51234"
+	//Act
+	w := serveAuth(proxy, r)
+	//Assert
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "192.0.2.1", proxy.address)
+}
+
+func Test_Auth_RequestPasswordReset_RateLimited_Returns429(t *testing.T) {
+	//Arrange
+	proxy := &authProxyStub{err: ErrTooManyRequests}
+	r := httptest.NewRequest(http.MethodPost, AuthReset, strings.NewReader(`{"email":"jane@example.com"}`))
+	//Act
+	w := serveAuth(proxy, r)
+	//Assert
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func Test_Auth_ConfirmPasswordReset_InvalidToken_Returns422(t *testing.T) {
+	//Arrange
+	proxy := &authProxyStub{err: ErrInvalidInput}
+	r := httptest.NewRequest(http.MethodPost, AuthConfirm, strings.NewReader(`{"token":"used","password":"Another-Horse-43"}`))
+	//Act
+	w := serveAuth(proxy, r)
+	//Assert
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func Test_Auth_Token_MFAGrant_ForwardsTheCode(t *testing.T) {
+	//Arrange
+	proxy := &authProxyStub{}
+	r := httptest.NewRequest(http.MethodPost, AuthToken, strings.NewReader(`{"grant_type":"mfa","mfa_token":"challenge","code":"287082"}`))
+	//Act
+	w := serveAuth(proxy, r)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "challenge", proxy.requested.MFAToken)
+	assert.Equal(t, "287082", proxy.requested.Code)
+}
+
+func Test_Auth_ConfirmTOTP_ReturnsRecoveryCodes(t *testing.T) {
+	//Arrange
+	r := httptest.NewRequest(http.MethodPost, AuthTOTPConfirm, strings.NewReader(`{"email":"jane@example.com","code":"287082"}`))
+	//Act
+	w := serveAuth(&authProxyStub{}, r)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	var body recoveryCodesResponse
+	json.NewDecoder(w.Body).Decode(&body)
+	assert.Equal(t, []string{"aaaa-bbbb-cccc-dddd"}, body.RecoveryCodes)
+}
+
+func Test_Auth_CreateAPIKey_ReturnsTheKeyOnce(t *testing.T) {
+	//Arrange
+	r := httptest.NewRequest(http.MethodPost, AuthAPIKeys, strings.NewReader(`{"name":"nightly-export","scopes":["admin"]}`))
+	//Act
+	w := serveAuth(&authProxyStub{}, r)
+	//Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	var body createAPIKeyResponse
+	json.NewDecoder(w.Body).Decode(&body)
+	assert.Equal(t, "/auth/api-keys/0123456789abcdef", body.Href)
+	assert.Equal(t, "0123456789abcdef.secret", body.APIKey.Key)
+}
+
+var createAPIKeyTestCases []struct {
+	name string
+	body string
+	err  error
+	code int
+} = []struct {
+	name string
+	body string
+	err  error
+	code int
+}{
+	{"without scopes", `{"name":"nightly-export"}`, nil, http.StatusUnprocessableEntity},
+	{"without name", `{"scopes":["admin"]}`, nil, http.StatusUnprocessableEntity},
+	{"not an administrator", `{"name":"nightly-export","scopes":["admin"]}`, ErrForbidden, http.StatusForbidden},
+}
+
+func TestCases_Auth_CreateAPIKey(t *testing.T) {
+	for _, tc := range createAPIKeyTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Arrange
+			r := httptest.NewRequest(http.MethodPost, AuthAPIKeys, strings.NewReader(tc.body))
+			//Act
+			w := serveAuth(&authProxyStub{err: tc.err}, r)
+			//Assert
+			assert.Equal(t, tc.code, w.Code)
+		})
+	}
+}
+
+func Test_Auth_RevokeAPIKey_ForwardsTheId(t *testing.T) {
+	//Arrange
+	proxy := &authProxyStub{}
+	r := httptest.NewRequest(http.MethodDelete, AuthAPIKeys+"/0123456789abcdef", nil)
+	//Act
+	w := serveAuth(proxy, r)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789abcdef", proxy.revoked)
+}
+
+func Test_AuthenticationMiddleware_APIKey_ActsWithItsScopes(t *testing.T) {
+	//Arrange
+	previous := APIKeys
+	APIKeys = &authProxyStub{}
+	defer func() { APIKeys = previous }()
+	var claims jwt.MapClaims
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { claims, _ = claimsFromContext(r.Context()) })
+	r := httptest.NewRequest(http.MethodGet, UsersBaseUri, nil)
+	r.Header.Set(APIKeyHeader, "0123456789abcdef.secret")
+	//Act
+	AuthenticationMiddleware(next).ServeHTTP(httptest.NewRecorder(), r)
+	//Assert
+	assert.Equal(t, "apikey:0123456789abcdef", claims["sub"])
+	assert.Equal(t, []string{"admin"}, rolesFromClaims(claims))
+	assert.Equal(t, []string{"apikey"}, methodsFromClaims(claims))
+}
+
+func Test_AuthenticationMiddleware_InvalidAPIKey_ReturnsUnauthorized(t *testing.T) {
+	//Arrange
+	previous := APIKeys
+	APIKeys = &authProxyStub{err: ErrUnauthorized}
+	defer func() { APIKeys = previous }()
+	served := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served = true })
+	r := httptest.NewRequest(http.MethodGet, UsersBaseUri, nil)
+	r.Header.Set(APIKeyHeader, "revoked")
+	w := httptest.NewRecorder()
+	//Act
+	AuthenticationMiddleware(next).ServeHTTP(w, r)
+	//Assert
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, served)
+}
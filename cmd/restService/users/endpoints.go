@@ -14,17 +14,43 @@ type Endpoints struct {
 	GetUserEndpoint      endpoint.Endpoint
 	GetAllUsersEndpoint  endpoint.Endpoint
 	PutUserEndpoint      endpoint.Endpoint
+	PatchUserEndpoint    endpoint.Endpoint
 	DeleteUserEndpoint   endpoint.Endpoint
+	RestoreUserEndpoint  endpoint.Endpoint
+	PurgeUserEndpoint    endpoint.Endpoint
+	UserHistoryEndpoint  endpoint.Endpoint
+	EmailChangeEndpoint  endpoint.Endpoint
+	ConfirmEmailEndpoint endpoint.Endpoint
+
+	SendVerificationEndpoint endpoint.Endpoint
+	VerifyEmailEndpoint      endpoint.Endpoint
+	SuspendEndpoint          endpoint.Endpoint
+	ReactivateEndpoint       endpoint.Endpoint
+	DeactivateEndpoint       endpoint.Endpoint
 }
 
-func MakeServerEndpoints(s GrpcUsersProxy) Endpoints {
+func MakeServerEndpoints(s GrpcUsersProxy, middleware ...endpoint.Middleware) Endpoints {
+	mw := chain(middleware)
+
 	return Endpoints{
-		PostUserEndpoint:     MakePostUserEndpoint(s),
-		PostManyUserEndpoint: MakePostUserEndpoint(s),
-		GetUserEndpoint:      MakeGetUserEndpoint(s),
-		GetAllUsersEndpoint:  MakeGetAllUsersEndpoint(s),
-		PutUserEndpoint:      MakePutUserEndpoint(s),
-		DeleteUserEndpoint:   MakeDeleteUserEndpoint(s),
+		PostUserEndpoint:     mw(MakePostUserEndpoint(s)),
+		PostManyUserEndpoint: mw(MakePostUserEndpoint(s)),
+		GetUserEndpoint:      mw(MakeGetUserEndpoint(s)),
+		GetAllUsersEndpoint:  mw(MakeGetAllUsersEndpoint(s)),
+		PutUserEndpoint:      mw(MakePutUserEndpoint(s)),
+		PatchUserEndpoint:    mw(MakePatchUserEndpoint(s)),
+		DeleteUserEndpoint:   mw(MakeDeleteUserEndpoint(s)),
+		RestoreUserEndpoint:  mw(MakeRestoreUserEndpoint(s)),
+		PurgeUserEndpoint:    mw(MakePurgeUserEndpoint(s)),
+		UserHistoryEndpoint:  mw(MakeUserHistoryEndpoint(s)),
+		EmailChangeEndpoint:  mw(MakeEmailChangeEndpoint(s)),
+		ConfirmEmailEndpoint: mw(MakeConfirmEmailEndpoint(s)),
+
+		SendVerificationEndpoint: mw(MakeSendVerificationEndpoint(s)),
+		VerifyEmailEndpoint:      mw(MakeVerifyEmailEndpoint(s)),
+		SuspendEndpoint:          mw(MakeChangeStatusEndpoint(s.Suspend)),
+		ReactivateEndpoint:       mw(MakeChangeStatusEndpoint(s.Reactivate)),
+		DeactivateEndpoint:       mw(MakeChangeStatusEndpoint(s.Deactivate)),
 	}
 }
 
@@ -72,11 +98,11 @@ func MakeGetUserEndpoint(s GrpcUsersProxy) endpoint.Endpoint {
 func MakeGetAllUsersEndpoint(s GrpcUsersProxy) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 
-		_, validCast := request.(getAllUsersRequest)
+		reqData, validCast := request.(getAllUsersRequest)
 		if !validCast {
 			return nil, errors.New("invalid input data")
 		}
-		p, e := s.GetAll(ctx) //pasar el context hasta el grpc
+		p, e := s.GetAll(ctx, reqData.Filters) //pasar el context hasta el grpc
 
 		if e != nil {
 			return WrapError(e), nil
@@ -94,13 +120,13 @@ func MakePutUserEndpoint(s GrpcUsersProxy) endpoint.Endpoint {
 		if !validCast {
 			return nil, errors.New("invalid input data")
 		}
-		_, e := s.Update(ctx, reqData.User)
+		usr, e := s.Update(ctx, reqData.User)
 
 		if e != nil {
 			return WrapError(e), nil
 		}
 
-		return putUserResponse{Err: e}, nil
+		return putUserResponse{Version: usr.Version}, nil
 	}
 }
 
@@ -112,7 +138,7 @@ func MakeDeleteUserEndpoint(s GrpcUsersProxy) endpoint.Endpoint {
 		if !validCast {
 			return nil, errors.New("invalid input data")
 		}
-		_, e := s.Delete(ctx, reqData.UserID)
+		_, e := s.Delete(ctx, reqData.UserID, reqData.Version)
 
 		if e != nil {
 			return WrapError(e), nil
@@ -121,3 +147,181 @@ func MakeDeleteUserEndpoint(s GrpcUsersProxy) endpoint.Endpoint {
 		return deleteUserResponse{Err: e}, nil
 	}
 }
+
+// MakeRestoreUserEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeRestoreUserEndpoint(s GrpcUsersProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(restoreUserRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		e := s.Restore(ctx, reqData.UserID)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return restoreUserResponse{Err: e}, nil
+	}
+}
+
+// MakePurgeUserEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakePurgeUserEndpoint(s GrpcUsersProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(purgeUserRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		e := s.Purge(ctx, reqData.UserID)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return purgeUserResponse{Err: e}, nil
+	}
+}
+
+// MakeUserHistoryEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeUserHistoryEndpoint(s GrpcUsersProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(userHistoryRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		entries, e := s.History(ctx, reqData.UserID, reqData.Filters)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return userHistoryResponse{Entries: entries}, nil
+	}
+}
+
+// MakePatchUserEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server. The current user is only read when the patch
+// has test operations, it must still be at the version the client sent.
+func MakePatchUserEndpoint(s GrpcUsersProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(patchUserRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+
+		current := User{Email: reqData.Email, Version: reqData.Version}
+
+		if hasTests(reqData.Operations) {
+			if current, err = s.GetByEmail(ctx, reqData.Email); err != nil {
+				return WrapError(err), nil
+			}
+			if current.Version != reqData.Version {
+				return WrapError(ErrPreconditionFailed), nil
+			}
+		}
+
+		patch, e := applyPatch(current, reqData.Operations)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		if e := s.Patch(ctx, patch); e != nil {
+			return WrapError(e), nil
+		}
+
+		return patchUserResponse{}, nil
+	}
+}
+
+// MakeEmailChangeEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeEmailChangeEndpoint(s GrpcUsersProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(emailChangeRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+
+		if e := s.RequestEmailChange(ctx, reqData.Email, reqData.NewEmail); e != nil {
+			return WrapError(e), nil
+		}
+
+		return emailChangeResponse{}, nil
+	}
+}
+
+// MakeConfirmEmailEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeConfirmEmailEndpoint(s GrpcUsersProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(confirmEmailChangeRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+
+		usr, e := s.ConfirmEmailChange(ctx, reqData.Token)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return confirmEmailChangeResponse{User: usr}, nil
+	}
+}
+
+// MakeSendVerificationEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeSendVerificationEndpoint(s GrpcUsersProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(getUserRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+
+		if e := s.SendVerification(ctx, reqData.Email); e != nil {
+			return WrapError(e), nil
+		}
+
+		return sendVerificationResponse{}, nil
+	}
+}
+
+// MakeVerifyEmailEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeVerifyEmailEndpoint(s GrpcUsersProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(verifyEmailRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+
+		usr, e := s.VerifyEmail(ctx, reqData.Token)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return verifyEmailResponse{User: usr}, nil
+	}
+}
+
+// MakeChangeStatusEndpoint returns an endpoint for one of the status
+// transitions, they all take the id of the user.
+func MakeChangeStatusEndpoint(transition func(context.Context, int) error) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(changeStatusRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+
+		if e := transition(ctx, reqData.UserID); e != nil {
+			return WrapError(e), nil
+		}
+
+		return changeStatusResponse{}, nil
+	}
+}
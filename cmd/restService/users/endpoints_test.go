@@ -13,8 +13,8 @@ type grpcProxyMock struct {
 	mock.Mock
 }
 
-func (up grpcProxyMock) GetAll(ctx context.Context) ([]User, error) {
-	args := up.Called(ctx)
+func (up grpcProxyMock) GetAll(ctx context.Context, filters Filters) ([]User, error) {
+	args := up.Called(ctx, filters)
 	return args.Get(0).([]User), args.Error(1)
 }
 
@@ -28,8 +28,13 @@ func (up grpcProxyMock) Update(ctx context.Context, u User) (User, error) {
 	return args.Get(0).(User), args.Error(1)
 }
 
-func (up grpcProxyMock) Delete(ctx context.Context, id int) (bool, error) {
-	args := up.Called(ctx, id)
+func (up grpcProxyMock) Patch(ctx context.Context, p UserPatch) error {
+	args := up.Called(ctx, p)
+	return args.Error(0)
+}
+
+func (up grpcProxyMock) Delete(ctx context.Context, id int, version int) (bool, error) {
+	args := up.Called(ctx, id, version)
 	return args.Bool(0), args.Error(1)
 }
 
@@ -38,6 +43,61 @@ func (up grpcProxyMock) GetByEmail(ctx context.Context, email string) (User, err
 	return args.Get(0).(User), args.Error(1)
 }
 
+func (up grpcProxyMock) Restore(ctx context.Context, id int) error {
+	args := up.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (up grpcProxyMock) Purge(ctx context.Context, id int) error {
+	args := up.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (up grpcProxyMock) Watch(ctx context.Context, after int64, send func(UserEvent) error) error {
+	args := up.Called(ctx, after, send)
+	return args.Error(0)
+}
+
+func (up grpcProxyMock) History(ctx context.Context, id int, filters HistoryFilters) ([]AuditEntry, error) {
+	args := up.Called(ctx, id, filters)
+	return args.Get(0).([]AuditEntry), args.Error(1)
+}
+
+func (up grpcProxyMock) RequestEmailChange(ctx context.Context, email string, newEmail string) error {
+	args := up.Called(ctx, email, newEmail)
+	return args.Error(0)
+}
+
+func (up grpcProxyMock) ConfirmEmailChange(ctx context.Context, token string) (User, error) {
+	args := up.Called(ctx, token)
+	return args.Get(0).(User), args.Error(1)
+}
+
+func (up grpcProxyMock) SendVerification(ctx context.Context, email string) error {
+	args := up.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (up grpcProxyMock) VerifyEmail(ctx context.Context, token string) (User, error) {
+	args := up.Called(ctx, token)
+	return args.Get(0).(User), args.Error(1)
+}
+
+func (up grpcProxyMock) Suspend(ctx context.Context, id int) error {
+	args := up.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (up grpcProxyMock) Reactivate(ctx context.Context, id int) error {
+	args := up.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (up grpcProxyMock) Deactivate(ctx context.Context, id int) error {
+	args := up.Called(ctx, id)
+	return args.Error(0)
+}
+
 func TestCases_Create(t *testing.T) {
 	ctx := context.Background()
 
@@ -89,7 +149,7 @@ func TestCases_GetAll(t *testing.T) {
 
 	for _, useCase := range getAllUsersTestCases {
 		proxyMock := grpcProxyMock{}
-		proxyMock.On("GetAll", ctx).Return(useCase.users, useCase.err)
+		proxyMock.On("GetAll", ctx, Filters{}).Return(useCase.users, useCase.err)
 		endpoints := MakeServerEndpoints(proxyMock)
 		result, err := endpoints.GetAllUsersEndpoint(ctx, getAllUsersRequest{})
 
@@ -123,3 +183,98 @@ var getAllUsersTestCases []struct {
 }
 
 var larryPage User = User{Id: 1, Name: "Larry", LastName: "Page", Email: "larry.page@gmail.com"}
+
+func TestCases_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	for _, useCase := range deleteUserTestCases {
+		proxyMock := grpcProxyMock{}
+		proxyMock.On("Delete", ctx, 1, 1).Return(useCase.err == nil, useCase.err)
+		endpoints := MakeServerEndpoints(proxyMock)
+
+		result, err := endpoints.DeleteUserEndpoint(ctx, deleteUserRequest{UserID: 1, Version: 1})
+
+		assert.Nil(t, err, useCase.name)
+		if appError, is := result.(AppError); is {
+			assert.Equal(t, useCase.err, appError.error(), useCase.name)
+		} else {
+			assert.Nil(t, useCase.err, useCase.name)
+		}
+	}
+}
+
+var deleteUserTestCases []struct {
+	name string
+	err  error
+} = []struct {
+	name string
+	err  error
+}{
+	{"Ok", nil},
+	{"AdminWithoutSecondFactor_Forbidden", ErrForbidden},
+	{"StaleVersion_PreconditionFailed", ErrPreconditionFailed},
+}
+
+func TestCases_Purge(t *testing.T) {
+	ctx := context.Background()
+
+	for _, useCase := range purgeUserTestCases {
+		proxyMock := grpcProxyMock{}
+		proxyMock.On("Purge", ctx, useCase.userID).Return(useCase.err)
+		endpoints := MakeServerEndpoints(proxyMock)
+
+		result, err := endpoints.PurgeUserEndpoint(ctx, purgeUserRequest{UserID: useCase.userID})
+
+		assert.Nil(t, err, useCase.name)
+		if appError, is := result.(AppError); is {
+			assert.Equal(t, useCase.err, appError.error(), useCase.name)
+		} else {
+			assert.Nil(t, useCase.err, useCase.name)
+		}
+	}
+}
+
+var purgeUserTestCases []struct {
+	name   string
+	userID int
+	err    error
+} = []struct {
+	name   string
+	userID int
+	err    error
+}{
+	{"Admin_Purged", 1, nil},
+	{"NotAdmin_Forbidden", 1, ErrForbidden},
+	{"ActiveUser_InvalidInput", 2, ErrInvalidInput},
+}
+
+func TestCases_Suspend(t *testing.T) {
+	ctx := context.Background()
+
+	for _, useCase := range suspendUserTestCases {
+		proxyMock := grpcProxyMock{}
+		proxyMock.On("Suspend", ctx, 1).Return(useCase.err)
+		endpoints := MakeServerEndpoints(proxyMock)
+
+		result, err := endpoints.SuspendEndpoint(ctx, changeStatusRequest{UserID: 1})
+
+		assert.Nil(t, err, useCase.name)
+		if appError, is := result.(AppError); is {
+			assert.Equal(t, useCase.err, appError.error(), useCase.name)
+		} else {
+			assert.Nil(t, useCase.err, useCase.name)
+		}
+	}
+}
+
+var suspendUserTestCases []struct {
+	name string
+	err  error
+} = []struct {
+	name string
+	err  error
+}{
+	{"Ok", nil},
+	{"NotAdmin_Forbidden", ErrForbidden},
+	{"AlreadySuspended_Conflict", ErrStatusConflict},
+}
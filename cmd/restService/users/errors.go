@@ -1,14 +1,97 @@
 package users
 
-import "errors"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	"google.golang.org/grpc/status"
+)
 
 var (
 	ErrNotFound          error = errors.New("not found")
 	ErrInternalFailure   error = errors.New("bad request")
 	ErrInvalidInput      error = errors.New("invalid data")
 	ErrUserAlreadyExists error = errors.New("already exists")
+	// ErrPreconditionFailed is returned when the If-Match header does not
+	// match the current version of the user.
+	ErrPreconditionFailed error = errors.New("precondition failed")
+	// ErrPreconditionRequired is returned when a write is sent without an
+	// If-Match header.
+	ErrPreconditionRequired error = errors.New("precondition required")
+	// ErrForbidden is returned when the caller is not allowed to perform the
+	// operation.
+	ErrForbidden error = errors.New("forbidden")
+	// ErrStatusConflict is returned when the current status of the user does
+	// not allow the requested transition.
+	ErrStatusConflict error = errors.New("status conflict")
+	// ErrUnauthorized is returned when the credentials or the refresh token
+	// are not valid.
+	ErrUnauthorized error = errors.New("unauthorized")
+	// ErrLocked is returned when the account is locked after too many failed
+	// logins.
+	ErrLocked error = errors.New("account locked")
+	// ErrWeakPassword is returned, wrapped with the rule it breaks, when a
+	// password does not meet the password policy.
+	ErrWeakPassword error = errors.New("weak password")
+	// ErrTooManyRequests is returned when a rate limit was exceeded.
+	ErrTooManyRequests error = errors.New("too many requests")
 )
 
+// errorCodes are the codes of the catalogue the errors of the gateway are
+// answered with.
+var errorCodes = []struct {
+	err  error
+	code problems.Code
+}{
+	{ErrBadRouting, problems.CodeBadRequest},
+	{ErrInvalidInput, problems.CodeValidationFailed},
+	{ErrWeakPassword, problems.CodeWeakPassword},
+	{ErrPayloadTooLarge, problems.CodePayloadTooLarge},
+	{ErrUnsupportedMediaType, problems.CodeUnsupportedMediaType},
+	{ErrUnauthorized, problems.CodeUnauthenticated},
+	{ErrForbidden, problems.CodeForbidden},
+	{ErrNotFound, problems.CodeNotFound},
+	{ErrUserAlreadyExists, problems.CodeAlreadyExists},
+	{ErrPreconditionFailed, problems.CodeVersionConflict},
+	{ErrPreconditionRequired, problems.CodePreconditionRequired},
+	{ErrStatusConflict, problems.CodeStatusConflict},
+	{ErrPatchTestFailed, problems.CodePatchTestFailed},
+	{ErrLocked, problems.CodeAccountLocked},
+	{ErrTooManyRequests, problems.CodeTooManyRequests},
+	{ErrInternalFailure, problems.CodeInternal},
+	{context.DeadlineExceeded, problems.CodeTimeout},
+}
+
+// errorCode returns the code of the catalogue err is answered with: the one
+// it carries, the one of the gateway error it wraps, or the one of the gRPC
+// status the call to the service failed with. Bodies that can not be decoded
+// are malformed requests.
+func errorCode(err error) problems.Code {
+	var coded problems.Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return problems.CodeMalformedRequest
+	}
+	if st, ok := status.FromError(err); ok {
+		return problems.FromStatus(st)
+	}
+	return problems.CodeInternal
+}
+
 type AppError struct {
 	errorMessage error
 }
@@ -0,0 +1,124 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// feedHead is the sequence to watch after to get only the events published
+// from now on, it is never the sequence of an event.
+const feedHead int64 = -1
+
+// EventsHeartbeat is how often an idle event stream sends a comment line, so
+// that proxies and clients do not drop the connection.
+var EventsHeartbeat = 15 * time.Second
+
+// MakeEventsHandler relays the user change feed as Server-Sent Events. The id
+// of every event is its sequence in the feed, a client that reconnects with
+// the Last-Event-ID header resumes right after it. Go kit transports encode a
+// single response, so the stream is served by a plain http.Handler.
+func MakeEventsHandler(s GrpcUsersProxy, heartbeat time.Duration, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			encodeError(r.Context(), ErrInternalFailure, w)
+			return
+		}
+
+		after, err := decodeLastEventID(r)
+		if err != nil {
+			encodeError(r.Context(), err, w)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+
+		feed := make(chan UserEvent)
+		errs := make(chan error, 1)
+
+		var wg sync.WaitGroup
+		defer func() {
+			cancel()
+			wg.Wait()
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.Watch(ctx, after, func(e UserEvent) error {
+				select {
+				case feed <- e:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+		}()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-errs:
+				// The feed ended, the client reconnects with the last id it got.
+				logger.Log("err", err)
+				return
+			case <-ticker.C:
+				if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			case e := <-feed:
+				if err := writeEvent(w, e); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	})
+}
+
+// decodeLastEventID reads the sequence the client already received, from the
+// Last-Event-ID header sent on reconnect or from the last_event_id query
+// parameter for the first connection. Without either the client gets the
+// events published from now on, last_event_id=0 replays the whole feed.
+func decodeLastEventID(r *http.Request) (int64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get(LastEventID)
+	}
+	if value == "" {
+		return feedHead, nil
+	}
+	after, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || after < 0 {
+		return 0, ErrInvalidInput
+	}
+	return after, nil
+}
+
+// writeEvent writes a user event in the text/event-stream format.
+func writeEvent(w io.Writer, e UserEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Sequence, e.Type, data)
+	return err
+}
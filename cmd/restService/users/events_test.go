@@ -0,0 +1,106 @@
+package users
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type feedProxy struct {
+	grpcProxyMock
+	after  int64
+	events []UserEvent
+}
+
+func (p *feedProxy) Watch(ctx context.Context, after int64, send func(UserEvent) error) error {
+	p.after = after
+	for _, e := range p.events {
+		if e.Sequence <= after {
+			continue
+		}
+		if err := send(e); err != nil {
+			return err
+		}
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func serveEvents(proxy GrpcUsersProxy, r *http.Request, heartbeat time.Duration, wait time.Duration) *httptest.ResponseRecorder {
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+	w := httptest.NewRecorder()
+	MakeEventsHandler(proxy, heartbeat, log.NewNopLogger()).ServeHTTP(w, r.WithContext(ctx))
+	return w
+}
+
+func Test_EventsHandler_StreamsEvents(t *testing.T) {
+	//Arrange
+	proxy := &feedProxy{events: []UserEvent{
+		{Sequence: 1, Type: "user.created", User: User{Id: 1, Email: "larry.page@gmail.com"}},
+		{Sequence: 2, Type: "user.updated", User: User{Id: 1, Email: "larry.page@gmail.com"}},
+	}}
+	r := httptest.NewRequest(http.MethodGet, UserEvents+"?last_event_id=0", nil)
+	//Act
+	w := serveEvents(proxy, r, time.Hour, 50*time.Millisecond)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, "id: 1\nevent: user.created\ndata: {")
+	assert.Contains(t, body, "id: 2\nevent: user.updated\ndata: {")
+	assert.Less(t, strings.Index(body, "id: 1"), strings.Index(body, "id: 2"))
+}
+
+func Test_EventsHandler_LastEventID_ResumesAfterIt(t *testing.T) {
+	//Arrange
+	proxy := &feedProxy{events: []UserEvent{
+		{Sequence: 1, Type: "user.created"},
+		{Sequence: 2, Type: "user.updated"},
+	}}
+	r := httptest.NewRequest(http.MethodGet, UserEvents, nil)
+	r.Header.Set("Last-Event-ID", "1")
+	//Act
+	w := serveEvents(proxy, r, time.Hour, 50*time.Millisecond)
+	//Assert
+	assert.Equal(t, int64(1), proxy.after)
+	assert.NotContains(t, w.Body.String(), "id: 1\n")
+	assert.Contains(t, w.Body.String(), "id: 2\n")
+}
+
+func Test_EventsHandler_NoLastEventID_StartsAtHead(t *testing.T) {
+	//Arrange
+	r := httptest.NewRequest(http.MethodGet, UserEvents, nil)
+	proxy := &feedProxy{}
+	//Act
+	serveEvents(proxy, r, time.Hour, 10*time.Millisecond)
+	//Assert
+	assert.Equal(t, feedHead, proxy.after)
+}
+
+func Test_EventsHandler_IdleStream_SendsHeartbeats(t *testing.T) {
+	//Arrange
+	r := httptest.NewRequest(http.MethodGet, UserEvents+"?last_event_id=5", nil)
+	proxy := &feedProxy{}
+	//Act
+	w := serveEvents(proxy, r, 10*time.Millisecond, 55*time.Millisecond)
+	//Assert
+	assert.Equal(t, int64(5), proxy.after)
+	assert.Contains(t, w.Body.String(), ": heartbeat\n\n")
+}
+
+func Test_EventsHandler_InvalidLastEventID_Returns422(t *testing.T) {
+	//Arrange
+	r := httptest.NewRequest(http.MethodGet, UserEvents, nil)
+	r.Header.Set("Last-Event-ID", "abc")
+	//Act
+	w := serveEvents(&feedProxy{}, r, time.Hour, 50*time.Millisecond)
+	//Assert
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
@@ -3,24 +3,60 @@ package users
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/caarlos0/env/v6"
-
-	proto "github.com/casmelad/GlobantPOC/cmd/restService/users/proto"
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/propagation/b3"
+
+	proto "github.com/casmelad/GlobantPOC/api/users/v1"
+	"github.com/casmelad/GlobantPOC/pkg/gateway"
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	domain "github.com/casmelad/GlobantPOC/pkg/users"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	glog "google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type GrpcUsersProxy interface {
-	GetAll(context.Context) ([]User, error)
+	GetAll(context.Context, Filters) ([]User, error)
 	Create(context.Context, User) (User, error)
 	Update(context.Context, User) (User, error)
-	Delete(context.Context, int) (bool, error)
+	Patch(context.Context, UserPatch) error
+	Delete(context.Context, int, int) (bool, error)
 	GetByEmail(context.Context, string) (User, error)
+	Restore(context.Context, int) error
+	Purge(context.Context, int) error
+	History(context.Context, int, HistoryFilters) ([]AuditEntry, error)
+	Watch(context.Context, int64, func(UserEvent) error) error
+	RequestEmailChange(context.Context, string, string) error
+	ConfirmEmailChange(context.Context, string) (User, error)
+	SendVerification(context.Context, string) error
+	VerifyEmail(context.Context, string) (User, error)
+	Suspend(context.Context, int) error
+	Reactivate(context.Context, int) error
+	Deactivate(context.Context, int) error
+}
+
+// Filters narrows the users returned by GetAll.
+type Filters struct {
+	IncludeDeleted bool
+	Status         string
+}
+
+// HistoryFilters narrows the audit entries returned by History, zero values
+// are not applied.
+type HistoryFilters struct {
+	From  time.Time
+	To    time.Time
+	Actor string
 }
 
 type UserProxy struct {
@@ -28,8 +64,9 @@ type UserProxy struct {
 }
 
 type config struct {
-	Port int    `env:"proto_PORT" envDefault:"9000"`
-	Host string `env:"proto_HOST" envDefault:"127.0.0.1"`
+	Port   int    `env:"proto_PORT" envDefault:"9000"`
+	Host   string `env:"proto_HOST" envDefault:"127.0.0.1"`
+	Secret string `env:"GATEWAY_SECRET"`
 }
 
 func NewUserProxy() *UserProxy {
@@ -38,31 +75,26 @@ func NewUserProxy() *UserProxy {
 	}
 }
 
-func (up UserProxy) GetAll(ctx context.Context) ([]User, error) {
+func (up UserProxy) GetAll(ctx context.Context, filters Filters) ([]User, error) {
 
 	serverCon, err := OpenServerConection(ctx)
 
 	if err != nil {
-		log.Fatalf(err.Error())
+		return nil, err
 	}
 
 	defer serverCon.dispose()
 	c := serverCon.client
-	result, errorFromCall := c.GetAllUsers(serverCon.context, &proto.Filters{})
+	result, errorFromCall := c.GetAllUsers(serverCon.context, &proto.Filters{IncludeDeleted: filters.IncludeDeleted, Status: filters.Status})
 
 	if errorFromCall != nil {
-		log.Fatalf(errorFromCall.Error())
+		return nil, errorFromCall
 	}
 
 	response := []User{}
 
 	for _, o := range result.Users {
-		response = append(response, User{
-			Id:       int(o.Id),
-			Email:    o.Email,
-			Name:     o.Name,
-			LastName: o.LastName,
-		})
+		response = append(response, userFromProto(o))
 	}
 
 	return response, errorFromCall
@@ -73,7 +105,7 @@ func (up UserProxy) Create(ctx context.Context, u User) (User, error) {
 	serverCon, err := OpenServerConection(ctx)
 
 	if err != nil {
-		log.Fatalf("did not connect to server: %s", err)
+		return User{}, err
 	}
 
 	defer serverCon.dispose()
@@ -87,6 +119,14 @@ func (up UserProxy) Create(ctx context.Context, u User) (User, error) {
 
 	result, errorFromCall := c.Create(serverCon.context, &proto.CreateUserRequest{User: externalUser})
 
+	if errorFromCall != nil {
+		return User{}, errorFromCall
+	}
+
+	if err := detailsError(result.Details); err != nil {
+		return User{}, err
+	}
+
 	if result.Code == proto.CodeResult_FAILED {
 		return User{}, ErrUserAlreadyExists
 	}
@@ -95,12 +135,8 @@ func (up UserProxy) Create(ctx context.Context, u User) (User, error) {
 		return User{}, ErrInvalidInput
 	}
 
-	if errorFromCall != nil {
-		return User{}, errorFromCall
-	}
-
 	u.Id = int(result.UserId)
-	return u, errorFromCall
+	return u, nil
 }
 
 func (up UserProxy) Update(ctx context.Context, u User) (User, error) {
@@ -108,7 +144,7 @@ func (up UserProxy) Update(ctx context.Context, u User) (User, error) {
 	serverCon, err := OpenServerConection(ctx)
 
 	if err != nil {
-		log.Fatalf("did not connect to server: %s", err)
+		return User{}, err
 	}
 
 	defer serverCon.dispose()
@@ -120,7 +156,23 @@ func (up UserProxy) Update(ctx context.Context, u User) (User, error) {
 		LastName: u.LastName,
 	}
 
-	result, errorFromCall := c.Update(serverCon.context, &proto.UpdateUserRequest{User: &externalUser})
+	result, errorFromCall := c.Update(serverCon.context, &proto.UpdateUserRequest{User: &externalUser, Version: int32(u.Version)})
+
+	if errorFromCall != nil {
+		return User{}, errorFromCall
+	}
+
+	if err := detailsError(result.Details); err != nil {
+		return User{}, err
+	}
+
+	if result.Code == proto.CodeResult_CONFLICT {
+		return User{}, ErrPreconditionFailed
+	}
+
+	if result.Code == proto.CodeResult_PRECONDITIONREQUIRED {
+		return User{}, ErrPreconditionRequired
+	}
 
 	if result.Code == proto.CodeResult_FAILED {
 		return User{}, ErrUserAlreadyExists
@@ -134,27 +186,70 @@ func (up UserProxy) Update(ctx context.Context, u User) (User, error) {
 		return User{}, ErrNotFound
 	}
 
+	u.Version = int(result.Version)
+	return u, nil
+}
+
+// Patch sends a partial update, only the fields in the patch paths are
+// changed.
+func (up UserProxy) Patch(ctx context.Context, p UserPatch) error {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.client
+	result, errorFromCall := c.Update(serverCon.context, &proto.UpdateUserRequest{
+		User:       &proto.User{Email: p.Email, Name: p.Name, LastName: p.LastName},
+		Version:    int32(p.Version),
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: p.Paths},
+	})
+
 	if errorFromCall != nil {
-		return User{}, errorFromCall
+		return errorFromCall
 	}
 
-	return u, nil
+	if err := detailsError(result.Details); err != nil {
+		return err
+	}
+
+	return errorFromCode(result.Code)
 }
 
-func (up UserProxy) Delete(ctx context.Context, id int) (bool, error) {
+func (up UserProxy) Delete(ctx context.Context, id int, version int) (bool, error) {
 
 	serverCon, err := OpenServerConection(ctx)
 
 	if err != nil {
-		log.Fatalf("did not connect to server: %s", err)
+		return false, err
 	}
 
 	defer serverCon.dispose()
 	c := serverCon.client
-	externalUserId := &proto.Id{
-		Value: int32(id),
+	externalUser := &proto.DeleteUserRequest{
+		Id:      int32(id),
+		Version: int32(version),
+	}
+	result, errorFromCall := c.Delete(serverCon.context, externalUser)
+
+	if errorFromCall != nil {
+		return false, errorFromCall
+	}
+
+	if result.Code == proto.CodeResult_FORBIDDEN {
+		return false, ErrForbidden
+	}
+
+	if result.Code == proto.CodeResult_CONFLICT {
+		return false, ErrPreconditionFailed
+	}
+
+	if result.Code == proto.CodeResult_PRECONDITIONREQUIRED {
+		return false, ErrPreconditionRequired
 	}
-	result, errorFromCall := c.Delete(serverCon.context, externalUserId)
 
 	if result.Code == proto.CodeResult_FAILED {
 		return false, ErrInternalFailure
@@ -164,11 +259,11 @@ func (up UserProxy) Delete(ctx context.Context, id int) (bool, error) {
 		return false, ErrNotFound
 	}
 
-	if errorFromCall != nil {
-		return true, errorFromCall
+	if result.Code == proto.CodeResult_INVALIDINPUT {
+		return false, ErrInvalidInput
 	}
 
-	return false, errorFromCall
+	return true, nil
 }
 
 func (up UserProxy) GetByEmail(ctx context.Context, email string) (User, error) {
@@ -176,66 +271,550 @@ func (up UserProxy) GetByEmail(ctx context.Context, email string) (User, error)
 	serverCon, err := OpenServerConection(ctx)
 
 	if err != nil {
-		log.Fatalf("did not connect to server: %s", err)
+		return User{}, err
 	}
 
 	defer serverCon.dispose()
 	c := serverCon.client
 	result, errorFromCall := c.GetUser(serverCon.context, &proto.EmailAddress{Value: email})
 
-	if result.User.Id == 0 {
+	if errorFromCall != nil {
+		return User{}, errorFromCall
+	}
+
+	if result.User == nil || result.User.Id == 0 {
 		return User{}, ErrNotFound
 	}
 
+	return userFromProto(result.User), nil
+}
+
+func (up UserProxy) Restore(ctx context.Context, id int) error {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.client
+	result, errorFromCall := c.Restore(serverCon.context, &proto.Id{Value: int32(id)})
+
+	if errorFromCall != nil {
+		return errorFromCall
+	}
+
+	return errorFromCode(result.Code)
+}
+
+func (up UserProxy) Purge(ctx context.Context, id int) error {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.client
+	result, errorFromCall := c.Purge(serverCon.context, &proto.Id{Value: int32(id)})
+
+	if errorFromCall != nil {
+		return errorFromCall
+	}
+
+	return errorFromCode(result.Code)
+}
+
+func (up UserProxy) History(ctx context.Context, id int, filters HistoryFilters) ([]AuditEntry, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.client
+
+	req := &proto.HistoryRequest{UserId: int32(id), Actor: filters.Actor}
+
+	if !filters.From.IsZero() {
+		req.From = timestamppb.New(filters.From)
+	}
+
+	if !filters.To.IsZero() {
+		req.To = timestamppb.New(filters.To)
+	}
+
+	result, errorFromCall := c.History(serverCon.context, req)
+
+	if errorFromCall != nil {
+		return nil, errorFromCall
+	}
+
+	if err := errorFromCode(result.Code); err != nil {
+		return nil, err
+	}
+
+	entries := []AuditEntry{}
+
+	for _, e := range result.Entries {
+		entry := AuditEntry{
+			Id:         int(e.Id),
+			UserId:     int(e.UserId),
+			Action:     e.Action,
+			Changes:    []FieldChange{},
+			Actor:      e.Actor,
+			RequestId:  e.RequestId,
+			Transport:  e.Transport,
+			OccurredAt: timeFromProto(e.OccurredAt),
+		}
+		for _, c := range e.Changes {
+			entry.Changes = append(entry.Changes, FieldChange{Field: c.Field, Before: c.Before, After: c.After})
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Watch relays the user change feed to send, starting after the given
+// sequence or at the head of the feed for feedHead, until the context is
+// done, the stream fails or send fails.
+func (up UserProxy) Watch(ctx context.Context, after int64, send func(UserEvent) error) error {
+
+	serverCon, err := OpenServerStream(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.client
+
+	req := &proto.WatchUsersRequest{AfterSequence: after}
+
+	if after == feedHead {
+		req = &proto.WatchUsersRequest{FromHead: true}
+	}
+
+	stream, err := c.WatchUsers(serverCon.context, req)
+
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+
+		if err != nil {
+			return err
+		}
+
+		err = send(UserEvent{
+			Sequence:   event.Sequence,
+			Type:       event.Type,
+			User:       userFromProto(event.User),
+			OccurredAt: timeFromProto(event.OccurredAt),
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// RequestEmailChange asks for the email of a user to be changed, a token is
+// sent to the new address to confirm it.
+func (up UserProxy) RequestEmailChange(ctx context.Context, email string, newEmail string) error {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.client
+	result, errorFromCall := c.RequestEmailChange(serverCon.context, &proto.EmailChangeRequest{Email: email, NewEmail: newEmail})
+
+	if errorFromCall != nil {
+		return errorFromCall
+	}
+
+	return emailChangeError(result.Code)
+}
+
+// ConfirmEmailChange changes the email of the user the token was issued for
+// and returns the changed user.
+func (up UserProxy) ConfirmEmailChange(ctx context.Context, token string) (User, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return User{}, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.client
+	result, errorFromCall := c.ConfirmEmailChange(serverCon.context, &proto.ConfirmEmailChangeRequest{Token: token})
+
 	if errorFromCall != nil {
-		fmt.Println("server call did not work:", errorFromCall)
 		return User{}, errorFromCall
 	}
 
-	userFromGrpc := result.User
+	if err := emailChangeError(result.Code); err != nil {
+		return User{}, err
+	}
+
+	return userFromProto(result.User), nil
+}
+
+// emailChangeError translates the result code of an email change, a conflict
+// means the new address is taken rather than a stale version.
+func emailChangeError(code proto.CodeResult) error {
+	if code == proto.CodeResult_CONFLICT {
+		return ErrUserAlreadyExists
+	}
+	return errorFromCode(code)
+}
+
+// SendVerification sends a new verification token to a pending user.
+func (up UserProxy) SendVerification(ctx context.Context, email string) error {
+
+	serverCon, err := OpenServerConection(ctx)
 
-	response := User{
-		Id:       int(userFromGrpc.Id),
-		Email:    userFromGrpc.Email,
-		Name:     userFromGrpc.Name,
-		LastName: userFromGrpc.LastName,
+	if err != nil {
+		return err
 	}
 
-	return response, nil
+	defer serverCon.dispose()
+	c := serverCon.client
+	result, errorFromCall := c.SendVerification(serverCon.context, &proto.EmailAddress{Value: email})
+
+	if errorFromCall != nil {
+		return errorFromCall
+	}
+
+	return statusError(result.Code)
 }
 
-func OpenServerConection(ctx context.Context) (*ServerConnection, error) {
+// VerifyEmail activates the pending user the token was issued for.
+func (up UserProxy) VerifyEmail(ctx context.Context, token string) (User, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return User{}, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.client
+	result, errorFromCall := c.VerifyEmail(serverCon.context, &proto.VerifyEmailRequest{Token: token})
+
+	if errorFromCall != nil {
+		return User{}, errorFromCall
+	}
+
+	if err := statusError(result.Code); err != nil {
+		return User{}, err
+	}
+
+	return userFromProto(result.User), nil
+}
+
+func (up UserProxy) Suspend(ctx context.Context, id int) error {
+	return up.changeStatus(ctx, id, proto.UsersClient.Suspend)
+}
+
+func (up UserProxy) Reactivate(ctx context.Context, id int) error {
+	return up.changeStatus(ctx, id, proto.UsersClient.Reactivate)
+}
+
+func (up UserProxy) Deactivate(ctx context.Context, id int) error {
+	return up.changeStatus(ctx, id, proto.UsersClient.Deactivate)
+}
+
+// changeStatus calls one of the status transitions, they all take the id of
+// the user.
+func (up UserProxy) changeStatus(ctx context.Context, id int, transition func(proto.UsersClient, context.Context, *proto.Id, ...grpc.CallOption) (*proto.ChangeStatusResponse, error)) error {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	result, errorFromCall := transition(serverCon.client, serverCon.context, &proto.Id{Value: int32(id)})
+
+	if errorFromCall != nil {
+		return errorFromCall
+	}
+
+	return statusError(result.Code)
+}
+
+// statusError translates the result code of a status change, a conflict
+// means the current status does not allow it.
+func statusError(code proto.CodeResult) error {
+	if code == proto.CodeResult_CONFLICT {
+		return ErrStatusConflict
+	}
+	return errorFromCode(code)
+}
+
+// errorFromCode translates the result code of a gRPC response into the
+// errors understood by the HTTP transport.
+func errorFromCode(code proto.CodeResult) error {
+	switch code {
+	case proto.CodeResult_OK:
+		return nil
+	case proto.CodeResult_NOTFOUND:
+		return ErrNotFound
+	case proto.CodeResult_INVALIDINPUT:
+		return ErrInvalidInput
+	case proto.CodeResult_CONFLICT:
+		return ErrPreconditionFailed
+	case proto.CodeResult_PRECONDITIONREQUIRED:
+		return ErrPreconditionRequired
+	case proto.CodeResult_FORBIDDEN:
+		return ErrForbidden
+	case proto.CodeResult_UNAUTHENTICATED:
+		return ErrUnauthorized
+	case proto.CodeResult_LOCKED:
+		return ErrLocked
+	case proto.CodeResult_TOOMANYREQUESTS:
+		return ErrTooManyRequests
+	default:
+		return ErrInternalFailure
+	}
+}
+
+// detailsError returns the error described by the details of a failed
+// result, with the fields that are not valid. It is nil when the service sent
+// no details.
+func detailsError(details *proto.ErrorDetails) error {
+	if details == nil || details.Code == "" {
+		return nil
+	}
+	var fields []problems.FieldError
+	for _, v := range details.Violations {
+		fields = append(fields, problems.FieldError{Field: v.Field, Rule: v.Rule, Detail: v.Description})
+	}
+	return problems.Error{Code: problems.Code(details.Code), Detail: details.Detail, Fields: fields}
+}
+
+// requestMetadata forwards the id of the request and the transport it came
+// from to the gRPC service so that they are recorded in the audit trail, and
+// the address of the client so the service can rate limit it.
+func requestMetadata(ctx context.Context) context.Context {
+	pairs := []string{"x-source-transport", "rest"}
+	if id := domain.RequestIDFromContext(ctx); id != "" {
+		pairs = append(pairs, "x-request-id", id)
+	}
+	if address, ok := ctx.Value(clientAddressContextKey{}).(string); ok && address != "" {
+		pairs = append(pairs, "x-client-address", address)
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+// callerMetadata forwards the identity of the authenticated caller to the
+// gRPC service so that it can authorize the operation.
+func callerMetadata(ctx context.Context) context.Context {
+	claims, ok := claimsFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	subject, _ := claims["sub"].(string)
+	return metadata.AppendToOutgoingContext(ctx,
+		"x-caller-subject", subject,
+		"x-caller-roles", strings.Join(rolesFromClaims(claims), ","),
+		"x-caller-amr", strings.Join(methodsFromClaims(claims), ","))
+}
+
+// signedMetadata signs the caller, the transport and the client address
+// forwarded to the gRPC service for the called method with the secret both
+// share, the service ignores them otherwise.
+func signedMetadata(ctx context.Context, secret string, method string) (context.Context, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	pairs, err := gateway.SignMetadata([]byte(secret), method, md, time.Now())
+	if err != nil {
+		return ctx, err
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...), nil
+}
+
+// signingUnaryInterceptor signs the forwarded metadata of every unary call
+// once its method is known.
+func signingUnaryInterceptor(secret string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := signedMetadata(ctx, secret, method)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// signingStreamInterceptor is the signingUnaryInterceptor of the streams.
+func signingStreamInterceptor(secret string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := signedMetadata(ctx, secret, method)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// outgoingMetadata forwards the trace, the caller and the request to the
+// gRPC service, the interceptors of the connection sign them.
+func outgoingMetadata(ctx context.Context) context.Context {
+	return requestMetadata(callerMetadata(traceMetadata(ctx)))
+}
+
+// traceMetadata propagates the span of the request to the gRPC service in
+// the B3 headers, the spans of both services then share the trace.
+func traceMetadata(ctx context.Context) context.Context {
+	span := zipkin.SpanFromContext(ctx)
+	if span == nil {
+		return ctx
+	}
+	md := metadata.MD{}
+	if err := b3.InjectGRPC(&md)(span.Context()); err != nil {
+		return ctx
+	}
+	pairs := []string{}
+	for key, values := range md {
+		for _, value := range values {
+			pairs = append(pairs, key, value)
+		}
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+// dialServer connects to the gRPC service at the address of the
+// configuration, the calls of the connection are signed with the secret of
+// the configuration.
+func dialServer() (*grpc.ClientConn, error) {
 
 	cfg := config{}
 
 	if err := env.Parse(&cfg); err != nil {
-		fmt.Printf("%+v\n", err)
+		return nil, err
 	}
 
-	conn, err := grpc.Dial(fmt.Sprintf("%s:%s", cfg.Host, strconv.Itoa(cfg.Port)), grpc.WithInsecure())
+	conn, err := grpc.Dial(fmt.Sprintf("%s:%s", cfg.Host, strconv.Itoa(cfg.Port)), grpc.WithInsecure(),
+		grpc.WithChainUnaryInterceptor(signingUnaryInterceptor(cfg.Secret), otelgrpc.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(signingStreamInterceptor(cfg.Secret), otelgrpc.StreamClientInterceptor()))
+
+	return conn, err
+}
+
+var (
+	sharedConnMtx sync.Mutex
+	sharedConn    *grpc.ClientConn
+)
+
+// serverConnection returns the connection to the gRPC service shared by the
+// proxies, it is dialed on the first call. A connection carries any number
+// of concurrent calls, dialing one per request would add a handshake to each
+// of them.
+func serverConnection() (*grpc.ClientConn, error) {
+
+	sharedConnMtx.Lock()
+	defer sharedConnMtx.Unlock()
+
+	if sharedConn == nil {
+		conn, err := dialServer()
+
+		if err != nil {
+			return nil, err
+		}
+
+		sharedConn = conn
+	}
+
+	return sharedConn, nil
+}
+
+func OpenServerConection(ctx context.Context) (*ServerConnection, error) {
+
+	conn, err := serverConnection()
 
 	if err != nil {
-		log.Fatalf("did not connect to server: %s", err)
 		return nil, err
 	}
 
-	ctxTO, cancel := context.WithTimeout(ctx, 10*time.Second)
+	ctxTO, cancel := context.WithTimeout(outgoingMetadata(ctx), 10*time.Second)
 
 	c := proto.NewUsersClient(conn)
 
 	return &ServerConnection{
-		client:  c,
-		context: ctxTO,
-		dispose: func() {
-			cancel()
-			conn.Close()
-		},
+		client:   c,
+		webhooks: proto.NewWebhooksClient(conn),
+		auth:     proto.NewAuthClient(conn),
+		context:  ctxTO,
+		dispose:  cancel,
 	}, nil
 
 }
 
+// OpenServerStream opens a long lived stream on the shared connection,
+// unlike OpenServerConection it is only bounded by the given context.
+func OpenServerStream(ctx context.Context) (*ServerConnection, error) {
+
+	conn, err := serverConnection()
+
+	if err != nil {
+		return nil, err
+	}
+
+	ctxStream, cancel := context.WithCancel(outgoingMetadata(ctx))
+
+	return &ServerConnection{
+		client:  proto.NewUsersClient(conn),
+		context: ctxStream,
+		dispose: cancel,
+	}, nil
+}
+
 type ServerConnection struct {
-	client  proto.UsersClient
-	context context.Context
-	dispose func()
+	client   proto.UsersClient
+	webhooks proto.WebhooksClient
+	auth     proto.AuthClient
+	context  context.Context
+	dispose  func()
+}
+
+func userFromProto(o *proto.User) User {
+	usr := User{
+		Id:        int(o.Id),
+		Email:     o.Email,
+		Name:      o.Name,
+		LastName:  o.LastName,
+		Version:   int(o.Version),
+		CreatedAt: timeFromProto(o.CreatedAt),
+		UpdatedAt: timeFromProto(o.UpdatedAt),
+		CreatedBy: o.CreatedBy,
+		UpdatedBy: o.UpdatedBy,
+		Status:    o.Status,
+	}
+
+	if o.DeletedAt != nil {
+		deletedAt := o.DeletedAt.AsTime()
+		usr.DeletedAt = &deletedAt
+	}
+
+	return usr
+}
+
+func timeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
 }
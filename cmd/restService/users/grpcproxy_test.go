@@ -1,4 +1,88 @@
 package users
 
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/gateway"
+	"github.com/casmelad/GlobantPOC/pkg/tracing"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	zipkinhttp "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
 type UsersProxyMock struct {
 }
+
+// invokedMetadata returns the outgoing metadata a unary call of the method
+// is sent with once the signing interceptor ran.
+func invokedMetadata(ctx context.Context, secret string, method string) metadata.MD {
+	var md metadata.MD
+	signingUnaryInterceptor(secret)(ctx, method, nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	})
+	return md
+}
+
+func Test_OutgoingMetadata_Caller_SignedForGrpc(t *testing.T) {
+	//Arrange
+	ctx := context.WithValue(httptest.NewRequest(http.MethodGet, UsersBaseUri, nil).Context(), claimsContextKey{}, jwt.MapClaims{"sub": "7"})
+	//Act
+	md := invokedMetadata(outgoingMetadata(ctx), "shared-secret", "/users.Users/Delete")
+	//Assert
+	assert.Equal(t, []string{"7"}, md.Get("x-caller-subject"))
+	assert.True(t, gateway.Verify([]byte("shared-secret"), "/users.Users/Delete", md, time.Now()))
+	assert.False(t, gateway.Verify([]byte("shared-secret"), "/users.Users/GetUser", md, time.Now()))
+}
+
+func Test_OutgoingMetadata_NoSecret_NotSigned(t *testing.T) {
+	//Arrange
+	ctx := httptest.NewRequest(http.MethodGet, UsersBaseUri, nil).Context()
+	//Act
+	md := invokedMetadata(outgoingMetadata(ctx), "", "/users.Users/Delete")
+	//Assert
+	assert.Empty(t, md.Get(gateway.SignatureKey))
+}
+
+func Test_TraceMetadata_ServerSpan_PropagatedToGrpc(t *testing.T) {
+	//Arrange
+	spans := recorder.NewReporter()
+	tracer, _, err := tracing.NewTracer(spans, "users-rest", "", 1)
+	assert.Nil(t, err)
+	var outgoing metadata.MD
+	capture := func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			outgoing, _ = metadata.FromOutgoingContext(traceMetadata(ctx))
+			return next(ctx, request)
+		}
+	}
+	handler := zipkinhttp.NewServerMiddleware(tracer)(MakeWebhooksHTTPHandler(&webhookProxyStub{}, log.NewNopLogger(), capture))
+	r := httptest.NewRequest(http.MethodGet, WebhooksBaseUri+"42", nil)
+	//Act
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	//Assert
+	recorded := spans.Flush()
+	assert.Len(t, recorded, 1)
+	assert.Equal(t, "GET "+GetWebhook, recorded[0].Name)
+	assert.Equal(t, model.Server, recorded[0].Kind)
+	assert.Equal(t, []string{recorded[0].TraceID.String()}, outgoing.Get("x-b3-traceid"))
+	assert.Equal(t, []string{recorded[0].ID.String()}, outgoing.Get("x-b3-spanid"))
+	assert.Equal(t, []string{"1"}, outgoing.Get("x-b3-sampled"))
+}
+
+func Test_TraceMetadata_NoSpan_NothingAdded(t *testing.T) {
+	//Act
+	_, ok := metadata.FromOutgoingContext(traceMetadata(context.Background()))
+	//Assert
+	assert.False(t, ok)
+}
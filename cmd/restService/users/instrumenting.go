@@ -0,0 +1,65 @@
+package users
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/casmelad/GlobantPOC/pkg/instrumentation"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/gorilla/mux"
+	"github.com/openzipkin/zipkin-go"
+)
+
+type routeContextKey struct{}
+
+// InstrumentingMiddleware returns the endpoint middleware recording the RED
+// metrics of the routes, labelled with the method and the path template of
+// the matched route.
+func InstrumentingMiddleware(m instrumentation.RED) endpoint.Middleware {
+	return instrumentation.EndpointMiddleware(m, routeFromContext, responseError)
+}
+
+// routeToContext is a transport/http.RequestFunc that keeps the matched route
+// in the context, the path template is used so that the metrics do not get a
+// label per user. The span of the request is named after it too.
+func routeToContext(ctx context.Context, r *http.Request) context.Context {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ctx
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ctx
+	}
+	name := r.Method + " " + template
+	if span := zipkin.SpanFromContext(ctx); span != nil {
+		span.SetName(name)
+		zipkin.TagHTTPRoute.Set(span, template)
+	}
+	return context.WithValue(ctx, routeContextKey{}, name)
+}
+
+func routeFromContext(ctx context.Context) string {
+	route, _ := ctx.Value(routeContextKey{}).(string)
+	return route
+}
+
+// responseError returns the business error of the responses implementing
+// errorer.
+func responseError(response interface{}) error {
+	if e, ok := response.(errorer); ok {
+		return e.error()
+	}
+	return nil
+}
+
+// chain composes the middlewares given to the endpoint constructors, the
+// first one being the outermost.
+func chain(middleware []endpoint.Middleware) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			next = middleware[i](next)
+		}
+		return next
+	}
+}
@@ -0,0 +1,55 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// routeRecorder is an endpoint middleware keeping the route and the business
+// error seen by the instrumenting middleware.
+type routeRecorder struct {
+	route string
+	err   error
+}
+
+func (rr *routeRecorder) middleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		response, err := next(ctx, request)
+		rr.route = routeFromContext(ctx)
+		rr.err = responseError(response)
+		return response, err
+	}
+}
+
+func Test_Instrumenting_Route_LabelledWithPathTemplate(t *testing.T) {
+	//Arrange
+	recorder := &routeRecorder{}
+	r := httptest.NewRequest(http.MethodGet, WebhooksBaseUri+"42", nil)
+	w := httptest.NewRecorder()
+	//Act
+	MakeWebhooksHTTPHandler(&webhookProxyStub{}, log.NewNopLogger(), recorder.middleware).ServeHTTP(w, r)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "GET "+GetWebhook, recorder.route)
+	assert.Nil(t, recorder.err)
+}
+
+func Test_Instrumenting_BusinessError_Reported(t *testing.T) {
+	//Arrange
+	recorder := &routeRecorder{}
+	r := httptest.NewRequest(http.MethodDelete, WebhooksBaseUri+"42", nil)
+	w := httptest.NewRecorder()
+	//Act
+	MakeWebhooksHTTPHandler(&webhookProxyStub{err: ErrNotFound}, log.NewNopLogger(), recorder.middleware).ServeHTTP(w, r)
+	//Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "DELETE "+DeleteWebhook, recorder.route)
+	assert.True(t, errors.Is(recorder.err, ErrNotFound))
+}
@@ -0,0 +1,30 @@
+package users
+
+import (
+	"net/http"
+
+	"github.com/casmelad/GlobantPOC/pkg/logging"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+)
+
+// LoggingMiddleware returns the endpoint middleware logging every call to
+// the routes, with the request and trace ids.
+func LoggingMiddleware(logger log.Logger) endpoint.Middleware {
+	return logging.EndpointMiddleware(logger, logging.Endpoint{
+		Operation: routeFromContext,
+		Failed:    responseError,
+		Classify:  errorClass,
+	})
+}
+
+// errorClass tells the errors the caller has to fix apart from the server
+// failures, using the status code they are answered with.
+func errorClass(err error) string {
+
+	if codeFrom(err) < http.StatusInternalServerError {
+		return logging.ErrorClassClient
+	}
+
+	return logging.ErrorClass(err)
+}
@@ -2,17 +2,62 @@ package users
 
 import (
 	"context"
+	"net"
 	"net/http"
 
+	domain "github.com/casmelad/GlobantPOC/pkg/users"
 	"github.com/google/uuid"
 )
 
+// RequestIDHeader carries the id of a request. The one sent by the client is
+// kept, otherwise a UUID is generated, and it is returned in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the ids accepted from the clients.
+const maxRequestIDLength = 128
+
+// UUIDContextMiddleware keeps the id of the request in its context, it is
+// forwarded to the gRPC service and written in the logs of both services.
 func UUIDContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-		uuidContext := context.WithValue(ctx, "uuid", uuid.New())
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.New().String()
+		}
 
-		r = r.WithContext(uuidContext)
+		rw.Header().Set(RequestIDHeader, id)
+
+		r = r.WithContext(domain.WithRequestID(r.Context(), id))
 		next.ServeHTTP(rw, r)
 	})
 }
+
+// validRequestID tells if an id sent by a client can be kept, only short
+// tokens are accepted so that they can not forge log lines or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type clientAddressContextKey struct{}
+
+// clientAddressToContext is a transport/http.RequestFunc that keeps the
+// address of the client in the context, it is forwarded to the gRPC service.
+// The address of the connection is used, forwarding headers are not trusted.
+func clientAddressToContext(ctx context.Context, r *http.Request) context.Context {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return context.WithValue(ctx, clientAddressContextKey{}, host)
+}
@@ -0,0 +1,77 @@
+package users
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	domain "github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func serveWithRequestID(r *http.Request) (*httptest.ResponseRecorder, string) {
+	var id string
+	w := httptest.NewRecorder()
+	UUIDContextMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = domain.RequestIDFromContext(r.Context())
+	})).ServeHTTP(w, r)
+	return w, id
+}
+
+func Test_UUIDContextMiddleware_IncomingID_KeptAndEchoed(t *testing.T) {
+	//Arrange
+	r := httptest.NewRequest(http.MethodGet, UsersBaseUri, nil)
+	r.Header.Set(RequestIDHeader, "client-42")
+	//Act
+	w, id := serveWithRequestID(r)
+	//Assert
+	assert.Equal(t, "client-42", id)
+	assert.Equal(t, "client-42", w.Header().Get(RequestIDHeader))
+}
+
+func Test_UUIDContextMiddleware_NoID_UUIDGenerated(t *testing.T) {
+	//Arrange
+	r := httptest.NewRequest(http.MethodGet, UsersBaseUri, nil)
+	//Act
+	w, id := serveWithRequestID(r)
+	//Assert
+	_, err := uuid.Parse(id)
+	assert.Nil(t, err)
+	assert.Equal(t, id, w.Header().Get(RequestIDHeader))
+}
+
+func Test_UUIDContextMiddleware_InvalidID_Replaced(t *testing.T) {
+	for _, invalid := range []string{"bad id\nlevel=error", strings.Repeat("a", maxRequestIDLength+1)} {
+		//Arrange
+		r := httptest.NewRequest(http.MethodGet, UsersBaseUri, nil)
+		r.Header.Set(RequestIDHeader, invalid)
+		//Act
+		w, id := serveWithRequestID(r)
+		//Assert
+		assert.NotEqual(t, invalid, id)
+		assert.Equal(t, id, w.Header().Get(RequestIDHeader))
+	}
+}
+
+func Test_RequestMetadata_RequestID_ForwardedToGrpc(t *testing.T) {
+	//Arrange
+	ctx := domain.WithRequestID(httptest.NewRequest(http.MethodGet, UsersBaseUri, nil).Context(), "client-42")
+	//Act
+	md, _ := metadata.FromOutgoingContext(requestMetadata(ctx))
+	//Assert
+	assert.Equal(t, []string{"client-42"}, md.Get("x-request-id"))
+}
+
+func Test_EncodeError_RequestID_InstanceOfProblem(t *testing.T) {
+	//Arrange
+	w := httptest.NewRecorder()
+	ctx := domain.WithRequestID(httptest.NewRequest(http.MethodGet, UsersBaseUri, nil).Context(), "client-42")
+	//Act
+	encodeError(ctx, ErrNotFound, w)
+	//Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"instance":"client-42"`)
+}
@@ -0,0 +1,149 @@
+package users
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/ratelimit"
+	"github.com/gorilla/mux"
+)
+
+// RateLimitRule limits the requests every client makes to the routes that
+// match the method and the path. The method "*" matches any method and a
+// path ending in "*" matches the paths that start with it.
+type RateLimitRule struct {
+	Method string
+	Path   string
+	Limit  ratelimit.Limit
+}
+
+// ParseRateLimitRules reads rules separated by ";", each of them as
+// "METHOD PATH REQUESTS/PERIOD [BURST]", for instance
+// "POST /users/ 30/1m; * /users/* 100/1s 200".
+func ParseRateLimitRules(value string) ([]RateLimitRule, error) {
+	rules := []RateLimitRule{}
+	for _, text := range strings.Split(value, ";") {
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 && len(fields) != 4 {
+			return nil, fmt.Errorf("rate limit rule %q: expected METHOD PATH REQUESTS/PERIOD [BURST]", text)
+		}
+
+		rule := RateLimitRule{Method: strings.ToUpper(fields[0]), Path: fields[1]}
+
+		rate := strings.SplitN(fields[2], "/", 2)
+		if len(rate) != 2 {
+			return nil, fmt.Errorf("rate limit rule %q: expected REQUESTS/PERIOD", text)
+		}
+		requests, err := strconv.Atoi(rate[0])
+		if err != nil || requests <= 0 {
+			return nil, fmt.Errorf("rate limit rule %q: invalid requests %q", text, rate[0])
+		}
+		per, err := time.ParseDuration(rate[1])
+		if err != nil || per <= 0 {
+			return nil, fmt.Errorf("rate limit rule %q: invalid period %q", text, rate[1])
+		}
+		rule.Limit = ratelimit.Limit{Requests: requests, Per: per}
+
+		if len(fields) == 4 {
+			if rule.Limit.Burst, err = strconv.Atoi(fields[3]); err != nil || rule.Limit.Burst <= 0 {
+				return nil, fmt.Errorf("rate limit rule %q: invalid burst %q", text, fields[3])
+			}
+		}
+
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// RateLimiter rejects the requests of the clients that went over the limit of
+// the route, the first rule that matches a request applies to it. The clients
+// are told apart by their API key or token subject and, for anonymous
+// requests, by their address.
+type RateLimiter struct {
+	router *mux.Router
+	rules  []RateLimitRule
+	store  ratelimit.Store
+	now    func() time.Time
+}
+
+// NewRateLimiter returns a RateLimiter that keeps the buckets of the clients
+// in the store.
+func NewRateLimiter(rules []RateLimitRule, store ratelimit.Store) *RateLimiter {
+	router := mux.NewRouter()
+	for i, rule := range rules {
+		route := router.NewRoute().Name(strconv.Itoa(i))
+		if strings.HasSuffix(rule.Path, "*") {
+			route.PathPrefix(strings.TrimSuffix(rule.Path, "*"))
+		} else {
+			route.Path(rule.Path)
+		}
+		if rule.Method != "*" {
+			route.Methods(rule.Method)
+		}
+	}
+	return &RateLimiter{router: router, rules: rules, store: store, now: time.Now}
+}
+
+// Middleware limits the requests served by next. It must run after
+// AuthenticationMiddleware so the authenticated clients are known. The
+// requests are served when the store fails, an outage of a shared store does
+// not take the gateway down.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var match mux.RouteMatch
+		if !l.router.Match(r, &match) {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		index, _ := strconv.Atoi(match.Route.GetName())
+		rule := l.rules[index]
+		key := fmt.Sprintf("%s %s|%s", rule.Method, rule.Path, rateLimitClient(r))
+
+		result, err := l.store.Take(r.Context(), key, rule.Limit, l.now())
+		if err != nil {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		rw.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		rw.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		rw.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.ResetAfter)))
+
+		if !result.Allowed {
+			rw.Header().Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+			encodeError(r.Context(), ErrTooManyRequests, rw)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// rateLimitClient tells the clients apart by the subject of their API key or
+// token and, for anonymous requests, by the address of the connection.
+func rateLimitClient(r *http.Request) string {
+	if claims, ok := claimsFromContext(r.Context()); ok {
+		if subject, _ := claims["sub"].(string); subject != "" {
+			return subject
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// seconds rounds a duration up to whole seconds, as the headers carry them.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,110 @@
+package users
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/ratelimit"
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRateLimiter(t *testing.T, rules string) *RateLimiter {
+	parsed, err := ParseRateLimitRules(rules)
+	assert.Nil(t, err)
+	limiter := NewRateLimiter(parsed, memory.NewInMemoryRateLimitStore())
+	limiter.now = func() time.Time { return time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC) }
+	return limiter
+}
+
+func serveLimited(limiter *RateLimiter, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, r)
+	return w
+}
+
+func Test_ParseRateLimitRules_ReadsEveryRule(t *testing.T) {
+	//Act
+	rules, err := ParseRateLimitRules("POST /users/ 30/1m; * /users/* 100/1s 200;")
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []RateLimitRule{
+		{Method: "POST", Path: "/users/", Limit: ratelimit.Limit{Requests: 30, Per: time.Minute}},
+		{Method: "*", Path: "/users/*", Limit: ratelimit.Limit{Requests: 100, Per: time.Second, Burst: 200}},
+	}, rules)
+}
+
+func TestCases_ParseRateLimitRules_InvalidRule(t *testing.T) {
+	testCases := []string{
+		"POST /users/",
+		"POST /users/ 30",
+		"POST /users/ x/1m",
+		"POST /users/ 30/soon",
+		"POST /users/ 0/1m",
+		"POST /users/ 30/1m many",
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc, func(t *testing.T) {
+			//Act
+			_, err := ParseRateLimitRules(tc)
+			//Assert
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func Test_RateLimiter_OverTheLimit_Returns429WithHeaders(t *testing.T) {
+	//Arrange
+	limiter := newTestRateLimiter(t, "POST /users/ 2/1m")
+	var responses []*httptest.ResponseRecorder
+	//Act
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest(http.MethodPost, PostUser, nil)
+		r.RemoteAddr = "192.0.2.1:51234"
+		responses = append(responses, serveLimited(limiter, r))
+	}
+	//Assert
+	assert.Equal(t, http.StatusOK, responses[0].Code)
+	assert.Equal(t, "2", responses[0].Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", responses[0].Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", responses[0].Header().Get("RateLimit-Reset"))
+	assert.Equal(t, http.StatusOK, responses[1].Code)
+	assert.Equal(t, http.StatusTooManyRequests, responses[2].Code)
+	assert.Equal(t, "0", responses[2].Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", responses[2].Header().Get("Retry-After"))
+}
+
+func Test_RateLimiter_AuthenticatedClients_HaveTheirOwnBuckets(t *testing.T) {
+	//Arrange
+	limiter := newTestRateLimiter(t, "POST /users/ 1/1m")
+	request := func(subject string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, PostUser, nil)
+		r.RemoteAddr = "192.0.2.1:51234"
+		return r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, jwt.MapClaims{"sub": subject}))
+	}
+	//Act
+	jane := serveLimited(limiter, request("jane@example.com"))
+	job := serveLimited(limiter, request("apikey:0123456789abcdef"))
+	janeAgain := serveLimited(limiter, request("jane@example.com"))
+	//Assert
+	assert.Equal(t, http.StatusOK, jane.Code)
+	assert.Equal(t, http.StatusOK, job.Code)
+	assert.Equal(t, http.StatusTooManyRequests, janeAgain.Code)
+}
+
+func Test_RateLimiter_UnmatchedRoute_IsNotLimited(t *testing.T) {
+	//Arrange
+	limiter := newTestRateLimiter(t, "POST /users/ 1/1m")
+	r := httptest.NewRequest(http.MethodGet, "/users/jane@example.com", nil)
+	//Act
+	first := serveLimited(limiter, r)
+	second := serveLimited(limiter, r)
+	//Assert
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Empty(t, first.Header().Get("RateLimit-Limit"))
+}
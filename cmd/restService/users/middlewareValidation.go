@@ -0,0 +1,235 @@
+package users
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// ErrPayloadTooLarge is returned when the body of a request is over the size
+// limit.
+var ErrPayloadTooLarge error = errors.New("payload too large")
+
+// RequestValidator rejects the requests that do not match the OpenAPI
+// document served by the gateway, before the endpoints decode them: their
+// path and query parameters, the media type of their body, and the body
+// itself. The request bodies can not carry members the document does not
+// declare.
+type RequestValidator struct {
+	router       routers.Router
+	maxBodyBytes int64
+}
+
+// NewRequestValidator returns a RequestValidator of the OpenAPI document,
+// the bodies over maxBodyBytes are refused.
+func NewRequestValidator(maxBodyBytes int64) (*RequestValidator, error) {
+
+	doc, err := openapi3.NewLoader().LoadFromData(openAPIDocument)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+
+	// The servers of the document are examples, the routes are matched
+	// whatever the host the gateway is reached at.
+	doc.Servers = nil
+
+	router, err := gorillamux.NewRouter(doc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &RequestValidator{router: router, maxBodyBytes: maxBodyBytes}, nil
+}
+
+// Middleware validates the requests served by next. The routes that are not
+// in the document are left to next, which answers them as it always did.
+func (v *RequestValidator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		route, params, err := v.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		if err := v.limitBody(r); err != nil {
+			encodeError(r.Context(), err, rw)
+			return
+		}
+
+		err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: params,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError: true,
+				// The credentials are checked by AuthenticationMiddleware and
+				// the gRPC service, not by the validation.
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		})
+		if err != nil {
+			encodeError(r.Context(), requestError(err), rw)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// limitBody reads the body of the request, so that it can be validated and
+// decoded afterwards, unless it is over the size limit.
+func (v *RequestValidator) limitBody(r *http.Request) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	if r.ContentLength > v.maxBodyBytes {
+		return ErrPayloadTooLarge
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, v.maxBodyBytes+1))
+	r.Body.Close()
+	if err != nil {
+		return err
+	}
+	if int64(len(body)) > v.maxBodyBytes {
+		return ErrPayloadTooLarge
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return nil
+}
+
+// requestError converts the errors of the validation into the error the
+// request is answered with. Every field that breaks the schema is listed,
+// while a body that can not be parsed, or comes with the wrong media type,
+// and a path that is not valid fail the request on their own, with the codes
+// the decoders of the endpoints used for them.
+func requestError(err error) error {
+
+	var fields []problems.FieldError
+
+	for _, e := range unpackErrors(err) {
+
+		var re *openapi3filter.RequestError
+
+		if !errors.As(e, &re) {
+			return e
+		}
+
+		switch {
+		case re.Parameter != nil && re.Parameter.In == openapi3.ParameterInHeader && re.Parameter.Name == "If-Match" && errors.Is(re.Err, openapi3filter.ErrInvalidRequired):
+			return ErrPreconditionRequired
+		case re.Parameter != nil && re.Parameter.In == openapi3.ParameterInPath:
+			return problems.Error{Code: problems.CodeBadRequest, Detail: re.Error(), Fields: parameterErrors(re)}
+		case re.Parameter != nil:
+			fields = append(fields, parameterErrors(re)...)
+		case strings.HasPrefix(re.Reason, "header Content-Type has unexpected value"):
+			return ErrUnsupportedMediaType
+		case errors.Is(re.Err, openapi3filter.ErrInvalidRequired):
+			return problems.Error{Code: problems.CodeMalformedRequest, Detail: "the request has no body"}
+		default:
+			var parse *openapi3filter.ParseError
+			if errors.As(re.Err, &parse) {
+				return problems.Error{Code: problems.CodeMalformedRequest, Detail: parse.Error()}
+			}
+			fields = append(fields, bodyErrors(re.Err)...)
+		}
+	}
+
+	if len(fields) == 0 {
+		return ErrInvalidInput
+	}
+
+	return problems.Error{Code: problems.CodeValidationFailed, Detail: fields[0].Detail, Fields: fields}
+}
+
+// parameterErrors describes the parameter of the request that is not valid.
+func parameterErrors(re *openapi3filter.RequestError) []problems.FieldError {
+
+	field := problems.FieldError{Field: re.Parameter.Name, Rule: "required", Detail: fmt.Sprintf("%s %s is required", re.Parameter.In, re.Parameter.Name)}
+
+	var schemaErr *openapi3.SchemaError
+
+	switch {
+	case errors.As(re.Err, &schemaErr):
+		field.Rule = schemaErr.SchemaField
+		field.Detail = fmt.Sprintf("%s %s: %s", re.Parameter.In, re.Parameter.Name, schemaErr.Reason)
+	case !errors.Is(re.Err, openapi3filter.ErrInvalidRequired):
+		field.Rule = "type"
+		field.Detail = fmt.Sprintf("%s %s: %s", re.Parameter.In, re.Parameter.Name, re.Reason)
+	}
+
+	return []problems.FieldError{field}
+}
+
+// unsupportedProperty reads the name of a member the schema does not
+// declare, which the validation only reports in its reason.
+var unsupportedProperty = regexp.MustCompile(`^property "(.*)" is unsupported$`)
+
+// bodyErrors describes every member of the body that breaks the schema, by
+// its JSON pointer without the leading slash.
+func bodyErrors(err error) []problems.FieldError {
+
+	var fields []problems.FieldError
+
+	for _, e := range unpackErrors(err) {
+
+		var schemaErr *openapi3.SchemaError
+
+		if !errors.As(e, &schemaErr) {
+			fields = append(fields, problems.FieldError{Detail: e.Error()})
+			continue
+		}
+
+		field := problems.FieldError{
+			Field:  strings.Join(schemaErr.JSONPointer(), "/"),
+			Rule:   schemaErr.SchemaField,
+			Detail: schemaErr.Reason,
+		}
+
+		if m := unsupportedProperty.FindStringSubmatch(schemaErr.Reason); m != nil {
+			field.Field = strings.TrimPrefix(field.Field+"/"+m[1], "/")
+			field.Rule = "additionalProperties"
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// unpackErrors flattens the multiple errors the validation returns.
+func unpackErrors(err error) []error {
+
+	multi, ok := err.(openapi3.MultiError)
+
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+
+	for _, e := range multi {
+		errs = append(errs, unpackErrors(e)...)
+	}
+
+	return errs
+}
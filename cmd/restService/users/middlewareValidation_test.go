@@ -0,0 +1,142 @@
+package users
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveValidated serves r through a RequestValidator, the body the next
+// handler receives is returned along with the response.
+func serveValidated(t *testing.T, maxBodyBytes int64, r *http.Request) (*httptest.ResponseRecorder, string) {
+	validator, err := NewRequestValidator(maxBodyBytes)
+	assert.Nil(t, err)
+	var body string
+	w := httptest.NewRecorder()
+	validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+	})).ServeHTTP(w, r)
+	return w, body
+}
+
+func newJSONRequest(method, path, body string) *http.Request {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) problems.Problem {
+	var problem problems.Problem
+	assert.Equal(t, problems.ContentType, w.Header().Get("Content-Type"))
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&problem))
+	return problem
+}
+
+func Test_RequestValidator_ValidBody_ServedWithBody(t *testing.T) {
+	//Arrange
+	r := newJSONRequest(http.MethodPost, UsersBaseUri, `{"email":"larry.page@gmail.com","name":"Larry","lastname":"Page"}`)
+	//Act
+	w, body := serveValidated(t, 1024, r)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"email":"larry.page@gmail.com","name":"Larry","lastname":"Page"}`, body)
+}
+
+func Test_RequestValidator_InvalidBody_ListsEveryField(t *testing.T) {
+	//Arrange
+	r := newJSONRequest(http.MethodPost, UsersBaseUri, `{"email":"larry.page@gmail.com","name":7,"role":"admin"}`)
+	//Act
+	w, _ := serveValidated(t, 1024, r)
+	//Assert
+	problem := decodeProblem(t, w)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, problems.CodeValidationFailed, problem.Code)
+	assert.ElementsMatch(t, []string{"name/type", "role/additionalProperties", "lastname/required"}, fieldRules(problem.Errors))
+}
+
+func Test_RequestValidator_BodyOverLimit_Returns413(t *testing.T) {
+	//Arrange
+	r := newJSONRequest(http.MethodPost, UsersBaseUri, `{"email":"larry.page@gmail.com","name":"Larry","lastname":"Page"}`)
+	r.ContentLength = -1
+	//Act
+	w, _ := serveValidated(t, 16, r)
+	//Assert
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, problems.CodePayloadTooLarge, decodeProblem(t, w).Code)
+}
+
+func Test_RequestValidator_MalformedBody_Returns400(t *testing.T) {
+	//Arrange
+	r := newJSONRequest(http.MethodPost, UsersBaseUri, `{"email":`)
+	//Act
+	w, _ := serveValidated(t, 1024, r)
+	//Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, problems.CodeMalformedRequest, decodeProblem(t, w).Code)
+}
+
+func Test_RequestValidator_WrongMediaType_Returns415(t *testing.T) {
+	//Arrange
+	r := httptest.NewRequest(http.MethodPatch, "/users/larry.page@gmail.com", strings.NewReader(`{"name":"Larry"}`))
+	r.Header.Set("Content-Type", "text/plain")
+	r.Header.Set("If-Match", `"1"`)
+	//Act
+	w, _ := serveValidated(t, 1024, r)
+	//Assert
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func Test_RequestValidator_MissingIfMatch_Returns428(t *testing.T) {
+	//Arrange
+	r := newJSONRequest(http.MethodPut, "/users/larry.page@gmail.com", `{"name":"Larry","lastname":"Page"}`)
+	//Act
+	w, _ := serveValidated(t, 1024, r)
+	//Assert
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+}
+
+func Test_RequestValidator_InvalidPathParameter_Returns400(t *testing.T) {
+	//Arrange
+	r := httptest.NewRequest(http.MethodPost, "/users/abc/restore", nil)
+	//Act
+	w, _ := serveValidated(t, 1024, r)
+	//Assert
+	problem := decodeProblem(t, w)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "id", problem.Errors[0].Field)
+}
+
+func Test_RequestValidator_InvalidQueryParameter_Returns422(t *testing.T) {
+	//Arrange
+	r := httptest.NewRequest(http.MethodGet, "/users/?status=banned", nil)
+	//Act
+	w, _ := serveValidated(t, 1024, r)
+	//Assert
+	problem := decodeProblem(t, w)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, []string{"status/enum"}, fieldRules(problem.Errors))
+}
+
+func Test_RequestValidator_RouteNotDocumented_Served(t *testing.T) {
+	//Arrange
+	r := httptest.NewRequest(http.MethodGet, OpenAPIDocument, nil)
+	//Act
+	w, _ := serveValidated(t, 1024, r)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func fieldRules(fields []problems.FieldError) []string {
+	var rules []string
+	for _, f := range fields {
+		rules = append(rules, f.Field+"/"+f.Rule)
+	}
+	return rules
+}
@@ -1,45 +1,129 @@
 package users
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strings"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
+// APIKeyHeader carries the API key of the clients that can not log in
+// interactively, it is accepted instead of a bearer token.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeys checks the keys sent in the APIKeyHeader.
+var APIKeys APIKeyAuthenticator = AuthProxy{}
+
+// AuthenticationMiddleware adds the claims of the bearer token or of the API
+// key of the request to its context. A request with an API key that is not
+// valid, expired or revoked is answered as unauthorized rather than served
+// anonymously.
 func AuthenticationMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("Authorization")
+		var (
+			claims jwt.MapClaims
+			valid  bool
+		)
+
+		if key := r.Header.Get(APIKeyHeader); key != "" {
+			var err error
+			if claims, err = validateAPIKey(r.Context(), key); err != nil {
+				encodeError(r.Context(), err, rw)
+				return
+			}
+			valid = true
+		} else {
+			token := r.Header.Get("Authorization")
 
-		token = strings.Replace(token, "Bearer ", "", -1)
+			token = strings.Replace(token, "Bearer ", "", -1)
 
-		if !validateToken(token) {
-			fmt.Println("InvalidToken")
-		} else {
-			fmt.Println("Valid User!!")
+			claims, valid = validateToken(token)
+		}
+
+		if valid {
+			r = r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, claims))
 		}
 
 		next.ServeHTTP(rw, r)
 	})
 }
 
+type claimsContextKey struct{}
+
+// claimsFromContext returns the claims of the validated bearer token, if the
+// request carried one.
+func claimsFromContext(ctx context.Context) (jwt.MapClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(jwt.MapClaims)
+	return claims, ok
+}
+
+// rolesFromClaims reads the roles granted to the token subject, they are
+// expected as a string array in the "roles" claim.
+func rolesFromClaims(claims jwt.MapClaims) []string {
+	return stringsFromClaim(claims, "roles")
+}
+
+// methodsFromClaims reads how the token subject authenticated, they are
+// expected as a string array in the "amr" claim.
+func methodsFromClaims(claims jwt.MapClaims) []string {
+	return stringsFromClaim(claims, "amr")
+}
+
+func stringsFromClaim(claims jwt.MapClaims, name string) []string {
+	result := []string{}
+	values, _ := claims[name].([]interface{})
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// apiKeyMethod is the amr of the callers authenticated with an API key.
+const apiKeyMethod = "apikey"
+
+// validateAPIKey checks an API key with the gRPC service and returns the
+// claims a bearer token of the key would carry, its scopes are the roles.
+func validateAPIKey(ctx context.Context, key string) (jwt.MapClaims, error) {
+	apiKey, err := APIKeys.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := []interface{}{}
+	for _, scope := range apiKey.Scopes {
+		roles = append(roles, scope)
+	}
+
+	return jwt.MapClaims{
+		"sub":   fmt.Sprintf("%s:%s", apiKeyMethod, apiKey.Id),
+		"roles": roles,
+		"amr":   []interface{}{apiKeyMethod},
+	}, nil
+}
+
 var hmacSampleSecret []byte
 
-func validateToken(stringToken string) bool {
+// PublicKeyFile is the PEM file of the RSA public key the bearer tokens are
+// verified with, the gRPC service signs them with the matching private key.
+var PublicKeyFile = "/home/adrian.castan/cert/id_rsa.pub"
+
+func validateToken(stringToken string) (jwt.MapClaims, bool) {
 
-	pubKey, err := ioutil.ReadFile("/home/adrian.castan/cert/id_rsa.pub") //env var !!!
+	pubKey, err := ioutil.ReadFile(PublicKeyFile)
 
 	if err != nil {
-		log.Fatalln(err)
+		return nil, false
 	}
 
 	key, err := jwt.ParseRSAPublicKeyFromPEM(pubKey)
 
 	if err != nil {
-		return false
+		return nil, false
 	}
 
 	tok, err := jwt.Parse(stringToken, func(jwtToken *jwt.Token) (interface{}, error) {
@@ -50,14 +134,14 @@ func validateToken(stringToken string) bool {
 		return key, nil
 	})
 	if err != nil {
-		return false
+		return nil, false
 	}
 
-	_, ok := tok.Claims.(jwt.MapClaims)
+	claims, ok := tok.Claims.(jwt.MapClaims)
 	if !ok || !tok.Valid {
-		return false
+		return nil, false
 	}
 
-	return true
+	return claims, true
 
 }
@@ -0,0 +1,36 @@
+package users
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// openAPIDocument is the OpenAPI 3 description of every route mounted by
+// MakeHTTPHandler, MakeAuthHTTPHandler and MakeWebhooksHTTPHandler. A test
+// fails when the routes and the document drift apart.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// docsPage renders openAPIDocument for the people reading the API.
+//
+//go:embed docs.html
+var docsPage []byte
+
+// MakeOpenAPIHandler serves the OpenAPI document and its docs page.
+func MakeOpenAPIHandler() http.Handler {
+	r := mux.NewRouter()
+
+	r.Methods(http.MethodGet).Path(OpenAPIDocument).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(openAPIDocument)
+	})
+	r.Methods(http.MethodGet).Path(APIDocs).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(docsPage)
+	})
+
+	return r
+}
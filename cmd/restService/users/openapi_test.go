@@ -0,0 +1,122 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type openAPISpec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]struct {
+				Enum []string `json:"enum"`
+			} `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+var pathParameter = regexp.MustCompile(`\{[^}]+\}`)
+
+// operationKey names an operation by its method and its path, the names of
+// the path parameters are left out since the spec can not declare two paths
+// that only differ in them.
+func operationKey(method, path string) string {
+	return strings.ToUpper(method) + " " + pathParameter.ReplaceAllString(path, "{}")
+}
+
+func registeredOperations(t *testing.T) []string {
+	var operations []string
+	for _, h := range []http.Handler{
+		MakeHTTPHandler(UserProxy{}, log.NewNopLogger()),
+		MakeAuthHTTPHandler(AuthProxy{}, log.NewNopLogger()),
+		MakeWebhooksHTTPHandler(WebhookProxy{}, log.NewNopLogger()),
+	} {
+		err := h.(*mux.Router).Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+			path, err := route.GetPathTemplate()
+			if err != nil {
+				return err
+			}
+			methods, err := route.GetMethods()
+			if err != nil {
+				return err
+			}
+			for _, m := range methods {
+				operations = append(operations, operationKey(m, path))
+			}
+			return nil
+		})
+		assert.Nil(t, err)
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+func documentedOperations(t *testing.T) []string {
+	var spec openAPISpec
+	assert.Nil(t, json.Unmarshal(openAPIDocument, &spec))
+	var operations []string
+	for path, item := range spec.Paths {
+		for method := range item {
+			if method != "parameters" {
+				operations = append(operations, operationKey(method, path))
+			}
+		}
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+func Test_OpenAPIDocument_MatchesRegisteredRoutes(t *testing.T) {
+	//Act
+	registered := registeredOperations(t)
+	documented := documentedOperations(t)
+	//Assert
+	assert.NotEmpty(t, registered)
+	assert.Equal(t, registered, documented)
+}
+
+func Test_OpenAPIDocument_ListsEveryErrorCode(t *testing.T) {
+	//Arrange
+	var spec openAPISpec
+	assert.Nil(t, json.Unmarshal(openAPIDocument, &spec))
+	var codes []string
+	for _, d := range problems.Catalogue {
+		codes = append(codes, string(d.Code))
+	}
+	//Assert
+	assert.Equal(t, codes, spec.Components.Schemas["Problem"].Properties["code"].Enum)
+}
+
+func Test_OpenAPIHandler_ServesDocument(t *testing.T) {
+	//Arrange
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, OpenAPIDocument, nil)
+	//Act
+	MakeOpenAPIHandler().ServeHTTP(w, r)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, openAPIDocument, w.Body.Bytes())
+}
+
+func Test_OpenAPIHandler_ServesDocsPage(t *testing.T) {
+	//Arrange
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, APIDocs, nil)
+	//Act
+	MakeOpenAPIHandler().ServeHTTP(w, r)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), OpenAPIDocument)
+}
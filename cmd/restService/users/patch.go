@@ -0,0 +1,164 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	// MergePatchContentType is the media type of an RFC 7396 JSON Merge Patch.
+	MergePatchContentType = "application/merge-patch+json"
+	// JSONPatchContentType is the media type of an RFC 6902 JSON Patch.
+	JSONPatchContentType = "application/json-patch+json"
+)
+
+var (
+	// ErrUnsupportedMediaType is returned when a PATCH is sent with a media
+	// type other than JSON Merge Patch or JSON Patch.
+	ErrUnsupportedMediaType error = errors.New("unsupported media type")
+	// ErrPatchTestFailed is returned when a test operation of a JSON Patch
+	// does not hold, nothing is changed.
+	ErrPatchTestFailed error = errors.New("patch test failed")
+)
+
+// patchablePaths maps the members of the user document a patch can change
+// to the field mask paths of the gRPC service.
+var patchablePaths = map[string]string{
+	"name":     "name",
+	"lastname": "last_name",
+}
+
+// UserPatch is a partial update of a user, only the fields named in Paths are
+// changed.
+type UserPatch struct {
+	Email    string
+	Version  int
+	Paths    []string
+	Name     string
+	LastName string
+}
+
+// patchOperation is a single operation of a JSON Patch, a JSON Merge Patch is
+// translated into replace operations.
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// decodePatchDocument reads the operations of a JSON Merge Patch or a JSON
+// Patch, depending on the Content-Type of the request.
+func decodePatchDocument(r *http.Request) ([]patchOperation, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	switch mediaType {
+	case MergePatchContentType:
+		return decodeMergePatch(r)
+	case JSONPatchContentType:
+		return decodeJSONPatch(r)
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+}
+
+func decodeMergePatch(r *http.Request) ([]patchOperation, error) {
+	var members map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&members); err != nil || len(members) == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ops := []patchOperation{}
+	for _, name := range names {
+		// A null removes the member, the patchable fields are all required.
+		if string(members[name]) == "null" {
+			return nil, ErrInvalidInput
+		}
+		ops = append(ops, patchOperation{Op: "replace", Path: "/" + name, Value: members[name]})
+	}
+	return ops, nil
+}
+
+func decodeJSONPatch(r *http.Request) ([]patchOperation, error) {
+	var ops []patchOperation
+	if err := json.NewDecoder(r.Body).Decode(&ops); err != nil || len(ops) == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	for _, op := range ops {
+		switch op.Op {
+		case "add", "replace", "test":
+		default:
+			// remove, move and copy would leave a required field empty or
+			// write a read only one.
+			return nil, ErrInvalidInput
+		}
+	}
+	return ops, nil
+}
+
+// hasTests reports whether the operations check the current state of the
+// user.
+func hasTests(ops []patchOperation) bool {
+	for _, op := range ops {
+		if op.Op == "test" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyPatch applies the operations in order to current and returns the
+// resulting partial update. The test operations are checked against the
+// state left by the previous operations.
+func applyPatch(current User, ops []patchOperation) (UserPatch, error) {
+	values := map[string]string{"name": current.Name, "lastname": current.LastName}
+	patch := UserPatch{Email: current.Email, Version: current.Version, Paths: []string{}}
+	touched := map[string]bool{}
+
+	for _, op := range ops {
+		member := strings.TrimPrefix(op.Path, "/")
+		path, ok := patchablePaths[member]
+		if !ok || !strings.HasPrefix(op.Path, "/") {
+			return UserPatch{}, ErrInvalidInput
+		}
+
+		var value string
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return UserPatch{}, ErrInvalidInput
+		}
+
+		if op.Op == "test" {
+			if values[member] != value {
+				return UserPatch{}, ErrPatchTestFailed
+			}
+			continue
+		}
+
+		values[member] = value
+		if !touched[path] {
+			touched[path] = true
+			patch.Paths = append(patch.Paths, path)
+		}
+	}
+
+	if len(patch.Paths) == 0 {
+		return UserPatch{}, ErrInvalidInput
+	}
+
+	patch.Name = values["name"]
+	patch.LastName = values["lastname"]
+	return patch, nil
+}
@@ -0,0 +1,116 @@
+package users
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPatchRequest(contentType string, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPatch, "/users/larry.page@gmail.com", strings.NewReader(body))
+	r = mux.SetURLVars(r, map[string]string{Email: "larry.page@gmail.com"})
+	r.Header.Set("If-Match", `"2"`)
+	r.Header.Set("Content-Type", contentType)
+	return r
+}
+
+var current = User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page", Version: 2}
+
+func TestCases_PatchProfile_Documents(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		patch       UserPatch
+		err         error
+	}{
+		{
+			name:        "merge patch changes one field",
+			contentType: MergePatchContentType,
+			body:        `{"name":"Lawrence"}`,
+			patch:       UserPatch{Email: current.Email, Version: 2, Paths: []string{"name"}, Name: "Lawrence", LastName: "Page"},
+		},
+		{
+			name:        "merge patch changes both fields",
+			contentType: MergePatchContentType + "; charset=utf-8",
+			body:        `{"lastname":"Brin","name":"Sergey"}`,
+			patch:       UserPatch{Email: current.Email, Version: 2, Paths: []string{"last_name", "name"}, Name: "Sergey", LastName: "Brin"},
+		},
+		{
+			name:        "merge patch removing a field is not valid",
+			contentType: MergePatchContentType,
+			body:        `{"lastname":null}`,
+			err:         ErrInvalidInput,
+		},
+		{
+			name:        "merge patch of a read only field is not valid",
+			contentType: MergePatchContentType,
+			body:        `{"email":"sergey.brin@gmail.com"}`,
+			err:         ErrInvalidInput,
+		},
+		{
+			name:        "json patch applies the operations in order",
+			contentType: JSONPatchContentType,
+			body:        `[{"op":"replace","path":"/name","value":"Lawrence"},{"op":"test","path":"/name","value":"Lawrence"},{"op":"add","path":"/name","value":"Larry E."}]`,
+			patch:       UserPatch{Email: current.Email, Version: 2, Paths: []string{"name"}, Name: "Larry E.", LastName: "Page"},
+		},
+		{
+			name:        "json patch failed test changes nothing",
+			contentType: JSONPatchContentType,
+			body:        `[{"op":"test","path":"/lastname","value":"Brin"},{"op":"replace","path":"/name","value":"Sergey"}]`,
+			err:         ErrPatchTestFailed,
+		},
+		{
+			name:        "json patch remove is not valid",
+			contentType: JSONPatchContentType,
+			body:        `[{"op":"remove","path":"/name"}]`,
+			err:         ErrInvalidInput,
+		},
+		{
+			name:        "json patch with only tests is not valid",
+			contentType: JSONPatchContentType,
+			body:        `[{"op":"test","path":"/name","value":"Larry"}]`,
+			err:         ErrInvalidInput,
+		},
+		{
+			name:        "plain json is not a patch",
+			contentType: "application/json",
+			body:        `{"name":"Lawrence"}`,
+			err:         ErrUnsupportedMediaType,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := decodePatchProfileRequest(context.Background(), newPatchRequest(tc.contentType, tc.body))
+			if err == nil {
+				var patch UserPatch
+				patch, err = applyPatch(current, request.(patchUserRequest).Operations)
+				assert.Equal(t, tc.patch, patch)
+			}
+			assert.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func Test_PatchUserEndpoint_TestOperation_StaleVersion_PreconditionFailed(t *testing.T) {
+	//Arrange
+	proxy := grpcProxyMock{}
+	proxy.On("GetByEmail", context.Background(), current.Email).Return(User{Email: current.Email, Name: "Larry", LastName: "Page", Version: 3}, nil)
+	endpoint := MakePatchUserEndpoint(&proxy)
+	request := patchUserRequest{Email: current.Email, Version: 2, Operations: []patchOperation{
+		{Op: "test", Path: "/name", Value: []byte(`"Larry"`)},
+		{Op: "replace", Path: "/name", Value: []byte(`"Lawrence"`)},
+	}}
+	//Act
+	response, err := endpoint(context.Background(), request)
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, WrapError(ErrPreconditionFailed), response)
+	proxy.AssertNumberOfCalls(t, "Patch", 0)
+}
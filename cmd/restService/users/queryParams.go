@@ -1,6 +1,15 @@
 package users
 
 const (
-	Email  = "email"
-	UserID = "id"
+	Email          = "email"
+	UserID         = "id"
+	IncludeDeleted = "include_deleted"
+	From           = "from"
+	To             = "to"
+	Actor          = "actor"
+	LastEventID    = "last_event_id"
+	SubscriptionID = "subscription_id"
+	APIKeyID       = "key_id"
+	DeliveryStatus = "status"
+	AccountStatus  = "status"
 )
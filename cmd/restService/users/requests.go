@@ -8,8 +8,15 @@ type putUserRequest struct {
 	User User
 }
 
+type patchUserRequest struct {
+	Email      string
+	Version    int
+	Operations []patchOperation
+}
+
 type deleteUserRequest struct {
-	UserID int
+	UserID  int
+	Version int
 }
 
 type getUserRequest struct {
@@ -17,4 +24,35 @@ type getUserRequest struct {
 }
 
 type getAllUsersRequest struct {
+	Filters Filters
+}
+
+type restoreUserRequest struct {
+	UserID int
+}
+
+type purgeUserRequest struct {
+	UserID int
+}
+
+type emailChangeRequest struct {
+	Email    string
+	NewEmail string `json:"new_email"`
+}
+
+type confirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type changeStatusRequest struct {
+	UserID int
+}
+
+type userHistoryRequest struct {
+	UserID  int
+	Filters HistoryFilters
 }
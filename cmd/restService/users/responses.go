@@ -1,11 +1,24 @@
 package users
 
+import "net/http"
+
 type postUserResponse struct {
 	Err  error  `json:"err,omitempty"`
 	Href string `json:"href,omitempty"`
 }
 
 type putUserResponse struct {
+	Err     error `json:"err,omitempty"`
+	Version int   `json:"-"`
+}
+
+// Headers exposes the version of the replaced user as its ETag, clients send
+// it back in If-Match on their next write.
+func (r putUserResponse) Headers() http.Header {
+	return http.Header{"ETag": []string{formatETag(r.Version)}}
+}
+
+type patchUserResponse struct {
 	Err error `json:"err,omitempty"`
 }
 
@@ -13,12 +26,74 @@ type deleteUserResponse struct {
 	Err error `json:"err,omitempty"`
 }
 
+type restoreUserResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+type purgeUserResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
 type getUserResponse struct {
 	Err  error `json:"err,omitempty"`
 	User User  `json:"user,omitempty"`
 }
 
+// Headers exposes the user version as the ETag of the resource, clients send
+// it back in If-Match when they update or delete the user.
+func (r getUserResponse) Headers() http.Header {
+	return http.Header{"ETag": []string{formatETag(r.User.Version)}}
+}
+
 type getAllUsersResponse struct {
 	Err   error  `json:"err,omitempty"`
 	Users []User `json:"users,omitempty"`
 }
+
+type userHistoryResponse struct {
+	Err     error        `json:"err,omitempty"`
+	Entries []AuditEntry `json:"entries"`
+}
+
+type emailChangeResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+// StatusCode reports the change as accepted, the email only changes once the
+// token sent to the new address is confirmed.
+func (r emailChangeResponse) StatusCode() int {
+	return http.StatusAccepted
+}
+
+type confirmEmailChangeResponse struct {
+	Err  error `json:"err,omitempty"`
+	User User  `json:"user,omitempty"`
+}
+
+// Headers exposes the version of the changed user as its ETag.
+func (r confirmEmailChangeResponse) Headers() http.Header {
+	return http.Header{"ETag": []string{formatETag(r.User.Version)}}
+}
+
+type sendVerificationResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+// StatusCode reports the token as accepted for delivery.
+func (r sendVerificationResponse) StatusCode() int {
+	return http.StatusAccepted
+}
+
+type verifyEmailResponse struct {
+	Err  error `json:"err,omitempty"`
+	User User  `json:"user,omitempty"`
+}
+
+// Headers exposes the version of the verified user as its ETag.
+func (r verifyEmailResponse) Headers() http.Header {
+	return http.Header{"ETag": []string{formatETag(r.User.Version)}}
+}
+
+type changeStatusResponse struct {
+	Err error `json:"err,omitempty"`
+}
@@ -0,0 +1,38 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	usersv2 "github.com/casmelad/GlobantPOC/api/users/v2"
+	"github.com/casmelad/GlobantPOC/pkg/transcoding"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// transcodedFiles are the proto files whose RPCs annotated with
+// google.api.http are served by the transcoding handler. An annotated RPC of
+// these files is served over REST without any code in the gateway.
+var transcodedFiles = []protoreflect.FileDescriptor{
+	usersv2.File_users_v2_users_proto,
+}
+
+// MakeTranscodingHandler serves the annotated RPCs of the gRPC service over
+// HTTP/JSON, alongside the hand-written handlers. The calls carry the same
+// metadata as the ones of the proxies, and fail with the same problem
+// documents.
+func MakeTranscodingHandler(maxBodyBytes int64) (*transcoding.Handler, error) {
+
+	conn, err := dialServer()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return transcoding.NewHandler(conn, transcodedFiles,
+		transcoding.WithOutgoingContext(func(ctx context.Context) context.Context {
+			return outgoingMetadata(ctx)
+		}),
+		transcoding.WithErrorEncoder(encodeError),
+		transcoding.WithTimeout(10*time.Second),
+		transcoding.WithMaxBodyBytes(maxBodyBytes))
+}
@@ -0,0 +1,34 @@
+package users
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MakeTranscodingHandler_ServesAnnotatedRPCs(t *testing.T) {
+	//Act
+	handler, err := MakeTranscodingHandler(1024)
+	//Assert
+	assert.Nil(t, err)
+	assert.Contains(t, handler.Routes(), "GET /v2/users/{email} /users.v2.Users/GetUser")
+	assert.Contains(t, handler.Routes(), "PATCH /v2/users/{user.email} /users.v2.Users/UpdateUser")
+}
+
+func Test_MakeTranscodingHandler_UnknownRoute_ProblemWithRequestID(t *testing.T) {
+	//Arrange
+	handler, _ := MakeTranscodingHandler(1024)
+	r := httptest.NewRequest(http.MethodGet, "/v3/users", nil)
+	r.Header.Set("X-Request-ID", "client-42")
+	w := httptest.NewRecorder()
+	//Act
+	UUIDContextMiddleware(handler).ServeHTTP(w, r)
+	//Assert
+	problem := decodeProblem(t, w)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, problems.CodeNotFound, problem.Code)
+	assert.Equal(t, "client-42", problem.Instance)
+}
@@ -6,11 +6,16 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/casmelad/GlobantPOC/pkg/logging"
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	domain "github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
-	"github.com/go-kit/kit/transport"
 	httptransport "github.com/go-kit/kit/transport/http"
 )
 
@@ -22,12 +27,13 @@ var (
 
 // MakeHTTPHandler mounts all of the service endpoints into an http.Handler.
 // Useful in a profilesvc server.
-func MakeHTTPHandler(s UserProxy, logger log.Logger) http.Handler {
+func MakeHTTPHandler(s UserProxy, logger log.Logger, middleware ...endpoint.Middleware) http.Handler {
 	r := mux.NewRouter()
-	e := MakeServerEndpoints(s)
+	e := MakeServerEndpoints(s, middleware...)
 	options := []httptransport.ServerOption{
-		httptransport.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		httptransport.ServerErrorHandler(logging.NewErrorHandler(logger)),
 		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(routeToContext),
 	}
 
 	r.Methods(http.MethodPost).Path(PostUser).Handler(httptransport.NewServer(
@@ -36,6 +42,8 @@ func MakeHTTPHandler(s UserProxy, logger log.Logger) http.Handler {
 		encodeResponse,
 		options...,
 	))
+	// Registered before GetUser, which would match the path too.
+	r.Methods(http.MethodGet).Path(UserEvents).Handler(MakeEventsHandler(s, EventsHeartbeat, logger))
 	r.Methods(http.MethodGet).Path(GetUser).Handler(httptransport.NewServer(
 		e.GetUserEndpoint,
 		decodeGetUserRequest,
@@ -54,12 +62,81 @@ func MakeHTTPHandler(s UserProxy, logger log.Logger) http.Handler {
 		encodeResponse,
 		options...,
 	))
+	r.Methods(http.MethodPatch).Path(PatchUser).Handler(httptransport.NewServer(
+		e.PatchUserEndpoint,
+		decodePatchProfileRequest,
+		encodeResponse,
+		options...,
+	))
 	r.Methods(http.MethodDelete).Path(DeleteUser).Handler(httptransport.NewServer(
 		e.DeleteUserEndpoint,
 		decodeDeleteProfileRequest,
 		encodeResponse,
 		options...,
 	))
+	r.Methods(http.MethodPost).Path(RestoreUser).Handler(httptransport.NewServer(
+		e.RestoreUserEndpoint,
+		decodeRestoreProfileRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodPost).Path(PurgeUser).Handler(httptransport.NewServer(
+		e.PurgeUserEndpoint,
+		decodePurgeProfileRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods(http.MethodGet).Path(UserHistory).Handler(httptransport.NewServer(
+		e.UserHistoryEndpoint,
+		decodeUserHistoryRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods(http.MethodPost).Path(ConfirmEmail).Handler(httptransport.NewServer(
+		e.ConfirmEmailEndpoint,
+		decodeConfirmEmailRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodPost).Path(EmailChange).Handler(httptransport.NewServer(
+		e.EmailChangeEndpoint,
+		decodeEmailChangeRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods(http.MethodPost).Path(VerifyEmail).Handler(httptransport.NewServer(
+		e.VerifyEmailEndpoint,
+		decodeVerifyEmailRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodPost).Path(Verification).Handler(httptransport.NewServer(
+		e.SendVerificationEndpoint,
+		decodeGetUserRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodPost).Path(SuspendUser).Handler(httptransport.NewServer(
+		e.SuspendEndpoint,
+		decodeChangeStatusRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodPost).Path(Reactivate).Handler(httptransport.NewServer(
+		e.ReactivateEndpoint,
+		decodeChangeStatusRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodPost).Path(Deactivate).Handler(httptransport.NewServer(
+		e.DeactivateEndpoint,
+		decodeChangeStatusRequest,
+		encodeResponse,
+		options...,
+	))
 
 	return r
 }
@@ -76,7 +153,21 @@ func decodeGetUserRequest(_ context.Context, r *http.Request) (request interface
 
 func decodeGetAllUsersRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
 
-	return getAllUsersRequest{}, nil
+	var filters Filters
+	if value := r.URL.Query().Get(IncludeDeleted); value != "" {
+		includeDeleted, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, ErrInvalidInput
+		}
+		filters.IncludeDeleted = includeDeleted
+	}
+	switch status := r.URL.Query().Get(AccountStatus); status {
+	case "", StatusPending, StatusActive, StatusSuspended, StatusDeactivated:
+		filters.Status = status
+	default:
+		return nil, ErrInvalidInput
+	}
+	return getAllUsersRequest{Filters: filters}, nil
 }
 
 func decodePostProfileRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
@@ -93,23 +184,156 @@ func decodePutProfileRequest(_ context.Context, r *http.Request) (request interf
 	if !ok {
 		return nil, ErrBadRouting
 	}
+	version, err := decodeIfMatch(r)
+	if err != nil {
+		return nil, err
+	}
 	var usr User
 	if err := json.NewDecoder(r.Body).Decode(&usr); err != nil {
 		return nil, err
 	}
 	usr.Email = email
+	usr.Version = version
 	return putUserRequest{
 		User: usr,
 	}, nil
 }
 
+func decodePatchProfileRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	email, ok := vars["email"]
+	if !ok {
+		return nil, ErrBadRouting
+	}
+	version, err := decodeIfMatch(r)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := decodePatchDocument(r)
+	if err != nil {
+		return nil, err
+	}
+	return patchUserRequest{Email: email, Version: version, Operations: ops}, nil
+}
+
 func decodeDeleteProfileRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
 	vars := mux.Vars(r)
 	id, ok := strconv.Atoi(vars["id"])
 	if ok != nil {
 		return nil, ErrBadRouting
 	}
-	return deleteUserRequest{UserID: id}, nil
+	version, err := decodeIfMatch(r)
+	if err != nil {
+		return nil, err
+	}
+	return deleteUserRequest{UserID: id, Version: version}, nil
+}
+
+func decodeRestoreProfileRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	id, ok := strconv.Atoi(vars["id"])
+	if ok != nil {
+		return nil, ErrBadRouting
+	}
+	return restoreUserRequest{UserID: id}, nil
+}
+
+func decodePurgeProfileRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	id, ok := strconv.Atoi(vars["id"])
+	if ok != nil {
+		return nil, ErrBadRouting
+	}
+	return purgeUserRequest{UserID: id}, nil
+}
+
+func decodeUserHistoryRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	id, ok := strconv.Atoi(vars["id"])
+	if ok != nil {
+		return nil, ErrBadRouting
+	}
+	query := r.URL.Query()
+	filters := HistoryFilters{Actor: query.Get(Actor)}
+	if filters.From, err = decodeTimeParam(query.Get(From)); err != nil {
+		return nil, err
+	}
+	if filters.To, err = decodeTimeParam(query.Get(To)); err != nil {
+		return nil, err
+	}
+	return userHistoryRequest{UserID: id, Filters: filters}, nil
+}
+
+func decodeEmailChangeRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	email, ok := vars["email"]
+	if !ok {
+		return nil, ErrBadRouting
+	}
+	var req emailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, ErrInvalidInput
+	}
+	req.Email = email
+	return req, nil
+}
+
+func decodeConfirmEmailRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req confirmEmailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		return nil, ErrInvalidInput
+	}
+	return req, nil
+}
+
+func decodeVerifyEmailRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req verifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		return nil, ErrInvalidInput
+	}
+	return req, nil
+}
+
+func decodeChangeStatusRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	id, ok := strconv.Atoi(vars["id"])
+	if ok != nil {
+		return nil, ErrBadRouting
+	}
+	return changeStatusRequest{UserID: id}, nil
+}
+
+// decodeTimeParam parses an optional RFC 3339 query parameter, a missing
+// parameter is the zero time.
+func decodeTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidInput
+	}
+	return t, nil
+}
+
+// decodeIfMatch reads the user version the client expects to modify from the
+// If-Match header. Writes without the header are refused so that two clients
+// cannot silently overwrite each other.
+func decodeIfMatch(r *http.Request) (int, error) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return 0, ErrPreconditionRequired
+	}
+	version, err := strconv.Atoi(strings.Trim(ifMatch, `"`))
+	if err != nil || version < 1 {
+		return 0, ErrPreconditionFailed
+	}
+	return version, nil
+}
+
+// formatETag renders a user version as a strong entity tag.
+func formatETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
 }
 
 // errorer is implemented by all concrete response types that may contain
@@ -130,32 +354,37 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 		encodeError(ctx, e.error(), w)
 		return nil
 	}
+	if h, ok := response.(httptransport.Headerer); ok {
+		for k, values := range h.Headers() {
+			for _, v := range values {
+				w.Header().Add(k, v)
+			}
+		}
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if sc, ok := response.(httptransport.StatusCoder); ok {
+		w.WriteHeader(sc.StatusCode())
+	}
 	return json.NewEncoder(w).Encode(response)
 }
 
-func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+// encodeError writes err as an RFC 7807 problem document, the id of the
+// request is its instance.
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
 	if err == nil {
 		panic("encodeError with nil error")
 	}
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(codeFrom(err))
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"error": err.Error(),
-	})
+	problem := problems.New(errorCode(err), err.Error(), domain.RequestIDFromContext(ctx))
+	var coded problems.Error
+	if errors.As(err, &coded) {
+		problem.Errors = coded.Fields
+	}
+	w.Header().Set("Content-Type", problems.ContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
 }
 
+// codeFrom returns the HTTP status code err is answered with.
 func codeFrom(err error) int {
-	switch err {
-	case ErrNotFound:
-		return http.StatusNotFound
-	case ErrUserAlreadyExists:
-		return http.StatusConflict
-	case ErrInvalidInput:
-		return http.StatusUnprocessableEntity
-	case ErrBadRouting:
-		return http.StatusBadRequest
-	default:
-		return http.StatusInternalServerError
-	}
+	return problems.Lookup(errorCode(err)).Status
 }
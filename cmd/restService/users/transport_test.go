@@ -0,0 +1,231 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCases_DecodePutProfileRequest_IfMatch(t *testing.T) {
+
+	for _, useCase := range ifMatchTestCases {
+		r := httptest.NewRequest(http.MethodPut, "/users/larry.page@gmail.com", strings.NewReader(`{"name":"Larry","lastname":"Page"}`))
+		r = mux.SetURLVars(r, map[string]string{Email: "larry.page@gmail.com"})
+		if useCase.ifMatch != "" {
+			r.Header.Set("If-Match", useCase.ifMatch)
+		}
+
+		result, err := decodePutProfileRequest(context.Background(), r)
+
+		assert.Equal(t, useCase.err, err, useCase.name)
+		if req, is := result.(putUserRequest); is {
+			assert.Equal(t, useCase.version, req.User.Version, useCase.name)
+		}
+	}
+}
+
+var ifMatchTestCases []struct {
+	name    string
+	ifMatch string
+	version int
+	err     error
+} = []struct {
+	name    string
+	ifMatch string
+	version int
+	err     error
+}{
+	{"QuotedVersion_Ok", `"3"`, 3, nil},
+	{"MissingHeader_PreconditionRequired", "", 0, ErrPreconditionRequired},
+	{"InvalidVersion_PreconditionFailed", `"abc"`, 0, ErrPreconditionFailed},
+}
+
+func Test_EncodeResponse_GetUser_SetsETag(t *testing.T) {
+	//Arrange
+	w := httptest.NewRecorder()
+	//Act
+	err := encodeResponse(context.Background(), w, getUserResponse{User: User{Id: 1, Version: 4}})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+}
+
+func Test_PutUser_Updated_SetsNewETag(t *testing.T) {
+	//Arrange
+	w := httptest.NewRecorder()
+	ctx := context.Background()
+	usr := User{Email: "test@gmail.com", Name: "John", LastName: "Connor", Version: 4}
+	proxyMock := grpcProxyMock{}
+	proxyMock.On("Update", ctx, usr).Return(User{Email: usr.Email, Name: usr.Name, LastName: usr.LastName, Version: 5}, nil)
+	response, _ := MakeServerEndpoints(proxyMock).PutUserEndpoint(ctx, putUserRequest{User: usr})
+	//Act
+	err := encodeResponse(ctx, w, response)
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+}
+
+func Test_EncodeError_PreconditionFailed_Returns412(t *testing.T) {
+	//Arrange
+	w := httptest.NewRecorder()
+	//Act
+	encodeError(context.Background(), ErrPreconditionFailed, w)
+	//Assert
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
+
+func TestCases_DecodeUserHistoryRequest(t *testing.T) {
+
+	for _, useCase := range userHistoryTestCases {
+		r := httptest.NewRequest(http.MethodGet, "/users/7/history?"+useCase.query, nil)
+		r = mux.SetURLVars(r, map[string]string{UserID: "7"})
+
+		result, err := decodeUserHistoryRequest(context.Background(), r)
+
+		assert.Equal(t, useCase.err, err, useCase.name)
+		if req, is := result.(userHistoryRequest); is {
+			assert.Equal(t, 7, req.UserID, useCase.name)
+			assert.Equal(t, useCase.filters, req.Filters, useCase.name)
+		}
+	}
+}
+
+var userHistoryTestCases []struct {
+	name    string
+	query   string
+	filters HistoryFilters
+	err     error
+} = []struct {
+	name    string
+	query   string
+	filters HistoryFilters
+	err     error
+}{
+	{"NoFilters_Ok", "", HistoryFilters{}, nil},
+	{"AllFilters_Ok", "from=2021-01-01T00:00:00Z&to=2021-02-01T00:00:00Z&actor=admin%40globant.com", HistoryFilters{
+		From:  time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:    time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+		Actor: "admin@globant.com",
+	}, nil},
+	{"InvalidFrom_InvalidInput", "from=yesterday", HistoryFilters{}, ErrInvalidInput},
+}
+
+func Test_DecodeEmailChangeRequest_TakesCurrentEmailFromPath(t *testing.T) {
+	//Arrange
+	r := httptest.NewRequest(http.MethodPost, "/users/larry.page@gmail.com/email-change", strings.NewReader(`{"new_email":"larry@alphabet.com"}`))
+	r = mux.SetURLVars(r, map[string]string{Email: "larry.page@gmail.com"})
+	//Act
+	result, err := decodeEmailChangeRequest(context.Background(), r)
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, emailChangeRequest{Email: "larry.page@gmail.com", NewEmail: "larry@alphabet.com"}, result)
+}
+
+func Test_DecodeConfirmEmailRequest_MissingToken_ReturnsInvalidInput(t *testing.T) {
+	//Arrange
+	r := httptest.NewRequest(http.MethodPost, "/users/email-change/confirm", strings.NewReader(`{}`))
+	//Act
+	_, err := decodeConfirmEmailRequest(context.Background(), r)
+	//Assert
+	assert.Equal(t, ErrInvalidInput, err)
+}
+
+func Test_EncodeResponse_EmailChange_Returns202(t *testing.T) {
+	//Arrange
+	w := httptest.NewRecorder()
+	//Act
+	err := encodeResponse(context.Background(), w, emailChangeResponse{})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestCases_DecodeGetAllUsersRequest_Status(t *testing.T) {
+
+	for _, useCase := range statusFilterTestCases {
+		r := httptest.NewRequest(http.MethodGet, "/users/?"+useCase.query, nil)
+
+		result, err := decodeGetAllUsersRequest(context.Background(), r)
+
+		assert.Equal(t, useCase.err, err, useCase.name)
+		if req, is := result.(getAllUsersRequest); is {
+			assert.Equal(t, useCase.filters, req.Filters, useCase.name)
+		}
+	}
+}
+
+var statusFilterTestCases []struct {
+	name    string
+	query   string
+	filters Filters
+	err     error
+} = []struct {
+	name    string
+	query   string
+	filters Filters
+	err     error
+}{
+	{"NoStatus_Ok", "", Filters{}, nil},
+	{"Suspended_Ok", "status=suspended", Filters{Status: StatusSuspended}, nil},
+	{"UnknownStatus_InvalidInput", "status=banned", Filters{}, ErrInvalidInput},
+}
+
+func Test_EncodeError_StatusConflict_Returns409(t *testing.T) {
+	//Arrange
+	w := httptest.NewRecorder()
+	//Act
+	encodeError(context.Background(), ErrStatusConflict, w)
+	//Assert
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func Test_EncodeError_ValidationFailed_ProblemListsFields(t *testing.T) {
+	//Arrange
+	w := httptest.NewRecorder()
+	err := problems.Error{Code: problems.CodeValidationFailed, Detail: "Email is not valid", Fields: []problems.FieldError{
+		{Field: "email", Rule: "email", Detail: "Email is not valid"},
+		{Field: "name", Rule: "required", Detail: "Name is not valid"},
+	}}
+	//Act
+	encodeError(context.Background(), err, w)
+	//Assert
+	var problem problems.Problem
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, problems.ContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, problems.CodeValidationFailed, problem.Code)
+	assert.Equal(t, "urn:users:problem:validation-failed", problem.Type)
+	assert.Len(t, problem.Errors, 2)
+}
+
+func Test_EncodeError_MalformedBody_Returns400(t *testing.T) {
+	//Arrange
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, UsersBaseUri, strings.NewReader(`{"email":`))
+	_, err := decodePostProfileRequest(context.Background(), r)
+	//Act
+	encodeError(context.Background(), err, w)
+	//Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"MALFORMED_REQUEST"`)
+}
+
+func Test_EncodeError_ServiceUnavailable_Returns503WithoutDetail(t *testing.T) {
+	//Arrange
+	w := httptest.NewRecorder()
+	//Act
+	encodeError(context.Background(), status.Error(codes.Unavailable, "connection refused"), w)
+	//Assert
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotContains(t, w.Body.String(), "connection refused")
+}
@@ -5,7 +5,42 @@ import "fmt"
 var (
 	UsersBaseUri = "/users/"
 	PostUser     = fmt.Sprintf("%s", UsersBaseUri)
+	UserEvents   = fmt.Sprintf("%sevents", UsersBaseUri)
 	GetUser      = fmt.Sprintf("%s{%s}", UsersBaseUri, Email)
 	PutUser      = GetUser
+	PatchUser    = GetUser
 	DeleteUser   = fmt.Sprintf("%s{%s}", UsersBaseUri, UserID)
+	RestoreUser  = fmt.Sprintf("%s/restore", DeleteUser)
+	PurgeUser    = fmt.Sprintf("%s/purge", DeleteUser)
+	UserHistory  = fmt.Sprintf("%s/history", DeleteUser)
+	EmailChange  = fmt.Sprintf("%s/email-change", GetUser)
+	ConfirmEmail = fmt.Sprintf("%semail-change/confirm", UsersBaseUri)
+	Verification = fmt.Sprintf("%s/verification", GetUser)
+	VerifyEmail  = fmt.Sprintf("%sverify", UsersBaseUri)
+	SuspendUser  = fmt.Sprintf("%s/suspend", DeleteUser)
+	Reactivate   = fmt.Sprintf("%s/reactivate", DeleteUser)
+	Deactivate   = fmt.Sprintf("%s/deactivate", DeleteUser)
+
+	AuthBaseUri     = "/auth/"
+	AuthToken       = fmt.Sprintf("%stoken", AuthBaseUri)
+	AuthRevoke      = fmt.Sprintf("%srevoke", AuthBaseUri)
+	AuthPassword    = fmt.Sprintf("%spassword", AuthBaseUri)
+	AuthRoles       = fmt.Sprintf("%sroles", AuthBaseUri)
+	AuthReset       = fmt.Sprintf("%spassword-reset", AuthBaseUri)
+	AuthConfirm     = fmt.Sprintf("%s/confirm", AuthReset)
+	AuthTOTP        = fmt.Sprintf("%smfa/totp", AuthBaseUri)
+	AuthTOTPConfirm = fmt.Sprintf("%s/confirm", AuthTOTP)
+	AuthRecovery    = fmt.Sprintf("%smfa/recovery-codes", AuthBaseUri)
+	AuthDisableMFA  = fmt.Sprintf("%smfa/disable", AuthBaseUri)
+	AuthAPIKeys     = fmt.Sprintf("%sapi-keys", AuthBaseUri)
+	AuthAPIKey      = fmt.Sprintf("%s/{%s}", AuthAPIKeys, APIKeyID)
+
+	WebhooksBaseUri    = "/webhooks/"
+	WebhookDeadLetters = fmt.Sprintf("%sdead-letters", WebhooksBaseUri)
+	GetWebhook         = fmt.Sprintf("%s{%s}", WebhooksBaseUri, SubscriptionID)
+	DeleteWebhook      = GetWebhook
+	WebhookDeliveries  = fmt.Sprintf("%s/deliveries", GetWebhook)
+
+	OpenAPIDocument = "/openapi.json"
+	APIDocs         = "/docs"
 )
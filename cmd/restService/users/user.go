@@ -1,8 +1,49 @@
 package users
 
+import "time"
+
 type User struct {
-	Id       int    `json:"id,omitempty"`
-	Email    string `json:"email,omitempty"`
-	Name     string `json:"name,omitempty"`
-	LastName string `json:"lastname,omitempty"`
+	Id        int        `json:"id,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	Name      string     `json:"name,omitempty"`
+	LastName  string     `json:"lastname,omitempty"`
+	Version   int        `json:"version,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Status    string     `json:"status,omitempty"`
+}
+
+// Account statuses, a user is pending until it verifies its email.
+const (
+	StatusPending     = "pending"
+	StatusActive      = "active"
+	StatusSuspended   = "suspended"
+	StatusDeactivated = "deactivated"
+)
+
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type AuditEntry struct {
+	Id         int           `json:"id"`
+	UserId     int           `json:"user_id"`
+	Action     string        `json:"action"`
+	Changes    []FieldChange `json:"changes"`
+	Actor      string        `json:"actor"`
+	RequestId  string        `json:"request_id,omitempty"`
+	Transport  string        `json:"transport,omitempty"`
+	OccurredAt time.Time     `json:"occurred_at"`
+}
+
+type UserEvent struct {
+	Sequence   int64     `json:"sequence"`
+	Type       string    `json:"type"`
+	User       User      `json:"user"`
+	OccurredAt time.Time `json:"occurred_at"`
 }
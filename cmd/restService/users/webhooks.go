@@ -0,0 +1,214 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	proto "github.com/casmelad/GlobantPOC/api/users/v1"
+)
+
+// GrpcWebhooksProxy manages the webhook subscriptions through the gRPC
+// service.
+type GrpcWebhooksProxy interface {
+	Subscribe(context.Context, WebhookSubscription) (WebhookSubscription, error)
+	GetSubscription(context.Context, int) (WebhookSubscription, error)
+	ListSubscriptions(context.Context) ([]WebhookSubscription, error)
+	Unsubscribe(context.Context, int) error
+	Deliveries(context.Context, DeliveryFilters) ([]WebhookDelivery, error)
+}
+
+// DeliveryFilters narrows the deliveries returned by Deliveries, zero values
+// are not applied.
+type DeliveryFilters struct {
+	SubscriptionID int
+	Status         string
+}
+
+// WebhookSubscription is a partner endpoint that receives the user events of
+// the given types. The secret is only returned when the subscription is
+// created.
+type WebhookSubscription struct {
+	Id         int       `json:"id"`
+	Url        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	CreatedBy  string    `json:"created_by"`
+}
+
+type DeliveryAttempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type WebhookDelivery struct {
+	Id             int               `json:"id"`
+	SubscriptionId int               `json:"subscription_id"`
+	Sequence       int64             `json:"sequence"`
+	EventType      string            `json:"event_type"`
+	Status         string            `json:"status"`
+	Attempts       []DeliveryAttempt `json:"attempts"`
+	NextAttemptAt  *time.Time        `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+type WebhookProxy struct{}
+
+func (wp WebhookProxy) Subscribe(ctx context.Context, s WebhookSubscription) (WebhookSubscription, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return WebhookSubscription{}, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.webhooks
+	result, errorFromCall := c.Subscribe(serverCon.context, &proto.SubscribeRequest{Subscription: &proto.WebhookSubscription{
+		Url:        s.Url,
+		EventTypes: s.EventTypes,
+		Secret:     s.Secret,
+	}})
+
+	if errorFromCall != nil {
+		return WebhookSubscription{}, errorFromCall
+	}
+
+	if err := errorFromCode(result.Code); err != nil {
+		return WebhookSubscription{}, err
+	}
+
+	return subscriptionFromProto(result.Subscription), nil
+}
+
+func (wp WebhookProxy) GetSubscription(ctx context.Context, id int) (WebhookSubscription, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return WebhookSubscription{}, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.webhooks
+	result, errorFromCall := c.GetSubscription(serverCon.context, &proto.Id{Value: int32(id)})
+
+	if errorFromCall != nil {
+		return WebhookSubscription{}, errorFromCall
+	}
+
+	if err := errorFromCode(result.Code); err != nil {
+		return WebhookSubscription{}, err
+	}
+
+	return subscriptionFromProto(result.Subscription), nil
+}
+
+func (wp WebhookProxy) ListSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.webhooks
+	result, errorFromCall := c.ListSubscriptions(serverCon.context, &proto.ListSubscriptionsRequest{})
+
+	if errorFromCall != nil {
+		return nil, errorFromCall
+	}
+
+	if err := errorFromCode(result.Code); err != nil {
+		return nil, err
+	}
+
+	subs := []WebhookSubscription{}
+
+	for _, s := range result.Subscriptions {
+		subs = append(subs, subscriptionFromProto(s))
+	}
+
+	return subs, nil
+}
+
+func (wp WebhookProxy) Unsubscribe(ctx context.Context, id int) error {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.webhooks
+	result, errorFromCall := c.Unsubscribe(serverCon.context, &proto.Id{Value: int32(id)})
+
+	if errorFromCall != nil {
+		return errorFromCall
+	}
+
+	return errorFromCode(result.Code)
+}
+
+func (wp WebhookProxy) Deliveries(ctx context.Context, filters DeliveryFilters) ([]WebhookDelivery, error) {
+
+	serverCon, err := OpenServerConection(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer serverCon.dispose()
+	c := serverCon.webhooks
+	result, errorFromCall := c.Deliveries(serverCon.context, &proto.DeliveriesRequest{
+		SubscriptionId: int32(filters.SubscriptionID),
+		Status:         filters.Status,
+	})
+
+	if errorFromCall != nil {
+		return nil, errorFromCall
+	}
+
+	if err := errorFromCode(result.Code); err != nil {
+		return nil, err
+	}
+
+	deliveries := []WebhookDelivery{}
+
+	for _, d := range result.Deliveries {
+		delivery := WebhookDelivery{
+			Id:             int(d.Id),
+			SubscriptionId: int(d.SubscriptionId),
+			Sequence:       d.Sequence,
+			EventType:      d.EventType,
+			Status:         d.Status,
+			Attempts:       []DeliveryAttempt{},
+			CreatedAt:      timeFromProto(d.CreatedAt),
+		}
+		// The next attempt is only meaningful while the delivery is pending.
+		if d.Status == "pending" && d.NextAttemptAt != nil {
+			next := d.NextAttemptAt.AsTime()
+			delivery.NextAttemptAt = &next
+		}
+		for _, a := range d.Attempts {
+			delivery.Attempts = append(delivery.Attempts, DeliveryAttempt{At: timeFromProto(a.At), StatusCode: int(a.StatusCode), Error: a.Error})
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+func subscriptionFromProto(s *proto.WebhookSubscription) WebhookSubscription {
+	return WebhookSubscription{
+		Id:         int(s.Id),
+		Url:        s.Url,
+		EventTypes: append([]string{}, s.EventTypes...),
+		Secret:     s.Secret,
+		CreatedAt:  timeFromProto(s.CreatedAt),
+		CreatedBy:  s.CreatedBy,
+	}
+}
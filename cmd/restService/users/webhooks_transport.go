@@ -0,0 +1,276 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/casmelad/GlobantPOC/pkg/logging"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+type WebhookEndpoints struct {
+	SubscribeEndpoint         endpoint.Endpoint
+	GetSubscriptionEndpoint   endpoint.Endpoint
+	ListSubscriptionsEndpoint endpoint.Endpoint
+	UnsubscribeEndpoint       endpoint.Endpoint
+	DeliveriesEndpoint        endpoint.Endpoint
+}
+
+func MakeWebhookEndpoints(s GrpcWebhooksProxy, middleware ...endpoint.Middleware) WebhookEndpoints {
+	mw := chain(middleware)
+
+	return WebhookEndpoints{
+		SubscribeEndpoint:         mw(MakeSubscribeEndpoint(s)),
+		GetSubscriptionEndpoint:   mw(MakeGetSubscriptionEndpoint(s)),
+		ListSubscriptionsEndpoint: mw(MakeListSubscriptionsEndpoint(s)),
+		UnsubscribeEndpoint:       mw(MakeUnsubscribeEndpoint(s)),
+		DeliveriesEndpoint:        mw(MakeDeliveriesEndpoint(s)),
+	}
+}
+
+type subscribeRequest struct {
+	Subscription WebhookSubscription
+}
+
+type subscriptionRequest struct {
+	SubscriptionID int
+}
+
+type listSubscriptionsRequest struct{}
+
+type unsubscribeRequest struct {
+	SubscriptionID int
+}
+
+type deliveriesRequest struct {
+	Filters DeliveryFilters
+}
+
+type subscribeResponse struct {
+	Err          error               `json:"err,omitempty"`
+	Href         string              `json:"href,omitempty"`
+	Subscription WebhookSubscription `json:"subscription"`
+}
+
+type subscriptionResponse struct {
+	Err          error               `json:"err,omitempty"`
+	Subscription WebhookSubscription `json:"subscription"`
+}
+
+type listSubscriptionsResponse struct {
+	Err           error                 `json:"err,omitempty"`
+	Subscriptions []WebhookSubscription `json:"subscriptions"`
+}
+
+type unsubscribeResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+type deliveriesResponse struct {
+	Err        error             `json:"err,omitempty"`
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+// MakeSubscribeEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeSubscribeEndpoint(s GrpcWebhooksProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(subscribeRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		sub, e := s.Subscribe(ctx, reqData.Subscription)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return subscribeResponse{Href: fmt.Sprintf("%s%d", WebhooksBaseUri, sub.Id), Subscription: sub}, nil
+	}
+}
+
+// MakeGetSubscriptionEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeGetSubscriptionEndpoint(s GrpcWebhooksProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(subscriptionRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		sub, e := s.GetSubscription(ctx, reqData.SubscriptionID)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return subscriptionResponse{Subscription: sub}, nil
+	}
+}
+
+// MakeListSubscriptionsEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeListSubscriptionsEndpoint(s GrpcWebhooksProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		if _, validCast := request.(listSubscriptionsRequest); !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		subs, e := s.ListSubscriptions(ctx)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return listSubscriptionsResponse{Subscriptions: subs}, nil
+	}
+}
+
+// MakeUnsubscribeEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeUnsubscribeEndpoint(s GrpcWebhooksProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(unsubscribeRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		e := s.Unsubscribe(ctx, reqData.SubscriptionID)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return unsubscribeResponse{}, nil
+	}
+}
+
+// MakeDeliveriesEndpoint returns an endpoint via the passed service.
+// Primarily useful in a server.
+func MakeDeliveriesEndpoint(s GrpcWebhooksProxy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData, validCast := request.(deliveriesRequest)
+		if !validCast {
+			return nil, errors.New("invalid input data")
+		}
+		deliveries, e := s.Deliveries(ctx, reqData.Filters)
+
+		if e != nil {
+			return WrapError(e), nil
+		}
+
+		return deliveriesResponse{Deliveries: deliveries}, nil
+	}
+}
+
+// MakeWebhooksHTTPHandler mounts the webhook management endpoints into an
+// http.Handler.
+func MakeWebhooksHTTPHandler(s GrpcWebhooksProxy, logger log.Logger, middleware ...endpoint.Middleware) http.Handler {
+	r := mux.NewRouter()
+	e := MakeWebhookEndpoints(s, middleware...)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorHandler(logging.NewErrorHandler(logger)),
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(routeToContext),
+	}
+
+	r.Methods(http.MethodPost).Path(WebhooksBaseUri).Handler(httptransport.NewServer(
+		e.SubscribeEndpoint,
+		decodeSubscribeRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodGet).Path(WebhooksBaseUri).Handler(httptransport.NewServer(
+		e.ListSubscriptionsEndpoint,
+		decodeListSubscriptionsRequest,
+		encodeResponse,
+		options...,
+	))
+	// Registered before GetWebhook, which would match the path too.
+	r.Methods(http.MethodGet).Path(WebhookDeadLetters).Handler(httptransport.NewServer(
+		e.DeliveriesEndpoint,
+		decodeDeadLettersRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodGet).Path(GetWebhook).Handler(httptransport.NewServer(
+		e.GetSubscriptionEndpoint,
+		decodeSubscriptionRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodDelete).Path(DeleteWebhook).Handler(httptransport.NewServer(
+		e.UnsubscribeEndpoint,
+		decodeUnsubscribeRequest,
+		encodeResponse,
+		options...,
+	))
+	r.Methods(http.MethodGet).Path(WebhookDeliveries).Handler(httptransport.NewServer(
+		e.DeliveriesEndpoint,
+		decodeDeliveriesRequest,
+		encodeResponse,
+		options...,
+	))
+
+	return r
+}
+
+func decodeSubscribeRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req subscribeRequest
+	if e := json.NewDecoder(r.Body).Decode(&req.Subscription); e != nil {
+		return nil, ErrInvalidInput
+	}
+	return req, nil
+}
+
+func decodeListSubscriptionsRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return listSubscriptionsRequest{}, nil
+}
+
+func decodeSubscriptionRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	id, err := decodeSubscriptionID(r)
+	if err != nil {
+		return nil, err
+	}
+	return subscriptionRequest{SubscriptionID: id}, nil
+}
+
+func decodeUnsubscribeRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	id, err := decodeSubscriptionID(r)
+	if err != nil {
+		return nil, err
+	}
+	return unsubscribeRequest{SubscriptionID: id}, nil
+}
+
+func decodeDeliveriesRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	id, err := decodeSubscriptionID(r)
+	if err != nil {
+		return nil, err
+	}
+	return deliveriesRequest{Filters: DeliveryFilters{SubscriptionID: id, Status: r.URL.Query().Get(DeliveryStatus)}}, nil
+}
+
+// decodeDeadLettersRequest lists the deliveries that were given up on, of
+// every subscription unless one is given in the query.
+func decodeDeadLettersRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	filters := DeliveryFilters{Status: "dead"}
+	if value := r.URL.Query().Get(SubscriptionID); value != "" {
+		if filters.SubscriptionID, err = strconv.Atoi(value); err != nil {
+			return nil, ErrInvalidInput
+		}
+	}
+	return deliveriesRequest{Filters: filters}, nil
+}
+
+func decodeSubscriptionID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)[SubscriptionID])
+	if err != nil {
+		return 0, ErrBadRouting
+	}
+	return id, nil
+}
@@ -0,0 +1,96 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type webhookProxyStub struct {
+	subscribed WebhookSubscription
+	filters    DeliveryFilters
+	err        error
+}
+
+func (p *webhookProxyStub) Subscribe(ctx context.Context, s WebhookSubscription) (WebhookSubscription, error) {
+	p.subscribed = s
+	s.Id = 1
+	s.Secret = "generated-secret-value"
+	return s, p.err
+}
+
+func (p *webhookProxyStub) GetSubscription(ctx context.Context, id int) (WebhookSubscription, error) {
+	return WebhookSubscription{Id: id}, p.err
+}
+
+func (p *webhookProxyStub) ListSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	return []WebhookSubscription{}, p.err
+}
+
+func (p *webhookProxyStub) Unsubscribe(ctx context.Context, id int) error {
+	return p.err
+}
+
+func (p *webhookProxyStub) Deliveries(ctx context.Context, filters DeliveryFilters) ([]WebhookDelivery, error) {
+	p.filters = filters
+	return []WebhookDelivery{}, p.err
+}
+
+func serveWebhooks(proxy GrpcWebhooksProxy, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	MakeWebhooksHTTPHandler(proxy, log.NewNopLogger()).ServeHTTP(w, r)
+	return w
+}
+
+func Test_Webhooks_Subscribe_ReturnsSecretAndHref(t *testing.T) {
+	//Arrange
+	proxy := &webhookProxyStub{}
+	r := httptest.NewRequest(http.MethodPost, WebhooksBaseUri, strings.NewReader(`{"url":"https://partner.example.com/hooks","event_types":["user.created"]}`))
+	//Act
+	w := serveWebhooks(proxy, r)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"user.created"}, proxy.subscribed.EventTypes)
+	var body subscribeResponse
+	json.NewDecoder(w.Body).Decode(&body)
+	assert.Equal(t, "/webhooks/1", body.Href)
+	assert.Equal(t, "generated-secret-value", body.Subscription.Secret)
+}
+
+func Test_Webhooks_DeadLetters_QueriesDeadDeliveries(t *testing.T) {
+	//Arrange
+	proxy := &webhookProxyStub{}
+	r := httptest.NewRequest(http.MethodGet, WebhookDeadLetters+"?subscription_id=3", nil)
+	//Act
+	w := serveWebhooks(proxy, r)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, DeliveryFilters{SubscriptionID: 3, Status: "dead"}, proxy.filters)
+}
+
+func Test_Webhooks_Deliveries_FiltersBySubscriptionAndStatus(t *testing.T) {
+	//Arrange
+	proxy := &webhookProxyStub{}
+	r := httptest.NewRequest(http.MethodGet, "/webhooks/2/deliveries?status=pending", nil)
+	//Act
+	w := serveWebhooks(proxy, r)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, DeliveryFilters{SubscriptionID: 2, Status: "pending"}, proxy.filters)
+}
+
+func Test_Webhooks_NotAdmin_Returns403(t *testing.T) {
+	//Arrange
+	proxy := &webhookProxyStub{err: ErrForbidden}
+	r := httptest.NewRequest(http.MethodGet, WebhooksBaseUri, nil)
+	//Act
+	w := serveWebhooks(proxy, r)
+	//Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+//Action - the kind of change recorded by an audit entry
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+	ActionPurge   Action = "purge"
+)
+
+//Change - the value of a single user field before and after a change
+type Change struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+//Entry - a recorded change of a user
+type Entry struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	Action     Action    `json:"action"`
+	Changes    []Change  `json:"changes"`
+	Actor      string    `json:"actor"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Transport  string    `json:"transport,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+//Query - criteria used to read the history of a user, zero values are not applied
+type Query struct {
+	//From - only entries that occurred at or after this time
+	From time.Time
+	//To - only entries that occurred before this time
+	To time.Time
+	//Actor - only entries recorded for this actor
+	Actor string
+}
+
+//Matches - reports whether the entry satisfies the query
+func (q Query) Matches(e Entry) bool {
+	if !q.From.IsZero() && e.OccurredAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !e.OccurredAt.Before(q.To) {
+		return false
+	}
+	return q.Actor == "" || q.Actor == e.Actor
+}
+
+//Store - the interface for the audit entries persistence
+type Store interface {
+	Append(ctx context.Context, e Entry) error
+	History(ctx context.Context, userID int, q Query) ([]Entry, error)
+	//Redact - empties the changes of the entries of a user, what was done, by whom and when is kept
+	Redact(ctx context.Context, userID int) error
+}
+
+//Historian - reads the history of the users
+type Historian interface {
+	History(ctx context.Context, userID int, q Query) ([]Entry, error)
+}
+
+//Diff - returns the user fields that differ between the two snapshots, a zero user stands for a missing one
+func Diff(before, after users.User) []Change {
+	changes := []Change{}
+
+	fields := []struct {
+		name          string
+		before, after string
+	}{
+		{"email", before.Email, after.Email},
+		{"name", before.Name, after.Name},
+		{"last_name", before.LastName, after.LastName},
+		{"deleted_at", formatTime(before.DeletedAt), formatTime(after.DeletedAt)},
+		{"status", string(before.Status), string(after.Status)},
+	}
+
+	for _, f := range fields {
+		if f.before != f.after {
+			changes = append(changes, Change{Field: f.name, Before: f.before, After: f.after})
+		}
+	}
+
+	return changes
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Diff_UnchangedUser_ReturnsNoChanges(t *testing.T) {
+	//Arrange
+	usr := users.User{ID: 1, Email: "test@gmail.com", Name: "Test", LastName: "LastName"}
+	//Act
+	result := Diff(usr, usr)
+	//Assert
+	assert.Empty(t, result)
+}
+
+func Test_Diff_PurgedUser_ReturnsClearedFields(t *testing.T) {
+	//Arrange
+	deletedAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	usr := users.User{ID: 1, Email: "test@gmail.com", Name: "Test", LastName: "LastName", DeletedAt: &deletedAt}
+	//Act
+	result := Diff(usr, users.User{})
+	//Assert
+	assert.Equal(t, []Change{
+		{Field: "email", Before: "test@gmail.com", After: ""},
+		{Field: "name", Before: "Test", After: ""},
+		{Field: "last_name", Before: "LastName", After: ""},
+		{Field: "deleted_at", Before: "2021-01-01T00:00:00Z", After: ""},
+	}, result)
+}
+
+func Test_Query_Matches_ToIsExclusive(t *testing.T) {
+	//Arrange
+	at := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := Entry{Actor: "admin@globant.com", OccurredAt: at}
+	//Assert
+	assert.True(t, Query{From: at}.Matches(entry))
+	assert.False(t, Query{To: at}.Matches(entry))
+	assert.False(t, Query{Actor: "test@gmail.com"}.Matches(entry))
+}
@@ -0,0 +1,286 @@
+package audit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+//Service - a users.Service decorator that records every write in the audit store,
+//within the same transaction as the change itself
+type Service struct {
+	next       users.Service
+	repository users.Repository
+	store      Store
+	transactor users.Transactor
+	clock      users.Clock
+}
+
+//NewService - returns a Service type pointer that decorates next, the repository is used to read the snapshots of the users
+func NewService(next users.Service, repo users.Repository, store Store, transactor users.Transactor, clock users.Clock) *Service {
+	return &Service{
+		next:       next,
+		repository: repo,
+		store:      store,
+		transactor: transactor,
+		clock:      clock,
+	}
+}
+
+//Create - creates the user and records its initial state
+func (s *Service) Create(ctx context.Context, usr users.User) (int, error) {
+
+	var id int
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		if id, err = s.next.Create(ctx, usr); err != nil {
+			return err
+		}
+
+		after, err := s.repository.GetByID(ctx, id)
+
+		if err != nil {
+			return err
+		}
+
+		return s.record(ctx, ActionCreate, id, users.User{}, after)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+//GetByEmail - reads are not audited
+func (s *Service) GetByEmail(ctx context.Context, email string) (users.User, error) {
+	return s.next.GetByEmail(ctx, email)
+}
+
+//GetAll - reads are not audited
+func (s *Service) GetAll(ctx context.Context, filter users.Filter) ([]users.User, error) {
+	return s.next.GetAll(ctx, filter)
+}
+
+//Update - updates the user and records the changed fields
+func (s *Service) Update(ctx context.Context, usr users.User) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.repository.GetByEmail(ctx, usr.Email)
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.next.Update(ctx, usr); err != nil {
+			return err
+		}
+
+		return s.recordChange(ctx, ActionUpdate, before)
+	})
+}
+
+//Patch - partially updates the user and records the changed fields
+func (s *Service) Patch(ctx context.Context, p users.Patch) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.repository.GetByEmail(ctx, p.Email)
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.next.Patch(ctx, p); err != nil {
+			return err
+		}
+
+		return s.recordChange(ctx, ActionUpdate, before)
+	})
+}
+
+//Delete - soft deletes the user and records it
+func (s *Service) Delete(ctx context.Context, usrID int, version int) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.repository.GetByID(ctx, usrID)
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.next.Delete(ctx, usrID, version); err != nil {
+			return err
+		}
+
+		return s.recordChange(ctx, ActionDelete, before)
+	})
+}
+
+//Restore - restores the user and records it
+func (s *Service) Restore(ctx context.Context, usrID int) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.repository.GetByID(ctx, usrID)
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.next.Restore(ctx, usrID); err != nil {
+			return err
+		}
+
+		return s.recordChange(ctx, ActionRestore, before)
+	})
+}
+
+//Purge - permanently removes the user and redacts the changes of its history, the history outlives the user
+//without its personal data
+func (s *Service) Purge(ctx context.Context, usrID int) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.next.Purge(ctx, usrID); err != nil {
+			return err
+		}
+
+		if err := s.store.Redact(ctx, usrID); err != nil {
+			return err
+		}
+
+		return s.record(ctx, ActionPurge, usrID, users.User{}, users.User{})
+	})
+}
+
+//RequestEmailChange - nothing changes until the new address is confirmed, requests are not audited
+func (s *Service) RequestEmailChange(ctx context.Context, email string, newEmail string) error {
+	return s.next.RequestEmailChange(ctx, email, newEmail)
+}
+
+//ConfirmEmailChange - changes the email and records it
+func (s *Service) ConfirmEmailChange(ctx context.Context, token string) (users.EmailChange, error) {
+
+	var change users.EmailChange
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		if change, err = s.next.ConfirmEmailChange(ctx, token); err != nil {
+			return err
+		}
+
+		after, err := s.repository.GetByID(ctx, change.UserID)
+
+		if err != nil {
+			return err
+		}
+
+		before := after
+		before.Email = change.OldEmail
+
+		return s.record(ctx, ActionUpdate, change.UserID, before, after)
+	})
+
+	if err != nil {
+		return users.EmailChange{}, err
+	}
+
+	return change, nil
+}
+
+//SendVerification - nothing changes until the email is verified, sending a token is not audited
+func (s *Service) SendVerification(ctx context.Context, email string) error {
+	return s.next.SendVerification(ctx, email)
+}
+
+//VerifyEmail - activates the user and records it
+func (s *Service) VerifyEmail(ctx context.Context, token string) (users.User, error) {
+
+	var after users.User
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		if after, err = s.next.VerifyEmail(ctx, token); err != nil {
+			return err
+		}
+
+		before := after
+		before.Status = users.AccountPending
+
+		return s.record(ctx, ActionUpdate, after.ID, before, after)
+	})
+
+	if err != nil {
+		return users.User{}, err
+	}
+
+	return after, nil
+}
+
+//Suspend - suspends the user and records it
+func (s *Service) Suspend(ctx context.Context, usrID int) error {
+	return s.recordTransition(ctx, usrID, s.next.Suspend)
+}
+
+//Reactivate - reactivates the user and records it
+func (s *Service) Reactivate(ctx context.Context, usrID int) error {
+	return s.recordTransition(ctx, usrID, s.next.Reactivate)
+}
+
+//Deactivate - deactivates the user and records it
+func (s *Service) Deactivate(ctx context.Context, usrID int) error {
+	return s.recordTransition(ctx, usrID, s.next.Deactivate)
+}
+
+//History - returns the audit entries of a user that match the query, oldest first, only administrators are allowed
+//to read them
+func (s *Service) History(ctx context.Context, usrID int, q Query) ([]Entry, error) {
+
+	if caller, ok := users.CallerFromContext(ctx); !ok || !caller.HasRole(users.AdminRole) {
+		return nil, users.UserError(users.ERRFORBIDDEN)
+	}
+
+	if usrID < 1 {
+		return nil, errors.New("invalid id")
+	}
+
+	return s.store.History(ctx, usrID, q)
+}
+
+func (s *Service) recordTransition(ctx context.Context, usrID int, transition func(context.Context, int) error) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.repository.GetByID(ctx, usrID)
+
+		if err != nil {
+			return err
+		}
+
+		if err := transition(ctx, usrID); err != nil {
+			return err
+		}
+
+		return s.recordChange(ctx, ActionUpdate, before)
+	})
+}
+
+func (s *Service) recordChange(ctx context.Context, action Action, before users.User) error {
+
+	after, err := s.repository.GetByID(ctx, before.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return s.record(ctx, action, before.ID, before, after)
+}
+
+func (s *Service) record(ctx context.Context, action Action, usrID int, before, after users.User) error {
+	return s.store.Append(ctx, Entry{
+		UserID:     usrID,
+		Action:     action,
+		Changes:    Diff(before, after),
+		Actor:      users.ActorFromContext(ctx),
+		RequestID:  users.RequestIDFromContext(ctx),
+		Transport:  users.TransportFromContext(ctx),
+		OccurredAt: s.clock.Now(),
+	})
+}
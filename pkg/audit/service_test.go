@@ -0,0 +1,269 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/audit"
+	"github.com/casmelad/GlobantPOC/pkg/events"
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/stretchr/testify/assert"
+)
+
+type steppingClock struct {
+	now time.Time
+}
+
+func (c *steppingClock) Now() time.Time {
+	return c.now
+}
+
+type failingStore struct {
+	audit.Store
+}
+
+func (failingStore) Append(ctx context.Context, e audit.Entry) error {
+	return errors.New("audit store unavailable")
+}
+
+type recordingTransactor struct {
+	users.Transactor
+	failed bool
+}
+
+func (t *recordingTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := t.Transactor.WithinTransaction(ctx, fn)
+	t.failed = err != nil
+	return err
+}
+
+var start = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newAuditedService(clock users.Clock) (*audit.Service, *memory.InMemoryUserRepository) {
+	repository := memory.NewInMemoryUserRepository(memory.WithClock(clock))
+	service := audit.NewService(users.NewUserService(repository), repository, memory.NewInMemoryAuditRepository(), repository, clock)
+	return service, repository
+}
+
+func requestContext(actor string) context.Context {
+	ctx := users.WithCaller(context.Background(), users.Caller{Subject: actor, Roles: []string{users.AdminRole}, Methods: []string{users.MFAMethod}})
+	ctx = users.WithRequestID(ctx, "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d")
+	return users.WithTransport(ctx, users.TransportREST)
+}
+
+func Test_Create_ValidData_RecordsEntry(t *testing.T) {
+	//Arrange
+	service, _ := newAuditedService(&steppingClock{now: start})
+	ctx := requestContext("admin@globant.com")
+	//Act
+	id, err := service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	history, _ := service.History(ctx, id, audit.Query{})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(history))
+	assert.Equal(t, audit.ActionCreate, history[0].Action)
+	assert.Equal(t, "admin@globant.com", history[0].Actor)
+	assert.Equal(t, "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", history[0].RequestID)
+	assert.Equal(t, users.TransportREST, history[0].Transport)
+	assert.Equal(t, start, history[0].OccurredAt)
+	assert.Equal(t, []audit.Change{
+		{Field: "email", Before: "", After: "test@gmail.com"},
+		{Field: "name", Before: "", After: "Test"},
+		{Field: "last_name", Before: "", After: "LastName"},
+		{Field: "status", Before: "", After: "active"},
+	}, history[0].Changes)
+}
+
+func Test_Update_ValidData_RecordsChangedFields(t *testing.T) {
+	//Arrange
+	service, _ := newAuditedService(&steppingClock{now: start})
+	ctx := requestContext("admin@globant.com")
+	id, _ := service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	//Act
+	err := service.Update(ctx, users.User{Email: "test@gmail.com", Name: "Test_Updated", LastName: "LastName", Version: 1})
+	history, _ := service.History(ctx, id, audit.Query{})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(history))
+	assert.Equal(t, audit.ActionUpdate, history[1].Action)
+	assert.Equal(t, []audit.Change{{Field: "name", Before: "Test", After: "Test_Updated"}}, history[1].Changes)
+}
+
+func Test_Update_StaleVersion_RecordsNothing(t *testing.T) {
+	//Arrange
+	service, _ := newAuditedService(&steppingClock{now: start})
+	ctx := requestContext("admin@globant.com")
+	id, _ := service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	//Act
+	err := service.Update(ctx, users.User{Email: "test@gmail.com", Name: "Test_Updated", LastName: "LastName", Version: 7})
+	history, _ := service.History(ctx, id, audit.Query{})
+	//Assert
+	assert.True(t, users.IsUserErrorType(users.ERRVERSIONCONFLICT, err))
+	assert.Equal(t, 1, len(history))
+}
+
+func Test_Patch_ValidData_RecordsPatchedField(t *testing.T) {
+	//Arrange
+	service, _ := newAuditedService(&steppingClock{now: start})
+	ctx := requestContext("admin@globant.com")
+	id, _ := service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	//Act
+	err := service.Patch(ctx, users.Patch{Email: "test@gmail.com", Version: 1, Paths: []string{users.LastNamePath}, LastName: "LastName_Patched"})
+	history, _ := service.History(ctx, id, audit.Query{})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(history))
+	assert.Equal(t, []audit.Change{{Field: "last_name", Before: "LastName", After: "LastName_Patched"}}, history[1].Changes)
+}
+
+func Test_DeleteAndRestore_RecordsEntries(t *testing.T) {
+	//Arrange
+	service, _ := newAuditedService(&steppingClock{now: start})
+	ctx := requestContext("admin@globant.com")
+	id, _ := service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	//Act
+	errD := service.Delete(ctx, id, 1)
+	errR := service.Restore(ctx, id)
+	history, _ := service.History(ctx, id, audit.Query{})
+	//Assert
+	assert.Nil(t, errD)
+	assert.Nil(t, errR)
+	assert.Equal(t, 3, len(history))
+	assert.Equal(t, audit.ActionDelete, history[1].Action)
+	assert.Equal(t, "deleted_at", history[1].Changes[0].Field)
+	assert.Equal(t, "", history[1].Changes[0].Before)
+	assert.Equal(t, audit.ActionRestore, history[2].Action)
+	assert.Equal(t, "", history[2].Changes[0].After)
+}
+
+func Test_Purge_RedactsChangesOfHistory(t *testing.T) {
+	//Arrange
+	service, _ := newAuditedService(&steppingClock{now: start})
+	ctx := requestContext("admin@globant.com")
+	id, _ := service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	service.Delete(ctx, id, 1)
+	//Act
+	err := service.Purge(ctx, id)
+	history, _ := service.History(ctx, id, audit.Query{})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(history))
+	assert.Equal(t, audit.ActionPurge, history[2].Action)
+	for _, e := range history {
+		assert.Empty(t, e.Changes)
+		assert.Equal(t, "admin@globant.com", e.Actor)
+	}
+}
+
+func Test_RetentionJob_RecordsEntryAndEventPerPurgedUser(t *testing.T) {
+	//Arrange
+	clock := &steppingClock{now: start}
+	repository := memory.NewInMemoryUserRepository(memory.WithClock(clock))
+	outbox := memory.NewInMemoryOutboxRepository()
+	service := audit.NewService(users.NewUserService(repository,
+		users.WithTransactor(repository),
+		users.WithEventEmitter(events.NewOutboxEmitter(outbox)),
+		users.WithClock(clock)), repository, memory.NewInMemoryAuditRepository(), repository, clock)
+	ctx := requestContext("admin@globant.com")
+	expired, _ := service.Create(ctx, users.User{Email: "expired@gmail.com", Name: "Test", LastName: "LastName"})
+	recent, _ := service.Create(ctx, users.User{Email: "recent@gmail.com", Name: "Test", LastName: "LastName"})
+	service.Delete(ctx, expired, 1)
+	clock.now = start.Add(2 * time.Hour)
+	service.Delete(ctx, recent, 1)
+	job := users.NewRetentionJob(repository, service, time.Hour, clock)
+	//Act
+	purged, err := job.RunOnce(context.Background())
+	history, _ := service.History(ctx, expired, audit.Query{})
+	messages, _ := outbox.Pending(ctx, 10)
+	remaining, _ := repository.GetByID(ctx, recent)
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, audit.ActionPurge, history[len(history)-1].Action)
+	assert.Equal(t, users.RetentionActor, history[len(history)-1].Actor)
+	assert.Equal(t, users.UserPurgedEvent, messages[len(messages)-1].Type)
+	assert.Equal(t, expired, messages[len(messages)-1].UserID)
+	assert.Equal(t, recent, remaining.ID)
+}
+
+func Test_SuspendAndReactivate_RecordsStatusChanges(t *testing.T) {
+	//Arrange
+	service, _ := newAuditedService(&steppingClock{now: start})
+	ctx := users.WithCaller(requestContext("admin@globant.com"), users.Caller{Subject: "admin@globant.com", Roles: []string{users.AdminRole}})
+	id, _ := service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	//Act
+	errS := service.Suspend(ctx, id)
+	errR := service.Reactivate(ctx, id)
+	history, _ := service.History(ctx, id, audit.Query{})
+	//Assert
+	assert.Nil(t, errS)
+	assert.Nil(t, errR)
+	assert.Equal(t, 3, len(history))
+	assert.Equal(t, []audit.Change{{Field: "status", Before: "active", After: "suspended"}}, history[1].Changes)
+	assert.Equal(t, []audit.Change{{Field: "status", Before: "suspended", After: "active"}}, history[2].Changes)
+}
+
+func Test_History_FiltersByTimeRangeAndActor(t *testing.T) {
+	//Arrange
+	clock := &steppingClock{now: start}
+	service, _ := newAuditedService(clock)
+	id, _ := service.Create(requestContext("admin@globant.com"), users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	clock.now = start.Add(time.Hour)
+	service.Update(requestContext("test@gmail.com"), users.User{Email: "test@gmail.com", Name: "Test1", LastName: "LastName", Version: 1})
+	clock.now = start.Add(2 * time.Hour)
+	service.Update(requestContext("admin@globant.com"), users.User{Email: "test@gmail.com", Name: "Test2", LastName: "LastName", Version: 2})
+	ctx := requestContext("admin@globant.com")
+	//Act
+	byActor, _ := service.History(ctx, id, audit.Query{Actor: "admin@globant.com"})
+	byRange, _ := service.History(ctx, id, audit.Query{From: start.Add(time.Hour), To: start.Add(2 * time.Hour)})
+	//Assert
+	assert.Equal(t, 2, len(byActor))
+	assert.Equal(t, 1, len(byRange))
+	assert.Equal(t, "test@gmail.com", byRange[0].Actor)
+}
+
+func Test_History_InvalidId_ReturnsError(t *testing.T) {
+	//Arrange
+	service, _ := newAuditedService(&steppingClock{now: start})
+	//Act
+	_, err := service.History(requestContext("admin@globant.com"), 0, audit.Query{})
+	//Assert
+	assert.EqualError(t, err, "invalid id")
+}
+
+func TestCases_History_NotAdmin_ReturnsForbiddenError(t *testing.T) {
+	testCases := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"Anonymous", context.Background()},
+		{"User", users.WithCaller(context.Background(), users.Caller{Subject: "test@gmail.com", Roles: []string{"user"}})},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Arrange
+			service, _ := newAuditedService(&steppingClock{now: start})
+			id, _ := service.Create(requestContext("admin@globant.com"), users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+			//Act
+			entries, err := service.History(tc.ctx, id, audit.Query{})
+			//Assert
+			assert.True(t, users.IsUserErrorType(users.ERRFORBIDDEN, err))
+			assert.Empty(t, entries)
+		})
+	}
+}
+
+func Test_Create_StoreFails_FailsTransaction(t *testing.T) {
+	//Arrange
+	repository := memory.NewInMemoryUserRepository()
+	transactor := &recordingTransactor{Transactor: repository}
+	service := audit.NewService(users.NewUserService(repository), repository, failingStore{}, transactor, users.SystemClock{})
+	//Act
+	_, err := service.Create(context.Background(), users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	//Assert
+	assert.EqualError(t, err, "audit store unavailable")
+	assert.True(t, transactor.failed)
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+var (
+	//ErrAPIKeysDisabled - the service was built without WithAPIKeys
+	ErrAPIKeysDisabled = errors.New("api keys are not enabled")
+	//ErrInvalidAPIKey - the API key is unknown, expired or revoked
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	//ErrAPIKeyNotFound - there is no API key with the given id
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	//ErrInvalidAPIKeyRequest - the API key has no name or scopes, has the admin scope, or it would be expired already
+	ErrInvalidAPIKeyRequest = errors.New("invalid api key request")
+)
+
+const (
+	apiKeyIDBytes     = 8
+	apiKeySecretBytes = 32
+	//apiKeySeparator - between the id and the secret of a key, the base64url secrets never contain it
+	apiKeySeparator = "."
+)
+
+//APIKey - a credential of a client that can not log in interactively, such as a batch job. Its scopes are
+//the roles it acts with, never the admin role as a key can not give a second factor. Only the hash of its secret is kept
+type APIKey struct {
+	ID         string
+	Name       string
+	Hash       string
+	Scopes     []string
+	CreatedBy  string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+//Active - reports whether the key is accepted at the given time
+func (k APIKey) Active(at time.Time) bool {
+	return k.ID != "" && k.RevokedAt == nil && (k.ExpiresAt == nil || at.Before(*k.ExpiresAt))
+}
+
+//APIKeyStore - the persistence of the API keys
+type APIKeyStore interface {
+	//AddAPIKey - stores a new API key
+	AddAPIKey(ctx context.Context, k APIKey) error
+	//GetAPIKey - retrieves an API key, a zero key when it does not exist
+	GetAPIKey(ctx context.Context, id string) (APIKey, error)
+	//ListAPIKeys - retrieves every API key, revoked ones included, oldest first
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	//RevokeAPIKey - marks an API key as revoked
+	RevokeAPIKey(ctx context.Context, id string, at time.Time) error
+	//TouchAPIKey - records when an API key was last used
+	TouchAPIKey(ctx context.Context, id string, at time.Time) error
+}
+
+//APIKeys - configures the API keys
+type APIKeys struct {
+	//Store - where the API keys are kept
+	Store APIKeyStore
+	//UsageResolution - the last-used time is only written when it is older than this, so busy keys do not
+	//write on every request
+	UsageResolution time.Duration
+}
+
+//WithAPIKeys - enables the API keys
+func WithAPIKeys(k APIKeys) Option {
+	return func(s *Service) {
+		s.apiKeys = &k
+	}
+}
+
+//CreateAPIKey - generates an API key, only administrators that gave a second factor are allowed to. The returned
+//key is the only place its secret is handed back
+func (s *Service) CreateAPIKey(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (APIKey, string, error) {
+
+	if err := s.authorizeAPIKeys(ctx); err != nil {
+		return APIKey{}, "", err
+	}
+
+	now := s.clock.Now()
+
+	if strings.TrimSpace(name) == "" || len(scopes) == 0 || (expiresAt != nil && !now.Before(*expiresAt)) {
+		return APIKey{}, "", ErrInvalidAPIKeyRequest
+	}
+
+	for _, scope := range scopes {
+		if strings.TrimSpace(scope) == "" || scope == users.AdminRole {
+			return APIKey{}, "", ErrInvalidAPIKeyRequest
+		}
+	}
+
+	raw := make([]byte, apiKeyIDBytes)
+
+	if _, err := rand.Read(raw); err != nil {
+		return APIKey{}, "", err
+	}
+
+	secret, err := randomToken(apiKeySecretBytes)
+
+	if err != nil {
+		return APIKey{}, "", err
+	}
+
+	key := APIKey{
+		ID:        hex.EncodeToString(raw),
+		Name:      name,
+		Hash:      hashToken(secret),
+		Scopes:    scopes,
+		CreatedBy: users.ActorFromContext(ctx),
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+
+	if err := s.apiKeys.Store.AddAPIKey(ctx, key); err != nil {
+		return APIKey{}, "", err
+	}
+
+	return key, key.ID + apiKeySeparator + secret, nil
+}
+
+//ListAPIKeys - retrieves every API key, only administrators that gave a second factor are allowed to
+func (s *Service) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+
+	if err := s.authorizeAPIKeys(ctx); err != nil {
+		return []APIKey{}, err
+	}
+
+	return s.apiKeys.Store.ListAPIKeys(ctx)
+}
+
+//RevokeAPIKey - stops accepting an API key, only administrators that gave a second factor are allowed to.
+//Revoking it again keeps the time of the first revocation
+func (s *Service) RevokeAPIKey(ctx context.Context, id string) error {
+
+	if err := s.authorizeAPIKeys(ctx); err != nil {
+		return err
+	}
+
+	key, err := s.apiKeys.Store.GetAPIKey(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	if key.ID == "" {
+		return ErrAPIKeyNotFound
+	}
+
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	return s.apiKeys.Store.RevokeAPIKey(ctx, id, s.clock.Now())
+}
+
+//AuthenticateAPIKey - checks an API key and returns it, its last-used time is recorded
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (APIKey, error) {
+
+	if s.apiKeys == nil {
+		return APIKey{}, ErrAPIKeysDisabled
+	}
+
+	parts := strings.SplitN(key, apiKeySeparator, 2)
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	stored, err := s.apiKeys.Store.GetAPIKey(ctx, parts[0])
+
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	now := s.clock.Now()
+
+	if !stored.Active(now) || subtle.ConstantTimeCompare([]byte(stored.Hash), []byte(hashToken(parts[1]))) != 1 {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	if stored.LastUsedAt == nil || !now.Before(stored.LastUsedAt.Add(s.apiKeys.UsageResolution)) {
+		if err := s.apiKeys.Store.TouchAPIKey(ctx, stored.ID, now); err != nil {
+			return APIKey{}, err
+		}
+		stored.LastUsedAt = &now
+	}
+
+	return stored, nil
+}
+
+func (s *Service) authorizeAPIKeys(ctx context.Context) error {
+
+	if s.apiKeys == nil {
+		return ErrAPIKeysDisabled
+	}
+
+	return authorizeAdmin(ctx)
+}
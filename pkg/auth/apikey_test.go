@@ -0,0 +1,144 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/auth"
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAPIKeyFixture(t *testing.T) fixture {
+	store := memory.NewInMemoryAuthRepository()
+	return newFixtureWithStore(t, store, auth.WithAPIKeys(auth.APIKeys{Store: store, UsageResolution: time.Minute}))
+}
+
+func Test_CreateAPIKey_KeyAuthenticatesWithItsScopes(t *testing.T) {
+	//Arrange
+	f := newAPIKeyFixture(t)
+	//Act
+	created, key, err := f.service.CreateAPIKey(asAdmin(), "nightly-export", []string{"exporter"}, nil)
+	authenticated, errAuth := f.service.AuthenticateAPIKey(context.Background(), key)
+	//Assert
+	assert.Nil(t, err)
+	assert.Len(t, created.Hash, 64)
+	assert.Equal(t, "admin@example.com", created.CreatedBy)
+	assert.Nil(t, errAuth)
+	assert.Equal(t, created.ID, authenticated.ID)
+	assert.Equal(t, []string{"exporter"}, authenticated.Scopes)
+	assert.Equal(t, now, *authenticated.LastUsedAt)
+}
+
+func Test_CreateAPIKey_NotAnAdministrator_ReturnsForbiddenError(t *testing.T) {
+	//Arrange
+	f := newAPIKeyFixture(t)
+	ctx := users.WithCaller(context.Background(), users.Caller{Subject: email})
+	//Act
+	_, _, err := f.service.CreateAPIKey(ctx, "nightly-export", []string{"exporter"}, nil)
+	//Assert
+	assert.True(t, users.IsUserErrorType(users.ERRFORBIDDEN, err))
+}
+
+func Test_CreateAPIKey_AdminWithoutSecondFactor_ReturnsForbiddenError(t *testing.T) {
+	//Arrange
+	f := newAPIKeyFixture(t)
+	//Act
+	_, _, err := f.service.CreateAPIKey(asAdminWithoutSecondFactor(), "nightly-export", []string{"exporter"}, nil)
+	_, errList := f.service.ListAPIKeys(asAdminWithoutSecondFactor())
+	//Assert
+	assert.True(t, users.IsUserErrorType(users.ERRFORBIDDEN, err))
+	assert.True(t, users.IsUserErrorType(users.ERRFORBIDDEN, errList))
+}
+
+func TestCases_CreateAPIKey_InvalidRequest(t *testing.T) {
+	past := now.Add(-time.Hour)
+	testCases := []struct {
+		name      string
+		keyName   string
+		scopes    []string
+		expiresAt *time.Time
+	}{
+		{"without name", "", []string{"exporter"}, nil},
+		{"without scopes", "nightly-export", nil, nil},
+		{"with an empty scope", "nightly-export", []string{""}, nil},
+		{"with the admin scope", "nightly-export", []string{"exporter", users.AdminRole}, nil},
+		{"already expired", "nightly-export", []string{"exporter"}, &past},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Arrange
+			f := newAPIKeyFixture(t)
+			//Act
+			_, _, err := f.service.CreateAPIKey(asAdmin(), tc.keyName, tc.scopes, tc.expiresAt)
+			//Assert
+			assert.Equal(t, auth.ErrInvalidAPIKeyRequest, err)
+		})
+	}
+}
+
+func Test_AuthenticateAPIKey_WrongSecret_ReturnsInvalidAPIKey(t *testing.T) {
+	//Arrange
+	f := newAPIKeyFixture(t)
+	created, _, _ := f.service.CreateAPIKey(asAdmin(), "nightly-export", []string{"exporter"}, nil)
+	//Act
+	_, err := f.service.AuthenticateAPIKey(context.Background(), created.ID+".not-the-secret")
+	_, errMalformed := f.service.AuthenticateAPIKey(context.Background(), "not-a-key")
+	//Assert
+	assert.Equal(t, auth.ErrInvalidAPIKey, err)
+	assert.Equal(t, auth.ErrInvalidAPIKey, errMalformed)
+}
+
+func Test_AuthenticateAPIKey_Expired_ReturnsInvalidAPIKey(t *testing.T) {
+	//Arrange
+	f := newAPIKeyFixture(t)
+	expiresAt := now.Add(time.Hour)
+	_, key, _ := f.service.CreateAPIKey(asAdmin(), "nightly-export", []string{"exporter"}, &expiresAt)
+	f.clock.now = expiresAt
+	//Act
+	_, err := f.service.AuthenticateAPIKey(context.Background(), key)
+	//Assert
+	assert.Equal(t, auth.ErrInvalidAPIKey, err)
+}
+
+func Test_RevokeAPIKey_KeyIsNoLongerAccepted(t *testing.T) {
+	//Arrange
+	f := newAPIKeyFixture(t)
+	created, key, _ := f.service.CreateAPIKey(asAdmin(), "nightly-export", []string{"exporter"}, nil)
+	//Act
+	err := f.service.RevokeAPIKey(asAdmin(), created.ID)
+	_, errAuth := f.service.AuthenticateAPIKey(context.Background(), key)
+	keys, _ := f.service.ListAPIKeys(asAdmin())
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, auth.ErrInvalidAPIKey, errAuth)
+	assert.Len(t, keys, 1)
+	assert.Equal(t, now, *keys[0].RevokedAt)
+}
+
+func Test_RevokeAPIKey_UnknownKey_ReturnsNotFound(t *testing.T) {
+	//Arrange
+	f := newAPIKeyFixture(t)
+	//Act
+	err := f.service.RevokeAPIKey(asAdmin(), "0123456789abcdef")
+	//Assert
+	assert.Equal(t, auth.ErrAPIKeyNotFound, err)
+}
+
+func Test_AuthenticateAPIKey_WithinTheUsageResolution_KeepsTheLastUsedTime(t *testing.T) {
+	//Arrange
+	f := newAPIKeyFixture(t)
+	_, key, _ := f.service.CreateAPIKey(asAdmin(), "nightly-export", []string{"exporter"}, nil)
+	f.service.AuthenticateAPIKey(context.Background(), key)
+	//Act
+	f.clock.now = now.Add(30 * time.Second)
+	within, _ := f.service.AuthenticateAPIKey(context.Background(), key)
+	f.clock.now = now.Add(time.Minute)
+	after, _ := f.service.AuthenticateAPIKey(context.Background(), key)
+	//Assert
+	assert.Equal(t, now, *within.LastUsedAt)
+	assert.Equal(t, now.Add(time.Minute), *after.LastUsedAt)
+}
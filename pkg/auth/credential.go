@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	//ErrInvalidCredentials - the email is unknown or the password does not match it
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	//ErrAccountLocked - too many failed logins, the account rejects logins until the lockout ends
+	ErrAccountLocked = errors.New("account locked")
+	//ErrAccountInactive - the credentials are right but the account is not active
+	ErrAccountInactive = errors.New("account not active")
+	//ErrInvalidRefreshToken - the refresh token is unknown, expired or revoked
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	//ErrWeakPassword - the password does not meet the password policy
+	ErrWeakPassword = errors.New("weak password")
+)
+
+//Credential - the password of a user and the state of its failed logins
+type Credential struct {
+	UserID       int
+	PasswordHash string
+	//Roles - the roles granted to the user, carried by its access tokens
+	Roles []string
+	//FailedAttempts - the failed logins since the last successful one or the last lockout
+	FailedAttempts int
+	//LockedUntil - logins are rejected until this time, zero when the account is not locked
+	LockedUntil time.Time
+	UpdatedAt   time.Time
+}
+
+//IsLocked - reports whether logins are rejected at the given time
+func (c Credential) IsLocked(at time.Time) bool {
+	return at.Before(c.LockedUntil)
+}
+
+//RefreshToken - a stored refresh token, only its hash is kept. Every token
+//belongs to the family started by a login, rotating a token keeps the family
+type RefreshToken struct {
+	Hash     string
+	UserID   int
+	FamilyID string
+	//Methods - the authentication methods of the login that started the family, the amr claim
+	Methods   []string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	//RevokedAt - when the token was rotated or revoked, nil while it can be used
+	RevokedAt *time.Time
+	//ReplacedBy - the hash of the token it was rotated to, empty when it was revoked
+	ReplacedBy string
+}
+
+//Store - the persistence of the credentials and of the refresh tokens
+type Store interface {
+	//GetCredential - retrieves the credential of a user, a zero credential when it has none
+	GetCredential(ctx context.Context, userID int) (Credential, error)
+	//SaveCredential - stores the credential of a user, replacing the previous one
+	SaveCredential(ctx context.Context, c Credential) error
+	//AddRefreshToken - stores a new refresh token
+	AddRefreshToken(ctx context.Context, t RefreshToken) error
+	//GetRefreshToken - retrieves the refresh token with the given hash, a zero token when there is none
+	GetRefreshToken(ctx context.Context, hash string) (RefreshToken, error)
+	//RevokeRefreshToken - revokes a token, replacedBy is the hash of the token it was rotated to.
+	//It returns ErrInvalidRefreshToken when the token was already revoked
+	RevokeRefreshToken(ctx context.Context, hash string, at time.Time, replacedBy string) error
+	//RevokeTokenFamily - revokes every token of a family that is not revoked yet
+	RevokeTokenFamily(ctx context.Context, familyID string, at time.Time) error
+	//RevokeUserTokens - revokes every token of a user that is not revoked yet
+	RevokeUserTokens(ctx context.Context, userID int, at time.Time) error
+}
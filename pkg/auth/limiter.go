@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+//Limit - at most Max requests for the same key in every Window, a zero Max does not limit
+type Limit struct {
+	Max    int
+	Window time.Duration
+}
+
+//windowLimiter - counts the requests of every key in fixed windows, the counts are kept in memory
+//so every instance of the service limits on its own
+type windowLimiter struct {
+	mtx       sync.Mutex
+	limit     Limit
+	clock     users.Clock
+	windows   map[string]window
+	lastSweep time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+func newWindowLimiter(limit Limit, clock users.Clock) *windowLimiter {
+	return &windowLimiter{limit: limit, clock: clock, windows: map[string]window{}}
+}
+
+//Allow - counts a request of the key and reports whether it is within the limit
+func (l *windowLimiter) Allow(key string) bool {
+
+	if l.limit.Max <= 0 {
+		return true
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := l.clock.Now()
+
+	if now.Sub(l.lastSweep) >= l.limit.Window {
+		for k, w := range l.windows {
+			if now.Sub(w.start) >= l.limit.Window {
+				delete(l.windows, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	w, ok := l.windows[key]
+
+	if !ok || now.Sub(w.start) >= l.limit.Window {
+		w = window{start: now}
+	}
+
+	w.count++
+	l.windows[key] = w
+
+	return w.count <= l.limit.Max
+}
@@ -0,0 +1,419 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+var (
+	//ErrMFADisabled - the service was built without WithMFA
+	ErrMFADisabled = errors.New("multi-factor authentication is not enabled")
+	//ErrMFAAlreadyEnabled - the user confirmed a second factor already, it must be disabled before enrolling again
+	ErrMFAAlreadyEnabled = errors.New("multi-factor authentication already enabled")
+	//ErrMFANotEnabled - the user has no confirmed second factor
+	ErrMFANotEnabled = errors.New("multi-factor authentication not enabled")
+	//ErrInvalidMFAToken - the MFA token of a login is unknown, expired or already used
+	ErrInvalidMFAToken = errors.New("invalid mfa token")
+	//ErrInvalidMFACode - the TOTP or recovery code does not match
+	ErrInvalidMFACode = errors.New("invalid mfa code")
+)
+
+const (
+	//MethodPassword - the amr value of a login with a password, RFC 8176
+	MethodPassword = "pwd"
+	//MethodOTP - the amr value of a login with a one-time code
+	MethodOTP = "otp"
+
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 10
+	mfaTokenBytes     = 32
+)
+
+//MFA - the second factor of a user, a TOTP secret and the hashes of the recovery codes not used yet
+type MFA struct {
+	UserID int
+	Secret string
+	//ConfirmedAt - when the user proved its authenticator has the secret, nil while enrolling
+	ConfirmedAt *time.Time
+	//LastStep - the TOTP step of the last code accepted, a code is not accepted twice
+	LastStep      int64
+	RecoveryCodes []string
+}
+
+//Enabled - reports whether logins of the user require the second factor
+func (m MFA) Enabled() bool {
+	return m.ConfirmedAt != nil
+}
+
+//MFAChallenge - a login whose password was right and that waits for the second factor
+type MFAChallenge struct {
+	TokenHash string
+	UserID    int
+	ExpiresAt time.Time
+}
+
+//Enrollment - the secret of a TOTP enrolment, to type it or to scan its URI in an authenticator app
+type Enrollment struct {
+	Secret string
+	URI    string
+}
+
+//MFAStore - the persistence of the second factors and of the logins waiting for them
+type MFAStore interface {
+	//GetMFA - retrieves the second factor of a user, a zero MFA when it has none
+	GetMFA(ctx context.Context, userID int) (MFA, error)
+	//SaveMFA - stores the second factor of a user, replacing the previous one
+	SaveMFA(ctx context.Context, m MFA) error
+	//DeleteMFA - removes the second factor of a user
+	DeleteMFA(ctx context.Context, userID int) error
+	//AddMFAChallenge - stores a login waiting for the second factor
+	AddMFAChallenge(ctx context.Context, c MFAChallenge) error
+	//GetMFAChallenge - retrieves the login with the given token hash, a zero challenge when there is none
+	GetMFAChallenge(ctx context.Context, tokenHash string) (MFAChallenge, error)
+	//DeleteMFAChallenge - removes the login with the given token hash,
+	//it returns ErrInvalidMFAToken when there is none so a token is only used once
+	DeleteMFAChallenge(ctx context.Context, tokenHash string) error
+}
+
+//MultiFactor - configures the second factor
+type MultiFactor struct {
+	//Store - where the second factors and the logins waiting for them are kept
+	Store MFAStore
+	//Issuer - the name authenticator apps show the codes under
+	Issuer string
+	//ChallengeTTL - how long a login waits for the second factor
+	ChallengeTTL time.Duration
+}
+
+//WithMFA - enables the TOTP second factor, the users that confirm one must give a code on every login
+func WithMFA(m MultiFactor) Option {
+	return func(s *Service) {
+		s.mfa = &m
+	}
+}
+
+//EnrollTOTP - generates a TOTP secret for the caller, it is not required on login until it is confirmed
+func (s *Service) EnrollTOTP(ctx context.Context, email string) (Enrollment, error) {
+
+	usr, m, err := s.ownMFA(ctx, email)
+
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	if m.Enabled() {
+		return Enrollment{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := newTOTPSecret()
+
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	if err := s.mfa.Store.SaveMFA(ctx, MFA{UserID: usr.ID, Secret: secret}); err != nil {
+		return Enrollment{}, err
+	}
+
+	return Enrollment{Secret: secret, URI: totpURI(s.mfa.Issuer, usr.Email, secret)}, nil
+}
+
+//ConfirmTOTP - checks a code of the enrolled secret and requires it from then on. It returns the recovery
+//codes, they are shown once and every one can replace a TOTP code once
+func (s *Service) ConfirmTOTP(ctx context.Context, email string, code string) ([]string, error) {
+
+	_, m, err := s.ownMFA(ctx, email)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Secret == "" {
+		return nil, ErrMFANotEnabled
+	}
+
+	if m.Enabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	now := s.clock.Now()
+
+	step, err := validateTOTP(m.Secret, code, now, m.LastStep)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if step == 0 {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.ConfirmedAt = &now
+	m.LastStep = step
+	m.RecoveryCodes = hashes
+
+	if err := s.mfa.Store.SaveMFA(ctx, m); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+//RegenerateRecoveryCodes - replaces the recovery codes of the caller, the previous ones can no longer be used
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, email string) ([]string, error) {
+
+	_, m, err := s.ownMFA(ctx, email)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.Enabled() {
+		return nil, ErrMFANotEnabled
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.RecoveryCodes = hashes
+
+	if err := s.mfa.Store.SaveMFA(ctx, m); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+//DisableMFA - removes the second factor of a user, only the user itself and administrators that gave a second
+//factor are allowed to
+func (s *Service) DisableMFA(ctx context.Context, email string) error {
+
+	if s.mfa == nil {
+		return ErrMFADisabled
+	}
+
+	if err := authorizeSelfOrAdmin(ctx, email); err != nil {
+		return err
+	}
+
+	usr, err := s.user(ctx, email)
+
+	if err != nil {
+		return err
+	}
+
+	return s.mfa.Store.DeleteMFA(ctx, usr.ID)
+}
+
+//VerifyMFA - completes a login waiting for the second factor with a TOTP code or a recovery code. Wrong codes
+//count towards the lockout as wrong passwords do
+func (s *Service) VerifyMFA(ctx context.Context, mfaToken string, code string) (Tokens, error) {
+
+	if s.mfa == nil {
+		return Tokens{}, ErrMFADisabled
+	}
+
+	challenge, err := s.mfa.Store.GetMFAChallenge(ctx, hashToken(mfaToken))
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	now := s.clock.Now()
+
+	if challenge.UserID == 0 || !now.Before(challenge.ExpiresAt) {
+		return Tokens{}, ErrInvalidMFAToken
+	}
+
+	usr, err := s.users.GetByID(ctx, challenge.UserID)
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if usr.ID == 0 || usr.IsDeleted() || usr.Status != users.AccountActive {
+		return Tokens{}, ErrAccountInactive
+	}
+
+	cred, err := s.store.GetCredential(ctx, usr.ID)
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if cred.IsLocked(now) {
+		return Tokens{}, ErrAccountLocked
+	}
+
+	m, err := s.mfa.Store.GetMFA(ctx, usr.ID)
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if !m.Enabled() {
+		return Tokens{}, ErrInvalidMFAToken
+	}
+
+	valid, err := m.use(code, now)
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if !valid {
+		if err := s.recordFailure(ctx, cred, now); err != nil {
+			return Tokens{}, err
+		}
+		return Tokens{}, ErrInvalidMFACode
+	}
+
+	familyID, err := randomToken(tokenIDBytes)
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	var tokens Tokens
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.mfa.Store.DeleteMFAChallenge(ctx, challenge.TokenHash); err != nil {
+			return err
+		}
+
+		if err := s.mfa.Store.SaveMFA(ctx, m); err != nil {
+			return err
+		}
+
+		if cred.FailedAttempts > 0 {
+			cred.FailedAttempts = 0
+			if err := s.store.SaveCredential(ctx, cred); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		tokens, _, err = s.issue(ctx, usr, cred.Roles, []string{MethodPassword, MethodOTP, users.MFAMethod}, familyID, now)
+		return err
+	})
+
+	return tokens, err
+}
+
+//challenge - stores a login waiting for the second factor and returns the token that completes it
+func (s *Service) challenge(ctx context.Context, userID int, now time.Time) (Tokens, error) {
+
+	token, err := randomToken(mfaTokenBytes)
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	err = s.mfa.Store.AddMFAChallenge(ctx, MFAChallenge{
+		TokenHash: hashToken(token),
+		UserID:    userID,
+		ExpiresAt: now.Add(s.mfa.ChallengeTTL),
+	})
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	return Tokens{MFAToken: token}, nil
+}
+
+//ownMFA - retrieves the caller and its second factor, only the user itself enrols its authenticator
+func (s *Service) ownMFA(ctx context.Context, email string) (users.User, MFA, error) {
+
+	if s.mfa == nil {
+		return users.User{}, MFA{}, ErrMFADisabled
+	}
+
+	if caller, ok := users.CallerFromContext(ctx); !ok || caller.Subject != email {
+		return users.User{}, MFA{}, users.UserError(users.ERRFORBIDDEN)
+	}
+
+	usr, err := s.user(ctx, email)
+
+	if err != nil {
+		return users.User{}, MFA{}, err
+	}
+
+	m, err := s.mfa.Store.GetMFA(ctx, usr.ID)
+
+	if err != nil {
+		return users.User{}, MFA{}, err
+	}
+
+	m.UserID = usr.ID
+
+	return usr, m, nil
+}
+
+//use - checks a TOTP code or a recovery code and spends it, the caller stores the MFA when it is valid
+func (m *MFA) use(code string, at time.Time) (bool, error) {
+
+	code = strings.TrimSpace(code)
+
+	if len(code) == totpDigits {
+		step, err := validateTOTP(m.Secret, code, at, m.LastStep)
+		if err != nil || step == 0 {
+			return false, err
+		}
+		m.LastStep = step
+		return true, nil
+	}
+
+	hash := hashToken(normalizeRecoveryCode(code))
+
+	for i, stored := range m.RecoveryCodes {
+		if stored == hash {
+			m.RecoveryCodes = append(m.RecoveryCodes[:i:i], m.RecoveryCodes[i+1:]...)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+//newRecoveryCodes - returns the recovery codes, formatted as xxxx-xxxx-xxxx-xxxx, and their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, recoveryCodeBytes)
+
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+
+		encoded := strings.ToLower(totpEncoding.EncodeToString(raw))
+		code := encoded[0:4] + "-" + encoded[4:8] + "-" + encoded[8:12] + "-" + encoded[12:16]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+
+	return codes, hashes, nil
+}
+
+//normalizeRecoveryCode - the codes are accepted in any case and with or without the dashes
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
@@ -0,0 +1,192 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/auth"
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+//totpAt - the code an authenticator app shows for the secret at the given time, RFC 6238 with SHA1
+func totpAt(secret string, at time.Time) string {
+	key, _ := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func asSelf() context.Context {
+	return users.WithCaller(context.Background(), users.Caller{Subject: email})
+}
+
+//newMFAServiceFixture - a fixture whose service supports TOTP, the user has not enrolled yet
+func newMFAServiceFixture(t *testing.T) fixture {
+	store := memory.NewInMemoryAuthRepository()
+	return newFixtureWithStore(t, store, auth.WithMFA(auth.MultiFactor{Store: store, Issuer: "users", ChallengeTTL: 5 * time.Minute}))
+}
+
+//newMFAFixture - a fixture whose user confirmed a TOTP secret, it returns the secret and the recovery codes
+func newMFAFixture(t *testing.T) (fixture, string, []string) {
+	f := newMFAServiceFixture(t)
+
+	enrollment, err := f.service.EnrollTOTP(asSelf(), email)
+	assert.Nil(t, err)
+
+	codes, err := f.service.ConfirmTOTP(asSelf(), email, totpAt(enrollment.Secret, f.clock.now))
+	assert.Nil(t, err)
+
+	return f, enrollment.Secret, codes
+}
+
+func Test_EnrollTOTP_ReturnsTheOtpauthURI(t *testing.T) {
+	//Arrange
+	f := newMFAServiceFixture(t)
+	//Act
+	enrollment, err := f.service.EnrollTOTP(asSelf(), email)
+	//Assert
+	assert.Nil(t, err)
+	assert.Len(t, enrollment.Secret, 32)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/users:jane@example.com?")
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+}
+
+func Test_EnrollTOTP_AnotherUser_ReturnsForbiddenError(t *testing.T) {
+	//Arrange
+	f := newMFAServiceFixture(t)
+	//Act
+	_, err := f.service.EnrollTOTP(asAdmin(), email)
+	//Assert
+	assert.True(t, users.IsUserErrorType(users.ERRFORBIDDEN, err))
+}
+
+func Test_ConfirmTOTP_WrongCode_KeepsMFADisabled(t *testing.T) {
+	//Arrange
+	f := newMFAServiceFixture(t)
+	f.service.EnrollTOTP(asSelf(), email)
+	//Act
+	_, err := f.service.ConfirmTOTP(asSelf(), email, "000000")
+	tokens, errLogin := f.service.Login(context.Background(), email, password)
+	//Assert
+	assert.Equal(t, auth.ErrInvalidMFACode, err)
+	assert.Nil(t, errLogin)
+	assert.NotEmpty(t, tokens.AccessToken)
+}
+
+func Test_Login_WithMFA_RequiresTheSecondFactor(t *testing.T) {
+	//Arrange
+	f, secret, codes := newMFAFixture(t)
+	f.clock.now = f.clock.now.Add(30 * time.Second)
+	//Act
+	challenge, err := f.service.Login(context.Background(), email, password)
+	tokens, errVerify := f.service.VerifyMFA(context.Background(), challenge.MFAToken, totpAt(secret, f.clock.now))
+	//Assert
+	assert.Nil(t, err)
+	assert.Len(t, codes, 10)
+	assert.Empty(t, challenge.AccessToken)
+	assert.NotEmpty(t, challenge.MFAToken)
+	assert.Nil(t, errVerify)
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	parsed, err := parser.Parse(tokens.AccessToken, func(*jwt.Token) (interface{}, error) { return &signingKey.PublicKey, nil })
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{auth.MethodPassword, auth.MethodOTP, users.MFAMethod}, parsed.Claims.(jwt.MapClaims)["amr"])
+}
+
+func Test_VerifyMFA_ReplayedCode_IsRejected(t *testing.T) {
+	//Arrange
+	f, secret, _ := newMFAFixture(t)
+	f.clock.now = f.clock.now.Add(30 * time.Second)
+	first, _ := f.service.Login(context.Background(), email, password)
+	f.service.VerifyMFA(context.Background(), first.MFAToken, totpAt(secret, f.clock.now))
+	second, _ := f.service.Login(context.Background(), email, password)
+	//Act
+	_, err := f.service.VerifyMFA(context.Background(), second.MFAToken, totpAt(secret, f.clock.now))
+	//Assert
+	assert.Equal(t, auth.ErrInvalidMFACode, err)
+}
+
+func Test_VerifyMFA_RecoveryCode_CanBeUsedOnce(t *testing.T) {
+	//Arrange
+	f, _, codes := newMFAFixture(t)
+	first, _ := f.service.Login(context.Background(), email, password)
+	second, _ := f.service.Login(context.Background(), email, password)
+	//Act
+	_, err := f.service.VerifyMFA(context.Background(), first.MFAToken, codes[0])
+	_, errReused := f.service.VerifyMFA(context.Background(), second.MFAToken, codes[0])
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, auth.ErrInvalidMFACode, errReused)
+}
+
+func Test_VerifyMFA_UsedToken_IsRejected(t *testing.T) {
+	//Arrange
+	f, _, codes := newMFAFixture(t)
+	challenge, _ := f.service.Login(context.Background(), email, password)
+	f.service.VerifyMFA(context.Background(), challenge.MFAToken, codes[0])
+	//Act
+	_, err := f.service.VerifyMFA(context.Background(), challenge.MFAToken, codes[1])
+	//Assert
+	assert.Equal(t, auth.ErrInvalidMFAToken, err)
+}
+
+func Test_VerifyMFA_ExpiredToken_IsRejected(t *testing.T) {
+	//Arrange
+	f, _, codes := newMFAFixture(t)
+	challenge, _ := f.service.Login(context.Background(), email, password)
+	f.clock.now = f.clock.now.Add(5 * time.Minute)
+	//Act
+	_, err := f.service.VerifyMFA(context.Background(), challenge.MFAToken, codes[0])
+	//Assert
+	assert.Equal(t, auth.ErrInvalidMFAToken, err)
+}
+
+func Test_VerifyMFA_RepeatedWrongCodes_LockTheAccount(t *testing.T) {
+	//Arrange
+	f, _, codes := newMFAFixture(t)
+	challenge, _ := f.service.Login(context.Background(), email, password)
+	//Act
+	for i := 0; i < 3; i++ {
+		_, err := f.service.VerifyMFA(context.Background(), challenge.MFAToken, "000000")
+		assert.Equal(t, auth.ErrInvalidMFACode, err)
+	}
+	_, err := f.service.VerifyMFA(context.Background(), challenge.MFAToken, codes[0])
+	//Assert
+	assert.Equal(t, auth.ErrAccountLocked, err)
+}
+
+func Test_DisableMFA_Admin_LoginIssuesTokensAgain(t *testing.T) {
+	//Arrange
+	f, _, _ := newMFAFixture(t)
+	//Act
+	err := f.service.DisableMFA(asAdmin(), email)
+	tokens, errLogin := f.service.Login(context.Background(), email, password)
+	//Assert
+	assert.Nil(t, err)
+	assert.Nil(t, errLogin)
+	assert.NotEmpty(t, tokens.AccessToken)
+}
+
+func Test_DisableMFA_AdminWithoutSecondFactor_ReturnsForbiddenError(t *testing.T) {
+	//Arrange
+	f, _, _ := newMFAFixture(t)
+	//Act
+	err := f.service.DisableMFA(asAdminWithoutSecondFactor(), email)
+	tokens, _ := f.service.Login(context.Background(), email, password)
+	//Assert
+	assert.True(t, users.IsUserErrorType(users.ERRFORBIDDEN, err))
+	assert.Empty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.MFAToken)
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//ErrUnknownHash - the stored hash was not produced by any supported algorithm
+var ErrUnknownHash = errors.New("unknown password hash")
+
+const argon2idPrefix = "$argon2id$"
+
+//Hasher - hashes the passwords with one algorithm
+type Hasher interface {
+	//Hash - returns the encoded hash of the password, it carries the salt and the parameters
+	Hash(password string) (string, error)
+	//Current - reports whether the hash was produced by this hasher with its current parameters
+	Current(hash string) bool
+}
+
+//Argon2id - hashes with argon2id, the encoded hashes follow the PHC string format
+type Argon2id struct {
+	//Memory - the memory used in KiB
+	Memory uint32
+	//Iterations - the passes over the memory
+	Iterations uint32
+	//Parallelism - the threads used
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+//DefaultArgon2id - the parameters recommended by RFC 9106 for memory constrained environments
+var DefaultArgon2id = Argon2id{Memory: 64 * 1024, Iterations: 3, Parallelism: 4, SaltLength: 16, KeyLength: 32}
+
+//Hash - returns $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+func (a Argon2id) Hash(password string) (string, error) {
+
+	salt := make([]byte, a.SaltLength)
+
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+//Current - reports whether the hash is an argon2id hash with the same parameters
+func (a Argon2id) Current(hash string) bool {
+	params, _, key, err := decodeArgon2id(hash)
+	return err == nil && params.Memory == a.Memory && params.Iterations == a.Iterations &&
+		params.Parallelism == a.Parallelism && uint32(len(key)) == a.KeyLength
+}
+
+//Bcrypt - hashes with bcrypt, passwords longer than 72 bytes are rejected by the algorithm
+type Bcrypt struct {
+	Cost int
+}
+
+//DefaultBcrypt - the default cost of the bcrypt package
+var DefaultBcrypt = Bcrypt{Cost: bcrypt.DefaultCost}
+
+//Hash - returns the modular crypt format of bcrypt
+func (b Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	return string(hash), err
+}
+
+//Current - reports whether the hash is a bcrypt hash with the same cost
+func (b Bcrypt) Current(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost == b.Cost
+}
+
+//VerifyPassword - reports whether the password matches the hash, whichever supported algorithm produced it
+func VerifyPassword(hash string, password string) (bool, error) {
+
+	if strings.HasPrefix(hash, argon2idPrefix) {
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false, err
+		}
+		other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(key, other) == 1, nil
+	}
+
+	if _, err := bcrypt.Cost([]byte(hash)); err == nil {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	return false, ErrUnknownHash
+}
+
+//decodeArgon2id - reads the parameters, the salt and the key of an encoded argon2id hash
+func decodeArgon2id(hash string) (Argon2id, []byte, []byte, error) {
+
+	parts := strings.Split(hash, "$")
+
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2id{}, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2id{}, nil, nil, ErrUnknownHash
+	}
+
+	var params Argon2id
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2id{}, nil, nil, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+
+	if err != nil {
+		return Argon2id{}, nil, nil, ErrUnknownHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+
+	if err != nil {
+		return Argon2id{}, nil, nil, ErrUnknownHash
+	}
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,67 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/casmelad/GlobantPOC/pkg/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCases_VerifyPassword(t *testing.T) {
+	testCases := []struct {
+		name   string
+		hasher auth.Hasher
+	}{
+		{"argon2id", fastArgon2id},
+		{"bcrypt", auth.Bcrypt{Cost: 4}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Arrange
+			hash, err := tc.hasher.Hash(password)
+			//Act
+			valid, errValid := auth.VerifyPassword(hash, password)
+			invalid, errInvalid := auth.VerifyPassword(hash, "Wrong-Horse-42")
+			//Assert
+			assert.Nil(t, err)
+			assert.True(t, tc.hasher.Current(hash))
+			assert.True(t, valid)
+			assert.Nil(t, errValid)
+			assert.False(t, invalid)
+			assert.Nil(t, errInvalid)
+		})
+	}
+}
+
+func Test_VerifyPassword_UnknownHash_ReturnsError(t *testing.T) {
+	//Act
+	_, err := auth.VerifyPassword("plain text", password)
+	//Assert
+	assert.Equal(t, auth.ErrUnknownHash, err)
+}
+
+func TestCases_PasswordPolicy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{"mixes three classes", "Correct-horse", true},
+		{"too short", "Short-1", false},
+		{"too few classes", "correcthorsebattery", false},
+		{"contains the email", "Jane-Correct-42", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Act
+			err := auth.DefaultPolicy.Check(tc.password, email)
+			//Assert
+			assert.Equal(t, tc.valid, err == nil)
+			if !tc.valid {
+				assert.ErrorIs(t, err, auth.ErrWeakPassword)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+//PasswordPolicy - the rules a new password must follow
+type PasswordPolicy struct {
+	MinLength int
+	//MaxLength - bounds the work of hashing, bcrypt only reads the first 72 bytes
+	MaxLength int
+	//MinClasses - how many of lower case letters, upper case letters, digits and symbols the password mixes
+	MinClasses int
+}
+
+//DefaultPolicy - the policy used when none is configured
+var DefaultPolicy = PasswordPolicy{MinLength: 12, MaxLength: 72, MinClasses: 3}
+
+//Check - returns an error wrapping ErrWeakPassword that tells the first rule the password breaks
+func (p PasswordPolicy) Check(password string, email string) error {
+
+	length := len([]rune(password))
+
+	if length < p.MinLength {
+		return fmt.Errorf("%w: it must have at least %d characters", ErrWeakPassword, p.MinLength)
+	}
+
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		return fmt.Errorf("%w: it must have at most %d bytes", ErrWeakPassword, p.MaxLength)
+	}
+
+	if classes(password) < p.MinClasses {
+		return fmt.Errorf("%w: it must mix %d of lower case, upper case, digits and symbols", ErrWeakPassword, p.MinClasses)
+	}
+
+	if local := strings.SplitN(email, "@", 2)[0]; len(local) >= 3 && strings.Contains(strings.ToLower(password), strings.ToLower(local)) {
+		return fmt.Errorf("%w: it must not contain the email", ErrWeakPassword)
+	}
+
+	return nil
+}
+
+func classes(password string) int {
+
+	var lower, upper, digit, symbol int
+
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+
+	return lower + upper + digit + symbol
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+var (
+	//ErrInvalidResetToken - the password reset token is unknown, expired or already used
+	ErrInvalidResetToken = errors.New("invalid password reset token")
+	//ErrTooManyRequests - the rate limit of the email or of the client address was exceeded
+	ErrTooManyRequests = errors.New("too many requests")
+	//ErrPasswordResetDisabled - the service was built without WithPasswordReset
+	ErrPasswordResetDisabled = errors.New("password reset is not enabled")
+)
+
+const resetTokenBytes = 32
+
+//PasswordResetRequest - a requested password reset waiting for its token to be used, only the hash of the token is kept
+type PasswordResetRequest struct {
+	UserID      int
+	TokenHash   string
+	RequestedAt time.Time
+	ExpiresAt   time.Time
+}
+
+//ResetStore - the persistence of the password reset requests
+type ResetStore interface {
+	//SavePasswordReset - stores the reset request of a user, replacing the previous one
+	SavePasswordReset(ctx context.Context, r PasswordResetRequest) error
+	//GetPasswordReset - retrieves the reset request with the given token hash, a zero request when there is none
+	GetPasswordReset(ctx context.Context, tokenHash string) (PasswordResetRequest, error)
+	//DeletePasswordReset - removes the reset request with the given token hash,
+	//it returns ErrInvalidResetToken when there is none so a token is only used once
+	DeletePasswordReset(ctx context.Context, tokenHash string) error
+}
+
+//PasswordReset - configures the password reset flow
+type PasswordReset struct {
+	//Store - where the reset requests are kept
+	Store ResetStore
+	//Notifier - delivers the reset token to the email of the user
+	Notifier users.Notifier
+	//TokenTTL - how long a reset token can be used
+	TokenTTL time.Duration
+	//PerEmail - how many resets can be requested for the same email
+	PerEmail Limit
+	//PerAddress - how many resets can be requested from the same client address
+	PerAddress Limit
+	//QueueSize - how many requested resets can wait for DeliverPasswordReset, 100 when it is not set. The requests
+	//made while it is full are dropped
+	QueueSize int
+}
+
+//WithPasswordReset - enables the password reset flow
+func WithPasswordReset(r PasswordReset) Option {
+	return func(s *Service) {
+		s.reset = &r
+	}
+}
+
+//RequestPasswordReset - queues a reset token for the email of an active user. The email is only looked up
+//by DeliverPasswordReset, so the request takes the same time whether the email belongs to a user or not and
+//only the rate limits of the email and of the client address are told back
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+
+	if s.reset == nil {
+		return ErrPasswordResetDisabled
+	}
+
+	if address := users.ClientAddressFromContext(ctx); address != "" && !s.resetsPerAddress.Allow(address) {
+		return ErrTooManyRequests
+	}
+
+	if !s.resetsPerEmail.Allow(strings.ToLower(email)) {
+		return ErrTooManyRequests
+	}
+
+	//A full queue is not told back: it is shared by every caller, one client filling it would deny the resets of
+	//all the others. The request is dropped as if it was queued, the user can ask again.
+	select {
+	case s.resetQueue <- email:
+	default:
+	}
+
+	return nil
+}
+
+//DeliverPasswordReset - waits for the next queued reset and sends its token when the email belongs to an
+//active user, it returns the error of the context once it is done
+func (s *Service) DeliverPasswordReset(ctx context.Context) error {
+
+	if s.reset == nil {
+		return ErrPasswordResetDisabled
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case email := <-s.resetQueue:
+		return s.sendPasswordReset(ctx, email)
+	}
+}
+
+//sendPasswordReset - stores a new reset request of the user with the email and sends its token
+func (s *Service) sendPasswordReset(ctx context.Context, email string) error {
+
+	usr, err := s.users.GetByEmail(ctx, email)
+
+	if err != nil {
+		return err
+	}
+
+	if usr.ID == 0 || usr.IsDeleted() || usr.Status != users.AccountActive {
+		return nil
+	}
+
+	token, err := randomToken(resetTokenBytes)
+
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+
+	request := PasswordResetRequest{
+		UserID:      usr.ID,
+		TokenHash:   hashToken(token),
+		RequestedAt: now,
+		ExpiresAt:   now.Add(s.reset.TokenTTL),
+	}
+
+	if err := s.reset.Store.SavePasswordReset(ctx, request); err != nil {
+		return err
+	}
+
+	return s.reset.Notifier.Notify(ctx, users.Notification{
+		To:      usr.Email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Use this token to choose a new password, it expires at %s: %s", request.ExpiresAt.Format(time.RFC3339), token),
+	})
+}
+
+//ConfirmPasswordReset - replaces the password of the user a reset token was sent to. The token is used once,
+//the lockout is lifted and the refresh tokens issued with the previous password are revoked
+func (s *Service) ConfirmPasswordReset(ctx context.Context, token string, password string) error {
+
+	if s.reset == nil {
+		return ErrPasswordResetDisabled
+	}
+
+	request, err := s.reset.Store.GetPasswordReset(ctx, hashToken(token))
+
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+
+	if request.UserID == 0 || !now.Before(request.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	usr, err := s.users.GetByID(ctx, request.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	if usr.ID == 0 || usr.IsDeleted() {
+		return ErrInvalidResetToken
+	}
+
+	//A rejected password does not use the token, the user can try another one.
+	if err := s.policy.Check(password, usr.Email); err != nil {
+		return err
+	}
+
+	hash, err := s.hasher.Hash(password)
+
+	if err != nil {
+		return err
+	}
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.reset.Store.DeletePasswordReset(ctx, request.TokenHash); err != nil {
+			return err
+		}
+
+		cred, err := s.store.GetCredential(ctx, usr.ID)
+
+		if err != nil {
+			return err
+		}
+
+		cred.UserID = usr.ID
+		cred.PasswordHash = hash
+		cred.FailedAttempts = 0
+		cred.LockedUntil = time.Time{}
+		cred.UpdatedAt = now
+
+		if err := s.store.SaveCredential(ctx, cred); err != nil {
+			return err
+		}
+
+		return s.store.RevokeUserTokens(ctx, usr.ID, now)
+	})
+}
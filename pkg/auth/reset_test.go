@@ -0,0 +1,198 @@
+package auth_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/auth"
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/stretchr/testify/assert"
+)
+
+//inbox - a notifier that keeps the notifications so the tests can read the tokens
+type inbox struct {
+	notifications []users.Notification
+}
+
+func (i *inbox) Notify(ctx context.Context, n users.Notification) error {
+	i.notifications = append(i.notifications, n)
+	return nil
+}
+
+//token - the token sent in the last notification, it is the last word of the body
+func (i *inbox) token() string {
+	if len(i.notifications) == 0 {
+		return ""
+	}
+	words := strings.Fields(i.notifications[len(i.notifications)-1].Body)
+	return words[len(words)-1]
+}
+
+func newResetFixture(t *testing.T) (fixture, *inbox) {
+	notifications := &inbox{}
+	store := memory.NewInMemoryAuthRepository()
+	f := newFixtureWithStore(t, store, auth.WithPasswordReset(auth.PasswordReset{
+		Store:      store,
+		Notifier:   notifications,
+		TokenTTL:   time.Hour,
+		PerEmail:   auth.Limit{Max: 2, Window: time.Hour},
+		PerAddress: auth.Limit{Max: 3, Window: time.Hour},
+	}))
+	return f, notifications
+}
+
+func Test_PasswordReset_ConfirmedToken_ReplacesThePassword(t *testing.T) {
+	//Arrange
+	f, notifications := newResetFixture(t)
+	ctx := context.Background()
+	tokens, _ := f.service.Login(ctx, email, password)
+	//Act
+	errRequest := f.service.RequestPasswordReset(ctx, email)
+	errDeliver := f.service.DeliverPasswordReset(ctx)
+	errConfirm := f.service.ConfirmPasswordReset(ctx, notifications.token(), "Another-Horse-43")
+	//Assert
+	assert.Nil(t, errRequest)
+	assert.Nil(t, errDeliver)
+	assert.Nil(t, errConfirm)
+	assert.Equal(t, email, notifications.notifications[0].To)
+	_, errOld := f.service.Login(ctx, email, password)
+	_, errNew := f.service.Login(ctx, email, "Another-Horse-43")
+	_, errRefresh := f.service.Refresh(ctx, tokens.RefreshToken)
+	assert.Equal(t, auth.ErrInvalidCredentials, errOld)
+	assert.Nil(t, errNew)
+	assert.Equal(t, auth.ErrInvalidRefreshToken, errRefresh)
+}
+
+func Test_PasswordReset_TokenIsSingleUse(t *testing.T) {
+	//Arrange
+	f, notifications := newResetFixture(t)
+	ctx := context.Background()
+	f.service.RequestPasswordReset(ctx, email)
+	f.service.DeliverPasswordReset(ctx)
+	token := notifications.token()
+	f.service.ConfirmPasswordReset(ctx, token, "Another-Horse-43")
+	//Act
+	err := f.service.ConfirmPasswordReset(ctx, token, "Third-Horse-44")
+	//Assert
+	assert.Equal(t, auth.ErrInvalidResetToken, err)
+}
+
+func Test_PasswordReset_ExpiredToken_ReturnsInvalidToken(t *testing.T) {
+	//Arrange
+	f, notifications := newResetFixture(t)
+	ctx := context.Background()
+	f.service.RequestPasswordReset(ctx, email)
+	f.service.DeliverPasswordReset(ctx)
+	f.clock.now = now.Add(time.Hour)
+	//Act
+	err := f.service.ConfirmPasswordReset(ctx, notifications.token(), "Another-Horse-43")
+	//Assert
+	assert.Equal(t, auth.ErrInvalidResetToken, err)
+}
+
+func Test_PasswordReset_WeakPassword_KeepsTheToken(t *testing.T) {
+	//Arrange
+	f, notifications := newResetFixture(t)
+	ctx := context.Background()
+	f.service.RequestPasswordReset(ctx, email)
+	f.service.DeliverPasswordReset(ctx)
+	//Act
+	errWeak := f.service.ConfirmPasswordReset(ctx, notifications.token(), "short")
+	errStrong := f.service.ConfirmPasswordReset(ctx, notifications.token(), "Another-Horse-43")
+	//Assert
+	assert.ErrorIs(t, errWeak, auth.ErrWeakPassword)
+	assert.Nil(t, errStrong)
+}
+
+func Test_PasswordReset_UnknownEmail_LooksTheSame(t *testing.T) {
+	//Arrange
+	f, notifications := newResetFixture(t)
+	ctx := context.Background()
+	//Act
+	errRequest := f.service.RequestPasswordReset(ctx, "nobody@example.com")
+	errDeliver := f.service.DeliverPasswordReset(ctx)
+	//Assert
+	assert.Nil(t, errRequest)
+	assert.Nil(t, errDeliver)
+	assert.Empty(t, notifications.notifications)
+}
+
+func Test_PasswordReset_Request_DoesNotWaitForTheNotifier(t *testing.T) {
+	//Arrange
+	f, notifications := newResetFixture(t)
+	ctx := context.Background()
+	//Act
+	err := f.service.RequestPasswordReset(ctx, email)
+	//Assert
+	assert.Nil(t, err)
+	assert.Empty(t, notifications.notifications)
+	assert.Nil(t, f.service.DeliverPasswordReset(ctx))
+	assert.Equal(t, email, notifications.notifications[0].To)
+}
+
+func Test_PasswordReset_DeliverWithoutRequests_ReturnsWhenTheContextIsDone(t *testing.T) {
+	//Arrange
+	f, _ := newResetFixture(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	//Act
+	err := f.service.DeliverPasswordReset(ctx)
+	//Assert
+	assert.Equal(t, context.Canceled, err)
+}
+
+func Test_PasswordReset_RateLimitedPerEmail(t *testing.T) {
+	//Arrange
+	f, _ := newResetFixture(t)
+	ctx := context.Background()
+	//Act
+	f.service.RequestPasswordReset(ctx, email)
+	f.service.RequestPasswordReset(ctx, email)
+	errLimited := f.service.RequestPasswordReset(ctx, strings.ToUpper(email))
+	f.clock.now = now.Add(time.Hour)
+	errAfter := f.service.RequestPasswordReset(ctx, email)
+	//Assert
+	assert.Equal(t, auth.ErrTooManyRequests, errLimited)
+	assert.Nil(t, errAfter)
+}
+
+func Test_PasswordReset_RateLimitedPerAddress(t *testing.T) {
+	//Arrange
+	f, _ := newResetFixture(t)
+	ctx := users.WithClientAddress(context.Background(), "192.0.2.1")
+	other := users.WithClientAddress(context.Background(), "192.0.2.2")
+	//Act
+	for _, e := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		assert.Nil(t, f.service.RequestPasswordReset(ctx, e))
+	}
+	errLimited := f.service.RequestPasswordReset(ctx, "d@example.com")
+	errOther := f.service.RequestPasswordReset(other, "d@example.com")
+	//Assert
+	assert.Equal(t, auth.ErrTooManyRequests, errLimited)
+	assert.Nil(t, errOther)
+}
+
+func Test_PasswordReset_QueueFull_DropsTheRequestWithoutError(t *testing.T) {
+	//Arrange
+	notifications := &inbox{}
+	store := memory.NewInMemoryAuthRepository()
+	f := newFixtureWithStore(t, store, auth.WithPasswordReset(auth.PasswordReset{
+		Store:     store,
+		Notifier:  notifications,
+		TokenTTL:  time.Hour,
+		QueueSize: 1,
+	}))
+	ctx := context.Background()
+	//Act
+	errQueued := f.service.RequestPasswordReset(ctx, email)
+	errDropped := f.service.RequestPasswordReset(ctx, "nobody@example.com")
+	errDeliver := f.service.DeliverPasswordReset(ctx)
+	//Assert
+	assert.Nil(t, errQueued)
+	assert.Nil(t, errDropped)
+	assert.Nil(t, errDeliver)
+	assert.Len(t, notifications.notifications, 1)
+}
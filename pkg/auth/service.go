@@ -0,0 +1,496 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+//Lockout - how many consecutive failed logins lock an account and for how long
+type Lockout struct {
+	MaxFailures int
+	Duration    time.Duration
+}
+
+//DefaultLockout - the lockout used when none is configured
+var DefaultLockout = Lockout{MaxFailures: 5, Duration: 15 * time.Minute}
+
+//Service - checks the passwords of the users and issues their tokens
+type Service struct {
+	users      users.Repository
+	store      Store
+	issuer     *Issuer
+	clock      users.Clock
+	transactor users.Transactor
+	hasher     Hasher
+	policy     PasswordPolicy
+	lockout    Lockout
+	refreshTTL time.Duration
+	reset      *PasswordReset
+	mfa        *MultiFactor
+	apiKeys    *APIKeys
+	//resetsPerEmail and resetsPerAddress - the rate limits of the password reset requests
+	resetsPerEmail   *windowLimiter
+	resetsPerAddress *windowLimiter
+	//resetQueue - the emails of the requested resets waiting for DeliverPasswordReset
+	resetQueue chan string
+	//dummyHash - verified when the user has no credential, so unknown emails take as long as wrong passwords
+	dummyHash string
+	//bootstrapAdmins - the emails of the users that are administrators whatever their stored roles
+	bootstrapAdmins map[string]bool
+}
+
+//Option - configures a Service
+type Option func(*Service)
+
+//WithTransactor - rotates the refresh tokens in a single transaction
+func WithTransactor(t users.Transactor) Option {
+	return func(s *Service) {
+		s.transactor = t
+	}
+}
+
+//WithHasher - hashes the new passwords with h, the stored hashes of other algorithms are still verified
+//and replaced on the next successful login
+func WithHasher(h Hasher) Option {
+	return func(s *Service) {
+		s.hasher = h
+	}
+}
+
+//WithPolicy - enforces p on the new passwords
+func WithPolicy(p PasswordPolicy) Option {
+	return func(s *Service) {
+		s.policy = p
+	}
+}
+
+//WithLockout - locks the accounts after l.MaxFailures consecutive failed logins
+func WithLockout(l Lockout) Option {
+	return func(s *Service) {
+		s.lockout = l
+	}
+}
+
+//WithRefreshTTL - the refresh tokens expire after ttl
+func WithRefreshTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.refreshTTL = ttl
+	}
+}
+
+//WithBootstrapAdmins - the users with these emails get the admin role on top of their stored roles, so a new
+//deployment has an administrator to grant the roles of the others
+func WithBootstrapAdmins(emails ...string) Option {
+	return func(s *Service) {
+		s.bootstrapAdmins = map[string]bool{}
+		for _, email := range emails {
+			if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+				s.bootstrapAdmins[email] = true
+			}
+		}
+	}
+}
+
+//NewService - returns a Service type pointer
+func NewService(repo users.Repository, store Store, issuer *Issuer, clock users.Clock, opts ...Option) (*Service, error) {
+
+	s := &Service{
+		users:      repo,
+		store:      store,
+		issuer:     issuer,
+		clock:      clock,
+		transactor: noTransaction{},
+		hasher:     DefaultArgon2id,
+		policy:     DefaultPolicy,
+		lockout:    DefaultLockout,
+		refreshTTL: 30 * 24 * time.Hour,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.reset != nil {
+		s.resetsPerEmail = newWindowLimiter(s.reset.PerEmail, clock)
+		s.resetsPerAddress = newWindowLimiter(s.reset.PerAddress, clock)
+		if s.reset.QueueSize <= 0 {
+			s.reset.QueueSize = 100
+		}
+		s.resetQueue = make(chan string, s.reset.QueueSize)
+	}
+
+	dummy, err := s.hasher.Hash("not a password of anyone")
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.dummyHash = dummy
+
+	return s, nil
+}
+
+//SetPassword - replaces the password of a user, only the user itself and administrators that gave a second factor
+//are allowed to. The refresh tokens issued with the previous password are revoked
+func (s *Service) SetPassword(ctx context.Context, email string, password string) error {
+
+	if err := authorizeSelfOrAdmin(ctx, email); err != nil {
+		return err
+	}
+
+	usr, err := s.user(ctx, email)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.policy.Check(password, usr.Email); err != nil {
+		return err
+	}
+
+	hash, err := s.hasher.Hash(password)
+
+	if err != nil {
+		return err
+	}
+
+	cred, err := s.store.GetCredential(ctx, usr.ID)
+
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+
+	cred.UserID = usr.ID
+	cred.PasswordHash = hash
+	cred.FailedAttempts = 0
+	cred.LockedUntil = time.Time{}
+	cred.UpdatedAt = now
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.store.SaveCredential(ctx, cred); err != nil {
+			return err
+		}
+
+		return s.store.RevokeUserTokens(ctx, usr.ID, now)
+	})
+}
+
+//SetRoles - replaces the roles carried by the access tokens of a user, only administrators that gave a second
+//factor are allowed to. The tokens already issued keep the previous roles until they expire
+func (s *Service) SetRoles(ctx context.Context, email string, roles []string) error {
+
+	if err := authorizeAdmin(ctx); err != nil {
+		return err
+	}
+
+	usr, err := s.user(ctx, email)
+
+	if err != nil {
+		return err
+	}
+
+	cred, err := s.store.GetCredential(ctx, usr.ID)
+
+	if err != nil {
+		return err
+	}
+
+	cred.UserID = usr.ID
+	cred.Roles = append([]string{}, roles...)
+	cred.UpdatedAt = s.clock.Now()
+
+	return s.store.SaveCredential(ctx, cred)
+}
+
+//Login - checks the password of an active user and issues its tokens. After Lockout.MaxFailures consecutive
+//failures the account is locked, while it is locked even the right password is rejected. When the user
+//confirmed a second factor only Tokens.MFAToken is returned, the login is completed by VerifyMFA
+func (s *Service) Login(ctx context.Context, email string, password string) (Tokens, error) {
+
+	usr, err := s.users.GetByEmail(ctx, email)
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	cred := Credential{}
+
+	if usr.ID > 0 && !usr.IsDeleted() {
+		if cred, err = s.store.GetCredential(ctx, usr.ID); err != nil {
+			return Tokens{}, err
+		}
+	}
+
+	//A user granted roles before choosing a password has a credential without a hash.
+	if cred.UserID == 0 || cred.PasswordHash == "" {
+		VerifyPassword(s.dummyHash, password)
+		return Tokens{}, ErrInvalidCredentials
+	}
+
+	now := s.clock.Now()
+
+	if cred.IsLocked(now) {
+		return Tokens{}, ErrAccountLocked
+	}
+
+	valid, err := VerifyPassword(cred.PasswordHash, password)
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if !valid {
+		if err := s.recordFailure(ctx, cred, now); err != nil {
+			return Tokens{}, err
+		}
+		return Tokens{}, ErrInvalidCredentials
+	}
+
+	//The status is checked after the password so it is not revealed to who does not know it.
+	if usr.Status != users.AccountActive {
+		return Tokens{}, ErrAccountInactive
+	}
+
+	if cred.FailedAttempts > 0 || !cred.LockedUntil.IsZero() || !s.hasher.Current(cred.PasswordHash) {
+		if err := s.resetFailures(ctx, cred, password, now); err != nil {
+			return Tokens{}, err
+		}
+	}
+
+	if s.mfa != nil {
+		m, err := s.mfa.Store.GetMFA(ctx, usr.ID)
+
+		if err != nil {
+			return Tokens{}, err
+		}
+
+		if m.Enabled() {
+			return s.challenge(ctx, usr.ID, now)
+		}
+	}
+
+	familyID, err := randomToken(tokenIDBytes)
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	var tokens Tokens
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		tokens, _, err = s.issue(ctx, usr, cred.Roles, []string{MethodPassword}, familyID, now)
+		return err
+	})
+
+	return tokens, err
+}
+
+//Refresh - exchanges a refresh token for new tokens, the refresh token is rotated and cannot be used again.
+//Presenting a token that was already rotated revokes every token of its family, as it was likely stolen
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (Tokens, error) {
+
+	stored, err := s.store.GetRefreshToken(ctx, hashToken(refreshToken))
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if stored.Hash == "" {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+
+	now := s.clock.Now()
+
+	if stored.RevokedAt != nil {
+		if stored.ReplacedBy != "" {
+			if err := s.store.RevokeTokenFamily(ctx, stored.FamilyID, now); err != nil {
+				return Tokens{}, err
+			}
+		}
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+
+	if !now.Before(stored.ExpiresAt) {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+
+	usr, err := s.users.GetByID(ctx, stored.UserID)
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if usr.ID == 0 || usr.IsDeleted() || usr.Status != users.AccountActive {
+		if err := s.store.RevokeTokenFamily(ctx, stored.FamilyID, now); err != nil {
+			return Tokens{}, err
+		}
+		return Tokens{}, ErrAccountInactive
+	}
+
+	cred, err := s.store.GetCredential(ctx, usr.ID)
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	var tokens Tokens
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		issued, hash, err := s.issue(ctx, usr, cred.Roles, stored.Methods, stored.FamilyID, now)
+
+		if err != nil {
+			return err
+		}
+
+		//The revocation fails when a concurrent refresh rotated the token first.
+		if err := s.store.RevokeRefreshToken(ctx, stored.Hash, now, hash); err != nil {
+			return err
+		}
+
+		tokens = issued
+		return nil
+	})
+
+	return tokens, err
+}
+
+//Revoke - revokes a refresh token and every token rotated from the same login. Unknown tokens are ignored
+func (s *Service) Revoke(ctx context.Context, refreshToken string) error {
+
+	stored, err := s.store.GetRefreshToken(ctx, hashToken(refreshToken))
+
+	if err != nil || stored.Hash == "" {
+		return err
+	}
+
+	return s.store.RevokeTokenFamily(ctx, stored.FamilyID, s.clock.Now())
+}
+
+//issue - signs an access token and stores a new refresh token of the family, it returns the hash of the refresh token.
+//The methods the user authenticated with are kept by the refresh token, the tokens rotated from it carry them too
+func (s *Service) issue(ctx context.Context, usr users.User, roles []string, methods []string, familyID string, now time.Time) (Tokens, string, error) {
+
+	if s.bootstrapAdmins[strings.ToLower(usr.Email)] && !hasRole(roles, users.AdminRole) {
+		roles = append(append([]string{}, roles...), users.AdminRole)
+	}
+
+	access, expiresAt, err := s.issuer.Issue(usr, roles, methods)
+
+	if err != nil {
+		return Tokens{}, "", err
+	}
+
+	refresh, err := randomToken(refreshTokenBytes)
+
+	if err != nil {
+		return Tokens{}, "", err
+	}
+
+	stored := RefreshToken{
+		Hash:      hashToken(refresh),
+		UserID:    usr.ID,
+		FamilyID:  familyID,
+		Methods:   methods,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}
+
+	if err := s.store.AddRefreshToken(ctx, stored); err != nil {
+		return Tokens{}, "", err
+	}
+
+	return Tokens{
+		AccessToken:      access,
+		TokenType:        TokenType,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, stored.Hash, nil
+}
+
+//recordFailure - counts a failed login, the account is locked when the failures reach Lockout.MaxFailures
+func (s *Service) recordFailure(ctx context.Context, cred Credential, now time.Time) error {
+
+	cred.FailedAttempts++
+
+	if s.lockout.MaxFailures > 0 && cred.FailedAttempts >= s.lockout.MaxFailures {
+		cred.FailedAttempts = 0
+		cred.LockedUntil = now.Add(s.lockout.Duration)
+	}
+
+	return s.store.SaveCredential(ctx, cred)
+}
+
+//resetFailures - clears the failed logins after a successful one, the password is hashed again when the
+//stored hash was made with another algorithm or other parameters
+func (s *Service) resetFailures(ctx context.Context, cred Credential, password string, now time.Time) error {
+
+	cred.FailedAttempts = 0
+	cred.LockedUntil = time.Time{}
+
+	if !s.hasher.Current(cred.PasswordHash) {
+		hash, err := s.hasher.Hash(password)
+		if err != nil {
+			return err
+		}
+		cred.PasswordHash = hash
+		cred.UpdatedAt = now
+	}
+
+	return s.store.SaveCredential(ctx, cred)
+}
+
+//user - retrieves a user that is not deleted
+func (s *Service) user(ctx context.Context, email string) (users.User, error) {
+
+	usr, err := s.users.GetByEmail(ctx, email)
+
+	if err != nil {
+		return users.User{}, err
+	}
+
+	if usr.ID == 0 || usr.IsDeleted() {
+		return users.User{}, users.UserError(users.ERRNOTFOUND)
+	}
+
+	return usr, nil
+}
+
+//authorizeAdmin - only administrators that gave a second factor can change the roles, the second factors or the
+//API keys, the API keys never give one
+func authorizeAdmin(ctx context.Context) error {
+	if caller, ok := users.CallerFromContext(ctx); !ok || !caller.HasRole(users.AdminRole) || !caller.HasMethod(users.MFAMethod) {
+		return users.UserError(users.ERRFORBIDDEN)
+	}
+	return nil
+}
+
+//authorizeSelfOrAdmin - the user with the email or an administrator that gave a second factor
+func authorizeSelfOrAdmin(ctx context.Context, email string) error {
+	if caller, ok := users.CallerFromContext(ctx); ok && caller.Subject == email {
+		return nil
+	}
+	return authorizeAdmin(ctx)
+}
+
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+//noTransaction - a users.Transactor for stores without transactions, fn runs directly
+type noTransaction struct{}
+
+func (noTransaction) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,277 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/auth"
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	email    = "jane@example.com"
+	password = "Correct-Horse-42"
+)
+
+var now = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+//fastArgon2id - cheap parameters so the tests do not spend their time hashing
+var fastArgon2id = auth.Argon2id{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+var signingKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+
+type movableClock struct {
+	now time.Time
+}
+
+func (c *movableClock) Now() time.Time {
+	return c.now
+}
+
+type fixture struct {
+	service *auth.Service
+	users   *memory.InMemoryUserRepository
+	store   *memory.InMemoryAuthRepository
+	clock   *movableClock
+	userID  int
+}
+
+func newFixture(t *testing.T, opts ...auth.Option) fixture {
+	return newFixtureWithStore(t, memory.NewInMemoryAuthRepository(), opts...)
+}
+
+func newFixtureWithStore(t *testing.T, store *memory.InMemoryAuthRepository, opts ...auth.Option) fixture {
+	clock := &movableClock{now: now}
+	repository := memory.NewInMemoryUserRepository(memory.WithClock(clock))
+	issuer := auth.NewIssuer(signingKey, "users", 15*time.Minute, clock)
+
+	opts = append([]auth.Option{auth.WithHasher(fastArgon2id), auth.WithLockout(auth.Lockout{MaxFailures: 3, Duration: time.Minute})}, opts...)
+	service, err := auth.NewService(repository, store, issuer, clock, opts...)
+	assert.Nil(t, err)
+
+	id, err := repository.Add(context.Background(), users.User{Email: email, Name: "Jane", LastName: "Doe", Status: users.AccountActive})
+	assert.Nil(t, err)
+
+	f := fixture{service: service, users: repository, store: store, clock: clock, userID: id}
+	assert.Nil(t, service.SetPassword(asAdmin(), email, password))
+
+	return f
+}
+
+func asAdmin() context.Context {
+	return users.WithCaller(context.Background(), users.Caller{Subject: "admin@example.com", Roles: []string{users.AdminRole}, Methods: []string{users.MFAMethod}})
+}
+
+func asAdminWithoutSecondFactor() context.Context {
+	return users.WithCaller(context.Background(), users.Caller{Subject: "admin@example.com", Roles: []string{users.AdminRole}, Methods: []string{"pwd"}})
+}
+
+func TestCases_SetRoles_NotAdminWithSecondFactor_ReturnsForbiddenError(t *testing.T) {
+	testCases := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"anonymous caller", context.Background()},
+		{"the user itself", users.WithCaller(context.Background(), users.Caller{Subject: email, Methods: []string{users.MFAMethod}})},
+		{"administrator without second factor", asAdminWithoutSecondFactor()},
+		{"api key with the admin role", users.WithCaller(context.Background(), users.Caller{Subject: "apikey:0123456789abcdef", Roles: []string{users.AdminRole}, Methods: []string{"apikey"}})},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Arrange
+			f := newFixture(t)
+			//Act
+			err := f.service.SetRoles(tc.ctx, email, []string{users.AdminRole})
+			//Assert
+			assert.True(t, users.IsUserErrorType(users.ERRFORBIDDEN, err))
+		})
+	}
+}
+
+func Test_Login_ValidPassword_IssuesVerifiableToken(t *testing.T) {
+	//Arrange
+	f := newFixture(t)
+	f.service.SetRoles(asAdmin(), email, []string{users.AdminRole})
+	//Act
+	tokens, err := f.service.Login(context.Background(), email, password)
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, auth.TokenType, tokens.TokenType)
+	assert.Equal(t, now.Add(15*time.Minute), tokens.ExpiresAt)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	//The clock of the test is in the past, only the signature is checked.
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	parsed, err := parser.Parse(tokens.AccessToken, func(*jwt.Token) (interface{}, error) { return &signingKey.PublicKey, nil })
+	assert.Nil(t, err)
+	assert.Equal(t, "RS256", parsed.Method.Alg())
+	claims := parsed.Claims.(jwt.MapClaims)
+	assert.Equal(t, email, claims["sub"])
+	assert.Equal(t, []interface{}{users.AdminRole}, claims["roles"])
+	assert.Equal(t, float64(f.userID), claims["uid"])
+}
+
+func Test_Login_BootstrapAdmin_TokenCarriesTheAdminRole(t *testing.T) {
+	//Arrange
+	f := newFixture(t, auth.WithBootstrapAdmins(" JANE@example.com "))
+	//Act
+	tokens, err := f.service.Login(context.Background(), email, password)
+	//Assert
+	assert.Nil(t, err)
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	parsed, err := parser.Parse(tokens.AccessToken, func(*jwt.Token) (interface{}, error) { return &signingKey.PublicKey, nil })
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{users.AdminRole}, parsed.Claims.(jwt.MapClaims)["roles"])
+}
+
+func Test_Login_RolesWithoutPassword_ReturnsInvalidCredentials(t *testing.T) {
+	//Arrange
+	f := newFixture(t)
+	ctx := context.Background()
+	f.users.Add(ctx, users.User{Email: "john@example.com", Name: "John", LastName: "Doe", Status: users.AccountActive})
+	errRoles := f.service.SetRoles(asAdmin(), "john@example.com", []string{"auditor"})
+	//Act
+	_, err := f.service.Login(ctx, "john@example.com", "")
+	//Assert
+	assert.Nil(t, errRoles)
+	assert.Equal(t, auth.ErrInvalidCredentials, err)
+}
+
+func Test_Login_UnknownEmail_ReturnsInvalidCredentials(t *testing.T) {
+	//Arrange
+	f := newFixture(t)
+	//Act
+	_, err := f.service.Login(context.Background(), "nobody@example.com", password)
+	//Assert
+	assert.Equal(t, auth.ErrInvalidCredentials, err)
+}
+
+func Test_Login_RepeatedFailures_LocksTheAccount(t *testing.T) {
+	//Arrange
+	f := newFixture(t)
+	ctx := context.Background()
+	//Act
+	for i := 0; i < 3; i++ {
+		_, err := f.service.Login(ctx, email, "Wrong-Horse-42")
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	}
+	_, errLocked := f.service.Login(ctx, email, password)
+	f.clock.now = now.Add(time.Minute)
+	_, errAfter := f.service.Login(ctx, email, password)
+	//Assert
+	assert.Equal(t, auth.ErrAccountLocked, errLocked)
+	assert.Nil(t, errAfter)
+}
+
+func Test_Login_SuspendedUser_ReturnsAccountInactive(t *testing.T) {
+	//Arrange
+	f := newFixture(t)
+	usr, _ := f.users.GetByID(context.Background(), f.userID)
+	f.users.SetStatus(context.Background(), f.userID, usr.Version, users.AccountSuspended)
+	//Act
+	_, err := f.service.Login(context.Background(), email, password)
+	//Assert
+	assert.Equal(t, auth.ErrAccountInactive, err)
+}
+
+func Test_Login_OutdatedHash_IsReplaced(t *testing.T) {
+	//Arrange
+	f := newFixture(t)
+	ctx := context.Background()
+	hash, _ := auth.Bcrypt{Cost: 4}.Hash(password)
+	f.store.SaveCredential(ctx, auth.Credential{UserID: f.userID, PasswordHash: hash})
+	//Act
+	_, err := f.service.Login(ctx, email, password)
+	//Assert
+	assert.Nil(t, err)
+	cred, _ := f.store.GetCredential(ctx, f.userID)
+	assert.True(t, fastArgon2id.Current(cred.PasswordHash))
+}
+
+func Test_Refresh_RotatesTheToken_ReuseRevokesTheFamily(t *testing.T) {
+	//Arrange
+	f := newFixture(t)
+	ctx := context.Background()
+	first, _ := f.service.Login(ctx, email, password)
+	//Act
+	second, errRotate := f.service.Refresh(ctx, first.RefreshToken)
+	_, errReuse := f.service.Refresh(ctx, first.RefreshToken)
+	_, errRevoked := f.service.Refresh(ctx, second.RefreshToken)
+	//Assert
+	assert.Nil(t, errRotate)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	assert.Equal(t, auth.ErrInvalidRefreshToken, errReuse)
+	assert.Equal(t, auth.ErrInvalidRefreshToken, errRevoked)
+}
+
+func Test_Refresh_ExpiredToken_ReturnsInvalidRefreshToken(t *testing.T) {
+	//Arrange
+	f := newFixture(t, auth.WithRefreshTTL(time.Hour))
+	ctx := context.Background()
+	tokens, _ := f.service.Login(ctx, email, password)
+	f.clock.now = now.Add(time.Hour)
+	//Act
+	_, err := f.service.Refresh(ctx, tokens.RefreshToken)
+	//Assert
+	assert.Equal(t, auth.ErrInvalidRefreshToken, err)
+}
+
+func Test_Revoke_RefreshTokenCannotBeUsed(t *testing.T) {
+	//Arrange
+	f := newFixture(t)
+	ctx := context.Background()
+	tokens, _ := f.service.Login(ctx, email, password)
+	//Act
+	errRevoke := f.service.Revoke(ctx, tokens.RefreshToken)
+	_, errRefresh := f.service.Refresh(ctx, tokens.RefreshToken)
+	//Assert
+	assert.Nil(t, errRevoke)
+	assert.Equal(t, auth.ErrInvalidRefreshToken, errRefresh)
+}
+
+func Test_SetPassword_RevokesTheRefreshTokens(t *testing.T) {
+	//Arrange
+	f := newFixture(t)
+	self := users.WithCaller(context.Background(), users.Caller{Subject: email})
+	tokens, _ := f.service.Login(context.Background(), email, password)
+	//Act
+	errSet := f.service.SetPassword(self, email, "Another-Horse-43")
+	_, errRefresh := f.service.Refresh(context.Background(), tokens.RefreshToken)
+	_, errLogin := f.service.Login(context.Background(), email, "Another-Horse-43")
+	//Assert
+	assert.Nil(t, errSet)
+	assert.Equal(t, auth.ErrInvalidRefreshToken, errRefresh)
+	assert.Nil(t, errLogin)
+}
+
+func TestCases_SetPassword(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		password string
+		want     error
+	}{
+		{"anonymous caller", context.Background(), "Another-Horse-43", users.UserError(users.ERRFORBIDDEN)},
+		{"another user", users.WithCaller(context.Background(), users.Caller{Subject: "john@example.com"}), "Another-Horse-43", users.UserError(users.ERRFORBIDDEN)},
+		{"administrator without second factor", asAdminWithoutSecondFactor(), "Another-Horse-43", users.UserError(users.ERRFORBIDDEN)},
+		{"weak password", asAdmin(), "short", auth.ErrWeakPassword},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Arrange
+			f := newFixture(t)
+			//Act
+			err := f.service.SetPassword(tc.ctx, email, tc.password)
+			//Assert
+			assert.ErrorIs(t, err, tc.want)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	//TokenType - the type of the access tokens, they are sent in the Authorization header
+	TokenType = "Bearer"
+
+	refreshTokenBytes = 32
+	tokenIDBytes      = 16
+)
+
+//Tokens - the tokens handed to a user after a login or a refresh
+type Tokens struct {
+	//AccessToken - an RS256 JWT, its claims are sub (the email), uid, roles, amr, iss, iat, exp and jti
+	AccessToken string
+	TokenType   string
+	ExpiresAt   time.Time
+	//RefreshToken - an opaque token exchanged for new tokens, it can be used once
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+	//MFAToken - set instead of the other tokens when the login waits for the second factor
+	MFAToken string
+}
+
+//Issuer - signs the access tokens with an RSA private key, they are verified with its public key
+type Issuer struct {
+	key    *rsa.PrivateKey
+	issuer string
+	ttl    time.Duration
+	clock  users.Clock
+}
+
+//NewIssuer - returns an Issuer type pointer, the tokens it signs are valid for ttl
+func NewIssuer(key *rsa.PrivateKey, issuer string, ttl time.Duration, clock users.Clock) *Issuer {
+	return &Issuer{key: key, issuer: issuer, ttl: ttl, clock: clock}
+}
+
+//ParsePrivateKey - reads a PEM encoded RSA private key, PKCS1 or PKCS8
+func ParsePrivateKey(pem []byte) (*rsa.PrivateKey, error) {
+	return jwt.ParseRSAPrivateKeyFromPEM(pem)
+}
+
+//Issue - signs an access token for the user, methods are the ways it authenticated (the RFC 8176 amr claim).
+//It returns the token and when it expires
+func (i *Issuer) Issue(usr users.User, roles []string, methods []string) (string, time.Time, error) {
+
+	jti, err := randomToken(tokenIDBytes)
+
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := i.clock.Now()
+	expiresAt := now.Add(i.ttl)
+
+	if roles == nil {
+		roles = []string{}
+	}
+
+	claims := jwt.MapClaims{
+		"sub":   usr.Email,
+		"uid":   usr.ID,
+		"roles": roles,
+		"amr":   methods,
+		"iss":   i.issuer,
+		"iat":   now.Unix(),
+		"exp":   expiresAt.Unix(),
+		"jti":   jti,
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(i.key)
+
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expiresAt, nil
+}
+
+//randomToken - returns n random bytes encoded as base64url
+func randomToken(n int) (string, error) {
+
+	b := make([]byte, n)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+//hashToken - the refresh tokens are stored by their SHA-256, so a leaked store cannot be replayed
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	//totpPeriod - the seconds every code is valid for, RFC 6238 recommends 30
+	totpPeriod = 30
+	totpDigits = 6
+	//totpSkew - the steps before and after the current one also accepted, for clocks that drift
+	totpSkew = 1
+
+	totpSecretBytes = 20
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+//newTOTPSecret - returns a random secret encoded as unpadded base32, the encoding authenticator apps read
+func newTOTPSecret() (string, error) {
+
+	secret := make([]byte, totpSecretBytes)
+
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+//totpURI - the otpauth:// URI authenticator apps enrol a secret with, usually shown as a QR code
+func totpURI(issuer string, account string, secret string) string {
+
+	label := url.PathEscape(issuer + ":" + account)
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+//totpStep - the time step a moment falls in
+func totpStep(at time.Time) int64 {
+	return at.Unix() / totpPeriod
+}
+
+//totpCode - the code of a step, HOTP (RFC 4226) over the step counter
+func totpCode(secret string, step int64) (string, error) {
+
+	key, err := totpEncoding.DecodeString(secret)
+
+	if err != nil {
+		return "", err
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+//validateTOTP - returns the step the code matches, around the given time and after lastStep so a code
+//is not accepted twice. Zero when it does not match
+func validateTOTP(secret string, code string, at time.Time, lastStep int64) (int64, error) {
+
+	current := totpStep(at)
+
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+
+		expected, err := totpCode(secret, step)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, nil
+		}
+	}
+
+	return 0, nil
+}
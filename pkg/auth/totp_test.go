@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//rfcSecret - the SHA1 secret of the RFC 6238 test vectors, "12345678901234567890"
+var rfcSecret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCases_TOTPCode_RFC6238Vectors(t *testing.T) {
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{20000000000, "353130"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.code, func(t *testing.T) {
+			//Act
+			code, err := totpCode(rfcSecret, totpStep(time.Unix(tc.unix, 0)))
+			//Assert
+			assert.Nil(t, err)
+			assert.Equal(t, tc.code, code)
+		})
+	}
+}
+
+func Test_ValidateTOTP_PreviousStep_IsAcceptedOnce(t *testing.T) {
+	//Arrange
+	at := time.Unix(1111111109, 0)
+	previous, _ := totpCode(rfcSecret, totpStep(at)-1)
+	//Act
+	step, err := validateTOTP(rfcSecret, previous, at, 0)
+	replayed, _ := validateTOTP(rfcSecret, previous, at, step)
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, totpStep(at)-1, step)
+	assert.Equal(t, int64(0), replayed)
+}
+
+func Test_ValidateTOTP_OutsideTheSkew_IsRejected(t *testing.T) {
+	//Arrange
+	at := time.Unix(1111111109, 0)
+	old, _ := totpCode(rfcSecret, totpStep(at)-2)
+	//Act
+	step, err := validateTOTP(rfcSecret, old, at, 0)
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), step)
+}
+
+func Test_TOTPURI_CarriesTheSecretAndIssuer(t *testing.T) {
+	//Act
+	uri := totpURI("users", "jane@example.com", rfcSecret)
+	//Assert
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/users:jane@example.com?"))
+	assert.Contains(t, uri, "secret="+rfcSecret)
+	assert.Contains(t, uri, "issuer=users")
+}
@@ -0,0 +1,137 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+//Broadcaster - a Publisher that hands every message to the live subscribers of the change feed,
+//subscribers that do not keep up lose the message and have to resume from the outbox
+type Broadcaster struct {
+	mtx         sync.RWMutex
+	subscribers map[chan Message]struct{}
+}
+
+//NewBroadcaster - returns a Broadcaster type pointer
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{subscribers: map[chan Message]struct{}{}}
+}
+
+//Subscribe - returns a channel that receives the published messages, buffering up to size of them,
+//and the function that cancels the subscription
+func (b *Broadcaster) Subscribe(size int) (<-chan Message, func()) {
+
+	ch := make(chan Message, size)
+
+	b.mtx.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mtx.Unlock()
+
+	return ch, func() {
+		b.mtx.Lock()
+		defer b.mtx.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+//Publish - sends the message to every subscriber without waiting for them, a subscriber with a full buffer
+//is dropped and its channel closed
+func (b *Broadcaster) Publish(ctx context.Context, m Message) error {
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- m:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return nil
+}
+
+//MultiPublisher - a Publisher that publishes every message to all of its publishers, in order
+type MultiPublisher []Publisher
+
+//Publish - publishes the message, it stops at the first failure
+func (p MultiPublisher) Publish(ctx context.Context, m Message) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//ChangeFeed - streams the user events, replaying the ones stored in the outbox to resume after a reconnect
+type ChangeFeed struct {
+	outbox      Outbox
+	broadcaster *Broadcaster
+	batchSize   int
+}
+
+//NewChangeFeed - returns a ChangeFeed type pointer, the broadcaster must be one of the publishers of the relay
+func NewChangeFeed(outbox Outbox, broadcaster *Broadcaster, batchSize int) *ChangeFeed {
+	return &ChangeFeed{outbox: outbox, broadcaster: broadcaster, batchSize: batchSize}
+}
+
+//Head - returns the position of the last message of the feed, watching from it sends only the messages published
+//afterwards
+func (f *ChangeFeed) Head(ctx context.Context) (int64, error) {
+	return f.outbox.Head(ctx)
+}
+
+//Watch - sends, in position order, the messages stored with a position greater than after and then the new ones
+//as they are published, until the context is done or send fails. It returns ErrFeedOverflow when the watcher
+//does not keep up, the watcher can resume from the last position it received. The positions follow the commit
+//order, a message committed after one with a greater sequence is not skipped
+func (f *ChangeFeed) Watch(ctx context.Context, after int64, send func(Message) error) error {
+
+	live, cancel := f.broadcaster.Subscribe(f.batchSize)
+	defer cancel()
+
+	last := after
+
+	for {
+		replayed, err := f.outbox.After(ctx, last, f.batchSize)
+
+		if err != nil {
+			return err
+		}
+
+		for _, m := range replayed {
+			if err := send(m); err != nil {
+				return err
+			}
+			last = m.Position
+		}
+
+		if len(replayed) < f.batchSize {
+			break
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case m, ok := <-live:
+			if !ok {
+				return ErrFeedOverflow
+			}
+			if m.Position <= last {
+				continue
+			}
+			if err := send(m); err != nil {
+				return err
+			}
+			last = m.Position
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/events"
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/stretchr/testify/assert"
+)
+
+var errEnough = errors.New("enough")
+
+func Test_Watch_ResumesAfterPosition_ThenStreamsLive(t *testing.T) {
+	//Arrange
+	outbox := memory.NewInMemoryOutboxRepository()
+	service := newEventedService(outbox)
+	broadcaster := events.NewBroadcaster()
+	relay := events.NewRelay(outbox, events.MultiPublisher{broadcaster}, 10, fixedClock(now))
+	feed := events.NewChangeFeed(outbox, broadcaster, 10)
+	ctx := context.Background()
+	service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	service.Create(ctx, users.User{Email: "test2@gmail.com", Name: "Test2", LastName: "LastName2"})
+	relay.RunOnce(ctx)
+	received := make(chan int64, 10)
+	done := make(chan error, 1)
+	//Act
+	go func() {
+		done <- feed.Watch(ctx, 1, func(m events.Message) error {
+			received <- m.Position
+			if m.Position == 3 {
+				return errEnough
+			}
+			return nil
+		})
+	}()
+	assert.Equal(t, int64(2), <-received)
+	service.Create(ctx, users.User{Email: "test3@gmail.com", Name: "Test3", LastName: "LastName3"})
+	relay.RunOnce(ctx)
+	//Assert
+	select {
+	case position := <-received:
+		assert.Equal(t, int64(3), position)
+	case <-time.After(time.Second):
+		t.Fatal("live event not received")
+	}
+	assert.Equal(t, errEnough, <-done)
+}
+
+func Test_Watch_FromHead_SkipsStoredEvents(t *testing.T) {
+	//Arrange
+	outbox := memory.NewInMemoryOutboxRepository()
+	service := newEventedService(outbox)
+	broadcaster := events.NewBroadcaster()
+	relay := events.NewRelay(outbox, events.MultiPublisher{broadcaster}, 10, fixedClock(now))
+	feed := events.NewChangeFeed(outbox, broadcaster, 10)
+	ctx := context.Background()
+	service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	service.Create(ctx, users.User{Email: "test2@gmail.com", Name: "Test2", LastName: "LastName2"})
+	relay.RunOnce(ctx)
+	head, _ := feed.Head(ctx)
+	received := make(chan int64, 10)
+	done := make(chan error, 1)
+	//Act
+	go func() {
+		done <- feed.Watch(ctx, head, func(m events.Message) error {
+			received <- m.Position
+			return errEnough
+		})
+	}()
+	service.Create(ctx, users.User{Email: "test3@gmail.com", Name: "Test3", LastName: "LastName3"})
+	relay.RunOnce(ctx)
+	//Assert
+	assert.Equal(t, int64(2), head)
+	assert.Equal(t, errEnough, <-done)
+	assert.Equal(t, int64(3), <-received)
+}
+
+func Test_Watch_SequenceCommittedLate_IsStreamedAndReplayed(t *testing.T) {
+	//Arrange
+	outbox := memory.NewInMemoryOutboxRepository()
+	broadcaster := events.NewBroadcaster()
+	relay := events.NewRelay(outbox, events.MultiPublisher{broadcaster}, 10, fixedClock(now))
+	feed := events.NewChangeFeed(outbox, broadcaster, 10)
+	ctx := context.Background()
+	outbox.Append(ctx, events.Message{Type: users.UserCreatedEvent})
+	outbox.Append(ctx, events.Message{Type: users.UserCreatedEvent})
+	//The transaction of the second sequence committed first, the relay published it before the first one.
+	outbox.AssignPosition(ctx, 2)
+	received := make(chan events.Message, 10)
+	done := make(chan error, 1)
+	//Act
+	go func() {
+		done <- feed.Watch(ctx, 0, func(m events.Message) error {
+			received <- m
+			if m.Sequence == 1 {
+				return errEnough
+			}
+			return nil
+		})
+	}()
+	first := <-received
+	relay.RunOnce(ctx)
+	//Assert
+	select {
+	case second := <-received:
+		assert.Equal(t, int64(2), first.Sequence)
+		assert.Equal(t, int64(1), second.Sequence)
+		assert.Equal(t, int64(2), second.Position)
+	case <-time.After(time.Second):
+		t.Fatal("late event not received")
+	}
+	assert.Equal(t, errEnough, <-done)
+	resumed, _ := outbox.After(ctx, first.Position, 10)
+	assert.Equal(t, int64(1), resumed[0].Sequence)
+}
+
+func Test_Watch_ContextDone_ReturnsContextError(t *testing.T) {
+	//Arrange
+	outbox := memory.NewInMemoryOutboxRepository()
+	feed := events.NewChangeFeed(outbox, events.NewBroadcaster(), 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	//Act
+	err := feed.Watch(ctx, 0, func(m events.Message) error { return nil })
+	//Assert
+	assert.Equal(t, context.Canceled, err)
+}
+
+func Test_Broadcaster_SlowSubscriber_IsDropped(t *testing.T) {
+	//Arrange
+	broadcaster := events.NewBroadcaster()
+	messages, cancel := broadcaster.Subscribe(1)
+	defer cancel()
+	ctx := context.Background()
+	//Act
+	broadcaster.Publish(ctx, events.Message{Sequence: 1})
+	broadcaster.Publish(ctx, events.Message{Sequence: 2})
+	//Assert
+	first, open := <-messages
+	assert.True(t, open)
+	assert.Equal(t, int64(1), first.Sequence)
+	_, open = <-messages
+	assert.False(t, open)
+}
+
+func Test_Watch_SlowWatcher_ReturnsOverflow(t *testing.T) {
+	//Arrange
+	outbox := memory.NewInMemoryOutboxRepository()
+	broadcaster := events.NewBroadcaster()
+	feed := events.NewChangeFeed(outbox, broadcaster, 1)
+	ctx := context.Background()
+	outbox.Append(ctx, events.Message{Type: users.UserCreatedEvent})
+	outbox.AssignPosition(ctx, 1)
+	replaying := make(chan struct{}, 1)
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	//Act
+	go func() {
+		done <- feed.Watch(ctx, 0, func(m events.Message) error {
+			if m.Position == 1 {
+				replaying <- struct{}{}
+				<-release
+			}
+			return nil
+		})
+	}()
+	<-replaying
+	for i := 0; i < 3; i++ {
+		broadcaster.Publish(ctx, events.Message{Sequence: int64(2 + i), Position: int64(2 + i)})
+	}
+	close(release)
+	//Assert
+	select {
+	case err := <-done:
+		assert.Equal(t, events.ErrFeedOverflow, err)
+	case <-time.After(time.Second):
+		t.Fatal("watch did not overflow")
+	}
+}
@@ -0,0 +1,110 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+//Message - an event stored in the outbox, the sequence orders the messages as they were written and the position,
+//zero until the relay assigns it, orders them in the change feed as they were committed
+type Message struct {
+	Sequence    int64           `json:"sequence"`
+	Position    int64           `json:"position,omitempty"`
+	Type        users.EventType `json:"type"`
+	UserID      int             `json:"user_id"`
+	Payload     json.RawMessage `json:"payload"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	PublishedAt *time.Time      `json:"published_at,omitempty"`
+}
+
+//Outbox - the interface for the outbox persistence, Append takes part in the transaction stored in the context
+type Outbox interface {
+	//Append - stores a message, the outbox assigns its sequence
+	Append(ctx context.Context, m Message) error
+	//Pending - retrieves up to limit messages not published yet, lowest sequence first
+	Pending(ctx context.Context, limit int) ([]Message, error)
+	//AssignPosition - gives the message with the given sequence the next position of the change feed, unless it
+	//already has one, and returns its position
+	AssignPosition(ctx context.Context, sequence int64) (int64, error)
+	//MarkPublished - records that the message with the given sequence was published
+	MarkPublished(ctx context.Context, sequence int64, at time.Time) error
+	//After - retrieves up to limit messages with a position greater than after, lowest position first
+	After(ctx context.Context, after int64, limit int) ([]Message, error)
+	//Head - returns the greatest position assigned, zero when no message has one
+	Head(ctx context.Context) (int64, error)
+}
+
+//OutboxEmitter - a users.EventEmitter that stores the events in the outbox
+type OutboxEmitter struct {
+	outbox Outbox
+}
+
+//NewOutboxEmitter - returns an OutboxEmitter type pointer
+func NewOutboxEmitter(outbox Outbox) *OutboxEmitter {
+	return &OutboxEmitter{outbox: outbox}
+}
+
+//Emit - stores the event in the outbox, the relay publishes it once the transaction commits
+func (e *OutboxEmitter) Emit(ctx context.Context, event users.Event) error {
+
+	payload, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	return e.outbox.Append(ctx, Message{
+		Type:       event.Type(),
+		UserID:     event.UserID(),
+		Payload:    payload,
+		OccurredAt: event.OccurredAt(),
+	})
+}
+
+var (
+	//ErrUnknownEventType - the message holds an event type this version does not know
+	ErrUnknownEventType = errors.New("unknown event type")
+	//ErrFeedOverflow - the watcher of the change feed did not keep up with the published messages
+	ErrFeedOverflow = errors.New("change feed overflow")
+)
+
+//Decode - rebuilds the typed event stored in the message
+func Decode(m Message) (users.Event, error) {
+
+	var event users.Event
+
+	switch m.Type {
+	case users.UserCreatedEvent:
+		e := users.UserCreated{}
+		if err := json.Unmarshal(m.Payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	case users.UserUpdatedEvent:
+		e := users.UserUpdated{}
+		if err := json.Unmarshal(m.Payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	case users.UserDeletedEvent:
+		e := users.UserDeleted{}
+		if err := json.Unmarshal(m.Payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	case users.UserPurgedEvent:
+		e := users.UserPurged{}
+		if err := json.Unmarshal(m.Payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	default:
+		return nil, ErrUnknownEventType
+	}
+
+	return event, nil
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+//LogPublisher - writes every message as a JSON line, to a log or to a file
+type LogPublisher struct {
+	mtx     sync.Mutex
+	encoder *json.Encoder
+}
+
+//NewLogPublisher - returns a LogPublisher type pointer that writes to w
+func NewLogPublisher(w io.Writer) *LogPublisher {
+	return &LogPublisher{encoder: json.NewEncoder(w)}
+}
+
+//Publish - writes the message
+func (p *LogPublisher) Publish(ctx context.Context, m Message) error {
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	return p.encoder.Encode(m)
+}
+
+//ChannelPublisher - hands the messages to in-process consumers through a channel
+type ChannelPublisher struct {
+	messages chan Message
+}
+
+//NewChannelPublisher - returns a ChannelPublisher type pointer whose channel buffers up to size messages
+func NewChannelPublisher(size int) *ChannelPublisher {
+	return &ChannelPublisher{messages: make(chan Message, size)}
+}
+
+//Messages - the channel the published messages are sent to
+func (p *ChannelPublisher) Messages() <-chan Message {
+	return p.messages
+}
+
+//Publish - sends the message, it blocks while the buffer is full until the context is done
+func (p *ChannelPublisher) Publish(ctx context.Context, m Message) error {
+	select {
+	case p.messages <- m:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
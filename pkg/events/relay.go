@@ -0,0 +1,59 @@
+package events
+
+import (
+	"context"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+//Publisher - delivers the outbox messages outside of the process
+type Publisher interface {
+	Publish(ctx context.Context, m Message) error
+}
+
+//Relay - moves the pending outbox messages to the publisher, a message is marked as published only after
+//the publisher accepted it, so it is delivered at least once. The pending messages are the committed ones, so
+//the positions the relay assigns follow the commit order even when the sequences were committed out of order,
+//a single relay must run for them to grow one by one
+type Relay struct {
+	outbox    Outbox
+	publisher Publisher
+	batchSize int
+	clock     users.Clock
+}
+
+//NewRelay - returns a Relay type pointer that publishes up to batchSize messages per run
+func NewRelay(outbox Outbox, publisher Publisher, batchSize int, clock users.Clock) *Relay {
+	return &Relay{outbox: outbox, publisher: publisher, batchSize: batchSize, clock: clock}
+}
+
+//RunOnce - publishes the pending messages in sequence order and returns how many were published,
+//it stops at the first failure so that the order is kept on the next run
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+
+	pending, err := r.outbox.Pending(ctx, r.batchSize)
+
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+
+	for _, m := range pending {
+		if m.Position, err = r.outbox.AssignPosition(ctx, m.Sequence); err != nil {
+			return published, err
+		}
+
+		if err := r.publisher.Publish(ctx, m); err != nil {
+			return published, err
+		}
+
+		if err := r.outbox.MarkPublished(ctx, m.Sequence, r.clock.Now()); err != nil {
+			return published, err
+		}
+
+		published++
+	}
+
+	return published, nil
+}
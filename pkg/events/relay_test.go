@@ -0,0 +1,150 @@
+package events_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/events"
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+type flakyPublisher struct {
+	failures  int
+	published []events.Message
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, m events.Message) error {
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, m)
+	return nil
+}
+
+var now = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newEventedService(outbox events.Outbox) *users.UserService {
+	repository := memory.NewInMemoryUserRepository(memory.WithClock(fixedClock(now)))
+	return users.NewUserService(repository, users.WithTransactor(repository), users.WithEventEmitter(events.NewOutboxEmitter(outbox)))
+}
+
+func Test_Relay_PublishesEventsInOrder(t *testing.T) {
+	//Arrange
+	outbox := memory.NewInMemoryOutboxRepository()
+	service := newEventedService(outbox)
+	publisher := events.NewChannelPublisher(10)
+	relay := events.NewRelay(outbox, publisher, 10, fixedClock(now))
+	ctx := users.WithCaller(context.Background(), users.Caller{Subject: "root", Roles: []string{users.AdminRole}, Methods: []string{users.MFAMethod}})
+	id, _ := service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	service.Update(ctx, users.User{Email: "test@gmail.com", Name: "Test_Updated", LastName: "LastName", Version: 1})
+	service.Delete(ctx, id, 2)
+	//Act
+	published, err := relay.RunOnce(ctx)
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 3, published)
+	for i, eventType := range []users.EventType{users.UserCreatedEvent, users.UserUpdatedEvent, users.UserDeletedEvent} {
+		m := <-publisher.Messages()
+		assert.Equal(t, int64(i+1), m.Sequence)
+		assert.Equal(t, int64(i+1), m.Position)
+		assert.Equal(t, eventType, m.Type)
+		assert.Equal(t, id, m.UserID)
+	}
+}
+
+func Test_Relay_PublishedMessages_AreNotPublishedAgain(t *testing.T) {
+	//Arrange
+	outbox := memory.NewInMemoryOutboxRepository()
+	service := newEventedService(outbox)
+	relay := events.NewRelay(outbox, events.NewChannelPublisher(10), 10, fixedClock(now))
+	ctx := context.Background()
+	service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	relay.RunOnce(ctx)
+	//Act
+	published, err := relay.RunOnce(ctx)
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 0, published)
+}
+
+func Test_Relay_PublisherFails_RetriesOnNextRun(t *testing.T) {
+	//Arrange
+	outbox := memory.NewInMemoryOutboxRepository()
+	service := newEventedService(outbox)
+	publisher := &flakyPublisher{failures: 1}
+	relay := events.NewRelay(outbox, publisher, 10, fixedClock(now))
+	ctx := context.Background()
+	service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	service.Create(ctx, users.User{Email: "test2@gmail.com", Name: "Test2", LastName: "LastName2"})
+	//Act
+	failed, err := relay.RunOnce(ctx)
+	retried, errRetry := relay.RunOnce(ctx)
+	//Assert
+	assert.EqualError(t, err, "broker unavailable")
+	assert.Equal(t, 0, failed)
+	assert.Nil(t, errRetry)
+	assert.Equal(t, 2, retried)
+	assert.Equal(t, int64(1), publisher.published[0].Sequence)
+	assert.Equal(t, int64(1), publisher.published[0].Position)
+}
+
+func Test_Decode_ReturnsTypedEvent(t *testing.T) {
+	//Arrange
+	outbox := memory.NewInMemoryOutboxRepository()
+	service := newEventedService(outbox)
+	ctx := context.Background()
+	service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	pending, _ := outbox.Pending(ctx, 1)
+	//Act
+	event, err := events.Decode(pending[0])
+	//Assert
+	assert.Nil(t, err)
+	created, is := event.(users.UserCreated)
+	assert.True(t, is)
+	assert.Equal(t, "test@gmail.com", created.User.Email)
+	assert.Equal(t, now, created.OccurredAt())
+}
+
+func Test_Decode_UnknownType_ReturnsError(t *testing.T) {
+	//Act
+	_, err := events.Decode(events.Message{Type: "user.renamed", Payload: json.RawMessage(`{}`)})
+	//Assert
+	assert.Equal(t, events.ErrUnknownEventType, err)
+}
+
+func Test_LogPublisher_WritesJSONLines(t *testing.T) {
+	//Arrange
+	var buffer bytes.Buffer
+	publisher := events.NewLogPublisher(&buffer)
+	//Act
+	err := publisher.Publish(context.Background(), events.Message{Sequence: 1, Type: users.UserCreatedEvent, UserID: 7, Payload: json.RawMessage(`{}`)})
+	//Assert
+	assert.Nil(t, err)
+	assert.Contains(t, buffer.String(), `"sequence":1`)
+	assert.Contains(t, buffer.String(), `"type":"user.created"`)
+	assert.Equal(t, byte('\n'), buffer.Bytes()[buffer.Len()-1])
+}
+
+func Test_ChannelPublisher_FullBuffer_StopsWhenContextIsDone(t *testing.T) {
+	//Arrange
+	publisher := events.NewChannelPublisher(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	//Act
+	err := publisher.Publish(ctx, events.Message{Sequence: 1})
+	//Assert
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,45 @@
+package gateway
+
+import (
+	"sync"
+	"time"
+)
+
+//ReplayCache - remembers the nonces of the verified signatures for as long as the signatures can be verified, so
+//that a captured signature cannot be sent again
+type ReplayCache struct {
+	mtx       sync.Mutex
+	seen      map[string]time.Time
+	nextSweep time.Time
+}
+
+//NewReplayCache - returns a ReplayCache type pointer
+func NewReplayCache() *ReplayCache {
+	return &ReplayCache{seen: map[string]time.Time{}}
+}
+
+//Seen - reports whether the nonce was already seen, it is remembered otherwise until a signature verified now can no
+//longer be verified
+func (c *ReplayCache) Seen(nonce string, now time.Time) bool {
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if now.After(c.nextSweep) {
+		for n, until := range c.seen {
+			if now.After(until) {
+				delete(c.seen, n)
+			}
+		}
+		c.nextSweep = now.Add(MaxSkew)
+	}
+
+	if until, ok := c.seen[nonce]; ok && !now.After(until) {
+		return true
+	}
+
+	//The timestamp of a signature verified now is at most now+MaxSkew, it cannot be verified after now+2*MaxSkew.
+	c.seen[nonce] = now.Add(2 * MaxSkew)
+
+	return false
+}
@@ -0,0 +1,24 @@
+package gateway_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/gateway"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReplayCache_SameNonce_IsSeenUntilItExpires(t *testing.T) {
+	//Arrange
+	cache := gateway.NewReplayCache()
+	//Act
+	first := cache.Seen("nonce", now)
+	replayed := cache.Seen("nonce", now.Add(gateway.MaxSkew))
+	other := cache.Seen("other", now)
+	expired := cache.Seen("nonce", now.Add(2*gateway.MaxSkew+time.Second))
+	//Assert
+	assert.False(t, first)
+	assert.True(t, replayed)
+	assert.False(t, other)
+	assert.False(t, expired)
+}
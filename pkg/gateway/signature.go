@@ -0,0 +1,111 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	//SignatureKey - carries the HMAC-SHA256 of the method, the timestamp, the nonce and the forwarded metadata,
+	//keyed with the secret shared by the REST gateway and the gRPC service
+	SignatureKey = "x-gateway-signature"
+	//TimestampKey - carries the unix time the metadata was signed at
+	TimestampKey = "x-gateway-timestamp"
+	//NonceKey - carries the random value that makes every signature unique, a signature is trusted only once
+	NonceKey = "x-gateway-nonce"
+	//MaxSkew - how far from the clock of the service the timestamp of a signature can be
+	MaxSkew = 5 * time.Minute
+
+	signaturePrefix = "sha256="
+	nonceBytes      = 16
+)
+
+//ForwardedKeys - the metadata the gateway vouches for, the service only trusts them when they are signed
+var ForwardedKeys = []string{
+	"x-caller-subject",
+	"x-caller-roles",
+	"x-caller-amr",
+	"x-source-transport",
+	"x-client-address",
+}
+
+//Sign - returns the signature of the forwarded metadata for a call of the given full method name, computed over the
+//method, the timestamp, the nonce and every forwarded key with its values, one per line
+func Sign(secret []byte, method string, timestamp int64, nonce string, md metadata.MD) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(method + "\n" + strconv.FormatInt(timestamp, 10) + "\n" + nonce))
+	for _, key := range ForwardedKeys {
+		mac.Write([]byte("\n" + key + "=" + strings.Join(md.Get(key), ",")))
+	}
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+//SignMetadata - returns the pairs of the timestamp, of a new nonce and of the signature of the forwarded metadata
+//for a call of the given full method name, none when there is no secret
+func SignMetadata(secret []byte, method string, md metadata.MD, now time.Time) ([]string, error) {
+	if len(secret) == 0 {
+		return nil, nil
+	}
+	b := make([]byte, nonceBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	timestamp, nonce := now.Unix(), hex.EncodeToString(b)
+	return []string{
+		TimestampKey, strconv.FormatInt(timestamp, 10),
+		NonceKey, nonce,
+		SignatureKey, Sign(secret, method, timestamp, nonce, md),
+	}, nil
+}
+
+//Verify - reports whether the forwarded metadata was signed with the secret for a call of the given full method
+//name no further than MaxSkew from now, it is never the case without a secret. It does not detect replays, see
+//ReplayCache
+func Verify(secret []byte, method string, md metadata.MD, now time.Time) bool {
+
+	if len(secret) == 0 {
+		return false
+	}
+
+	timestamps, nonces, signatures := md.Get(TimestampKey), md.Get(NonceKey), md.Get(SignatureKey)
+
+	if len(timestamps) != 1 || len(nonces) != 1 || len(signatures) != 1 || nonces[0] == "" {
+		return false
+	}
+
+	timestamp, err := strconv.ParseInt(timestamps[0], 10, 64)
+
+	if err != nil {
+		return false
+	}
+
+	if skew := now.Sub(time.Unix(timestamp, 0)); skew > MaxSkew || skew < -MaxSkew {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(secret, method, timestamp, nonces[0], md)), []byte(signatures[0]))
+}
+
+//Nonce - returns the nonce of the signed metadata, empty when there is none
+func Nonce(md metadata.MD) string {
+	if nonces := md.Get(NonceKey); len(nonces) == 1 {
+		return nonces[0]
+	}
+	return ""
+}
+
+//Strip - returns a copy of the metadata without the forwarded keys and their signature
+func Strip(md metadata.MD) metadata.MD {
+	md = md.Copy()
+	for _, key := range append([]string{TimestampKey, NonceKey, SignatureKey}, ForwardedKeys...) {
+		md.Delete(key)
+	}
+	return md
+}
@@ -0,0 +1,113 @@
+package gateway_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/gateway"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	secret = []byte("shared-secret")
+	now    = time.Date(2021, 11, 3, 10, 0, 0, 0, time.UTC)
+)
+
+const method = "/users.Users/Delete"
+
+func signed(secret []byte, at time.Time) metadata.MD {
+	md := metadata.Pairs("x-caller-subject", "7", "x-caller-roles", "admin", "x-client-address", "192.0.2.1")
+	pairs, _ := gateway.SignMetadata(secret, method, md, at)
+	return metadata.Join(md, metadata.Pairs(pairs...))
+}
+
+func Test_Verify_SignedMetadata_IsTrusted(t *testing.T) {
+	//Arrange
+	md := signed(secret, now)
+	//Act
+	trusted := gateway.Verify(secret, method, md, now.Add(time.Minute))
+	//Assert
+	assert.True(t, trusted)
+}
+
+func Test_Verify_ChangedValue_IsNotTrusted(t *testing.T) {
+	//Arrange
+	md := signed(secret, now)
+	md.Set("x-caller-roles", "admin,superuser")
+	//Act
+	trusted := gateway.Verify(secret, method, md, now)
+	//Assert
+	assert.False(t, trusted)
+}
+
+func Test_Verify_OtherMethod_IsNotTrusted(t *testing.T) {
+	//Arrange
+	md := signed(secret, now)
+	//Act
+	trusted := gateway.Verify(secret, "/users.Users/GetUser", md, now)
+	//Assert
+	assert.False(t, trusted)
+}
+
+func Test_Verify_ChangedNonce_IsNotTrusted(t *testing.T) {
+	//Arrange
+	md := signed(secret, now)
+	md.Set(gateway.NonceKey, "0123456789abcdef")
+	//Act
+	trusted := gateway.Verify(secret, method, md, now)
+	//Assert
+	assert.False(t, trusted)
+}
+
+func Test_SignMetadata_EverySignature_HasItsOwnNonce(t *testing.T) {
+	//Arrange
+	first, second := signed(secret, now), signed(secret, now)
+	//Act
+	firstNonce, secondNonce := gateway.Nonce(first), gateway.Nonce(second)
+	//Assert
+	assert.NotEmpty(t, firstNonce)
+	assert.NotEqual(t, firstNonce, secondNonce)
+	assert.NotEqual(t, first.Get(gateway.SignatureKey), second.Get(gateway.SignatureKey))
+}
+
+func Test_Verify_OtherSecret_IsNotTrusted(t *testing.T) {
+	//Arrange
+	md := signed([]byte("another-secret"), now)
+	//Act
+	trusted := gateway.Verify(secret, method, md, now)
+	//Assert
+	assert.False(t, trusted)
+}
+
+func Test_Verify_StaleSignature_IsNotTrusted(t *testing.T) {
+	//Arrange
+	md := signed(secret, now)
+	//Act
+	trusted := gateway.Verify(secret, method, md, now.Add(gateway.MaxSkew+time.Second))
+	//Assert
+	assert.False(t, trusted)
+}
+
+func Test_Verify_NoSecret_IsNotTrusted(t *testing.T) {
+	//Arrange
+	md := signed(nil, now)
+	//Act
+	trusted := gateway.Verify(nil, method, md, now)
+	//Assert
+	assert.False(t, trusted)
+}
+
+func Test_Strip_RemovesForwardedKeys(t *testing.T) {
+	//Arrange
+	md := metadata.Join(signed(secret, now), metadata.Pairs("x-request-id", "42"))
+	//Act
+	stripped := gateway.Strip(md)
+	//Assert
+	assert.Empty(t, stripped.Get("x-caller-subject"))
+	assert.Empty(t, stripped.Get("x-client-address"))
+	assert.Empty(t, stripped.Get(gateway.SignatureKey))
+	assert.Empty(t, stripped.Get(gateway.NonceKey))
+	assert.Equal(t, []string{"42"}, stripped.Get("x-request-id"))
+	assert.Equal(t, []string{"7"}, md.Get("x-caller-subject"))
+}
@@ -0,0 +1,88 @@
+package instrumentation
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+//UnknownOperation - the operation label of the requests whose operation could not be resolved
+const UnknownOperation = "unknown"
+
+//RED - the rate, errors and duration metrics of the requests served, labelled by operation
+type RED struct {
+	Requests metrics.Counter
+	Errors   metrics.Counter
+	Duration metrics.Histogram
+}
+
+//NewRED - returns the RED metrics registered in the default prometheus registry under namespace and subsystem
+func NewRED(namespace, subsystem string) RED {
+	return RED{
+		Requests: kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requests_total",
+			Help:      "Number of requests received.",
+		}, []string{"operation"}),
+		Errors: kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "errors_total",
+			Help:      "Number of requests that failed.",
+		}, []string{"operation"}),
+		Duration: kitprometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Time spent serving the requests, in seconds.",
+			Buckets:   stdprometheus.DefBuckets,
+		}, []string{"operation", "success"}),
+	}
+}
+
+//Observe - records a request to operation started at begin, err is the error it failed with, if any
+func (m RED) Observe(operation string, begin time.Time, err error) {
+
+	if operation == "" {
+		operation = UnknownOperation
+	}
+
+	m.Requests.With("operation", operation).Add(1)
+
+	if err != nil {
+		m.Errors.With("operation", operation).Add(1)
+	}
+
+	m.Duration.With("operation", operation, "success", boolLabel(err == nil)).Observe(time.Since(begin).Seconds())
+}
+
+//EndpointMiddleware - returns a go-kit middleware recording the RED metrics of the endpoints it wraps. The operation
+//label is resolved from the request context, failed returns the business error carried by a response, if any
+func EndpointMiddleware(m RED, operation func(context.Context) string, failed func(response interface{}) error) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+
+			defer func(begin time.Time) {
+				failure := err
+				if failure == nil && failed != nil {
+					failure = failed(response)
+				}
+				m.Observe(operation(ctx), begin, failure)
+			}(time.Now())
+
+			return next(ctx, request)
+		}
+	}
+}
+
+func boolLabel(value bool) string {
+	if value {
+		return "true"
+	}
+	return "false"
+}
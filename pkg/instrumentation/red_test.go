@@ -0,0 +1,142 @@
+package instrumentation_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/casmelad/GlobantPOC/pkg/instrumentation"
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/go-kit/kit/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+//recorder - keeps the values the fake metrics were given, keyed by name and label values
+type recorder map[string]float64
+
+type counter struct {
+	values recorder
+	name   string
+	labels []string
+}
+
+func (c counter) With(labelValues ...string) metrics.Counter {
+	return counter{values: c.values, name: c.name, labels: append(append([]string{}, c.labels...), labelValues...)}
+}
+
+func (c counter) Add(delta float64) {
+	c.values[key(c.name, c.labels)] += delta
+}
+
+type histogram struct {
+	values recorder
+	name   string
+	labels []string
+}
+
+func (h histogram) With(labelValues ...string) metrics.Histogram {
+	return histogram{values: h.values, name: h.name, labels: append(append([]string{}, h.labels...), labelValues...)}
+}
+
+//Observe - counts the observations, the durations are not deterministic
+func (h histogram) Observe(value float64) {
+	h.values[key(h.name, h.labels)]++
+}
+
+func key(name string, labels []string) string {
+	return name + "{" + strings.Join(labels, ",") + "}"
+}
+
+func newRED() (instrumentation.RED, recorder) {
+	values := recorder{}
+	return instrumentation.RED{
+		Requests: counter{values: values, name: "requests"},
+		Errors:   counter{values: values, name: "errors"},
+		Duration: histogram{values: values, name: "duration"},
+	}, values
+}
+
+type operationKey struct{}
+
+func operation(ctx context.Context) string {
+	name, _ := ctx.Value(operationKey{}).(string)
+	return name
+}
+
+type failedResponse struct {
+	Error error
+}
+
+func failed(response interface{}) error {
+	if r, ok := response.(failedResponse); ok {
+		return r.Error
+	}
+	return nil
+}
+
+func Test_EndpointMiddleware_Success_RecordsRequestAndDuration(t *testing.T) {
+	//Arrange
+	m, values := newRED()
+	e := instrumentation.EndpointMiddleware(m, operation, failed)(func(ctx context.Context, request interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	ctx := context.WithValue(context.Background(), operationKey{}, "GET /users/{email}")
+	//Act
+	response, err := e(ctx, nil)
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", response)
+	assert.Equal(t, recorder{
+		"requests{operation,GET /users/{email}}":              1,
+		"duration{operation,GET /users/{email},success,true}": 1,
+	}, values)
+}
+
+func Test_EndpointMiddleware_ResponseError_RecordsError(t *testing.T) {
+	//Arrange
+	m, values := newRED()
+	e := instrumentation.EndpointMiddleware(m, operation, failed)(func(ctx context.Context, request interface{}) (interface{}, error) {
+		return failedResponse{Error: errors.New("not found")}, nil
+	})
+	ctx := context.WithValue(context.Background(), operationKey{}, "/proto.Users/GetUser")
+	//Act
+	_, err := e(ctx, nil)
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), values["errors{operation,/proto.Users/GetUser}"])
+	assert.Equal(t, float64(1), values["duration{operation,/proto.Users/GetUser,success,false}"])
+}
+
+func Test_EndpointMiddleware_EndpointError_RecordsError(t *testing.T) {
+	//Arrange
+	m, values := newRED()
+	e := instrumentation.EndpointMiddleware(m, operation, nil)(func(ctx context.Context, request interface{}) (interface{}, error) {
+		return nil, errors.New("invalid input data")
+	})
+	//Act
+	_, err := e(context.Background(), nil)
+	//Assert
+	assert.NotNil(t, err)
+	assert.Equal(t, float64(1), values["requests{operation,unknown}"])
+	assert.Equal(t, float64(1), values["errors{operation,unknown}"])
+}
+
+func Test_Repository_Queries_RecordedPerOperation(t *testing.T) {
+	//Arrange
+	m, values := newRED()
+	repository := instrumentation.NewRepository(memory.NewInMemoryUserRepository(), m)
+	ctx := context.Background()
+	//Act
+	id, err := repository.Add(ctx, users.User{Email: "user@globant.com", Name: "User", LastName: "Name"})
+	repository.GetByID(ctx, id)
+	deleteErr := repository.Delete(ctx, id, 99)
+	//Assert
+	assert.Nil(t, err)
+	assert.NotNil(t, deleteErr)
+	assert.Equal(t, float64(1), values["requests{operation,Add}"])
+	assert.Equal(t, float64(1), values["duration{operation,GetByID,success,true}"])
+	assert.Equal(t, float64(1), values["errors{operation,Delete}"])
+	assert.Equal(t, float64(1), values["duration{operation,Delete,success,false}"])
+}
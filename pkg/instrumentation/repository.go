@@ -0,0 +1,86 @@
+package instrumentation
+
+import (
+	"context"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+//Repository - a users.Repository decorator that records the latency and the errors of every query
+type Repository struct {
+	next    users.Repository
+	metrics RED
+}
+
+//NewRepository - returns a Repository type pointer that decorates next
+func NewRepository(next users.Repository, m RED) *Repository {
+	return &Repository{
+		next:    next,
+		metrics: m,
+	}
+}
+
+//Add - adds a user to the repository
+func (r *Repository) Add(ctx context.Context, usr users.User) (id int, err error) {
+	defer r.observe("Add", time.Now(), &err)
+	return r.next.Add(ctx, usr)
+}
+
+//GetByID - retrieves a user from the repository based on the integer id
+func (r *Repository) GetByID(ctx context.Context, id int) (usr users.User, err error) {
+	defer r.observe("GetByID", time.Now(), &err)
+	return r.next.GetByID(ctx, id)
+}
+
+//GetByEmail - retrieves a user from the repository based on the email address
+func (r *Repository) GetByEmail(ctx context.Context, email string) (usr users.User, err error) {
+	defer r.observe("GetByEmail", time.Now(), &err)
+	return r.next.GetByEmail(ctx, email)
+}
+
+//GetAll - retrieves the users from the repository that match the filter
+func (r *Repository) GetAll(ctx context.Context, filter users.Filter) (usrs []users.User, err error) {
+	defer r.observe("GetAll", time.Now(), &err)
+	return r.next.GetAll(ctx, filter)
+}
+
+//Update - updates the information of a user
+func (r *Repository) Update(ctx context.Context, usr users.User) (err error) {
+	defer r.observe("Update", time.Now(), &err)
+	return r.next.Update(ctx, usr)
+}
+
+//SetStatus - changes the status of a user
+func (r *Repository) SetStatus(ctx context.Context, id int, version int, status users.AccountStatus) (err error) {
+	defer r.observe("SetStatus", time.Now(), &err)
+	return r.next.SetStatus(ctx, id, version, status)
+}
+
+//Delete - soft deletes a user from the repository
+func (r *Repository) Delete(ctx context.Context, id int, version int) (err error) {
+	defer r.observe("Delete", time.Now(), &err)
+	return r.next.Delete(ctx, id, version)
+}
+
+//Restore - undoes the soft delete of a user
+func (r *Repository) Restore(ctx context.Context, id int) (err error) {
+	defer r.observe("Restore", time.Now(), &err)
+	return r.next.Restore(ctx, id)
+}
+
+//Purge - permanently removes a user from the repository
+func (r *Repository) Purge(ctx context.Context, id int) (err error) {
+	defer r.observe("Purge", time.Now(), &err)
+	return r.next.Purge(ctx, id)
+}
+
+//DeletedBefore - retrieves the ids of the users soft deleted before the given time
+func (r *Repository) DeletedBefore(ctx context.Context, before time.Time) (ids []int, err error) {
+	defer r.observe("DeletedBefore", time.Now(), &err)
+	return r.next.DeletedBefore(ctx, before)
+}
+
+func (r *Repository) observe(operation string, begin time.Time, err *error) {
+	r.metrics.Observe(operation, begin, *err)
+}
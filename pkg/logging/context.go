@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/casmelad/GlobantPOC/pkg/tracing"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+//WithRequest - returns a logger adding the request_id, trace_id and span_id of the request being served to every
+//line, the ones missing from the context are left out
+func WithRequest(ctx context.Context, logger log.Logger) log.Logger {
+
+	if id := users.RequestIDFromContext(ctx); id != "" {
+		logger = log.With(logger, "request_id", id)
+	}
+
+	return tracing.WithTrace(ctx, logger)
+}
+
+//ErrorHandler - a go-kit transport.ErrorHandler logging the errors along with the request and the trace they belong to
+type ErrorHandler struct {
+	logger log.Logger
+}
+
+//NewErrorHandler - returns an ErrorHandler type pointer writing to logger
+func NewErrorHandler(logger log.Logger) *ErrorHandler {
+	return &ErrorHandler{
+		logger: logger,
+	}
+}
+
+//Handle - logs the error as such with the ids found in the context
+func (h *ErrorHandler) Handle(ctx context.Context, err error) {
+	level.Error(WithRequest(ctx, h.logger)).Log("err", err)
+}
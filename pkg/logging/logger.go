@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+const (
+	//FormatJSON - every line is written as a JSON object
+	FormatJSON = "json"
+	//FormatLogfmt - every line is written as logfmt key=value pairs
+	FormatLogfmt = "logfmt"
+)
+
+const (
+	//LevelDebug - every line is written
+	LevelDebug = "debug"
+	//LevelInfo - the debug lines are dropped
+	LevelInfo = "info"
+	//LevelWarn - only the warnings and the errors are written
+	LevelWarn = "warn"
+	//LevelError - only the errors are written
+	LevelError = "error"
+)
+
+var (
+	//ErrUnknownFormat - the configured log format is not supported
+	ErrUnknownFormat = errors.New("unknown log format")
+	//ErrUnknownLevel - the configured log level is not supported
+	ErrUnknownLevel = errors.New("unknown log level")
+)
+
+//Config - the settings of the logger of a binary
+type Config struct {
+	Level  string
+	Format string
+}
+
+//New - returns the logger writing to w in the configured format. The lines below the configured level are dropped,
+//the ones without a level are logged as info, and the personal data is redacted
+func New(w io.Writer, cfg Config) (log.Logger, error) {
+
+	allowed, err := levelOption(cfg.Level)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var logger log.Logger
+
+	switch cfg.Format {
+	case FormatLogfmt, "":
+		logger = log.NewLogfmtLogger(log.NewSyncWriter(w))
+	case FormatJSON:
+		logger = log.NewJSONLogger(log.NewSyncWriter(w))
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, cfg.Format)
+	}
+
+	logger = level.NewInjector(level.NewFilter(Redact(logger), allowed), level.InfoValue())
+
+	return log.With(logger, "ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller), nil
+}
+
+func levelOption(name string) (level.Option, error) {
+
+	switch name {
+	case LevelDebug:
+		return level.AllowDebug(), nil
+	case LevelInfo, "":
+		return level.AllowInfo(), nil
+	case LevelWarn:
+		return level.AllowWarn(), nil
+	case LevelError:
+		return level.AllowError(), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownLevel, name)
+}
@@ -0,0 +1,161 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/casmelad/GlobantPOC/pkg/logging"
+	"github.com/casmelad/GlobantPOC/pkg/tracing"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/openzipkin/zipkin-go/reporter"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newJSONLogger(t *testing.T, lvl string) (log.Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	logger, err := logging.New(buf, logging.Config{Level: lvl, Format: logging.FormatJSON})
+	assert.Nil(t, err)
+	return logger, buf
+}
+
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	result := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal([]byte(line), &fields))
+		result = append(result, fields)
+	}
+	return result
+}
+
+func Test_New_JSONFormat_LevelledLines(t *testing.T) {
+	//Arrange
+	logger, buf := newJSONLogger(t, logging.LevelInfo)
+	//Act
+	logger.Log("msg", "started")
+	level.Debug(logger).Log("msg", "dropped")
+	level.Warn(logger).Log("msg", "kept")
+	//Assert
+	logged := lines(t, buf)
+	assert.Len(t, logged, 2)
+	assert.Equal(t, "info", logged[0]["level"])
+	assert.Equal(t, "started", logged[0]["msg"])
+	assert.NotEmpty(t, logged[0]["ts"])
+	assert.Equal(t, "warn", logged[1]["level"])
+}
+
+func Test_New_LogfmtFormat_KeyValuePairs(t *testing.T) {
+	//Arrange
+	buf := &bytes.Buffer{}
+	logger, err := logging.New(buf, logging.Config{Level: logging.LevelError, Format: logging.FormatLogfmt})
+	//Act
+	level.Info(logger).Log("msg", "dropped")
+	level.Error(logger).Log("msg", "failed")
+	//Assert
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "level=error")
+	assert.Contains(t, buf.String(), "msg=failed")
+	assert.NotContains(t, buf.String(), "dropped")
+}
+
+func Test_New_UnknownFormat_Fails(t *testing.T) {
+	//Act
+	_, err := logging.New(&bytes.Buffer{}, logging.Config{Format: "xml"})
+	//Assert
+	assert.True(t, errors.Is(err, logging.ErrUnknownFormat))
+}
+
+func Test_New_UnknownLevel_Fails(t *testing.T) {
+	//Act
+	_, err := logging.New(&bytes.Buffer{}, logging.Config{Level: "verbose"})
+	//Assert
+	assert.True(t, errors.Is(err, logging.ErrUnknownLevel))
+}
+
+func Test_Redact_PersonalData_NotWritten(t *testing.T) {
+	//Arrange
+	logger, buf := newJSONLogger(t, logging.LevelDebug)
+	//Act
+	logger.Log("email", "user@globant.com", "Name", "User", "last_name", "Name", "err", errors.New("user@globant.com already exists"), "id", 7)
+	//Assert
+	assert.NotContains(t, buf.String(), "user@globant.com")
+	logged := lines(t, buf)
+	assert.Equal(t, logging.Redacted, logged[0]["email"])
+	assert.Equal(t, logging.Redacted, logged[0]["Name"])
+	assert.Equal(t, logging.Redacted, logged[0]["last_name"])
+	assert.Equal(t, logging.Redacted+" already exists", logged[0]["err"])
+	assert.Equal(t, float64(7), logged[0]["id"])
+}
+
+func Test_WithRequest_RequestAndTraceIDs_OnEveryLine(t *testing.T) {
+	//Arrange
+	logger, buf := newJSONLogger(t, logging.LevelInfo)
+	tracer, _, err := tracing.NewTracer(reporter.NewNoopReporter(), "users-grpc", "", 1)
+	assert.Nil(t, err)
+	span, ctx := tracer.StartSpanFromContext(users.WithRequestID(context.Background(), "c0ffee"), "request")
+	defer span.Finish()
+	//Act
+	logging.WithRequest(ctx, logger).Log("msg", "served")
+	//Assert
+	logged := lines(t, buf)
+	assert.Equal(t, "c0ffee", logged[0]["request_id"])
+	assert.Equal(t, span.Context().TraceID.String(), logged[0]["trace_id"])
+}
+
+func Test_EndpointMiddleware_Success_InfoLine(t *testing.T) {
+	//Arrange
+	logger, buf := newJSONLogger(t, logging.LevelInfo)
+	mw := logging.EndpointMiddleware(logger, logging.Endpoint{
+		Operation: func(context.Context) string { return "/proto.Users/GetUser" },
+	})
+	ctx := users.WithRequestID(context.Background(), "c0ffee")
+	//Act
+	mw(func(context.Context, interface{}) (interface{}, error) { return "ok", nil })(ctx, nil)
+	//Assert
+	logged := lines(t, buf)
+	assert.Len(t, logged, 1)
+	assert.Equal(t, "info", logged[0]["level"])
+	assert.Equal(t, "/proto.Users/GetUser", logged[0]["method"])
+	assert.Equal(t, "c0ffee", logged[0]["request_id"])
+	assert.NotEmpty(t, logged[0]["duration"])
+	assert.Nil(t, logged[0]["error_class"])
+}
+
+func Test_EndpointMiddleware_BusinessError_WarnLineWithClass(t *testing.T) {
+	//Arrange
+	logger, buf := newJSONLogger(t, logging.LevelInfo)
+	failure := errors.New("user not found")
+	mw := logging.EndpointMiddleware(logger, logging.Endpoint{
+		Operation: func(context.Context) string { return "GET /users/{email}" },
+		Failed:    func(interface{}) error { return failure },
+		Classify:  func(error) string { return logging.ErrorClassClient },
+	})
+	//Act
+	mw(func(context.Context, interface{}) (interface{}, error) { return "response", nil })(context.Background(), nil)
+	//Assert
+	logged := lines(t, buf)
+	assert.Equal(t, "warn", logged[0]["level"])
+	assert.Equal(t, logging.ErrorClassClient, logged[0]["error_class"])
+	assert.Equal(t, failure.Error(), logged[0]["err"])
+}
+
+func Test_ErrorClass_Errors_Classified(t *testing.T) {
+	//Assert
+	assert.Equal(t, logging.ErrorClassCanceled, logging.ErrorClass(context.Canceled))
+	assert.Equal(t, logging.ErrorClassTimeout, logging.ErrorClass(context.DeadlineExceeded))
+	assert.Equal(t, logging.ErrorClassTimeout, logging.ErrorClass(status.Error(codes.DeadlineExceeded, "slow")))
+	assert.Equal(t, logging.ErrorClassClient, logging.ErrorClass(status.Error(codes.NotFound, "missing")))
+	assert.Equal(t, logging.ErrorClassServer, logging.ErrorClass(status.Error(codes.Unavailable, "down")))
+	assert.Equal(t, logging.ErrorClassServer, logging.ErrorClass(errors.New("boom")))
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	//ErrorClassClient - the request was rejected, it has to be changed before it is retried
+	ErrorClassClient = "client"
+	//ErrorClassServer - the request failed on the server, or on a service it depends on
+	ErrorClassServer = "server"
+	//ErrorClassCanceled - the caller gave up on the request
+	ErrorClassCanceled = "canceled"
+	//ErrorClassTimeout - the request did not complete in time
+	ErrorClassTimeout = "timeout"
+)
+
+//Endpoint - describes the calls to the endpoints a middleware wraps. Operation resolves the method being served from
+//the request context, Failed returns the business error carried by a response, if any, and Classify the class of an
+//error, ErrorClass when nil
+type Endpoint struct {
+	Operation func(context.Context) string
+	Failed    func(response interface{}) error
+	Classify  func(error) string
+}
+
+//EndpointMiddleware - returns a go-kit middleware logging the method, duration and error class of every call to the
+//endpoints it wraps, along with the ids of the request. The failed calls are logged as warnings
+func EndpointMiddleware(logger log.Logger, e Endpoint) endpoint.Middleware {
+
+	classify := e.Classify
+
+	if classify == nil {
+		classify = ErrorClass
+	}
+
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+
+			defer func(begin time.Time) {
+				failure := err
+				if failure == nil && e.Failed != nil {
+					failure = e.Failed(response)
+				}
+				keyvals := []interface{}{"method", operation(ctx, e.Operation), "duration", time.Since(begin)}
+				if failure == nil {
+					level.Info(WithRequest(ctx, logger)).Log(keyvals...)
+					return
+				}
+				keyvals = append(keyvals, "error_class", classify(failure), "err", failure)
+				level.Warn(WithRequest(ctx, logger)).Log(keyvals...)
+			}(time.Now())
+
+			return next(ctx, request)
+		}
+	}
+}
+
+//ErrorClass - returns the class of err from the context and gRPC status errors, any other error is a server one
+func ErrorClass(err error) string {
+
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCanceled
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+
+	if s, ok := status.FromError(err); ok && err != nil {
+		switch s.Code() {
+		case codes.Canceled:
+			return ErrorClassCanceled
+		case codes.DeadlineExceeded:
+			return ErrorClassTimeout
+		case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+			codes.FailedPrecondition, codes.OutOfRange, codes.Unauthenticated, codes.ResourceExhausted:
+			return ErrorClassClient
+		}
+	}
+
+	return ErrorClassServer
+}
+
+func operation(ctx context.Context, resolve func(context.Context) string) string {
+
+	if resolve == nil {
+		return ""
+	}
+
+	return resolve(ctx)
+}
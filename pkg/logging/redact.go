@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+)
+
+//Redacted - replaces the personal data in the log lines
+const Redacted = "[REDACTED]"
+
+//piiKeys - the keys whose values are personal data, they are never written
+var piiKeys = map[string]bool{
+	"email":      true,
+	"new_email":  true,
+	"name":       true,
+	"last_name":  true,
+	"lastname":   true,
+	"first_name": true,
+	"password":   true,
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+type redactingLogger struct {
+	next log.Logger
+}
+
+//Redact - returns a logger that replaces the values of the personal data keys, and the email addresses found in the
+//text values and errors, before writing them to next
+func Redact(next log.Logger) log.Logger {
+	return &redactingLogger{next: next}
+}
+
+//Log - redacts the key values and writes them to the wrapped logger
+func (l *redactingLogger) Log(keyvals ...interface{}) error {
+
+	redacted := make([]interface{}, len(keyvals))
+
+	for i := 0; i < len(keyvals); i += 2 {
+		redacted[i] = keyvals[i]
+		if i+1 == len(keyvals) {
+			break
+		}
+		if key, ok := keyvals[i].(string); ok && piiKeys[strings.ToLower(key)] {
+			redacted[i+1] = Redacted
+			continue
+		}
+		redacted[i+1] = redactValue(keyvals[i+1])
+	}
+
+	return l.next.Log(redacted...)
+}
+
+func redactValue(value interface{}) interface{} {
+
+	var text string
+
+	switch v := value.(type) {
+	case string:
+		text = v
+	case error:
+		text = v.Error()
+	case fmt.Stringer:
+		text = v.String()
+	default:
+		return value
+	}
+
+	if !emailPattern.MatchString(text) {
+		return value
+	}
+
+	return emailPattern.ReplaceAllString(text, Redacted)
+}
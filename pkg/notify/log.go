@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+//LogNotifier - writes every notification as a JSON line instead of sending it, for local use
+type LogNotifier struct {
+	mtx     sync.Mutex
+	encoder *json.Encoder
+}
+
+//NewLogNotifier - returns a LogNotifier type pointer that writes to w
+func NewLogNotifier(w io.Writer) *LogNotifier {
+	return &LogNotifier{encoder: json.NewEncoder(w)}
+}
+
+//Notify - writes the notification
+func (n *LogNotifier) Notify(ctx context.Context, notification users.Notification) error {
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	return n.encoder.Encode(notification)
+}
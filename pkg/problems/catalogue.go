@@ -0,0 +1,119 @@
+package problems
+
+import (
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+)
+
+//Code - a stable, machine readable error code. The codes are shared by the REST gateway, in the problem documents,
+//and the gRPC service, in the error details. They are documented in docs/errors.md
+type Code string
+
+//Domain - the domain of the google.rpc.ErrorInfo details carrying the codes over gRPC
+const Domain = "users"
+
+const (
+	//CodeBadRequest - the request is missing a path parameter or carries an invalid one
+	CodeBadRequest Code = "BAD_REQUEST"
+	//CodeMalformedRequest - the body of the request is not valid JSON, or does not match the expected shape
+	CodeMalformedRequest Code = "MALFORMED_REQUEST"
+	//CodeValidationFailed - one or more fields break a validation rule, they are listed in the errors
+	CodeValidationFailed Code = "VALIDATION_FAILED"
+	//CodeWeakPassword - the password does not meet the password policy
+	CodeWeakPassword Code = "WEAK_PASSWORD"
+	//CodePayloadTooLarge - the body of the request is over the size limit of the gateway
+	CodePayloadTooLarge Code = "PAYLOAD_TOO_LARGE"
+	//CodeUnsupportedMediaType - the content type of the body is not accepted by the operation
+	CodeUnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE"
+	//CodeUnauthenticated - the credentials, token or API key are missing or not valid
+	CodeUnauthenticated Code = "UNAUTHENTICATED"
+	//CodeForbidden - the caller is not allowed to perform the operation
+	CodeForbidden Code = "FORBIDDEN"
+	//CodeNotFound - the resource does not exist
+	CodeNotFound Code = "NOT_FOUND"
+	//CodeAlreadyExists - a user with the same email address already exists
+	CodeAlreadyExists Code = "ALREADY_EXISTS"
+	//CodeVersionConflict - the resource was modified since the version sent in If-Match
+	CodeVersionConflict Code = "VERSION_CONFLICT"
+	//CodePreconditionRequired - the write has to be sent with an If-Match header, or with the version over gRPC
+	CodePreconditionRequired Code = "PRECONDITION_REQUIRED"
+	//CodeStatusConflict - the current status of the user does not allow the transition
+	CodeStatusConflict Code = "STATUS_CONFLICT"
+	//CodePatchTestFailed - a test operation of a JSON patch did not match
+	CodePatchTestFailed Code = "PATCH_TEST_FAILED"
+	//CodeAccountLocked - the account is locked after too many failed logins
+	CodeAccountLocked Code = "ACCOUNT_LOCKED"
+	//CodeTooManyRequests - a rate limit was exceeded, the request can be retried later
+	CodeTooManyRequests Code = "TOO_MANY_REQUESTS"
+	//CodeInternal - the request failed on the server
+	CodeInternal Code = "INTERNAL"
+	//CodeUnavailable - a service the request depends on can not be reached
+	CodeUnavailable Code = "UNAVAILABLE"
+	//CodeTimeout - the request did not complete in time
+	CodeTimeout Code = "TIMEOUT"
+)
+
+//Definition - how an error code is answered: the title of its problem documents, and the HTTP and gRPC status codes
+type Definition struct {
+	Code   Code
+	Title  string
+	Status int
+	GRPC   codes.Code
+}
+
+//Catalogue - every error code, the first one of a gRPC status code is the one it is translated to
+var Catalogue = []Definition{
+	{CodeBadRequest, "Bad request", http.StatusBadRequest, codes.InvalidArgument},
+	{CodeMalformedRequest, "Malformed request", http.StatusBadRequest, codes.InvalidArgument},
+	{CodeValidationFailed, "Validation failed", http.StatusUnprocessableEntity, codes.InvalidArgument},
+	{CodeWeakPassword, "Weak password", http.StatusUnprocessableEntity, codes.InvalidArgument},
+	{CodePayloadTooLarge, "Payload too large", http.StatusRequestEntityTooLarge, codes.InvalidArgument},
+	{CodeUnsupportedMediaType, "Unsupported media type", http.StatusUnsupportedMediaType, codes.InvalidArgument},
+	{CodeUnauthenticated, "Unauthenticated", http.StatusUnauthorized, codes.Unauthenticated},
+	{CodeForbidden, "Forbidden", http.StatusForbidden, codes.PermissionDenied},
+	{CodeNotFound, "Not found", http.StatusNotFound, codes.NotFound},
+	{CodeAlreadyExists, "Already exists", http.StatusConflict, codes.AlreadyExists},
+	{CodeVersionConflict, "Version conflict", http.StatusPreconditionFailed, codes.Aborted},
+	{CodePreconditionRequired, "Precondition required", http.StatusPreconditionRequired, codes.FailedPrecondition},
+	{CodeStatusConflict, "Status conflict", http.StatusConflict, codes.FailedPrecondition},
+	{CodePatchTestFailed, "Patch test failed", http.StatusConflict, codes.FailedPrecondition},
+	{CodeAccountLocked, "Account locked", http.StatusLocked, codes.PermissionDenied},
+	{CodeTooManyRequests, "Too many requests", http.StatusTooManyRequests, codes.ResourceExhausted},
+	{CodeInternal, "Internal error", http.StatusInternalServerError, codes.Internal},
+	{CodeUnavailable, "Service unavailable", http.StatusServiceUnavailable, codes.Unavailable},
+	{CodeTimeout, "Timeout", http.StatusGatewayTimeout, codes.DeadlineExceeded},
+}
+
+//Lookup - returns the definition of code, the one of CodeInternal when it is not in the catalogue
+func Lookup(code Code) Definition {
+
+	for _, d := range Catalogue {
+		if d.Code == code {
+			return d
+		}
+	}
+
+	return Lookup(CodeInternal)
+}
+
+//FromGRPC - returns the error code a gRPC status code is translated to, CodeInternal when none matches
+func FromGRPC(c codes.Code) Code {
+
+	for _, d := range Catalogue {
+		if d.GRPC == c {
+			return d.Code
+		}
+	}
+
+	return CodeInternal
+}
+
+//Type - returns the URI that identifies the problem type of the code
+func (c Code) Type() string {
+	return TypeURIPrefix + strings.ReplaceAll(strings.ToLower(string(c)), "_", "-")
+}
+
+//TypeURIPrefix - the problem type URIs are this prefix followed by the code in kebab case
+const TypeURIPrefix = "urn:users:problem:"
@@ -0,0 +1,80 @@
+package problems
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+//ContentType - the media type of the problem documents, RFC 7807
+const ContentType = "application/problem+json"
+
+//FieldError - a field of the request that breaks a validation rule
+type FieldError struct {
+	Field  string `json:"field"`
+	Rule   string `json:"rule,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+//Problem - an RFC 7807 problem document. Instance is the id of the request that failed, Code the error code of the
+//catalogue and Errors the fields that are not valid, if any
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     Code         `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+//New - returns the problem document of code, the detail is left out for the internal errors so that they do not leak
+func New(code Code, detail string, instance string) Problem {
+
+	d := Lookup(code)
+
+	if d.Status >= 500 {
+		detail = ""
+	}
+
+	return Problem{
+		Type:     d.Code.Type(),
+		Title:    d.Title,
+		Status:   d.Status,
+		Detail:   detail,
+		Instance: instance,
+		Code:     d.Code,
+	}
+}
+
+//Error - an error with a code of the catalogue, and the fields that are not valid when it is a validation error
+type Error struct {
+	Code   Code
+	Detail string
+	Fields []FieldError
+}
+
+//Error - returns the detail of the error, the title of its code when it has none
+func (e Error) Error() string {
+	if e.Detail != "" {
+		return e.Detail
+	}
+	return Lookup(e.Code).Title
+}
+
+//ErrorInfo - returns the google.rpc.ErrorInfo detail carrying code over gRPC
+func ErrorInfo(code Code) *errdetails.ErrorInfo {
+	return &errdetails.ErrorInfo{Reason: string(code), Domain: Domain}
+}
+
+//FromStatus - returns the error code of a gRPC status: the one of its ErrorInfo detail, or the one its status code is
+//translated to
+func FromStatus(st *status.Status) Code {
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == Domain && info.Reason != "" {
+			return Code(info.Reason)
+		}
+	}
+
+	return FromGRPC(st.Code())
+}
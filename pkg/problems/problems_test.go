@@ -0,0 +1,77 @@
+package problems_test
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_Lookup_UnknownCode_ReturnsInternal(t *testing.T) {
+	//Act
+	d := problems.Lookup("NOT_IN_CATALOGUE")
+	//Assert
+	assert.Equal(t, problems.CodeInternal, d.Code)
+	assert.Equal(t, http.StatusInternalServerError, d.Status)
+}
+
+func TestCases_FromGRPC(t *testing.T) {
+
+	testCases := []struct {
+		grpc codes.Code
+		code problems.Code
+	}{
+		{codes.InvalidArgument, problems.CodeBadRequest},
+		{codes.NotFound, problems.CodeNotFound},
+		{codes.Aborted, problems.CodeVersionConflict},
+		{codes.Unavailable, problems.CodeUnavailable},
+		{codes.DeadlineExceeded, problems.CodeTimeout},
+		{codes.Unknown, problems.CodeInternal},
+	}
+
+	for _, useCase := range testCases {
+		assert.Equal(t, useCase.code, problems.FromGRPC(useCase.grpc), useCase.grpc.String())
+	}
+}
+
+func Test_FromStatus_ErrorInfo_ReturnsItsCode(t *testing.T) {
+	//Arrange
+	st, err := status.New(codes.PermissionDenied, "locked").WithDetails(problems.ErrorInfo(problems.CodeAccountLocked))
+	assert.Nil(t, err)
+	//Act
+	code := problems.FromStatus(st)
+	//Assert
+	assert.Equal(t, problems.CodeAccountLocked, code)
+}
+
+func Test_New_ServerError_LeavesDetailOut(t *testing.T) {
+	//Act
+	problem := problems.New(problems.CodeInternal, "dial tcp 10.0.0.1:3306: connection refused", "req-1")
+	//Assert
+	assert.Equal(t, "", problem.Detail)
+	assert.Equal(t, "req-1", problem.Instance)
+	assert.Equal(t, "urn:users:problem:internal", problem.Type)
+}
+
+func Test_New_ClientError_KeepsDetail(t *testing.T) {
+	//Act
+	problem := problems.New(problems.CodeNotFound, "user not found", "")
+	//Assert
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "user not found", problem.Detail)
+}
+
+func Test_Catalogue_EveryCodeDocumented(t *testing.T) {
+	//Arrange
+	doc, err := os.ReadFile("../../docs/errors.md")
+	assert.Nil(t, err)
+	//Assert
+	for _, d := range problems.Catalogue {
+		assert.True(t, strings.Contains(string(doc), "`"+string(d.Code)+"`"), string(d.Code))
+	}
+}
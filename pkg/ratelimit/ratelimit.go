@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+//Limit - a token bucket that holds Burst tokens and is refilled with Requests tokens every Per. Every request
+//takes a token, so a client can make Burst requests at once and Requests every Per after that
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	//Burst - the size of the bucket, Requests when it is zero
+	Burst int
+}
+
+//Capacity - the tokens a full bucket holds
+func (l Limit) Capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+//interval - the time a single token takes to be refilled
+func (l Limit) interval() time.Duration {
+	return l.Per / time.Duration(l.Requests)
+}
+
+//Result - the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	//Limit - the capacity of the bucket
+	Limit int
+	//Remaining - the whole tokens left in the bucket
+	Remaining int
+	//RetryAfter - the time until a token is available, zero when the request was allowed
+	RetryAfter time.Duration
+	//ResetAfter - the time until the bucket is full again
+	ResetAfter time.Duration
+}
+
+//Bucket - the state of a token bucket, the zero bucket is full
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+//Take - refills the bucket up to the given time and takes a token when there is one
+func (b Bucket) Take(l Limit, at time.Time) (Bucket, Result) {
+
+	capacity := float64(l.Capacity())
+	interval := float64(l.interval())
+	tokens := b.tokens(l, at)
+
+	result := Result{Limit: l.Capacity()}
+
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - tokens) * interval))
+	}
+
+	result.Remaining = int(tokens)
+	result.ResetAfter = time.Duration(math.Ceil((capacity - tokens) * interval))
+
+	return Bucket{Tokens: tokens, UpdatedAt: at}, result
+}
+
+//Full - reports whether the bucket is full at the given time, a full bucket can be forgotten as it is the
+//same as a new one
+func (b Bucket) Full(l Limit, at time.Time) bool {
+	return b.tokens(l, at) >= float64(l.Capacity())
+}
+
+func (b Bucket) tokens(l Limit, at time.Time) float64 {
+
+	capacity := float64(l.Capacity())
+
+	if b.UpdatedAt.IsZero() {
+		return capacity
+	}
+
+	return math.Min(capacity, b.Tokens+float64(at.Sub(b.UpdatedAt))/float64(l.interval()))
+}
+
+//Store - keeps the buckets of the clients. An in memory store limits every instance on its own, a store
+//shared by the instances limits the clients across all of them
+type Store interface {
+	//Take - takes a token from the bucket of the key at the given time, a bucket that does not exist is full
+	Take(ctx context.Context, key string, limit Limit, at time.Time) (Result, error)
+}
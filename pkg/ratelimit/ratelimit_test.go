@@ -0,0 +1,50 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func Test_Take_EmptyBucket_ReturnsRetryAfterOneToken(t *testing.T) {
+	//Arrange
+	limit := ratelimit.Limit{Requests: 10, Per: time.Minute, Burst: 2}
+	bucket := ratelimit.Bucket{}
+	//Act
+	bucket, first := bucket.Take(limit, now)
+	bucket, second := bucket.Take(limit, now)
+	_, third := bucket.Take(limit, now)
+	//Assert
+	assert.Equal(t, ratelimit.Result{Allowed: true, Limit: 2, Remaining: 1, ResetAfter: 6 * time.Second}, first)
+	assert.Equal(t, ratelimit.Result{Allowed: true, Limit: 2, Remaining: 0, ResetAfter: 12 * time.Second}, second)
+	assert.Equal(t, ratelimit.Result{Allowed: false, Limit: 2, Remaining: 0, RetryAfter: 6 * time.Second, ResetAfter: 12 * time.Second}, third)
+}
+
+func Test_Take_AfterTheInterval_RefillsAToken(t *testing.T) {
+	//Arrange
+	limit := ratelimit.Limit{Requests: 1, Per: time.Second}
+	bucket, _ := ratelimit.Bucket{}.Take(limit, now)
+	//Act
+	_, early := bucket.Take(limit, now.Add(500*time.Millisecond))
+	_, refilled := bucket.Take(limit, now.Add(time.Second))
+	//Assert
+	assert.False(t, early.Allowed)
+	assert.Equal(t, 500*time.Millisecond, early.RetryAfter)
+	assert.True(t, refilled.Allowed)
+}
+
+func Test_Full_RefilledBucket_IsFull(t *testing.T) {
+	//Arrange
+	limit := ratelimit.Limit{Requests: 2, Per: time.Second}
+	bucket, _ := ratelimit.Bucket{}.Take(limit, now)
+	//Act
+	partial := bucket.Full(limit, now.Add(100*time.Millisecond))
+	full := bucket.Full(limit, now.Add(500*time.Millisecond))
+	//Assert
+	assert.False(t, partial)
+	assert.True(t, full)
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/casmelad/GlobantPOC/pkg/audit"
+)
+
+//InMemoryAuditRepository is an in memory implementation of audit Store
+type InMemoryAuditRepository struct {
+	mtx     sync.RWMutex
+	entries []audit.Entry
+}
+
+//NewInMemoryAuditRepository returns an InMemoryAuditRepository type pointer
+func NewInMemoryAuditRepository() *InMemoryAuditRepository {
+	return &InMemoryAuditRepository{entries: []audit.Entry{}}
+}
+
+//Append - adds an entry to the audit trail
+func (repo *InMemoryAuditRepository) Append(ctx context.Context, e audit.Entry) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	e.ID = len(repo.entries) + 1
+	repo.entries = append(repo.entries, e)
+
+	return nil
+}
+
+//Redact - empties the changes of the entries of a user
+func (repo *InMemoryAuditRepository) Redact(ctx context.Context, userID int) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	for i, e := range repo.entries {
+		if e.UserID == userID {
+			repo.entries[i].Changes = []audit.Change{}
+		}
+	}
+
+	return nil
+}
+
+//History - retrieves the entries of a user that match the query, oldest first
+func (repo *InMemoryAuditRepository) History(ctx context.Context, userID int, q audit.Query) ([]audit.Entry, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	result := []audit.Entry{}
+
+	for _, e := range repo.entries {
+		if e.UserID == userID && q.Matches(e) {
+			result = append(result, e)
+		}
+	}
+
+	return result, nil
+}
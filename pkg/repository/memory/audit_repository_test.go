@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/audit"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_History_ReturnsOnlyUserEntries(t *testing.T) {
+	//Arrange
+	repository := NewInMemoryAuditRepository()
+	ctx := context.Background()
+	at := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	repository.Append(ctx, audit.Entry{UserID: 1, Action: audit.ActionCreate, OccurredAt: at})
+	repository.Append(ctx, audit.Entry{UserID: 2, Action: audit.ActionCreate, OccurredAt: at})
+	repository.Append(ctx, audit.Entry{UserID: 1, Action: audit.ActionUpdate, OccurredAt: at.Add(time.Hour)})
+	//Act
+	result, err := repository.History(ctx, 1, audit.Query{})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, 1, result[0].ID)
+	assert.Equal(t, 3, result[1].ID)
+}
+
+func Test_History_AppliesQuery(t *testing.T) {
+	//Arrange
+	repository := NewInMemoryAuditRepository()
+	ctx := context.Background()
+	at := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	repository.Append(ctx, audit.Entry{UserID: 1, Actor: "admin@globant.com", OccurredAt: at})
+	repository.Append(ctx, audit.Entry{UserID: 1, Actor: "test@gmail.com", OccurredAt: at.Add(time.Hour)})
+	//Act
+	result, _ := repository.History(ctx, 1, audit.Query{From: at.Add(time.Minute)})
+	//Assert
+	assert.Equal(t, 1, len(result))
+	assert.Equal(t, "test@gmail.com", result[0].Actor)
+}
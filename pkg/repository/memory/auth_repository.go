@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/auth"
+)
+
+//InMemoryAuthRepository is an in memory implementation of auth Store
+type InMemoryAuthRepository struct {
+	mtx         sync.RWMutex
+	credentials map[int]auth.Credential
+	tokens      map[string]auth.RefreshToken
+	resets      map[int]auth.PasswordResetRequest
+	factors     map[int]auth.MFA
+	challenges  map[string]auth.MFAChallenge
+	apiKeys     map[string]auth.APIKey
+}
+
+//NewInMemoryAuthRepository returns an InMemoryAuthRepository type pointer
+func NewInMemoryAuthRepository() *InMemoryAuthRepository {
+	return &InMemoryAuthRepository{
+		credentials: map[int]auth.Credential{},
+		tokens:      map[string]auth.RefreshToken{},
+		resets:      map[int]auth.PasswordResetRequest{},
+		factors:     map[int]auth.MFA{},
+		challenges:  map[string]auth.MFAChallenge{},
+		apiKeys:     map[string]auth.APIKey{},
+	}
+}
+
+//GetCredential - retrieves the credential of a user, a zero credential when it has none
+func (repo *InMemoryAuthRepository) GetCredential(ctx context.Context, userID int) (auth.Credential, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	c := repo.credentials[userID]
+	c.Roles = append(c.Roles[:0:0], c.Roles...)
+
+	return c, nil
+}
+
+//SaveCredential - stores the credential of a user, replacing the previous one
+func (repo *InMemoryAuthRepository) SaveCredential(ctx context.Context, c auth.Credential) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	c.Roles = append(c.Roles[:0:0], c.Roles...)
+	repo.credentials[c.UserID] = c
+
+	return nil
+}
+
+//AddRefreshToken - stores a new refresh token
+func (repo *InMemoryAuthRepository) AddRefreshToken(ctx context.Context, t auth.RefreshToken) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	t.Methods = append(t.Methods[:0:0], t.Methods...)
+	repo.tokens[t.Hash] = t
+
+	return nil
+}
+
+//GetRefreshToken - retrieves the refresh token with the given hash, a zero token when there is none
+func (repo *InMemoryAuthRepository) GetRefreshToken(ctx context.Context, hash string) (auth.RefreshToken, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	return repo.tokens[hash], nil
+}
+
+//RevokeRefreshToken - revokes a token, replacedBy is the hash of the token it was rotated to.
+//It returns auth.ErrInvalidRefreshToken when the token was already revoked
+func (repo *InMemoryAuthRepository) RevokeRefreshToken(ctx context.Context, hash string, at time.Time, replacedBy string) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	t, ok := repo.tokens[hash]
+
+	if !ok || t.RevokedAt != nil {
+		return auth.ErrInvalidRefreshToken
+	}
+
+	t.RevokedAt = &at
+	t.ReplacedBy = replacedBy
+	repo.tokens[hash] = t
+
+	return nil
+}
+
+//RevokeTokenFamily - revokes every token of a family that is not revoked yet
+func (repo *InMemoryAuthRepository) RevokeTokenFamily(ctx context.Context, familyID string, at time.Time) error {
+	return repo.revokeWhere(func(t auth.RefreshToken) bool { return t.FamilyID == familyID }, at)
+}
+
+//RevokeUserTokens - revokes every token of a user that is not revoked yet
+func (repo *InMemoryAuthRepository) RevokeUserTokens(ctx context.Context, userID int, at time.Time) error {
+	return repo.revokeWhere(func(t auth.RefreshToken) bool { return t.UserID == userID }, at)
+}
+
+func (repo *InMemoryAuthRepository) revokeWhere(match func(auth.RefreshToken) bool, at time.Time) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	for hash, t := range repo.tokens {
+		if t.RevokedAt == nil && match(t) {
+			revokedAt := at
+			t.RevokedAt = &revokedAt
+			repo.tokens[hash] = t
+		}
+	}
+
+	return nil
+}
+
+//SavePasswordReset - stores the reset request of a user, replacing the previous one
+func (repo *InMemoryAuthRepository) SavePasswordReset(ctx context.Context, r auth.PasswordResetRequest) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	repo.resets[r.UserID] = r
+
+	return nil
+}
+
+//GetPasswordReset - retrieves the reset request with the given token hash, a zero request when there is none
+func (repo *InMemoryAuthRepository) GetPasswordReset(ctx context.Context, tokenHash string) (auth.PasswordResetRequest, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	for _, r := range repo.resets {
+		if r.TokenHash == tokenHash {
+			return r, nil
+		}
+	}
+
+	return auth.PasswordResetRequest{}, nil
+}
+
+//DeletePasswordReset - removes the reset request with the given token hash, auth.ErrInvalidResetToken when there is none
+func (repo *InMemoryAuthRepository) DeletePasswordReset(ctx context.Context, tokenHash string) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	for userID, r := range repo.resets {
+		if r.TokenHash == tokenHash {
+			delete(repo.resets, userID)
+			return nil
+		}
+	}
+
+	return auth.ErrInvalidResetToken
+}
+
+//GetMFA - retrieves the second factor of a user, a zero MFA when it has none
+func (repo *InMemoryAuthRepository) GetMFA(ctx context.Context, userID int) (auth.MFA, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	m := repo.factors[userID]
+	m.RecoveryCodes = append(m.RecoveryCodes[:0:0], m.RecoveryCodes...)
+
+	return m, nil
+}
+
+//SaveMFA - stores the second factor of a user, replacing the previous one
+func (repo *InMemoryAuthRepository) SaveMFA(ctx context.Context, m auth.MFA) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	m.RecoveryCodes = append(m.RecoveryCodes[:0:0], m.RecoveryCodes...)
+	repo.factors[m.UserID] = m
+
+	return nil
+}
+
+//DeleteMFA - removes the second factor of a user
+func (repo *InMemoryAuthRepository) DeleteMFA(ctx context.Context, userID int) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	delete(repo.factors, userID)
+
+	return nil
+}
+
+//AddMFAChallenge - stores a login waiting for the second factor
+func (repo *InMemoryAuthRepository) AddMFAChallenge(ctx context.Context, c auth.MFAChallenge) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	repo.challenges[c.TokenHash] = c
+
+	return nil
+}
+
+//GetMFAChallenge - retrieves the login with the given token hash, a zero challenge when there is none
+func (repo *InMemoryAuthRepository) GetMFAChallenge(ctx context.Context, tokenHash string) (auth.MFAChallenge, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	return repo.challenges[tokenHash], nil
+}
+
+//DeleteMFAChallenge - removes the login with the given token hash, auth.ErrInvalidMFAToken when there is none
+func (repo *InMemoryAuthRepository) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	if _, ok := repo.challenges[tokenHash]; !ok {
+		return auth.ErrInvalidMFAToken
+	}
+
+	delete(repo.challenges, tokenHash)
+
+	return nil
+}
+
+//AddAPIKey - stores a new API key
+func (repo *InMemoryAuthRepository) AddAPIKey(ctx context.Context, k auth.APIKey) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	k.Scopes = append(k.Scopes[:0:0], k.Scopes...)
+	repo.apiKeys[k.ID] = k
+
+	return nil
+}
+
+//GetAPIKey - retrieves an API key, a zero key when it does not exist
+func (repo *InMemoryAuthRepository) GetAPIKey(ctx context.Context, id string) (auth.APIKey, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	k := repo.apiKeys[id]
+	k.Scopes = append(k.Scopes[:0:0], k.Scopes...)
+
+	return k, nil
+}
+
+//ListAPIKeys - retrieves every API key, revoked ones included, oldest first
+func (repo *InMemoryAuthRepository) ListAPIKeys(ctx context.Context) ([]auth.APIKey, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	keys := make([]auth.APIKey, 0, len(repo.apiKeys))
+
+	for _, k := range repo.apiKeys {
+		k.Scopes = append(k.Scopes[:0:0], k.Scopes...)
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+//RevokeAPIKey - marks an API key as revoked
+func (repo *InMemoryAuthRepository) RevokeAPIKey(ctx context.Context, id string, at time.Time) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	if k, ok := repo.apiKeys[id]; ok {
+		k.RevokedAt = &at
+		repo.apiKeys[id] = k
+	}
+
+	return nil
+}
+
+//TouchAPIKey - records when an API key was last used
+func (repo *InMemoryAuthRepository) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	if k, ok := repo.apiKeys[id]; ok {
+		k.LastUsedAt = &at
+		repo.apiKeys[id] = k
+	}
+
+	return nil
+}
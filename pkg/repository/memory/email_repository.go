@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+//SaveEmailChange - stores the pending change of a user, replacing the previous one
+func (repo *InMemoryUserRepository) SaveEmailChange(ctx context.Context, c users.EmailChange) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	repo.changes[c.UserID] = c
+
+	return nil
+}
+
+//GetEmailChange - retrieves the pending change with the given token hash, a zero change when there is none
+func (repo *InMemoryUserRepository) GetEmailChange(ctx context.Context, tokenHash string) (users.EmailChange, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	for _, c := range repo.changes {
+		if c.TokenHash == tokenHash {
+			return c, nil
+		}
+	}
+
+	return users.EmailChange{}, nil
+}
+
+//DeleteEmailChange - removes the pending change of a user
+func (repo *InMemoryUserRepository) DeleteEmailChange(ctx context.Context, userID int) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	delete(repo.changes, userID)
+
+	return nil
+}
+
+//ChangeEmail - replaces the email of a user at the given version and keeps the old one as an alias until aliasUntil
+func (repo *InMemoryUserRepository) ChangeEmail(ctx context.Context, userID int, version int, newEmail string, aliasUntil time.Time) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	if _, taken := repo.dict[newEmail]; taken {
+		return users.UserError(users.ERRALREADYEXISTS)
+	}
+
+	for email, usr := range repo.dict {
+		if usr.ID != userID {
+			continue
+		}
+
+		if usr.Version != version || usr.IsDeleted() {
+			return users.UserError(users.ERRVERSIONCONFLICT)
+		}
+
+		usr.Email = newEmail
+		usr.Version++
+		usr.UpdatedAt = repo.clock.Now()
+		usr.UpdatedBy = users.ActorFromContext(ctx)
+
+		delete(repo.dict, email)
+		delete(repo.aliases, newEmail)
+		repo.dict[newEmail] = usr
+		repo.aliases[email] = emailAlias{userID: userID, until: aliasUntil}
+
+		return nil
+	}
+
+	return users.UserError(users.ERRVERSIONCONFLICT)
+}
+
+//ResolveAlias - returns the id of the user an alias not expired at the given time points to, zero when there is none
+func (repo *InMemoryUserRepository) ResolveAlias(ctx context.Context, email string, at time.Time) (int, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	if alias, ok := repo.aliases[email]; ok && at.Before(alias.until) {
+		return alias.userID, nil
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ChangeEmail_KeepsOldEmailAsAlias(t *testing.T) {
+	//Arrange
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	repository := NewInMemoryUserRepository(WithClock(fixedClock(now)))
+	ctx := context.Background()
+	id, _ := repository.Add(ctx, users.User{Email: "larry.page@gmail.com"})
+	//Act
+	err := repository.ChangeEmail(ctx, id, 1, "larry@alphabet.com", now.Add(time.Hour))
+	changed, _ := repository.GetByEmail(ctx, "larry@alphabet.com")
+	old, _ := repository.GetByEmail(ctx, "larry.page@gmail.com")
+	aliasOwner, _ := repository.ResolveAlias(ctx, "larry.page@gmail.com", now)
+	expired, _ := repository.ResolveAlias(ctx, "larry.page@gmail.com", now.Add(time.Hour))
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, id, changed.ID)
+	assert.Equal(t, 2, changed.Version)
+	assert.Equal(t, 0, old.ID)
+	assert.Equal(t, id, aliasOwner)
+	assert.Equal(t, 0, expired)
+}
+
+func Test_ChangeEmail_StaleVersion_ReturnsConflict(t *testing.T) {
+	//Arrange
+	repository := NewInMemoryUserRepository()
+	ctx := context.Background()
+	id, _ := repository.Add(ctx, users.User{Email: "larry.page@gmail.com"})
+	//Act
+	err := repository.ChangeEmail(ctx, id, 3, "larry@alphabet.com", time.Now())
+	//Assert
+	assert.True(t, users.IsUserErrorType(users.ERRVERSIONCONFLICT, err))
+}
+
+func Test_Purge_RemovesEmailChangeAndAliases(t *testing.T) {
+	//Arrange
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	repository := NewInMemoryUserRepository(WithClock(fixedClock(now)))
+	ctx := context.Background()
+	id, _ := repository.Add(ctx, users.User{Email: "larry.page@gmail.com"})
+	repository.ChangeEmail(ctx, id, 1, "larry@alphabet.com", now.Add(time.Hour))
+	repository.SaveEmailChange(ctx, users.EmailChange{UserID: id, NewEmail: "larry@google.com", TokenHash: "hash"})
+	//Act
+	err := repository.Purge(ctx, id)
+	aliasOwner, _ := repository.ResolveAlias(ctx, "larry.page@gmail.com", now)
+	change, _ := repository.GetEmailChange(ctx, "hash")
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 0, aliasOwner)
+	assert.Zero(t, change.UserID)
+}
@@ -2,41 +2,83 @@ package repository
 
 import (
 	"context"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/casmelad/GlobantPOC/pkg/users"
 )
 
 //InMemoryUserRepository is an in memory implementation of user Repository
 type InMemoryUserRepository struct {
-	dict   map[string]users.User
-	regist []int
+	mtx     sync.RWMutex
+	dict    map[string]users.User
+	regist  []int
+	clock   users.Clock
+	changes map[int]users.EmailChange
+	aliases map[string]emailAlias
+}
+
+//emailAlias - an old address of a user, it resolves to the user until it expires
+type emailAlias struct {
+	userID int
+	until  time.Time
+}
+
+//Option configures an InMemoryUserRepository
+type Option func(*InMemoryUserRepository)
+
+//WithClock sets the clock used to timestamp the writes
+func WithClock(clock users.Clock) Option {
+	return func(repo *InMemoryUserRepository) {
+		repo.clock = clock
+	}
 }
 
 //NewInMemoryUserRepository returns an InMemoryUserRepository type pointer
-func NewInMemoryUserRepository() *InMemoryUserRepository {
-	return &InMemoryUserRepository{
-		dict:   map[string]users.User{},
-		regist: []int{},
+func NewInMemoryUserRepository(opts ...Option) *InMemoryUserRepository {
+	repo := &InMemoryUserRepository{
+		dict:    map[string]users.User{},
+		regist:  []int{},
+		clock:   users.SystemClock{},
+		changes: map[int]users.EmailChange{},
+		aliases: map[string]emailAlias{},
+	}
+	for _, opt := range opts {
+		opt(repo)
 	}
+	return repo
 }
 
-//Add - adds a user to the repository
+//Add - adds a user to the repository, the email of a soft deleted user is still taken until it is purged
 func (repo *InMemoryUserRepository) Add(ctx context.Context, u users.User) (int, error) {
 
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
 	if _, ok := repo.dict[u.Email]; ok {
-		return 0, nil
+		return 0, users.UserError(users.ERRALREADYEXISTS)
 	}
 
+	now := repo.clock.Now()
+	actor := users.ActorFromContext(ctx)
+
 	u.ID = len(repo.regist) + 1
+	u.Version = 1
+	u.CreatedAt, u.UpdatedAt = now, now
+	u.CreatedBy, u.UpdatedBy = actor, actor
 	repo.regist = append(repo.regist, u.ID)
 	repo.dict[u.Email] = u
 
 	return u.ID, nil
 }
 
-//GetByID - retrieves a user from the repository based on the integer id
+//GetByID - retrieves a user from the repository based on the integer id, soft deleted users included
 func (repo *InMemoryUserRepository) GetByID(ctx context.Context, userID int) (users.User, error) {
 
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
 	for _, usr := range repo.dict {
 		if usr.ID == userID {
 			return usr, nil
@@ -46,24 +88,37 @@ func (repo *InMemoryUserRepository) GetByID(ctx context.Context, userID int) (us
 	return users.User{}, nil
 }
 
-//GetByEmail - retrieves a user from the repository based on the email address
+//GetByEmail - retrieves a user from the repository based on the email address, soft deleted users are hidden
 func (repo *InMemoryUserRepository) GetByEmail(ctx context.Context, id string) (users.User, error) {
-	return repo.dict[id], nil
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	if usr := repo.dict[id]; !usr.IsDeleted() {
+		return usr, nil
+	}
+
+	return users.User{}, nil
 }
 
-//GetAll - retrieves all the users from the repository
-func (repo *InMemoryUserRepository) GetAll(ctx context.Context) ([]users.User, error) {
+//GetAll - retrieves the users from the repository that match the filter
+func (repo *InMemoryUserRepository) GetAll(ctx context.Context, filter users.Filter) ([]users.User, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
 
 	result := []users.User{}
 
 	for _, usr := range repo.dict {
-		result = append(result, usr)
+		if filter.Matches(usr) {
+			result = append(result, usr)
+		}
 	}
 
 	return result, nil
 }
 
-//Update -  updates the information of a user
+//Update -  updates the information of a user if the stored version matches the user version
 func (repo *InMemoryUserRepository) Update(ctx context.Context, u users.User) error {
 
 	userToUpdate, err := repo.GetByEmail(ctx, u.Email)
@@ -72,17 +127,104 @@ func (repo *InMemoryUserRepository) Update(ctx context.Context, u users.User) er
 		return err
 	}
 
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
 	if userToUpdate.ID > 0 {
+		if repo.dict[userToUpdate.Email].Version != u.Version {
+			return users.UserError(users.ERRVERSIONCONFLICT)
+		}
 		userToUpdate.Name = u.Name
 		userToUpdate.LastName = u.LastName
+		userToUpdate.Version++
+		userToUpdate.UpdatedAt = repo.clock.Now()
+		userToUpdate.UpdatedBy = users.ActorFromContext(ctx)
 		repo.dict[userToUpdate.Email] = userToUpdate
 	}
 
 	return nil
 }
 
-//Delete - deletes a user from the repository
-func (repo *InMemoryUserRepository) Delete(ctx context.Context, userID int) error {
+//SetStatus - changes the status of a user if the stored version matches the given one
+func (repo *InMemoryUserRepository) SetStatus(ctx context.Context, userID int, version int, status users.AccountStatus) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	for _, usr := range repo.dict {
+		if usr.ID == userID && !usr.IsDeleted() {
+			if usr.Version != version {
+				return users.UserError(users.ERRVERSIONCONFLICT)
+			}
+			usr.Status = status
+			usr.Version++
+			usr.UpdatedAt = repo.clock.Now()
+			usr.UpdatedBy = users.ActorFromContext(ctx)
+			repo.dict[usr.Email] = usr
+			return nil
+		}
+	}
+
+	return users.UserError(users.ERRVERSIONCONFLICT)
+}
+
+//Delete - soft deletes a user from the repository if the stored version matches the given one
+func (repo *InMemoryUserRepository) Delete(ctx context.Context, userID int, version int) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	for _, usr := range repo.dict {
+		if usr.ID == userID && !usr.IsDeleted() {
+			if usr.Version != version {
+				return users.UserError(users.ERRVERSIONCONFLICT)
+			}
+			deletedAt := repo.clock.Now()
+			usr.DeletedAt = &deletedAt
+			usr.Version++
+			usr.UpdatedAt = deletedAt
+			usr.UpdatedBy = users.ActorFromContext(ctx)
+			repo.dict[usr.Email] = usr
+			return nil
+		}
+	}
+
+	return nil
+}
+
+//Restore - undoes the soft delete of a user
+func (repo *InMemoryUserRepository) Restore(ctx context.Context, userID int) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	for _, usr := range repo.dict {
+		if usr.ID == userID && usr.IsDeleted() {
+			usr.DeletedAt = nil
+			usr.Version++
+			usr.UpdatedAt = repo.clock.Now()
+			usr.UpdatedBy = users.ActorFromContext(ctx)
+			repo.dict[usr.Email] = usr
+			return nil
+		}
+	}
+
+	return nil
+}
+
+//Purge - permanently removes a user from the repository along with its pending email change and its aliases
+func (repo *InMemoryUserRepository) Purge(ctx context.Context, userID int) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	delete(repo.changes, userID)
+
+	for email, alias := range repo.aliases {
+		if alias.userID == userID {
+			delete(repo.aliases, email)
+		}
+	}
 
 	for _, usr := range repo.dict {
 		if usr.ID == userID {
@@ -93,3 +235,27 @@ func (repo *InMemoryUserRepository) Delete(ctx context.Context, userID int) erro
 
 	return nil
 }
+
+//DeletedBefore - retrieves the ids of the users soft deleted before the given time
+func (repo *InMemoryUserRepository) DeletedBefore(ctx context.Context, before time.Time) ([]int, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	ids := []int{}
+
+	for _, usr := range repo.dict {
+		if usr.IsDeleted() && usr.DeletedAt.Before(before) {
+			ids = append(ids, usr.ID)
+		}
+	}
+
+	sort.Ints(ids)
+
+	return ids, nil
+}
+
+//WithinTransaction - the in memory repository has no transactions, fn runs directly with the given context
+func (repo *InMemoryUserRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
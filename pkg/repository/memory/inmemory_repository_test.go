@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/casmelad/GlobantPOC/pkg/users"
 	"github.com/stretchr/testify/assert"
@@ -20,7 +21,22 @@ func Test_Add_ValidData_ReturnsNewId(t *testing.T) {
 
 }
 
-func Test_Add_DuplicatedData_ReturnsInvalidResult(t *testing.T) {
+func Test_Add_ValidData_TracksCreation(t *testing.T) {
+	//Arrange
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	repository := NewInMemoryUserRepository(WithClock(fixedClock(now)))
+	ctx := users.WithCaller(context.Background(), users.Caller{Subject: "admin@globant.com"})
+	//Act
+	repository.Add(ctx, users.User{Email: "test@gmail.com"})
+	result, _ := repository.GetByEmail(ctx, "test@gmail.com")
+	//Assert
+	assert.Equal(t, now, result.CreatedAt)
+	assert.Equal(t, now, result.UpdatedAt)
+	assert.Equal(t, "admin@globant.com", result.CreatedBy)
+	assert.Equal(t, "admin@globant.com", result.UpdatedBy)
+}
+
+func Test_Add_DuplicatedData_ReturnsAlreadyExistsError(t *testing.T) {
 	//Arrange
 	repository := NewInMemoryUserRepository()
 	userToAdd := users.User{Email: "test@gmail.com"}
@@ -30,15 +46,29 @@ func Test_Add_DuplicatedData_ReturnsInvalidResult(t *testing.T) {
 	result, err := repository.Add(ctx, userToAdd)
 	//Assert
 	assert.Equal(t, result, 0)
-	assert.Nil(t, err)
+	assert.True(t, users.IsUserErrorType(users.ERRALREADYEXISTS, err))
+}
+
+func Test_Add_EmailOfDeletedUser_ReturnsAlreadyExistsError(t *testing.T) {
+	//Arrange
+	repository := NewInMemoryUserRepository()
+	ctx := context.Background()
+	userID, _ := repository.Add(ctx, users.User{Email: "test@gmail.com"})
+	repository.Delete(ctx, userID, 1)
+	//Act
+	result, err := repository.Add(ctx, users.User{Email: "test@gmail.com"})
+	//Assert
+	assert.Equal(t, 0, result)
+	assert.True(t, users.IsUserErrorType(users.ERRALREADYEXISTS, err))
 }
 
 func Test_GetByEmail_ReturnsExistingData(t *testing.T) {
 	//Arrange
 	id := "test@gmail.com"
-	repository := NewInMemoryUserRepository()
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	repository := NewInMemoryUserRepository(WithClock(fixedClock(now)))
 	userToAdd := users.User{Email: id}
-	expected := users.User{ID: 1, Email: id}
+	expected := users.User{ID: 1, Email: id, Version: 1, CreatedAt: now, UpdatedAt: now, CreatedBy: users.AnonymousActor, UpdatedBy: users.AnonymousActor}
 	ctx := context.Background()
 	repository.Add(ctx, userToAdd)
 	//Act
@@ -64,7 +94,7 @@ func Test_GetByAll_ReturnsNoData(t *testing.T) {
 	//Arrange
 	repository := NewInMemoryUserRepository()
 	//Act
-	result, err := repository.GetAll(context.Background())
+	result, err := repository.GetAll(context.Background(), users.Filter{})
 	//Assert
 	assert.Equal(t, []users.User{}, result)
 	assert.Nil(t, err)
@@ -76,7 +106,7 @@ func Test_GetByAll_ReturnsData(t *testing.T) {
 	repository.Add(context.Background(), users.User{Email: "test@gmail.com"})
 	repository.Add(context.Background(), users.User{Email: "test2@gmail.com"})
 	//Act
-	result, err := repository.GetAll(context.Background())
+	result, err := repository.GetAll(context.Background(), users.Filter{})
 	//Assert
 	assert.Equal(t, 2, len(result))
 	assert.Nil(t, err)
@@ -87,7 +117,7 @@ func Test_Update_ValidData_UpdatesData(t *testing.T) {
 	repository := NewInMemoryUserRepository()
 	userToAdd := users.User{Email: "test@gmail.com", Name: "Test1", LastName: "LastName1"}
 	userToAdd2 := users.User{Email: "test2@gmail.com", Name: "Test1", LastName: "LastName1"}
-	newUserData := users.User{Email: "test@gmail.com", Name: "Test1_Updated", LastName: "LastName1_Updated"}
+	newUserData := users.User{Email: "test@gmail.com", Name: "Test1_Updated", LastName: "LastName1_Updated", Version: 1}
 	ctx := context.Background()
 	userID, _ := repository.Add(ctx, userToAdd)
 	repository.Add(ctx, userToAdd2)
@@ -102,6 +132,42 @@ func Test_Update_ValidData_UpdatesData(t *testing.T) {
 
 }
 
+func Test_Update_ValidData_TracksChange(t *testing.T) {
+	//Arrange
+	created := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &steppingClock{now: created}
+	repository := NewInMemoryUserRepository(WithClock(clock))
+	repository.Add(context.Background(), users.User{Email: "test@gmail.com", Name: "Test1", LastName: "LastName1"})
+	ctx := users.WithCaller(context.Background(), users.Caller{Subject: "admin@globant.com"})
+	clock.now = created.Add(time.Hour)
+	//Act
+	err := repository.Update(ctx, users.User{Email: "test@gmail.com", Name: "Test1_Updated", LastName: "LastName1", Version: 1})
+	result, _ := repository.GetByEmail(ctx, "test@gmail.com")
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, created, result.CreatedAt)
+	assert.Equal(t, users.AnonymousActor, result.CreatedBy)
+	assert.Equal(t, clock.now, result.UpdatedAt)
+	assert.Equal(t, "admin@globant.com", result.UpdatedBy)
+}
+
+func Test_Update_StaleVersion_ReturnsConflictError(t *testing.T) {
+	//Arrange
+	repository := NewInMemoryUserRepository()
+	ctx := context.Background()
+	userID, _ := repository.Add(ctx, users.User{Email: "test@gmail.com", Name: "Test1", LastName: "LastName1"})
+	firstWrite := users.User{ID: userID, Email: "test@gmail.com", Name: "First", LastName: "Writer", Version: 1}
+	secondWrite := users.User{ID: userID, Email: "test@gmail.com", Name: "Second", LastName: "Writer", Version: 1}
+	repository.Update(ctx, firstWrite)
+	//Act
+	err := repository.Update(ctx, secondWrite)
+	userUpdated, _ := repository.GetByEmail(ctx, "test@gmail.com")
+	//Assert
+	assert.True(t, users.IsUserErrorType(users.ERRVERSIONCONFLICT, err))
+	assert.Equal(t, "First", userUpdated.Name)
+	assert.Equal(t, 2, userUpdated.Version)
+}
+
 func Test_Update_InvalidData_ReturnsInvalidResult(t *testing.T) {
 	//Arrange
 	repository := NewInMemoryUserRepository()
@@ -119,7 +185,98 @@ func Test_Delete_ValidId_DeletesUser(t *testing.T) {
 	ctx := context.Background()
 	userID, _ := repository.Add(ctx, userToAdd)
 	//Act
-	err := repository.Delete(ctx, userID)
+	err := repository.Delete(ctx, userID, 1)
+	//Assert
+	assert.Nil(t, err)
+}
+
+func Test_Delete_StaleVersion_ReturnsConflictError(t *testing.T) {
+	//Arrange
+	repository := NewInMemoryUserRepository()
+	ctx := context.Background()
+	userID, _ := repository.Add(ctx, users.User{Email: "test@gmail.com", Name: "Test1", LastName: "LastName1"})
+	//Act
+	err := repository.Delete(ctx, userID, 2)
+	//Assert
+	assert.True(t, users.IsUserErrorType(users.ERRVERSIONCONFLICT, err))
+}
+
+func Test_Delete_ValidId_HidesUser(t *testing.T) {
+	//Arrange
+	repository := NewInMemoryUserRepository()
+	ctx := context.Background()
+	userID, _ := repository.Add(ctx, users.User{Email: "test@gmail.com", Name: "Test1", LastName: "LastName1"})
+	//Act
+	repository.Delete(ctx, userID, 1)
+	byEmail, _ := repository.GetByEmail(ctx, "test@gmail.com")
+	byID, _ := repository.GetByID(ctx, userID)
+	active, _ := repository.GetAll(ctx, users.Filter{})
+	all, _ := repository.GetAll(ctx, users.Filter{IncludeDeleted: true})
+	//Assert
+	assert.Zero(t, byEmail.ID)
+	assert.True(t, byID.IsDeleted())
+	assert.Empty(t, active)
+	assert.Equal(t, 1, len(all))
+}
+
+func Test_Restore_DeletedUser_ShowsUser(t *testing.T) {
+	//Arrange
+	repository := NewInMemoryUserRepository()
+	ctx := context.Background()
+	userID, _ := repository.Add(ctx, users.User{Email: "test@gmail.com", Name: "Test1", LastName: "LastName1"})
+	repository.Delete(ctx, userID, 1)
+	//Act
+	err := repository.Restore(ctx, userID)
+	result, _ := repository.GetByEmail(ctx, "test@gmail.com")
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, userID, result.ID)
+	assert.Equal(t, 3, result.Version)
+}
+
+func Test_DeletedBefore_ReturnsOnlyExpiredUsers(t *testing.T) {
+	//Arrange
+	repository := NewInMemoryUserRepository()
+	ctx := context.Background()
+	deletedID, _ := repository.Add(ctx, users.User{Email: "test@gmail.com", Name: "Test1", LastName: "LastName1"})
+	repository.Add(ctx, users.User{Email: "test2@gmail.com", Name: "Test2", LastName: "LastName2"})
+	repository.Delete(ctx, deletedID, 1)
+	//Act
+	expired, err := repository.DeletedBefore(ctx, time.Now().Add(time.Minute))
+	notYet, _ := repository.DeletedBefore(ctx, time.Now().Add(-time.Minute))
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []int{deletedID}, expired)
+	assert.Empty(t, notYet)
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+type steppingClock struct {
+	now time.Time
+}
+
+func (c *steppingClock) Now() time.Time {
+	return c.now
+}
+
+func Test_SetStatus_ChangesStatusAndFiltersByIt(t *testing.T) {
+	//Arrange
+	repository := NewInMemoryUserRepository()
+	ctx := context.Background()
+	id, _ := repository.Add(ctx, users.User{Email: "test@gmail.com", Status: users.AccountActive})
+	repository.Add(ctx, users.User{Email: "other@gmail.com", Status: users.AccountActive})
+	//Act
+	err := repository.SetStatus(ctx, id, 1, users.AccountSuspended)
+	errStale := repository.SetStatus(ctx, id, 1, users.AccountActive)
+	suspended, _ := repository.GetAll(ctx, users.Filter{Status: users.AccountSuspended})
 	//Assert
 	assert.Nil(t, err)
+	assert.True(t, users.IsUserErrorType(users.ERRVERSIONCONFLICT, errStale))
+	assert.Equal(t, 1, len(suspended))
+	assert.Equal(t, 2, suspended[0].Version)
 }
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/events"
+)
+
+//InMemoryOutboxRepository is an in memory implementation of events Outbox
+type InMemoryOutboxRepository struct {
+	mtx      sync.RWMutex
+	messages []events.Message
+	position int64
+}
+
+//NewInMemoryOutboxRepository returns an InMemoryOutboxRepository type pointer
+func NewInMemoryOutboxRepository() *InMemoryOutboxRepository {
+	return &InMemoryOutboxRepository{messages: []events.Message{}}
+}
+
+//Append - adds a message to the outbox
+func (repo *InMemoryOutboxRepository) Append(ctx context.Context, m events.Message) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	m.Sequence = int64(len(repo.messages) + 1)
+	m.PublishedAt = nil
+	repo.messages = append(repo.messages, m)
+
+	return nil
+}
+
+//Pending - retrieves up to limit messages not published yet, lowest sequence first
+func (repo *InMemoryOutboxRepository) Pending(ctx context.Context, limit int) ([]events.Message, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	result := []events.Message{}
+
+	for _, m := range repo.messages {
+		if len(result) == limit {
+			break
+		}
+		if m.PublishedAt == nil {
+			result = append(result, m)
+		}
+	}
+
+	return result, nil
+}
+
+//AssignPosition - gives the message the next position of the change feed, unless it already has one
+func (repo *InMemoryOutboxRepository) AssignPosition(ctx context.Context, sequence int64) (int64, error) {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	if sequence < 1 || sequence > int64(len(repo.messages)) {
+		return 0, nil
+	}
+
+	m := &repo.messages[sequence-1]
+
+	if m.Position == 0 {
+		repo.position++
+		m.Position = repo.position
+	}
+
+	return m.Position, nil
+}
+
+//MarkPublished - records that the message with the given sequence was published
+func (repo *InMemoryOutboxRepository) MarkPublished(ctx context.Context, sequence int64, at time.Time) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	if sequence < 1 || sequence > int64(len(repo.messages)) {
+		return nil
+	}
+
+	repo.messages[sequence-1].PublishedAt = &at
+
+	return nil
+}
+
+//After - retrieves up to limit messages with a position greater than after, lowest position first
+func (repo *InMemoryOutboxRepository) After(ctx context.Context, after int64, limit int) ([]events.Message, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	result := []events.Message{}
+
+	for _, m := range repo.messages {
+		if m.Position > after {
+			result = append(result, m)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Position < result[j].Position })
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+//Head - returns the greatest position assigned, zero when no message has one
+func (repo *InMemoryOutboxRepository) Head(ctx context.Context) (int64, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	return repo.position, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/ratelimit"
+)
+
+//rateLimitSweepInterval - how often the full buckets are forgotten
+const rateLimitSweepInterval = time.Minute
+
+//InMemoryRateLimitStore is an in memory implementation of ratelimit Store
+type InMemoryRateLimitStore struct {
+	mtx       sync.Mutex
+	buckets   map[string]limitedBucket
+	lastSweep time.Time
+}
+
+type limitedBucket struct {
+	bucket ratelimit.Bucket
+	limit  ratelimit.Limit
+}
+
+//NewInMemoryRateLimitStore returns an InMemoryRateLimitStore type pointer
+func NewInMemoryRateLimitStore() *InMemoryRateLimitStore {
+	return &InMemoryRateLimitStore{buckets: map[string]limitedBucket{}}
+}
+
+//Take - takes a token from the bucket of the key at the given time, a bucket that does not exist is full
+func (repo *InMemoryRateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit, at time.Time) (ratelimit.Result, error) {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	if at.Sub(repo.lastSweep) >= rateLimitSweepInterval {
+		for k, b := range repo.buckets {
+			if b.bucket.Full(b.limit, at) {
+				delete(repo.buckets, k)
+			}
+		}
+		repo.lastSweep = at
+	}
+
+	bucket, result := repo.buckets[key].bucket.Take(limit, at)
+	repo.buckets[key] = limitedBucket{bucket: bucket, limit: limit}
+
+	return result, nil
+}
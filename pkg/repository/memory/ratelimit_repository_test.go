@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RateLimitTake_KeysHaveTheirOwnBuckets(t *testing.T) {
+	//Arrange
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewInMemoryRateLimitStore()
+	limit := ratelimit.Limit{Requests: 1, Per: time.Minute}
+	ctx := context.Background()
+	//Act
+	first, _ := store.Take(ctx, "jane@example.com", limit, now)
+	second, _ := store.Take(ctx, "jane@example.com", limit, now)
+	other, _ := store.Take(ctx, "john@example.com", limit, now)
+	//Assert
+	assert.True(t, first.Allowed)
+	assert.False(t, second.Allowed)
+	assert.True(t, other.Allowed)
+}
+
+func Test_RateLimitTake_FullBucketsAreSwept(t *testing.T) {
+	//Arrange
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewInMemoryRateLimitStore()
+	limit := ratelimit.Limit{Requests: 10, Per: time.Second}
+	ctx := context.Background()
+	store.Take(ctx, "jane@example.com", limit, now)
+	//Act
+	store.Take(ctx, "john@example.com", limit, now.Add(time.Minute))
+	//Assert
+	assert.Len(t, store.buckets, 1)
+	assert.Contains(t, store.buckets, "john@example.com")
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/webhooks"
+)
+
+//InMemorySubscriptionRepository is an in memory implementation of webhooks SubscriptionRepository
+type InMemorySubscriptionRepository struct {
+	mtx           sync.RWMutex
+	subscriptions map[int]webhooks.Subscription
+	lastID        int
+}
+
+//NewInMemorySubscriptionRepository returns an InMemorySubscriptionRepository type pointer
+func NewInMemorySubscriptionRepository() *InMemorySubscriptionRepository {
+	return &InMemorySubscriptionRepository{subscriptions: map[int]webhooks.Subscription{}}
+}
+
+//Add - stores a subscription and returns its id
+func (repo *InMemorySubscriptionRepository) Add(ctx context.Context, s webhooks.Subscription) (int, error) {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	repo.lastID++
+	s.ID = repo.lastID
+	s.EventTypes = append(s.EventTypes[:0:0], s.EventTypes...)
+	repo.subscriptions[s.ID] = s
+
+	return s.ID, nil
+}
+
+//GetByID - retrieves a subscription, a zero subscription when it does not exist
+func (repo *InMemorySubscriptionRepository) GetByID(ctx context.Context, id int) (webhooks.Subscription, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	return repo.subscriptions[id], nil
+}
+
+//GetAll - retrieves every subscription, lowest id first
+func (repo *InMemorySubscriptionRepository) GetAll(ctx context.Context) ([]webhooks.Subscription, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	result := []webhooks.Subscription{}
+
+	for id := 1; id <= repo.lastID; id++ {
+		if s, ok := repo.subscriptions[id]; ok {
+			result = append(result, s)
+		}
+	}
+
+	return result, nil
+}
+
+//Delete - removes a subscription
+func (repo *InMemorySubscriptionRepository) Delete(ctx context.Context, id int) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	delete(repo.subscriptions, id)
+
+	return nil
+}
+
+//InMemoryDeliveryRepository is an in memory implementation of webhooks DeliveryRepository
+type InMemoryDeliveryRepository struct {
+	mtx        sync.RWMutex
+	deliveries []webhooks.Delivery
+}
+
+//NewInMemoryDeliveryRepository returns an InMemoryDeliveryRepository type pointer
+func NewInMemoryDeliveryRepository() *InMemoryDeliveryRepository {
+	return &InMemoryDeliveryRepository{deliveries: []webhooks.Delivery{}}
+}
+
+//Add - stores a delivery and returns its id
+func (repo *InMemoryDeliveryRepository) Add(ctx context.Context, d webhooks.Delivery) (int, error) {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	d.ID = len(repo.deliveries) + 1
+	d.Attempts = append([]webhooks.Attempt{}, d.Attempts...)
+	repo.deliveries = append(repo.deliveries, d)
+
+	return d.ID, nil
+}
+
+//Update - stores the status, the attempts and the next attempt of a delivery
+func (repo *InMemoryDeliveryRepository) Update(ctx context.Context, d webhooks.Delivery) error {
+
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	if d.ID < 1 || d.ID > len(repo.deliveries) {
+		return nil
+	}
+
+	stored := &repo.deliveries[d.ID-1]
+	stored.Status = d.Status
+	stored.Attempts = append([]webhooks.Attempt{}, d.Attempts...)
+	stored.NextAttemptAt = d.NextAttemptAt
+
+	return nil
+}
+
+//Due - retrieves up to limit pending deliveries whose next attempt is not after the given time, oldest first
+func (repo *InMemoryDeliveryRepository) Due(ctx context.Context, at time.Time, limit int) ([]webhooks.Delivery, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	result := []webhooks.Delivery{}
+
+	for _, d := range repo.deliveries {
+		if len(result) == limit {
+			break
+		}
+		if d.Status == webhooks.DeliveryPending && !d.NextAttemptAt.After(at) {
+			result = append(result, repo.copy(d))
+		}
+	}
+
+	return result, nil
+}
+
+//Find - retrieves the deliveries that match the query, oldest first
+func (repo *InMemoryDeliveryRepository) Find(ctx context.Context, q webhooks.DeliveryQuery) ([]webhooks.Delivery, error) {
+
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	result := []webhooks.Delivery{}
+
+	for _, d := range repo.deliveries {
+		if q.Matches(d) {
+			result = append(result, repo.copy(d))
+		}
+	}
+
+	return result, nil
+}
+
+//copy - detaches the attempts of a delivery from the stored ones
+func (repo *InMemoryDeliveryRepository) copy(d webhooks.Delivery) webhooks.Delivery {
+	d.Attempts = append([]webhooks.Attempt{}, d.Attempts...)
+	return d
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/casmelad/GlobantPOC/pkg/audit"
+)
+
+const (
+	INSERTAUDITENTRY  = "INSERT INTO UserAudit(UserId, Action, Changes, Actor, RequestId, Transport, OccurredAt) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	SELECTUSERHISTORY = "SELECT Id, UserId, Action, Changes, Actor, RequestId, Transport, OccurredAt FROM UserAudit WHERE UserId = ?"
+	REDACTUSERHISTORY = "UPDATE UserAudit SET Changes = '[]' WHERE UserId = ?"
+)
+
+//MySQLAuditRepository - is a mysql implementation of audit Store, it shares the database and the transactions of a MySQLRepository
+type MySQLAuditRepository struct {
+	users *MySQLRepository
+}
+
+//NewMySQLAuditRepository - returns a MySQLAuditRepository type pointer that writes within the transactions of the users repository
+func NewMySQLAuditRepository(usersRepository *MySQLRepository) *MySQLAuditRepository {
+	return &MySQLAuditRepository{users: usersRepository}
+}
+
+//Append - adds an entry to the audit trail
+func (r *MySQLAuditRepository) Append(ctx context.Context, e audit.Entry) error {
+
+	changes, err := json.Marshal(e.Changes)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.users.conn(ctx).Exec(INSERTAUDITENTRY, e.UserID, e.Action, changes, e.Actor, e.RequestID, e.Transport, e.OccurredAt.UTC())
+
+	return err
+}
+
+//Redact - empties the changes of the entries of a user
+func (r *MySQLAuditRepository) Redact(ctx context.Context, userID int) error {
+
+	_, err := r.users.conn(ctx).Exec(REDACTUSERHISTORY, userID)
+
+	return err
+}
+
+//History - retrieves the entries of a user that match the query, oldest first
+func (r *MySQLAuditRepository) History(ctx context.Context, userID int, q audit.Query) ([]audit.Entry, error) {
+
+	query := SELECTUSERHISTORY
+	args := []interface{}{userID}
+
+	if !q.From.IsZero() {
+		query += " AND OccurredAt >= ?"
+		args = append(args, q.From.UTC())
+	}
+
+	if !q.To.IsZero() {
+		query += " AND OccurredAt < ?"
+		args = append(args, q.To.UTC())
+	}
+
+	if q.Actor != "" {
+		query += " AND Actor = ?"
+		args = append(args, q.Actor)
+	}
+
+	query += " ORDER BY OccurredAt, Id"
+
+	entries := []audit.Entry{}
+	records, err := r.users.conn(ctx).Query(query, args...)
+
+	if err != nil {
+		return entries, err
+	}
+
+	defer records.Close()
+
+	for records.Next() {
+		e := audit.Entry{}
+		var changes []byte
+
+		if err := records.Scan(&e.ID, &e.UserID, &e.Action, &changes, &e.Actor, &e.RequestID, &e.Transport, &e.OccurredAt); err != nil {
+			return []audit.Entry{}, err
+		}
+
+		if err := json.Unmarshal(changes, &e.Changes); err != nil {
+			return []audit.Entry{}, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, records.Err()
+}
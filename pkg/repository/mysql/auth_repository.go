@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/auth"
+)
+
+const (
+	SELECTCREDENTIAL    = "SELECT UserId, PasswordHash, Roles, FailedAttempts, LockedUntil, UpdatedAt FROM UserCredential WHERE UserId = ?"
+	SAVECREDENTIAL      = "INSERT INTO UserCredential(UserId, PasswordHash, Roles, FailedAttempts, LockedUntil, UpdatedAt) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE PasswordHash = VALUES(PasswordHash), Roles = VALUES(Roles), FailedAttempts = VALUES(FailedAttempts), LockedUntil = VALUES(LockedUntil), UpdatedAt = VALUES(UpdatedAt)"
+	INSERTREFRESHTOKEN  = "INSERT INTO RefreshToken(TokenHash, UserId, FamilyId, Methods, CreatedAt, ExpiresAt) VALUES (?, ?, ?, ?, ?, ?)"
+	SELECTREFRESHTOKEN  = "SELECT TokenHash, UserId, FamilyId, Methods, CreatedAt, ExpiresAt, RevokedAt, ReplacedBy FROM RefreshToken WHERE TokenHash = ?"
+	REVOKEREFRESHTOKEN  = "UPDATE RefreshToken SET RevokedAt = ?, ReplacedBy = ? WHERE TokenHash = ? AND RevokedAt IS NULL"
+	REVOKETOKENFAMILY   = "UPDATE RefreshToken SET RevokedAt = ? WHERE FamilyId = ? AND RevokedAt IS NULL"
+	REVOKEUSERTOKENS    = "UPDATE RefreshToken SET RevokedAt = ? WHERE UserId = ? AND RevokedAt IS NULL"
+	SAVEPASSWORDRESET   = "INSERT INTO PasswordReset(UserId, TokenHash, RequestedAt, ExpiresAt) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE TokenHash = VALUES(TokenHash), RequestedAt = VALUES(RequestedAt), ExpiresAt = VALUES(ExpiresAt)"
+	SELECTPASSWORDRESET = "SELECT UserId, TokenHash, RequestedAt, ExpiresAt FROM PasswordReset WHERE TokenHash = ?"
+	DELETEPASSWORDRESET = "DELETE FROM PasswordReset WHERE TokenHash = ?"
+	SELECTMFA           = "SELECT UserId, Secret, ConfirmedAt, LastStep, RecoveryCodes FROM UserMFA WHERE UserId = ?"
+	SAVEMFA             = "INSERT INTO UserMFA(UserId, Secret, ConfirmedAt, LastStep, RecoveryCodes) VALUES (?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE Secret = VALUES(Secret), ConfirmedAt = VALUES(ConfirmedAt), LastStep = VALUES(LastStep), RecoveryCodes = VALUES(RecoveryCodes)"
+	DELETEMFA           = "DELETE FROM UserMFA WHERE UserId = ?"
+	INSERTMFACHALLENGE  = "INSERT INTO MFAChallenge(TokenHash, UserId, ExpiresAt) VALUES (?, ?, ?)"
+	SELECTMFACHALLENGE  = "SELECT TokenHash, UserId, ExpiresAt FROM MFAChallenge WHERE TokenHash = ?"
+	DELETEMFACHALLENGE  = "DELETE FROM MFAChallenge WHERE TokenHash = ?"
+	INSERTAPIKEY        = "INSERT INTO APIKey(Id, Name, KeyHash, Scopes, CreatedBy, CreatedAt, ExpiresAt) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	SELECTAPIKEYS       = "SELECT Id, Name, KeyHash, Scopes, CreatedBy, CreatedAt, ExpiresAt, LastUsedAt, RevokedAt FROM APIKey"
+	REVOKEAPIKEY        = "UPDATE APIKey SET RevokedAt = ? WHERE Id = ? AND RevokedAt IS NULL"
+	TOUCHAPIKEY         = "UPDATE APIKey SET LastUsedAt = ? WHERE Id = ?"
+)
+
+//MySQLAuthRepository - is a mysql implementation of auth Store, it shares the database of a MySQLRepository
+type MySQLAuthRepository struct {
+	users *MySQLRepository
+}
+
+//NewMySQLAuthRepository - returns a MySQLAuthRepository type pointer
+func NewMySQLAuthRepository(usersRepository *MySQLRepository) *MySQLAuthRepository {
+	return &MySQLAuthRepository{users: usersRepository}
+}
+
+//GetCredential - retrieves the credential of a user, a zero credential when it has none
+func (r *MySQLAuthRepository) GetCredential(ctx context.Context, userID int) (auth.Credential, error) {
+
+	var (
+		c           auth.Credential
+		roles       []byte
+		lockedUntil sql.NullTime
+	)
+
+	err := r.users.conn(ctx).QueryRow(SELECTCREDENTIAL, userID).Scan(&c.UserID, &c.PasswordHash, &roles, &c.FailedAttempts, &lockedUntil, &c.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return auth.Credential{}, nil
+	}
+
+	if err != nil {
+		return auth.Credential{}, err
+	}
+
+	if err := json.Unmarshal(roles, &c.Roles); err != nil {
+		return auth.Credential{}, err
+	}
+
+	if lockedUntil.Valid {
+		c.LockedUntil = lockedUntil.Time
+	}
+
+	return c, nil
+}
+
+//SaveCredential - stores the credential of a user, replacing the previous one
+func (r *MySQLAuthRepository) SaveCredential(ctx context.Context, c auth.Credential) error {
+
+	if c.Roles == nil {
+		c.Roles = []string{}
+	}
+
+	roles, err := json.Marshal(c.Roles)
+
+	if err != nil {
+		return err
+	}
+
+	var lockedUntil sql.NullTime
+
+	if !c.LockedUntil.IsZero() {
+		lockedUntil = sql.NullTime{Time: c.LockedUntil.UTC(), Valid: true}
+	}
+
+	_, err = r.users.conn(ctx).Exec(SAVECREDENTIAL, c.UserID, c.PasswordHash, roles, c.FailedAttempts, lockedUntil, c.UpdatedAt.UTC())
+
+	return err
+}
+
+//AddRefreshToken - stores a new refresh token
+func (r *MySQLAuthRepository) AddRefreshToken(ctx context.Context, t auth.RefreshToken) error {
+
+	if t.Methods == nil {
+		t.Methods = []string{}
+	}
+
+	methods, err := json.Marshal(t.Methods)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.users.conn(ctx).Exec(INSERTREFRESHTOKEN, t.Hash, t.UserID, t.FamilyID, methods, t.CreatedAt.UTC(), t.ExpiresAt.UTC())
+
+	return err
+}
+
+//GetRefreshToken - retrieves the refresh token with the given hash, a zero token when there is none
+func (r *MySQLAuthRepository) GetRefreshToken(ctx context.Context, hash string) (auth.RefreshToken, error) {
+
+	var (
+		t         auth.RefreshToken
+		methods   []byte
+		revokedAt sql.NullTime
+	)
+
+	err := r.users.conn(ctx).QueryRow(SELECTREFRESHTOKEN, hash).Scan(&t.Hash, &t.UserID, &t.FamilyID, &methods, &t.CreatedAt, &t.ExpiresAt, &revokedAt, &t.ReplacedBy)
+
+	if err == sql.ErrNoRows {
+		return auth.RefreshToken{}, nil
+	}
+
+	if err != nil {
+		return auth.RefreshToken{}, err
+	}
+
+	if err := json.Unmarshal(methods, &t.Methods); err != nil {
+		return auth.RefreshToken{}, err
+	}
+
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+
+	return t, nil
+}
+
+//RevokeRefreshToken - revokes a token, replacedBy is the hash of the token it was rotated to.
+//It returns auth.ErrInvalidRefreshToken when the token was already revoked
+func (r *MySQLAuthRepository) RevokeRefreshToken(ctx context.Context, hash string, at time.Time, replacedBy string) error {
+
+	result, err := r.users.conn(ctx).Exec(REVOKEREFRESHTOKEN, at.UTC(), replacedBy, hash)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); rows == 0 || err != nil {
+		return auth.ErrInvalidRefreshToken
+	}
+
+	return nil
+}
+
+//RevokeTokenFamily - revokes every token of a family that is not revoked yet
+func (r *MySQLAuthRepository) RevokeTokenFamily(ctx context.Context, familyID string, at time.Time) error {
+
+	_, err := r.users.conn(ctx).Exec(REVOKETOKENFAMILY, at.UTC(), familyID)
+
+	return err
+}
+
+//RevokeUserTokens - revokes every token of a user that is not revoked yet
+func (r *MySQLAuthRepository) RevokeUserTokens(ctx context.Context, userID int, at time.Time) error {
+
+	_, err := r.users.conn(ctx).Exec(REVOKEUSERTOKENS, at.UTC(), userID)
+
+	return err
+}
+
+//SavePasswordReset - stores the reset request of a user, replacing the previous one
+func (r *MySQLAuthRepository) SavePasswordReset(ctx context.Context, p auth.PasswordResetRequest) error {
+
+	_, err := r.users.conn(ctx).Exec(SAVEPASSWORDRESET, p.UserID, p.TokenHash, p.RequestedAt.UTC(), p.ExpiresAt.UTC())
+
+	return err
+}
+
+//GetPasswordReset - retrieves the reset request with the given token hash, a zero request when there is none
+func (r *MySQLAuthRepository) GetPasswordReset(ctx context.Context, tokenHash string) (auth.PasswordResetRequest, error) {
+
+	p := auth.PasswordResetRequest{}
+	err := r.users.conn(ctx).QueryRow(SELECTPASSWORDRESET, tokenHash).Scan(&p.UserID, &p.TokenHash, &p.RequestedAt, &p.ExpiresAt)
+
+	if err == sql.ErrNoRows {
+		return auth.PasswordResetRequest{}, nil
+	}
+
+	return p, err
+}
+
+//DeletePasswordReset - removes the reset request with the given token hash, auth.ErrInvalidResetToken when there is none
+func (r *MySQLAuthRepository) DeletePasswordReset(ctx context.Context, tokenHash string) error {
+
+	result, err := r.users.conn(ctx).Exec(DELETEPASSWORDRESET, tokenHash)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); rows == 0 || err != nil {
+		return auth.ErrInvalidResetToken
+	}
+
+	return nil
+}
+
+//GetMFA - retrieves the second factor of a user, a zero MFA when it has none
+func (r *MySQLAuthRepository) GetMFA(ctx context.Context, userID int) (auth.MFA, error) {
+
+	var (
+		m           auth.MFA
+		confirmedAt sql.NullTime
+		codes       []byte
+	)
+
+	err := r.users.conn(ctx).QueryRow(SELECTMFA, userID).Scan(&m.UserID, &m.Secret, &confirmedAt, &m.LastStep, &codes)
+
+	if err == sql.ErrNoRows {
+		return auth.MFA{}, nil
+	}
+
+	if err != nil {
+		return auth.MFA{}, err
+	}
+
+	if err := json.Unmarshal(codes, &m.RecoveryCodes); err != nil {
+		return auth.MFA{}, err
+	}
+
+	if confirmedAt.Valid {
+		m.ConfirmedAt = &confirmedAt.Time
+	}
+
+	return m, nil
+}
+
+//SaveMFA - stores the second factor of a user, replacing the previous one
+func (r *MySQLAuthRepository) SaveMFA(ctx context.Context, m auth.MFA) error {
+
+	if m.RecoveryCodes == nil {
+		m.RecoveryCodes = []string{}
+	}
+
+	codes, err := json.Marshal(m.RecoveryCodes)
+
+	if err != nil {
+		return err
+	}
+
+	var confirmedAt sql.NullTime
+
+	if m.ConfirmedAt != nil {
+		confirmedAt = sql.NullTime{Time: m.ConfirmedAt.UTC(), Valid: true}
+	}
+
+	_, err = r.users.conn(ctx).Exec(SAVEMFA, m.UserID, m.Secret, confirmedAt, m.LastStep, codes)
+
+	return err
+}
+
+//DeleteMFA - removes the second factor of a user
+func (r *MySQLAuthRepository) DeleteMFA(ctx context.Context, userID int) error {
+
+	_, err := r.users.conn(ctx).Exec(DELETEMFA, userID)
+
+	return err
+}
+
+//AddMFAChallenge - stores a login waiting for the second factor
+func (r *MySQLAuthRepository) AddMFAChallenge(ctx context.Context, c auth.MFAChallenge) error {
+
+	_, err := r.users.conn(ctx).Exec(INSERTMFACHALLENGE, c.TokenHash, c.UserID, c.ExpiresAt.UTC())
+
+	return err
+}
+
+//GetMFAChallenge - retrieves the login with the given token hash, a zero challenge when there is none
+func (r *MySQLAuthRepository) GetMFAChallenge(ctx context.Context, tokenHash string) (auth.MFAChallenge, error) {
+
+	c := auth.MFAChallenge{}
+	err := r.users.conn(ctx).QueryRow(SELECTMFACHALLENGE, tokenHash).Scan(&c.TokenHash, &c.UserID, &c.ExpiresAt)
+
+	if err == sql.ErrNoRows {
+		return auth.MFAChallenge{}, nil
+	}
+
+	return c, err
+}
+
+//DeleteMFAChallenge - removes the login with the given token hash, auth.ErrInvalidMFAToken when there is none
+func (r *MySQLAuthRepository) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+
+	result, err := r.users.conn(ctx).Exec(DELETEMFACHALLENGE, tokenHash)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); rows == 0 || err != nil {
+		return auth.ErrInvalidMFAToken
+	}
+
+	return nil
+}
+
+//AddAPIKey - stores a new API key
+func (r *MySQLAuthRepository) AddAPIKey(ctx context.Context, k auth.APIKey) error {
+
+	scopes, err := json.Marshal(k.Scopes)
+
+	if err != nil {
+		return err
+	}
+
+	var expiresAt sql.NullTime
+
+	if k.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: k.ExpiresAt.UTC(), Valid: true}
+	}
+
+	_, err = r.users.conn(ctx).Exec(INSERTAPIKEY, k.ID, k.Name, k.Hash, scopes, k.CreatedBy, k.CreatedAt.UTC(), expiresAt)
+
+	return err
+}
+
+//GetAPIKey - retrieves an API key, a zero key when it does not exist
+func (r *MySQLAuthRepository) GetAPIKey(ctx context.Context, id string) (auth.APIKey, error) {
+
+	keys, err := r.queryAPIKeys(ctx, SELECTAPIKEYS+" WHERE Id = ?", id)
+
+	if err != nil || len(keys) == 0 {
+		return auth.APIKey{}, err
+	}
+
+	return keys[0], nil
+}
+
+//ListAPIKeys - retrieves every API key, revoked ones included, oldest first
+func (r *MySQLAuthRepository) ListAPIKeys(ctx context.Context) ([]auth.APIKey, error) {
+	return r.queryAPIKeys(ctx, SELECTAPIKEYS+" ORDER BY CreatedAt, Id")
+}
+
+//RevokeAPIKey - marks an API key as revoked
+func (r *MySQLAuthRepository) RevokeAPIKey(ctx context.Context, id string, at time.Time) error {
+
+	_, err := r.users.conn(ctx).Exec(REVOKEAPIKEY, at.UTC(), id)
+
+	return err
+}
+
+//TouchAPIKey - records when an API key was last used
+func (r *MySQLAuthRepository) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+
+	_, err := r.users.conn(ctx).Exec(TOUCHAPIKEY, at.UTC(), id)
+
+	return err
+}
+
+func (r *MySQLAuthRepository) queryAPIKeys(ctx context.Context, query string, args ...interface{}) ([]auth.APIKey, error) {
+
+	keys := []auth.APIKey{}
+	records, err := r.users.conn(ctx).Query(query, args...)
+
+	if err != nil {
+		return keys, err
+	}
+
+	defer records.Close()
+
+	for records.Next() {
+		var (
+			k                                auth.APIKey
+			scopes                           []byte
+			expiresAt, lastUsedAt, revokedAt sql.NullTime
+		)
+
+		if err := records.Scan(&k.ID, &k.Name, &k.Hash, &scopes, &k.CreatedBy, &k.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt); err != nil {
+			return []auth.APIKey{}, err
+		}
+
+		if err := json.Unmarshal(scopes, &k.Scopes); err != nil {
+			return []auth.APIKey{}, err
+		}
+
+		if expiresAt.Valid {
+			k.ExpiresAt = &expiresAt.Time
+		}
+
+		if lastUsedAt.Valid {
+			k.LastUsedAt = &lastUsedAt.Time
+		}
+
+		if revokedAt.Valid {
+			k.RevokedAt = &revokedAt.Time
+		}
+
+		keys = append(keys, k)
+	}
+
+	return keys, records.Err()
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	SAVEEMAILCHANGE   = "INSERT INTO UserEmailChange(UserId, OldEmail, NewEmail, TokenHash, RequestedAt, ExpiresAt) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE OldEmail = VALUES(OldEmail), NewEmail = VALUES(NewEmail), TokenHash = VALUES(TokenHash), RequestedAt = VALUES(RequestedAt), ExpiresAt = VALUES(ExpiresAt)"
+	SELECTEMAILCHANGE = "SELECT UserId, OldEmail, NewEmail, TokenHash, RequestedAt, ExpiresAt FROM UserEmailChange WHERE TokenHash = ?"
+	DELETEEMAILCHANGE = "DELETE FROM UserEmailChange WHERE UserId = ?"
+	SELECTUSEREMAIL   = "SELECT Email FROM Users WHERE Id = ? AND Version = ? AND DeletedAt IS NULL"
+	UPDATEUSEREMAIL   = "UPDATE Users SET Email=?, UpdatedAt=?, UpdatedBy=?, Version=Version+1 WHERE Id = ? AND Version = ? AND DeletedAt IS NULL"
+	DELETEEMAILALIAS  = "DELETE FROM UserEmailAlias WHERE Email = ?"
+	SAVEEMAILALIAS    = "INSERT INTO UserEmailAlias(Email, UserId, ExpiresAt) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE UserId = VALUES(UserId), ExpiresAt = VALUES(ExpiresAt)"
+	SELECTEMAILALIAS  = "SELECT UserId FROM UserEmailAlias WHERE Email = ? AND ExpiresAt > ?"
+)
+
+//SaveEmailChange - stores the pending change of a user, replacing the previous one
+func (r *MySQLRepository) SaveEmailChange(ctx context.Context, c users.EmailChange) error {
+
+	_, err := r.conn(ctx).Exec(SAVEEMAILCHANGE, c.UserID, c.OldEmail, c.NewEmail, c.TokenHash, c.RequestedAt.UTC(), c.ExpiresAt.UTC())
+
+	return err
+}
+
+//GetEmailChange - retrieves the pending change with the given token hash, a zero change when there is none
+func (r *MySQLRepository) GetEmailChange(ctx context.Context, tokenHash string) (users.EmailChange, error) {
+
+	c := users.EmailChange{}
+	err := r.conn(ctx).QueryRow(SELECTEMAILCHANGE, tokenHash).Scan(&c.UserID, &c.OldEmail, &c.NewEmail, &c.TokenHash, &c.RequestedAt, &c.ExpiresAt)
+
+	if err == sql.ErrNoRows {
+		return users.EmailChange{}, nil
+	}
+
+	return c, err
+}
+
+//DeleteEmailChange - removes the pending change of a user
+func (r *MySQLRepository) DeleteEmailChange(ctx context.Context, userID int) error {
+
+	_, err := r.conn(ctx).Exec(DELETEEMAILCHANGE, userID)
+
+	return err
+}
+
+//ChangeEmail - replaces the email of a user at the given version and keeps the old one as an alias until aliasUntil,
+//it must run within a transaction
+func (r *MySQLRepository) ChangeEmail(ctx context.Context, userID int, version int, newEmail string, aliasUntil time.Time) error {
+
+	var oldEmail string
+
+	err := r.conn(ctx).QueryRow(SELECTUSEREMAIL, userID, version).Scan(&oldEmail)
+
+	if err == sql.ErrNoRows {
+		return users.UserError(users.ERRVERSIONCONFLICT)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	result, err := r.conn(ctx).Exec(UPDATEUSEREMAIL, newEmail, r.clock.Now(), users.ActorFromContext(ctx), userID, version)
+
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == duplicateEntryError {
+		return users.UserError(users.ERRALREADYEXISTS)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); rows == 0 || err != nil {
+		return users.UserError(users.ERRVERSIONCONFLICT)
+	}
+
+	if _, err := r.conn(ctx).Exec(DELETEEMAILALIAS, newEmail); err != nil {
+		return err
+	}
+
+	_, err = r.conn(ctx).Exec(SAVEEMAILALIAS, oldEmail, userID, aliasUntil.UTC())
+
+	return err
+}
+
+//ResolveAlias - returns the id of the user an alias not expired at the given time points to, zero when there is none
+func (r *MySQLRepository) ResolveAlias(ctx context.Context, email string, at time.Time) (int, error) {
+
+	var userID int
+
+	err := r.conn(ctx).QueryRow(SELECTEMAILALIAS, email, at.UTC()).Scan(&userID)
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return userID, err
+}
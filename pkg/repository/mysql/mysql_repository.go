@@ -5,21 +5,40 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/casmelad/GlobantPOC/pkg/users"
-	_ "github.com/go-sql-driver/mysql" //only for implicit use
+	"github.com/go-sql-driver/mysql"
 )
 
 const (
-	INSERTUSER         = "INSERT INTO Users(Email, Name, LastName) VALUES (?, ?, ?)"
-	SELECTUSERBYID     = "SELECT Id, Email, Name, LastName FROM Users WHERE Id = ?"
-	SELECTUSEERBYEMAIL = "SELECT Id, Email, Name, LastName FROM Users WHERE Email = ?"
-	SELECTALLUSERS     = "SELECT Id, Email, Name, LastName FROM Users"
-	UPDATEUSER         = "UPDATE Users SET Name=?, LastName=? WHERE Id = ?"
-	DELETEUSER         = "DELETE FROM Users WHERE Id= ?"
+	INSERTUSER         = "INSERT INTO Users(Email, Name, LastName, Status, CreatedAt, UpdatedAt, CreatedBy, UpdatedBy) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	SELECTUSERBYID     = "SELECT Id, Email, Name, LastName, Version, CreatedAt, UpdatedAt, CreatedBy, UpdatedBy, DeletedAt, Status FROM Users WHERE Id = ?"
+	SELECTUSEERBYEMAIL = "SELECT Id, Email, Name, LastName, Version, CreatedAt, UpdatedAt, CreatedBy, UpdatedBy, DeletedAt, Status FROM Users WHERE Email = ? AND DeletedAt IS NULL"
+	SELECTEVERYUSER    = "SELECT Id, Email, Name, LastName, Version, CreatedAt, UpdatedAt, CreatedBy, UpdatedBy, DeletedAt, Status FROM Users"
+	UPDATEUSER         = "UPDATE Users SET Name=?, LastName=?, UpdatedAt=?, UpdatedBy=?, Version=Version+1 WHERE Id = ? AND Version = ? AND DeletedAt IS NULL"
+	SETUSERSTATUS      = "UPDATE Users SET Status=?, UpdatedAt=?, UpdatedBy=?, Version=Version+1 WHERE Id = ? AND Version = ? AND DeletedAt IS NULL"
+	DELETEUSER         = "UPDATE Users SET DeletedAt=?, UpdatedAt=?, UpdatedBy=?, Version=Version+1 WHERE Id= ? AND Version = ? AND DeletedAt IS NULL"
+	RESTOREUSER        = "UPDATE Users SET DeletedAt=NULL, UpdatedAt=?, UpdatedBy=?, Version=Version+1 WHERE Id = ? AND DeletedAt IS NOT NULL"
+	PURGEUSER          = "DELETE FROM Users WHERE Id = ?"
+	SELECTDELETEDIDS   = "SELECT Id FROM Users WHERE DeletedAt IS NOT NULL AND DeletedAt < ? ORDER BY Id"
+
+	duplicateEntryError = 1062
 )
 
+//purgeUserRows - the statements that remove the rows that belong to a user, they run before PURGEUSER
+var purgeUserRows = []string{
+	"DELETE FROM UserEmailChange WHERE UserId = ?",
+	"DELETE FROM UserEmailAlias WHERE UserId = ?",
+	"DELETE FROM UserCredential WHERE UserId = ?",
+	"DELETE FROM RefreshToken WHERE UserId = ?",
+	"DELETE FROM PasswordReset WHERE UserId = ?",
+	"DELETE FROM UserMFA WHERE UserId = ?",
+	"DELETE FROM MFAChallenge WHERE UserId = ?",
+}
+
 type config struct {
 	User      string `env:"MYSQL_USER" envDefault:"root"`
 	Password  string `env:"MYSQL_PASSWORD" envDefault:"BulkD3v_mysql"`
@@ -33,10 +52,10 @@ func initMySQLRepository() (*sql.DB, error) {
 	cfg := config{}
 
 	if err := env.Parse(&cfg); err != nil {
-		fmt.Printf("%+v\n", err)
+		return nil, err
 	}
 
-	connectionString := fmt.Sprintf("%s:%s@tcp(%s%s)/%s", cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DefaultDB)
+	connectionString := fmt.Sprintf("%s:%s@tcp(%s%s)/%s?parseTime=true", cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DefaultDB)
 	db, err := sql.Open("mysql", connectionString)
 
 	if err != nil {
@@ -52,11 +71,22 @@ func initMySQLRepository() (*sql.DB, error) {
 
 //MySQLRepository - is a mysql implementation of users repository
 type MySQLRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	clock users.Clock
+}
+
+//Option - configures a MySQLRepository
+type Option func(*MySQLRepository)
+
+//WithClock - sets the clock used to timestamp the writes
+func WithClock(clock users.Clock) Option {
+	return func(r *MySQLRepository) {
+		r.clock = clock
+	}
 }
 
 //NewMySQLUserRepository - returns a MySQLRepository type pointer
-func NewMySQLUserRepository() (*MySQLRepository, error) {
+func NewMySQLUserRepository(opts ...Option) (*MySQLRepository, error) {
 
 	db, err := initMySQLRepository()
 
@@ -64,21 +94,41 @@ func NewMySQLUserRepository() (*MySQLRepository, error) {
 		return nil, err
 	}
 
-	return &MySQLRepository{
-		db: db,
-	}, nil
+	r := &MySQLRepository{
+		db:    db,
+		clock: users.SystemClock{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r, nil
+}
+
+//DB - returns the connection pool of the repository, its statistics are exported as metrics
+func (r *MySQLRepository) DB() *sql.DB {
+	return r.db
 }
 
 //Add - adds a user to the repository
 func (r *MySQLRepository) Add(ctx context.Context, usr users.User) (int, error) {
 
-	stmt, err := r.db.Prepare(INSERTUSER)
+	stmt, err := r.conn(ctx).Prepare(INSERTUSER)
 
 	if err != nil {
 		return 0, err
 	}
 
-	result, err := stmt.Exec(usr.Email, usr.Name, usr.LastName)
+	now := r.clock.Now()
+	actor := users.ActorFromContext(ctx)
+
+	result, err := stmt.Exec(usr.Email, usr.Name, usr.LastName, string(usr.Status), now, now, actor, actor)
+
+	//The email stays unique while the user is soft deleted.
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == duplicateEntryError {
+		return 0, users.UserError(users.ERRALREADYEXISTS)
+	}
 
 	if err != nil {
 		return 0, err
@@ -97,13 +147,10 @@ func (r *MySQLRepository) Add(ctx context.Context, usr users.User) (int, error)
 //GetByID - retrieves a user from the repository based on the integer id
 func (r *MySQLRepository) GetByID(ctx context.Context, userID int) (users.User, error) {
 
-	usr := users.User{}
-
-	err := r.db.QueryRow(SELECTUSERBYID, userID).
-		Scan(&usr.ID, &usr.Email, &usr.Name, &usr.LastName)
+	usr, err := scanUser(r.conn(ctx).QueryRow(SELECTUSERBYID, userID))
 
 	if err == sql.ErrNoRows {
-		return usr, nil
+		return users.User{}, nil
 	}
 
 	return usr, err
@@ -113,33 +160,49 @@ func (r *MySQLRepository) GetByID(ctx context.Context, userID int) (users.User,
 //GetByEmail - retrieves a user from the repository based on the email address
 func (r *MySQLRepository) GetByEmail(ctx context.Context, email string) (users.User, error) {
 
-	usr := users.User{}
-	row := r.db.QueryRow(SELECTUSEERBYEMAIL, email)
-	err := row.Scan(&usr.ID, &usr.Email, &usr.Name, &usr.LastName)
+	row := r.conn(ctx).QueryRow(SELECTUSEERBYEMAIL, email)
+	usr, err := scanUser(row)
 
 	if err == sql.ErrNoRows {
-		return usr, nil
+		return users.User{}, nil
 	}
 
 	return usr, err
 }
 
-//GetAll - retrieves all the users from the repository
-func (r *MySQLRepository) GetAll(ctx context.Context) ([]users.User, error) {
+//GetAll - retrieves the users from the repository that match the filter
+func (r *MySQLRepository) GetAll(ctx context.Context, filter users.Filter) ([]users.User, error) {
+
+	query := SELECTEVERYUSER
+	conditions := []string{}
+	args := []interface{}{}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "DeletedAt IS NULL")
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "Status = ?")
+		args = append(args, string(filter.Status))
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
 
 	usrs := []users.User{}
-	records, err := r.db.Query(SELECTALLUSERS)
+	records, err := r.conn(ctx).Query(query, args...)
 
 	if err != nil {
-		fmt.Println(err)
+		return usrs, err
 	}
 
 	defer records.Close()
 
 	for records.Next() {
-		var user users.User
+		user, err := scanUser(records)
 
-		if err := records.Scan(&user.ID, &user.Email, &user.Name, &user.LastName); err != nil {
+		if err != nil {
 			return []users.User{}, err
 		}
 
@@ -149,45 +212,203 @@ func (r *MySQLRepository) GetAll(ctx context.Context) ([]users.User, error) {
 	return usrs, nil
 }
 
-//Update -  updates the information of a user
+//Update -  updates the information of a user if the stored version matches the user version
 func (r *MySQLRepository) Update(ctx context.Context, usr users.User) error {
 
-	stmt, err := r.db.Prepare(UPDATEUSER)
+	stmt, err := r.conn(ctx).Prepare(UPDATEUSER)
+	if err != nil {
+		return err
+	}
+
+	result, err := stmt.Exec(usr.Name, usr.LastName, r.clock.Now(), users.ActorFromContext(ctx), usr.ID, usr.Version)
+
 	if err != nil {
 		return err
 	}
 
-	result, err := stmt.Exec(usr.Name, usr.LastName, usr.ID)
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		return errors.New("no records were updated " + err.Error())
+	}
+
+	if rows == 0 {
+		return users.UserError(users.ERRVERSIONCONFLICT)
+	}
+
+	return nil
+}
+
+//SetStatus - changes the status of a user if the stored version matches the given one
+func (r *MySQLRepository) SetStatus(ctx context.Context, userID int, version int, status users.AccountStatus) error {
+
+	result, err := r.conn(ctx).Exec(SETUSERSTATUS, string(status), r.clock.Now(), users.ActorFromContext(ctx), userID, version)
 
 	if err != nil {
 		return err
 	}
 
 	if rows, err := result.RowsAffected(); rows == 0 || err != nil {
-		return errors.New("no records were updated ")
+		return users.UserError(users.ERRVERSIONCONFLICT)
 	}
 
 	return nil
 }
 
-//Delete - deletes a user from the repository
-func (r *MySQLRepository) Delete(ctx context.Context, userID int) error {
+//Delete - soft deletes a user from the repository if the stored version matches the given one
+func (r *MySQLRepository) Delete(ctx context.Context, userID int, version int) error {
 
-	stmt, err := r.db.Prepare(DELETEUSER)
+	stmt, err := r.conn(ctx).Prepare(DELETEUSER)
 
 	if err != nil {
 		return err
 	}
 
-	result, err := stmt.Exec(userID)
+	now := r.clock.Now()
+
+	result, err := stmt.Exec(now, now, users.ActorFromContext(ctx), userID, version)
 
 	if err != nil {
 		return err
 	}
 
-	if rows, err := result.RowsAffected(); rows == 0 || err != nil {
+	rows, err := result.RowsAffected()
+
+	if err != nil {
 		return errors.New("no records were deleted " + err.Error())
 	}
 
+	if rows == 0 {
+		return users.UserError(users.ERRVERSIONCONFLICT)
+	}
+
 	return nil
 }
+
+//Restore - undoes the soft delete of a user
+func (r *MySQLRepository) Restore(ctx context.Context, userID int) error {
+
+	result, err := r.conn(ctx).Exec(RESTOREUSER, r.clock.Now(), users.ActorFromContext(ctx), userID)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); rows == 0 || err != nil {
+		return errors.New("no records were restored")
+	}
+
+	return nil
+}
+
+//Purge - permanently removes a user from the repository along with its email changes, aliases, credentials,
+//tokens, password resets and second factors, all in the same transaction
+func (r *MySQLRepository) Purge(ctx context.Context, userID int) error {
+
+	return r.WithinTransaction(ctx, func(ctx context.Context) error {
+
+		for _, statement := range purgeUserRows {
+			if _, err := r.conn(ctx).Exec(statement, userID); err != nil {
+				return err
+			}
+		}
+
+		result, err := r.conn(ctx).Exec(PURGEUSER, userID)
+
+		if err != nil {
+			return err
+		}
+
+		if rows, err := result.RowsAffected(); rows == 0 || err != nil {
+			return users.UserError(users.ERRNOTFOUND)
+		}
+
+		return nil
+	})
+}
+
+//DeletedBefore - retrieves the ids of the users soft deleted before the given time
+func (r *MySQLRepository) DeletedBefore(ctx context.Context, before time.Time) ([]int, error) {
+
+	ids := []int{}
+	records, err := r.conn(ctx).Query(SELECTDELETEDIDS, before.UTC())
+
+	if err != nil {
+		return ids, err
+	}
+
+	defer records.Close()
+
+	for records.Next() {
+		var id int
+
+		if err := records.Scan(&id); err != nil {
+			return []int{}, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, records.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner) (users.User, error) {
+
+	usr := users.User{}
+	var deletedAt sql.NullTime
+
+	if err := row.Scan(&usr.ID, &usr.Email, &usr.Name, &usr.LastName, &usr.Version,
+		&usr.CreatedAt, &usr.UpdatedAt, &usr.CreatedBy, &usr.UpdatedBy, &deletedAt, &usr.Status); err != nil {
+		return usr, err
+	}
+
+	if deletedAt.Valid {
+		usr.DeletedAt = &deletedAt.Time
+	}
+
+	return usr, nil
+}
+
+type txContextKey struct{}
+
+//executor - the statements shared by sql.DB and sql.Tx
+type executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Prepare(query string) (*sql.Stmt, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+//conn - returns the transaction stored in the context, if any, or the database
+func (r *MySQLRepository) conn(ctx context.Context) executor {
+	if tx, ok := ctx.Value(txContextKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return r.db
+}
+
+//WithinTransaction - runs fn in a transaction that is committed when fn succeeds and rolled back otherwise,
+//nested calls join the transaction already stored in the context
+func (r *MySQLRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+
+	if _, ok := ctx.Value(txContextKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	if err := fn(context.WithValue(ctx, txContextKey{}, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
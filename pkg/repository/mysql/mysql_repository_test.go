@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/casmelad/GlobantPOC/pkg/users"
 	"github.com/stretchr/testify/assert"
@@ -37,7 +38,7 @@ func Test_Add_ValidData_ReturnsNewId(t *testing.T) {
 
 }
 
-func Test_Add_DuplicatedData_ReturnsInvalidResult(t *testing.T) {
+func Test_Add_DuplicatedData_ReturnsAlreadyExistsError(t *testing.T) {
 	//Arrange
 	userToAdd := users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"}
 	ctx := context.Background()
@@ -46,17 +47,20 @@ func Test_Add_DuplicatedData_ReturnsInvalidResult(t *testing.T) {
 	result, err := repository.Add(ctx, userToAdd)
 	//Assert
 	assert.Equal(t, result, 0)
-	assert.NotNil(t, err)
+	assert.True(t, users.IsUserErrorType(users.ERRALREADYEXISTS, err))
 }
 
 func Test_GetByEmail_ReturnsExistingData(t *testing.T) {
 	//Arrange
 	emailAddress := "test@gmail.com"
-	expected := users.User{ID: userID, Email: "test@gmail.com", Name: "Test", LastName: "LastName"}
+	expected := users.User{ID: userID, Email: "test@gmail.com", Name: "Test", LastName: "LastName", Version: 1, CreatedBy: users.AnonymousActor, UpdatedBy: users.AnonymousActor}
 	ctx := context.Background()
 	//Act
 	result, err := repository.GetByEmail(ctx, emailAddress)
 	//Assert
+	assert.False(t, result.CreatedAt.IsZero())
+	assert.Equal(t, result.CreatedAt, result.UpdatedAt)
+	result.CreatedAt, result.UpdatedAt = time.Time{}, time.Time{}
 	assert.Equal(t, expected, result)
 	assert.Nil(t, err)
 }
@@ -73,7 +77,7 @@ func Test_GetByEmail_InvalidId_ReturnsNoData(t *testing.T) {
 func Test_GetByAll_ReturnsData(t *testing.T) {
 	//Arrange
 	//Act
-	result, err := repository.GetAll(context.Background())
+	result, err := repository.GetAll(context.Background(), users.Filter{})
 	//Assert
 	assert.NotZero(t, len(result))
 	assert.Nil(t, err)
@@ -81,7 +85,7 @@ func Test_GetByAll_ReturnsData(t *testing.T) {
 
 func Test_Update_ValidData_UpdatesData(t *testing.T) {
 	//Arrange
-	newUserData := users.User{ID: userID, Email: "test@gmail.com", Name: "Test1_Updated", LastName: "LastName1_Updated"}
+	newUserData := users.User{ID: userID, Email: "test@gmail.com", Name: "Test1_Updated", LastName: "LastName1_Updated", Version: 1}
 	ctx := context.Background()
 	newUserData.ID = userID
 	//Act
@@ -104,7 +108,18 @@ func Test_Delete_ValidId_DeletesUser(t *testing.T) {
 	//Arrange
 	ctx := context.Background()
 	//Act
-	err := repository.Delete(ctx, userID)
+	err := repository.Delete(ctx, userID, 2)
 	//Assert
 	assert.Nil(t, err)
 }
+
+func Test_Purge_DeletedUser_RemovesUser(t *testing.T) {
+	//Arrange
+	ctx := context.Background()
+	//Act
+	err := repository.Purge(ctx, userID)
+	result, _ := repository.GetByID(ctx, userID)
+	//Assert
+	assert.Nil(t, err)
+	assert.Empty(t, result)
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/events"
+)
+
+const (
+	INSERTOUTBOXMESSAGE   = "INSERT INTO UserOutbox(EventType, UserId, Payload, OccurredAt) VALUES (?, ?, ?, ?)"
+	SELECTPENDINGMESSAGES = "SELECT Sequence, Position, EventType, UserId, Payload, OccurredAt, PublishedAt FROM UserOutbox WHERE PublishedAt IS NULL ORDER BY Sequence LIMIT ?"
+	ASSIGNMESSAGEPOSITION = "UPDATE UserOutbox SET Position = (SELECT Next FROM (SELECT COALESCE(MAX(Position), 0) + 1 AS Next FROM UserOutbox) AS Head) WHERE Sequence = ? AND Position IS NULL"
+	SELECTMESSAGEPOSITION = "SELECT Position FROM UserOutbox WHERE Sequence = ?"
+	MARKMESSAGEPUBLISHED  = "UPDATE UserOutbox SET PublishedAt = ? WHERE Sequence = ?"
+	SELECTMESSAGESAFTER   = "SELECT Sequence, Position, EventType, UserId, Payload, OccurredAt, PublishedAt FROM UserOutbox WHERE Position > ? ORDER BY Position LIMIT ?"
+	SELECTOUTBOXHEAD      = "SELECT COALESCE(MAX(Position), 0) FROM UserOutbox"
+)
+
+//MySQLOutboxRepository - is a mysql implementation of events Outbox, it shares the database and the transactions of a MySQLRepository
+type MySQLOutboxRepository struct {
+	users *MySQLRepository
+}
+
+//NewMySQLOutboxRepository - returns a MySQLOutboxRepository type pointer that writes within the transactions of the users repository
+func NewMySQLOutboxRepository(usersRepository *MySQLRepository) *MySQLOutboxRepository {
+	return &MySQLOutboxRepository{users: usersRepository}
+}
+
+//Append - adds a message to the outbox
+func (r *MySQLOutboxRepository) Append(ctx context.Context, m events.Message) error {
+
+	_, err := r.users.conn(ctx).Exec(INSERTOUTBOXMESSAGE, m.Type, m.UserID, []byte(m.Payload), m.OccurredAt.UTC())
+
+	return err
+}
+
+//Pending - retrieves up to limit messages not published yet, lowest sequence first
+func (r *MySQLOutboxRepository) Pending(ctx context.Context, limit int) ([]events.Message, error) {
+	return r.query(ctx, SELECTPENDINGMESSAGES, limit)
+}
+
+//AssignPosition - gives the message the next position of the change feed, unless it already has one
+func (r *MySQLOutboxRepository) AssignPosition(ctx context.Context, sequence int64) (int64, error) {
+
+	if _, err := r.users.conn(ctx).Exec(ASSIGNMESSAGEPOSITION, sequence); err != nil {
+		return 0, err
+	}
+
+	var position sql.NullInt64
+
+	if err := r.users.conn(ctx).QueryRow(SELECTMESSAGEPOSITION, sequence).Scan(&position); err != nil {
+		return 0, err
+	}
+
+	return position.Int64, nil
+}
+
+//MarkPublished - records that the message with the given sequence was published
+func (r *MySQLOutboxRepository) MarkPublished(ctx context.Context, sequence int64, at time.Time) error {
+
+	_, err := r.users.conn(ctx).Exec(MARKMESSAGEPUBLISHED, at.UTC(), sequence)
+
+	return err
+}
+
+//After - retrieves up to limit messages with a position greater than after, lowest position first
+func (r *MySQLOutboxRepository) After(ctx context.Context, after int64, limit int) ([]events.Message, error) {
+	return r.query(ctx, SELECTMESSAGESAFTER, after, limit)
+}
+
+//Head - returns the greatest position assigned, zero when no message has one
+func (r *MySQLOutboxRepository) Head(ctx context.Context) (int64, error) {
+
+	var head int64
+
+	err := r.users.conn(ctx).QueryRow(SELECTOUTBOXHEAD).Scan(&head)
+
+	return head, err
+}
+
+func (r *MySQLOutboxRepository) query(ctx context.Context, query string, args ...interface{}) ([]events.Message, error) {
+
+	messages := []events.Message{}
+	records, err := r.users.conn(ctx).Query(query, args...)
+
+	if err != nil {
+		return messages, err
+	}
+
+	defer records.Close()
+
+	for records.Next() {
+		m := events.Message{}
+		var payload []byte
+		var position sql.NullInt64
+		var publishedAt sql.NullTime
+
+		if err := records.Scan(&m.Sequence, &position, &m.Type, &m.UserID, &payload, &m.OccurredAt, &publishedAt); err != nil {
+			return []events.Message{}, err
+		}
+
+		m.Payload = payload
+		m.Position = position.Int64
+
+		if publishedAt.Valid {
+			m.PublishedAt = &publishedAt.Time
+		}
+
+		messages = append(messages, m)
+	}
+
+	return messages, records.Err()
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/webhooks"
+)
+
+const (
+	INSERTSUBSCRIPTION     = "INSERT INTO WebhookSubscription(Url, EventTypes, Secret, CreatedAt, CreatedBy) VALUES (?, ?, ?, ?, ?)"
+	SELECTSUBSCRIPTIONS    = "SELECT Id, Url, EventTypes, Secret, CreatedAt, CreatedBy FROM WebhookSubscription"
+	DELETESUBSCRIPTION     = "DELETE FROM WebhookSubscription WHERE Id = ?"
+	INSERTDELIVERY         = "INSERT INTO WebhookDelivery(SubscriptionId, Sequence, EventType, Payload, Status, Attempts, NextAttemptAt, CreatedAt) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	UPDATEDELIVERY         = "UPDATE WebhookDelivery SET Status = ?, Attempts = ?, NextAttemptAt = ? WHERE Id = ?"
+	SELECTDELIVERIES       = "SELECT Id, SubscriptionId, Sequence, EventType, Payload, Status, Attempts, NextAttemptAt, CreatedAt FROM WebhookDelivery"
+	SELECTDUEDELIVERIES    = SELECTDELIVERIES + " WHERE Status = ? AND NextAttemptAt <= ? ORDER BY Id LIMIT ?"
+	SELECTSUBSCRIPTIONBYID = SELECTSUBSCRIPTIONS + " WHERE Id = ?"
+)
+
+//MySQLSubscriptionRepository - is a mysql implementation of webhooks SubscriptionRepository, it shares the database of a MySQLRepository
+type MySQLSubscriptionRepository struct {
+	users *MySQLRepository
+}
+
+//NewMySQLSubscriptionRepository - returns a MySQLSubscriptionRepository type pointer
+func NewMySQLSubscriptionRepository(usersRepository *MySQLRepository) *MySQLSubscriptionRepository {
+	return &MySQLSubscriptionRepository{users: usersRepository}
+}
+
+//Add - stores a subscription and returns its id
+func (r *MySQLSubscriptionRepository) Add(ctx context.Context, s webhooks.Subscription) (int, error) {
+
+	eventTypes, err := json.Marshal(s.EventTypes)
+
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.users.conn(ctx).Exec(INSERTSUBSCRIPTION, s.URL, eventTypes, s.Secret, s.CreatedAt.UTC(), s.CreatedBy)
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+//GetByID - retrieves a subscription, a zero subscription when it does not exist
+func (r *MySQLSubscriptionRepository) GetByID(ctx context.Context, id int) (webhooks.Subscription, error) {
+
+	subs, err := r.query(ctx, SELECTSUBSCRIPTIONBYID, id)
+
+	if err != nil || len(subs) == 0 {
+		return webhooks.Subscription{}, err
+	}
+
+	return subs[0], nil
+}
+
+//GetAll - retrieves every subscription, lowest id first
+func (r *MySQLSubscriptionRepository) GetAll(ctx context.Context) ([]webhooks.Subscription, error) {
+	return r.query(ctx, SELECTSUBSCRIPTIONS+" ORDER BY Id")
+}
+
+//Delete - removes a subscription, its deliveries are kept
+func (r *MySQLSubscriptionRepository) Delete(ctx context.Context, id int) error {
+
+	_, err := r.users.conn(ctx).Exec(DELETESUBSCRIPTION, id)
+
+	return err
+}
+
+func (r *MySQLSubscriptionRepository) query(ctx context.Context, query string, args ...interface{}) ([]webhooks.Subscription, error) {
+
+	subs := []webhooks.Subscription{}
+	records, err := r.users.conn(ctx).Query(query, args...)
+
+	if err != nil {
+		return subs, err
+	}
+
+	defer records.Close()
+
+	for records.Next() {
+		s := webhooks.Subscription{}
+		var eventTypes []byte
+
+		if err := records.Scan(&s.ID, &s.URL, &eventTypes, &s.Secret, &s.CreatedAt, &s.CreatedBy); err != nil {
+			return []webhooks.Subscription{}, err
+		}
+
+		if err := json.Unmarshal(eventTypes, &s.EventTypes); err != nil {
+			return []webhooks.Subscription{}, err
+		}
+
+		subs = append(subs, s)
+	}
+
+	return subs, records.Err()
+}
+
+//MySQLDeliveryRepository - is a mysql implementation of webhooks DeliveryRepository, it shares the database of a MySQLRepository
+type MySQLDeliveryRepository struct {
+	users *MySQLRepository
+}
+
+//NewMySQLDeliveryRepository - returns a MySQLDeliveryRepository type pointer
+func NewMySQLDeliveryRepository(usersRepository *MySQLRepository) *MySQLDeliveryRepository {
+	return &MySQLDeliveryRepository{users: usersRepository}
+}
+
+//Add - stores a delivery and returns its id
+func (r *MySQLDeliveryRepository) Add(ctx context.Context, d webhooks.Delivery) (int, error) {
+
+	attempts, err := json.Marshal(d.Attempts)
+
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.users.conn(ctx).Exec(INSERTDELIVERY, d.SubscriptionID, d.Sequence, d.EventType, d.Payload, d.Status, attempts, d.NextAttemptAt.UTC(), d.CreatedAt.UTC())
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+//Update - stores the status, the attempts and the next attempt of a delivery
+func (r *MySQLDeliveryRepository) Update(ctx context.Context, d webhooks.Delivery) error {
+
+	attempts, err := json.Marshal(d.Attempts)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.users.conn(ctx).Exec(UPDATEDELIVERY, d.Status, attempts, d.NextAttemptAt.UTC(), d.ID)
+
+	return err
+}
+
+//Due - retrieves up to limit pending deliveries whose next attempt is not after the given time, oldest first
+func (r *MySQLDeliveryRepository) Due(ctx context.Context, at time.Time, limit int) ([]webhooks.Delivery, error) {
+	return r.query(ctx, SELECTDUEDELIVERIES, webhooks.DeliveryPending, at.UTC(), limit)
+}
+
+//Find - retrieves the deliveries that match the query, oldest first
+func (r *MySQLDeliveryRepository) Find(ctx context.Context, q webhooks.DeliveryQuery) ([]webhooks.Delivery, error) {
+
+	query := SELECTDELIVERIES + " WHERE 1 = 1"
+	args := []interface{}{}
+
+	if q.SubscriptionID != 0 {
+		query += " AND SubscriptionId = ?"
+		args = append(args, q.SubscriptionID)
+	}
+
+	if q.Status != "" {
+		query += " AND Status = ?"
+		args = append(args, q.Status)
+	}
+
+	return r.query(ctx, query+" ORDER BY Id", args...)
+}
+
+func (r *MySQLDeliveryRepository) query(ctx context.Context, query string, args ...interface{}) ([]webhooks.Delivery, error) {
+
+	deliveries := []webhooks.Delivery{}
+	records, err := r.users.conn(ctx).Query(query, args...)
+
+	if err != nil {
+		return deliveries, err
+	}
+
+	defer records.Close()
+
+	for records.Next() {
+		d := webhooks.Delivery{}
+		var attempts []byte
+
+		if err := records.Scan(&d.ID, &d.SubscriptionID, &d.Sequence, &d.EventType, &d.Payload, &d.Status, &attempts, &d.NextAttemptAt, &d.CreatedAt); err != nil {
+			return []webhooks.Delivery{}, err
+		}
+
+		if err := json.Unmarshal(attempts, &d.Attempts); err != nil {
+			return []webhooks.Delivery{}, err
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, records.Err()
+}
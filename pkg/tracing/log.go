@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/openzipkin/zipkin-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//TraceIDs - returns the ids of the trace and the span found in the context, OpenTelemetry first and Zipkin otherwise.
+//Both are empty when the request is not traced
+func TraceIDs(ctx context.Context) (traceID string, spanID string) {
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return sc.TraceID().String(), sc.SpanID().String()
+	}
+
+	if span := zipkin.SpanFromContext(ctx); span != nil && !span.Context().TraceID.Empty() {
+		return span.Context().TraceID.String(), span.Context().ID.String()
+	}
+
+	return "", ""
+}
+
+//WithTrace - returns a logger adding the trace_id and span_id of the request to every line, logger as is when the
+//request is not traced
+func WithTrace(ctx context.Context, logger log.Logger) log.Logger {
+
+	traceID, spanID := TraceIDs(ctx)
+
+	if traceID == "" {
+		return logger
+	}
+
+	return log.With(logger, "trace_id", traceID, "span_id", spanID)
+}
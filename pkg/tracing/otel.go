@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+const (
+	//ExporterOTLP - exports the spans to an OTLP collector over gRPC
+	ExporterOTLP = "otlp"
+	//ExporterStdout - writes the spans as JSON to a file or the standard output, for local runs
+	ExporterStdout = "stdout"
+)
+
+//ErrUnknownExporter - the configured OpenTelemetry exporter is not supported
+var ErrUnknownExporter = errors.New("unknown OpenTelemetry exporter")
+
+//OTelConfig - the settings of the OpenTelemetry tracer provider
+type OTelConfig struct {
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	File        string
+	ServiceName string
+	SampleRate  float64
+}
+
+//NewOTelProvider - returns the tracer provider exporting the spans as configured, registered as the global one along
+//with the W3C trace context propagator, and the function flushing and closing it
+func NewOTelProvider(ctx context.Context, cfg OTelConfig) (*sdktrace.TracerProvider, func(context.Context) error, error) {
+
+	exporter, closeOutput, err := newExporter(ctx, cfg)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRate))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(cfg.ServiceName))))
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider, func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeErr := closeOutput(); err == nil {
+			err = closeErr
+		}
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context, cfg OTelConfig) (sdktrace.SpanExporter, func() error, error) {
+
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		return exporter, func() error { return nil }, err
+	case ExporterStdout:
+		var w io.Writer = os.Stdout
+		closeOutput := func() error { return nil }
+		if cfg.File != "" {
+			f, err := os.OpenFile(cfg.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				return nil, nil, err
+			}
+			w, closeOutput = f, f.Close
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			closeOutput()
+			return nil, nil, err
+		}
+		return exporter, closeOutput, nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: %q", ErrUnknownExporter, cfg.Exporter)
+}
@@ -0,0 +1,126 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/tracing"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRecordedProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	spans := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)), spans
+}
+
+func Test_Service_Calls_ChildSpansOfRequest(t *testing.T) {
+	//Arrange
+	provider, spans := newRecordedProvider()
+	tracer := provider.Tracer("users")
+	service := tracing.NewService(users.NewUserService(memory.NewInMemoryUserRepository()), tracer)
+	ctx, parent := tracer.Start(context.Background(), "/proto.Users/Create")
+	//Act
+	id, err := service.Create(ctx, users.User{Email: "user@globant.com", Name: "User", LastName: "Name"})
+	parent.End()
+	//Assert
+	assert.Nil(t, err)
+	ended := spans.Ended()
+	assert.Len(t, ended, 2)
+	assert.Equal(t, "users.Service/Create", ended[0].Name())
+	assert.Equal(t, parent.SpanContext().TraceID(), ended[0].SpanContext().TraceID())
+	assert.Equal(t, parent.SpanContext().SpanID(), ended[0].Parent().SpanID())
+	for _, attr := range ended[0].Attributes() {
+		assert.NotEqual(t, "user@globant.com", attr.Value.AsString())
+		if attr.Key == "user.id" {
+			assert.Equal(t, int64(id), attr.Value.AsInt64())
+		}
+	}
+}
+
+func Test_Service_Error_RecordedOnSpan(t *testing.T) {
+	//Arrange
+	provider, spans := newRecordedProvider()
+	service := tracing.NewService(users.NewUserService(memory.NewInMemoryUserRepository()), provider.Tracer("users"))
+	//Act
+	_, err := service.GetByEmail(context.Background(), "missing@globant.com")
+	//Assert
+	assert.NotNil(t, err)
+	ended := spans.Ended()
+	assert.Len(t, ended, 1)
+	assert.Equal(t, "users.Service/GetByEmail", ended[0].Name())
+	assert.Equal(t, codes.Error, ended[0].Status().Code)
+	assert.Len(t, ended[0].Events(), 1)
+}
+
+func Test_TraceIDs_OTelSpan_IDsOfSpan(t *testing.T) {
+	//Arrange
+	provider, _ := newRecordedProvider()
+	ctx, span := provider.Tracer("users").Start(context.Background(), "request")
+	defer span.End()
+	//Act
+	traceID, spanID := tracing.TraceIDs(ctx)
+	//Assert
+	assert.Equal(t, span.SpanContext().TraceID().String(), traceID)
+	assert.Equal(t, span.SpanContext().SpanID().String(), spanID)
+}
+
+func Test_TraceIDs_ZipkinSpan_IDsOfSpan(t *testing.T) {
+	//Arrange
+	tracer, _ := newRecordedTracer(t)
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "request")
+	defer span.Finish()
+	//Act
+	traceID, spanID := tracing.TraceIDs(ctx)
+	//Assert
+	assert.Equal(t, span.Context().TraceID.String(), traceID)
+	assert.Equal(t, span.Context().ID.String(), spanID)
+}
+
+func Test_WithTrace_NoSpan_LoggerAsIs(t *testing.T) {
+	//Arrange
+	logger := log.NewNopLogger()
+	//Act
+	traced := tracing.WithTrace(context.Background(), logger)
+	//Assert
+	assert.Equal(t, logger, traced)
+}
+
+func Test_Setup_UnknownProvider_Fails(t *testing.T) {
+	//Act
+	_, _, err := tracing.Setup(context.Background(), tracing.Settings{Provider: "jaeger"})
+	//Assert
+	assert.NotNil(t, err)
+}
+
+func Test_NewOTelProvider_UnknownExporter_Fails(t *testing.T) {
+	//Act
+	_, _, err := tracing.NewOTelProvider(context.Background(), tracing.OTelConfig{Exporter: "jaeger"})
+	//Assert
+	assert.True(t, errors.Is(err, tracing.ErrUnknownExporter))
+}
+
+func Test_NewOTelProvider_StdoutExporter_SpansWrittenToFile(t *testing.T) {
+	//Arrange
+	file := filepath.Join(t.TempDir(), "spans.json")
+	provider, shutdown, err := tracing.NewOTelProvider(context.Background(), tracing.OTelConfig{
+		Exporter: tracing.ExporterStdout, File: file, ServiceName: "users-grpc", SampleRate: 1})
+	assert.Nil(t, err)
+	//Act
+	_, span := provider.Tracer("users").Start(context.Background(), "users.Service/GetAll")
+	span.End()
+	err = shutdown(context.Background())
+	//Assert
+	assert.Nil(t, err)
+	written, _ := os.ReadFile(file)
+	assert.Contains(t, string(written), "users.Service/GetAll")
+	assert.Contains(t, string(written), "users-grpc")
+}
@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/model"
+)
+
+//TagSQLOperation - the tag holding the SQL statement kind a repository call runs
+const TagSQLOperation = "sql.operation"
+
+//Repository - a users.Repository decorator that records a span for every call, child of the span found in the context
+type Repository struct {
+	next   users.Repository
+	tracer *zipkin.Tracer
+}
+
+//NewRepository - returns a Repository type pointer that decorates next
+func NewRepository(next users.Repository, tracer *zipkin.Tracer) *Repository {
+	return &Repository{
+		next:   next,
+		tracer: tracer,
+	}
+}
+
+//Add - adds a user to the repository
+func (r *Repository) Add(ctx context.Context, usr users.User) (id int, err error) {
+	ctx, finish := r.span(ctx, "Add", "INSERT")
+	defer func() { finish(err) }()
+	return r.next.Add(ctx, usr)
+}
+
+//GetByID - retrieves a user from the repository based on the integer id
+func (r *Repository) GetByID(ctx context.Context, id int) (usr users.User, err error) {
+	ctx, finish := r.span(ctx, "GetByID", "SELECT")
+	defer func() { finish(err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+//GetByEmail - retrieves a user from the repository based on the email address
+func (r *Repository) GetByEmail(ctx context.Context, email string) (usr users.User, err error) {
+	ctx, finish := r.span(ctx, "GetByEmail", "SELECT")
+	defer func() { finish(err) }()
+	return r.next.GetByEmail(ctx, email)
+}
+
+//GetAll - retrieves the users from the repository that match the filter
+func (r *Repository) GetAll(ctx context.Context, filter users.Filter) (usrs []users.User, err error) {
+	ctx, finish := r.span(ctx, "GetAll", "SELECT")
+	defer func() { finish(err) }()
+	return r.next.GetAll(ctx, filter)
+}
+
+//Update - updates the information of a user
+func (r *Repository) Update(ctx context.Context, usr users.User) (err error) {
+	ctx, finish := r.span(ctx, "Update", "UPDATE")
+	defer func() { finish(err) }()
+	return r.next.Update(ctx, usr)
+}
+
+//SetStatus - changes the status of a user
+func (r *Repository) SetStatus(ctx context.Context, id int, version int, status users.AccountStatus) (err error) {
+	ctx, finish := r.span(ctx, "SetStatus", "UPDATE")
+	defer func() { finish(err) }()
+	return r.next.SetStatus(ctx, id, version, status)
+}
+
+//Delete - soft deletes a user from the repository
+func (r *Repository) Delete(ctx context.Context, id int, version int) (err error) {
+	ctx, finish := r.span(ctx, "Delete", "UPDATE")
+	defer func() { finish(err) }()
+	return r.next.Delete(ctx, id, version)
+}
+
+//Restore - undoes the soft delete of a user
+func (r *Repository) Restore(ctx context.Context, id int) (err error) {
+	ctx, finish := r.span(ctx, "Restore", "UPDATE")
+	defer func() { finish(err) }()
+	return r.next.Restore(ctx, id)
+}
+
+//Purge - permanently removes a user from the repository
+func (r *Repository) Purge(ctx context.Context, id int) (err error) {
+	ctx, finish := r.span(ctx, "Purge", "DELETE")
+	defer func() { finish(err) }()
+	return r.next.Purge(ctx, id)
+}
+
+//DeletedBefore - retrieves the ids of the users soft deleted before the given time
+func (r *Repository) DeletedBefore(ctx context.Context, before time.Time) (ids []int, err error) {
+	ctx, finish := r.span(ctx, "DeletedBefore", "SELECT")
+	defer func() { finish(err) }()
+	return r.next.DeletedBefore(ctx, before)
+}
+
+//span - starts the span of a repository call, the returned function tags the error, if any, and finishes it
+func (r *Repository) span(ctx context.Context, method, operation string) (context.Context, func(error)) {
+
+	span, ctx := r.tracer.StartSpanFromContext(ctx, "repository."+method, zipkin.Kind(model.Client))
+	span.Tag(TagSQLOperation, operation)
+
+	return ctx, func(err error) {
+		if err != nil {
+			zipkin.TagError.Set(span, err.Error())
+		}
+		span.Finish()
+	}
+}
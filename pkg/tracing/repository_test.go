@@ -0,0 +1,91 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/tracing"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/reporter"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRecordedTracer(t *testing.T) (*zipkin.Tracer, *recorder.ReporterRecorder) {
+	spans := recorder.NewReporter()
+	tracer, _, err := tracing.NewTracer(spans, "users-grpc", "", 1)
+	assert.Nil(t, err)
+	return tracer, spans
+}
+
+func Test_Repository_Calls_ChildSpansOfRequest(t *testing.T) {
+	//Arrange
+	tracer, spans := newRecordedTracer(t)
+	repository := tracing.NewRepository(memory.NewInMemoryUserRepository(), tracer)
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "/proto.Users/Create")
+	//Act
+	id, err := repository.Add(ctx, users.User{Email: "user@globant.com", Name: "User", LastName: "Name"})
+	repository.GetByID(ctx, id)
+	parent.Finish()
+	//Assert
+	assert.Nil(t, err)
+	recorded := spans.Flush()
+	assert.Len(t, recorded, 3)
+	assert.Equal(t, "repository.Add", recorded[0].Name)
+	assert.Equal(t, "INSERT", recorded[0].Tags[tracing.TagSQLOperation])
+	assert.Equal(t, model.Client, recorded[0].Kind)
+	assert.Equal(t, "repository.GetByID", recorded[1].Name)
+	assert.Equal(t, "SELECT", recorded[1].Tags[tracing.TagSQLOperation])
+	for _, span := range recorded[:2] {
+		assert.Equal(t, parent.Context().TraceID, span.TraceID)
+		assert.Equal(t, parent.Context().ID, *span.ParentID)
+	}
+}
+
+func Test_Repository_Error_TaggedOnSpan(t *testing.T) {
+	//Arrange
+	tracer, spans := newRecordedTracer(t)
+	memoryRepository := memory.NewInMemoryUserRepository()
+	id, _ := memoryRepository.Add(context.Background(), users.User{Email: "user@globant.com", Name: "User", LastName: "Name"})
+	repository := tracing.NewRepository(memoryRepository, tracer)
+	//Act
+	err := repository.Delete(context.Background(), id, 99)
+	//Assert
+	assert.NotNil(t, err)
+	recorded := spans.Flush()
+	assert.Len(t, recorded, 1)
+	assert.Equal(t, "repository.Delete", recorded[0].Name)
+	assert.Equal(t, "UPDATE", recorded[0].Tags[tracing.TagSQLOperation])
+	assert.Equal(t, err.Error(), recorded[0].Tags[string(zipkin.TagError)])
+}
+
+func Test_NewTracer_ZeroRate_RecordsNothing(t *testing.T) {
+	//Arrange
+	spans := recorder.NewReporter()
+	tracer, _, err := tracing.NewTracer(spans, "users-rest", "", 0)
+	repository := tracing.NewRepository(memory.NewInMemoryUserRepository(), tracer)
+	//Act
+	repository.GetAll(context.Background(), users.Filter{})
+	//Assert
+	assert.Nil(t, err)
+	assert.Empty(t, spans.Flush())
+}
+
+func Test_NewZipkinTracer_NoURL_NoopTracer(t *testing.T) {
+	//Act
+	tracer, closeTracer, err := tracing.NewZipkinTracer(tracing.Config{ServiceName: "users-grpc", SampleRate: 1})
+	//Assert
+	assert.Nil(t, err)
+	assert.True(t, zipkin.IsNoop(tracer.StartSpan("noop")))
+	assert.Nil(t, closeTracer())
+}
+
+func Test_NewTracer_InvalidRate_Fails(t *testing.T) {
+	//Act
+	_, _, err := tracing.NewTracer(reporter.NewNoopReporter(), "users-grpc", "", 2)
+	//Assert
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,145 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//Service - a users.Service decorator that records an OpenTelemetry span for every call
+type Service struct {
+	next   users.Service
+	tracer trace.Tracer
+}
+
+//NewService - returns a Service type pointer that decorates next, the spans are started with tracer
+func NewService(next users.Service, tracer trace.Tracer) *Service {
+	return &Service{
+		next:   next,
+		tracer: tracer,
+	}
+}
+
+//Create - creates a user
+func (s *Service) Create(ctx context.Context, usr users.User) (id int, err error) {
+	ctx, span := s.start(ctx, "Create")
+	defer func() { end(span, err) }()
+	id, err = s.next.Create(ctx, usr)
+	span.SetAttributes(attribute.Int("user.id", id))
+	return id, err
+}
+
+//GetByEmail - retrieves a user by email address
+func (s *Service) GetByEmail(ctx context.Context, email string) (usr users.User, err error) {
+	ctx, span := s.start(ctx, "GetByEmail")
+	defer func() { end(span, err) }()
+	return s.next.GetByEmail(ctx, email)
+}
+
+//GetAll - retrieves the users that match the filter
+func (s *Service) GetAll(ctx context.Context, filter users.Filter) (usrs []users.User, err error) {
+	ctx, span := s.start(ctx, "GetAll")
+	defer func() { end(span, err) }()
+	return s.next.GetAll(ctx, filter)
+}
+
+//Update - updates the information of a user
+func (s *Service) Update(ctx context.Context, usr users.User) (err error) {
+	ctx, span := s.start(ctx, "Update")
+	defer func() { end(span, err) }()
+	return s.next.Update(ctx, usr)
+}
+
+//Patch - applies a partial update to a user
+func (s *Service) Patch(ctx context.Context, patch users.Patch) (err error) {
+	ctx, span := s.start(ctx, "Patch")
+	defer func() { end(span, err) }()
+	return s.next.Patch(ctx, patch)
+}
+
+//Delete - soft deletes a user
+func (s *Service) Delete(ctx context.Context, id int, version int) (err error) {
+	ctx, span := s.start(ctx, "Delete", attribute.Int("user.id", id))
+	defer func() { end(span, err) }()
+	return s.next.Delete(ctx, id, version)
+}
+
+//Restore - undoes the soft delete of a user
+func (s *Service) Restore(ctx context.Context, id int) (err error) {
+	ctx, span := s.start(ctx, "Restore", attribute.Int("user.id", id))
+	defer func() { end(span, err) }()
+	return s.next.Restore(ctx, id)
+}
+
+//Purge - permanently removes a user
+func (s *Service) Purge(ctx context.Context, id int) (err error) {
+	ctx, span := s.start(ctx, "Purge", attribute.Int("user.id", id))
+	defer func() { end(span, err) }()
+	return s.next.Purge(ctx, id)
+}
+
+//RequestEmailChange - starts the change of the email address of a user
+func (s *Service) RequestEmailChange(ctx context.Context, current string, requested string) (err error) {
+	ctx, span := s.start(ctx, "RequestEmailChange")
+	defer func() { end(span, err) }()
+	return s.next.RequestEmailChange(ctx, current, requested)
+}
+
+//ConfirmEmailChange - completes the change of an email address
+func (s *Service) ConfirmEmailChange(ctx context.Context, token string) (change users.EmailChange, err error) {
+	ctx, span := s.start(ctx, "ConfirmEmailChange")
+	defer func() { end(span, err) }()
+	return s.next.ConfirmEmailChange(ctx, token)
+}
+
+//SendVerification - sends the verification token of an email address
+func (s *Service) SendVerification(ctx context.Context, email string) (err error) {
+	ctx, span := s.start(ctx, "SendVerification")
+	defer func() { end(span, err) }()
+	return s.next.SendVerification(ctx, email)
+}
+
+//VerifyEmail - marks the email address of the token as verified
+func (s *Service) VerifyEmail(ctx context.Context, token string) (usr users.User, err error) {
+	ctx, span := s.start(ctx, "VerifyEmail")
+	defer func() { end(span, err) }()
+	return s.next.VerifyEmail(ctx, token)
+}
+
+//Suspend - suspends a user
+func (s *Service) Suspend(ctx context.Context, id int) (err error) {
+	ctx, span := s.start(ctx, "Suspend", attribute.Int("user.id", id))
+	defer func() { end(span, err) }()
+	return s.next.Suspend(ctx, id)
+}
+
+//Reactivate - reactivates a suspended user
+func (s *Service) Reactivate(ctx context.Context, id int) (err error) {
+	ctx, span := s.start(ctx, "Reactivate", attribute.Int("user.id", id))
+	defer func() { end(span, err) }()
+	return s.next.Reactivate(ctx, id)
+}
+
+//Deactivate - deactivates a user
+func (s *Service) Deactivate(ctx context.Context, id int) (err error) {
+	ctx, span := s.start(ctx, "Deactivate", attribute.Int("user.id", id))
+	defer func() { end(span, err) }()
+	return s.next.Deactivate(ctx, id)
+}
+
+//start - starts the span of a service call, the email addresses and names are never recorded
+func (s *Service) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "users.Service/"+method, trace.WithAttributes(attrs...))
+}
+
+//end - records the error of the call, if any, and ends its span
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
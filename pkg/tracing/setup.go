@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openzipkin/zipkin-go"
+)
+
+const (
+	//ProviderZipkin - the spans are recorded with the zipkin tracer and propagated in the B3 headers
+	ProviderZipkin = "zipkin"
+	//ProviderOTel - the spans are recorded with OpenTelemetry and propagated in the W3C trace context headers
+	ProviderOTel = "otel"
+)
+
+//Settings - the tracing settings of a binary, only the ones of the selected provider are used
+type Settings struct {
+	Provider string
+	Zipkin   Config
+	OTel     OTelConfig
+}
+
+//Setup - returns the zipkin tracer the transports are instrumented with, a no-op one when OpenTelemetry is the
+//provider so that the requests are not traced twice, and the function flushing the spans on exit
+func Setup(ctx context.Context, s Settings) (*zipkin.Tracer, func(), error) {
+
+	switch s.Provider {
+	case ProviderZipkin, "":
+		tracer, closeTracer, err := NewZipkinTracer(s.Zipkin)
+		if err != nil {
+			return nil, nil, err
+		}
+		return tracer, func() { closeTracer() }, nil
+	case ProviderOTel:
+		_, shutdown, err := NewOTelProvider(ctx, s.OTel)
+		if err != nil {
+			return nil, nil, err
+		}
+		noop := s.Zipkin
+		noop.URL = ""
+		tracer, _, err := NewZipkinTracer(noop)
+		if err != nil {
+			shutdown(ctx)
+			return nil, nil, err
+		}
+		return tracer, func() { shutdown(context.Background()) }, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown tracing provider: %q", s.Provider)
+}
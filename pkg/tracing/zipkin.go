@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/reporter"
+	zipkinhttp "github.com/openzipkin/zipkin-go/reporter/http"
+)
+
+//Config - the settings of the zipkin tracer, without an URL the tracer is a no-op
+type Config struct {
+	URL         string
+	ServiceName string
+	HostPort    string
+	SampleRate  float64
+}
+
+//NewZipkinTracer - returns the tracer reporting the spans to the zipkin collector and the function flushing the
+//spans not reported yet
+func NewZipkinTracer(cfg Config) (*zipkin.Tracer, func() error, error) {
+
+	if cfg.URL == "" {
+		return NewTracer(reporter.NewNoopReporter(), cfg.ServiceName, cfg.HostPort, 0)
+	}
+
+	return NewTracer(zipkinhttp.NewReporter(cfg.URL), cfg.ServiceName, cfg.HostPort, cfg.SampleRate)
+}
+
+//NewTracer - returns a tracer sending the sampled spans to r, a zero rate makes it a no-op
+func NewTracer(r reporter.Reporter, serviceName, hostPort string, rate float64) (*zipkin.Tracer, func() error, error) {
+
+	sampler, err := zipkin.NewCountingSampler(rate)
+
+	if err != nil {
+		r.Close()
+		return nil, nil, err
+	}
+
+	local, err := zipkin.NewEndpoint(serviceName, hostPort)
+
+	if err != nil {
+		r.Close()
+		return nil, nil, err
+	}
+
+	tracer, err := zipkin.NewTracer(r,
+		zipkin.WithLocalEndpoint(local),
+		zipkin.WithSampler(sampler),
+		zipkin.WithNoopTracer(rate == 0))
+
+	if err != nil {
+		r.Close()
+		return nil, nil, err
+	}
+
+	return tracer, r.Close, nil
+}
@@ -0,0 +1,171 @@
+package transcoding
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+//fieldMaskName - the field masks are bound from the comma separated list of their paths
+const fieldMaskName protoreflect.FullName = "google.protobuf.FieldMask"
+
+//fieldByName - returns the field of the message with the given proto or JSON name
+func fieldByName(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	fields := md.Fields()
+	if fd := fields.ByName(protoreflect.Name(name)); fd != nil {
+		return fd
+	}
+	return fields.ByJSONName(name)
+}
+
+//lookupField - returns the fields along the dotted path, starting at the message
+func lookupField(md protoreflect.MessageDescriptor, path string) ([]protoreflect.FieldDescriptor, error) {
+
+	var fields []protoreflect.FieldDescriptor
+
+	names := strings.Split(path, ".")
+
+	for i, name := range names {
+
+		fd := fieldByName(md, name)
+
+		if fd == nil {
+			return nil, fmt.Errorf("%s has no field %s", md.FullName(), name)
+		}
+
+		fields = append(fields, fd)
+
+		if i == len(names)-1 {
+			break
+		}
+
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return nil, fmt.Errorf("field %s of %s is not a message", name, md.FullName())
+		}
+
+		md = fd.Message()
+	}
+
+	return fields, nil
+}
+
+//setField - sets the field at the dotted path of the message to the values, parsed from their text form. The repeated
+//fields take every value, the others a single one
+func setField(msg protoreflect.Message, path string, values []string) error {
+
+	fields, err := lookupField(msg.Descriptor(), path)
+
+	if err != nil {
+		return err
+	}
+
+	for _, fd := range fields[:len(fields)-1] {
+		msg = msg.Mutable(fd).Message()
+	}
+
+	fd := fields[len(fields)-1]
+
+	if fd.IsMap() {
+		return fmt.Errorf("%s is a map, it can not be bound from the path or the query", path)
+	}
+
+	if fd.IsList() {
+		list := msg.Mutable(fd).List()
+		for _, s := range values {
+			v, err := parseValue(msg, fd, s)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+
+	if len(values) != 1 {
+		return fmt.Errorf("%s takes a single value", path)
+	}
+
+	v, err := parseValue(msg, fd, values[0])
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	msg.Set(fd, v)
+
+	return nil
+}
+
+//parseValue - parses the text form of a value of the field. The enums are written by name or number, the bytes in
+//base64 and the messages, which are the well-known types, in their JSON form
+func parseValue(msg protoreflect.Message, fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			b, err = base64.URLEncoding.DecodeString(s)
+		}
+		return protoreflect.ValueOfBytes(b), err
+	case protoreflect.EnumKind:
+		if v := fd.Enum().Values().ByName(protoreflect.Name(s)); v != nil {
+			return protoreflect.ValueOfEnum(v.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("%q is not a value of %s", s, fd.Enum().FullName())
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	}
+
+	var m protoreflect.Message
+
+	if fd.IsList() {
+		m = msg.Mutable(fd).List().NewElement().Message()
+	} else {
+		m = msg.NewField(fd).Message()
+	}
+
+	if m.Descriptor().FullName() == fieldMaskName {
+		paths := m.Mutable(m.Descriptor().Fields().ByName("paths")).List()
+		for _, path := range strings.Split(s, ",") {
+			paths.Append(protoreflect.ValueOfString(strings.TrimSpace(path)))
+		}
+		return protoreflect.ValueOfMessage(m), nil
+	}
+
+	if err := protojson.Unmarshal([]byte(strconv.Quote(s)), m.Interface()); err != nil {
+		if rawErr := protojson.Unmarshal([]byte(s), m.Interface()); rawErr != nil {
+			return protoreflect.Value{}, err
+		}
+	}
+
+	return protoreflect.ValueOfMessage(m), nil
+}
@@ -0,0 +1,470 @@
+//Package transcoding serves the RPCs of a gRPC service over HTTP/JSON, as the google.api.http annotations of their
+//proto files map them: the path variables, the query parameters and the body of the requests are bound to the fields
+//of the request message, the response message is answered as JSON and the status of a failed call as the problem
+//document of its code. An RPC is served as soon as it is annotated, there is no code to write for it.
+package transcoding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+//ErrorEncoder - answers a request that failed, the errors carry a code of the catalogue as problems.Error
+type ErrorEncoder func(context.Context, error, http.ResponseWriter)
+
+//Handler - serves the annotated RPCs of the gRPC services over HTTP/JSON
+type Handler struct {
+	conn         grpc.ClientConnInterface
+	routes       []route
+	outgoing     func(context.Context) context.Context
+	encodeError  ErrorEncoder
+	timeout      time.Duration
+	maxBodyBytes int64
+	marshal      protojson.MarshalOptions
+}
+
+//Option - configures a Handler
+type Option func(*Handler)
+
+//WithOutgoingContext - derives the context of the calls to the service from the one of the request, as in adding the
+//outgoing metadata
+func WithOutgoingContext(outgoing func(context.Context) context.Context) Option {
+	return func(h *Handler) {
+		h.outgoing = outgoing
+	}
+}
+
+//WithErrorEncoder - answers the failed requests with encode instead of a bare problem document
+func WithErrorEncoder(encode ErrorEncoder) Option {
+	return func(h *Handler) {
+		h.encodeError = encode
+	}
+}
+
+//WithTimeout - bounds the calls to the service, they are only bounded by the request when it is not set
+func WithTimeout(timeout time.Duration) Option {
+	return func(h *Handler) {
+		h.timeout = timeout
+	}
+}
+
+//WithMaxBodyBytes - refuses the bodies over the size limit, 1 MiB by default
+func WithMaxBodyBytes(maxBodyBytes int64) Option {
+	return func(h *Handler) {
+		h.maxBodyBytes = maxBodyBytes
+	}
+}
+
+//route - an HTTP rule of an RPC
+type route struct {
+	method       string
+	template     *template
+	fullMethod   string
+	body         string
+	responseBody string
+	input        protoreflect.MessageType
+	output       protoreflect.MessageType
+}
+
+//NewHandler - returns a Handler of the RPCs of the files that have a google.api.http annotation, served through conn.
+//The message types of the RPCs have to be linked into the binary, as they are when their generated package is
+//imported
+func NewHandler(conn grpc.ClientConnInterface, files []protoreflect.FileDescriptor, options ...Option) (*Handler, error) {
+
+	h := &Handler{
+		conn:         conn,
+		outgoing:     func(ctx context.Context) context.Context { return ctx },
+		encodeError:  encodeProblem,
+		maxBodyBytes: 1 << 20,
+		marshal:      protojson.MarshalOptions{UseProtoNames: true},
+	}
+
+	for _, option := range options {
+		option(h)
+	}
+
+	for _, file := range files {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				routes, err := methodRoutes(methods.Get(j))
+				if err != nil {
+					return nil, err
+				}
+				h.routes = append(h.routes, routes...)
+			}
+		}
+	}
+
+	return h, nil
+}
+
+//methodRoutes - returns the routes of the HTTP rule of the method and of its additional bindings, none when it is not
+//annotated
+func methodRoutes(md protoreflect.MethodDescriptor) ([]route, error) {
+
+	rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+
+	if !ok || rule == nil || rule.GetPattern() == nil {
+		return nil, nil
+	}
+
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("%s streams, it can not be transcoded", md.FullName())
+	}
+
+	input, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", md.FullName(), err)
+	}
+
+	output, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", md.FullName(), err)
+	}
+
+	var routes []route
+
+	for _, r := range append([]*annotations.HttpRule{rule}, rule.AdditionalBindings...) {
+
+		rt, err := newRoute(r, md, input, output)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", md.FullName(), err)
+		}
+
+		routes = append(routes, rt)
+	}
+
+	return routes, nil
+}
+
+//newRoute - returns the route of an HTTP rule of the method, after checking the fields it binds
+func newRoute(rule *annotations.HttpRule, md protoreflect.MethodDescriptor, input, output protoreflect.MessageType) (route, error) {
+
+	rt := route{
+		fullMethod:   fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name()),
+		body:         rule.Body,
+		responseBody: rule.ResponseBody,
+		input:        input,
+		output:       output,
+	}
+
+	var path string
+
+	switch p := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		rt.method, path = http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		rt.method, path = http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		rt.method, path = http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		rt.method, path = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		rt.method, path = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		rt.method, path = p.Custom.Kind, p.Custom.Path
+	}
+
+	t, err := parseTemplate(path)
+
+	if err != nil {
+		return route{}, err
+	}
+
+	rt.template = t
+
+	for _, v := range t.variables {
+		fields, err := lookupField(input.Descriptor(), v.path)
+		if err != nil {
+			return route{}, err
+		}
+		if fd := fields[len(fields)-1]; fd.IsList() || fd.IsMap() || fd.Message() != nil {
+			return route{}, fmt.Errorf("path variable %s is not a scalar field", v.path)
+		}
+	}
+
+	if rt.body != "" && rt.body != "*" && input.Descriptor().Fields().ByName(protoreflect.Name(rt.body)) == nil {
+		return route{}, fmt.Errorf("body %s is not a field of %s", rt.body, input.Descriptor().FullName())
+	}
+
+	if rt.responseBody != "" && output.Descriptor().Fields().ByName(protoreflect.Name(rt.responseBody)) == nil {
+		return route{}, fmt.Errorf("response body %s is not a field of %s", rt.responseBody, output.Descriptor().FullName())
+	}
+
+	return rt, nil
+}
+
+//ServeHTTP - transcodes the request into a call of the RPC its route is mapped to
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	rt, values := h.match(r)
+
+	if rt == nil {
+		h.encodeError(r.Context(), problems.Error{Code: problems.CodeNotFound, Detail: fmt.Sprintf("no RPC is mapped to %s %s", r.Method, r.URL.Path)}, w)
+		return
+	}
+
+	req := rt.input.New()
+
+	if err := h.bind(r, rt, values, req); err != nil {
+		h.encodeError(r.Context(), err, w)
+		return
+	}
+
+	ctx := h.outgoing(r.Context())
+
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	resp := rt.output.New()
+
+	if err := h.conn.Invoke(ctx, rt.fullMethod, req.Interface(), resp.Interface()); err != nil {
+		h.encodeError(r.Context(), StatusError(err), w)
+		return
+	}
+
+	data, err := h.responseBody(rt, resp)
+
+	if err != nil {
+		h.encodeError(r.Context(), err, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+//match - returns the route the request is mapped to along with the values of its path variables, the routes with the
+//most literal segments are preferred
+func (h *Handler) match(r *http.Request) (*route, map[string]string) {
+
+	var (
+		best   *route
+		values map[string]string
+	)
+
+	for i := range h.routes {
+		rt := &h.routes[i]
+		if rt.method != r.Method {
+			continue
+		}
+		v, ok := rt.template.match(r.URL.EscapedPath())
+		if ok && (best == nil || rt.template.literals() > best.template.literals()) {
+			best, values = rt, v
+		}
+	}
+
+	return best, values
+}
+
+//bind - sets the fields of the request message: first the ones of the body, then the path variables, and the query
+//parameters when the body is not bound as a whole
+func (h *Handler) bind(r *http.Request, rt *route, values map[string]string, req protoreflect.Message) error {
+
+	if rt.body != "" {
+		if err := h.bindBody(r, rt.body, req); err != nil {
+			return err
+		}
+	}
+
+	for path, value := range values {
+		if err := setField(req, path, []string{value}); err != nil {
+			return problems.Error{Code: problems.CodeBadRequest, Detail: err.Error(), Fields: []problems.FieldError{{Field: path, Rule: "type", Detail: err.Error()}}}
+		}
+	}
+
+	var fields []problems.FieldError
+
+	for key, query := range r.URL.Query() {
+
+		if _, bound := values[key]; bound {
+			continue
+		}
+
+		if rt.body == "*" {
+			fields = append(fields, problems.FieldError{Field: key, Rule: "additionalProperties", Detail: "the body carries the whole request, the query can not"})
+			continue
+		}
+
+		if _, err := lookupField(req.Descriptor(), key); err != nil {
+			fields = append(fields, problems.FieldError{Field: key, Rule: "additionalProperties", Detail: err.Error()})
+			continue
+		}
+
+		if err := setField(req, key, query); err != nil {
+			fields = append(fields, problems.FieldError{Field: key, Rule: "type", Detail: err.Error()})
+		}
+	}
+
+	if len(fields) > 0 {
+		return problems.Error{Code: problems.CodeValidationFailed, Detail: fields[0].Detail, Fields: fields}
+	}
+
+	return nil
+}
+
+//bindBody - sets the field of the request the body is mapped to, or the whole request for "*"
+func (h *Handler) bindBody(r *http.Request, body string, req protoreflect.Message) error {
+
+	data, err := h.readBody(r)
+
+	if err != nil || len(bytes.TrimSpace(data)) == 0 {
+		return err
+	}
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != "application/json" {
+			return problems.Error{Code: problems.CodeUnsupportedMediaType, Detail: fmt.Sprintf("the body has to be application/json, not %s", contentType)}
+		}
+	}
+
+	if body == "*" {
+		if err := protojson.Unmarshal(data, req.Interface()); err != nil {
+			return problems.Error{Code: problems.CodeMalformedRequest, Detail: err.Error()}
+		}
+		return nil
+	}
+
+	//The body is the JSON value of the field, it is read as the only member
+	//of a request so that protojson parses it whatever its type.
+	fd := req.Descriptor().Fields().ByName(protoreflect.Name(body))
+	wrapped := req.Type().New()
+
+	if err := protojson.Unmarshal([]byte(fmt.Sprintf(`{%q:%s}`, fd.JSONName(), data)), wrapped.Interface()); err != nil {
+		return problems.Error{Code: problems.CodeMalformedRequest, Detail: err.Error()}
+	}
+
+	req.Set(fd, wrapped.Get(fd))
+
+	return nil
+}
+
+//readBody - reads the body of the request, unless it is over the size limit
+func (h *Handler) readBody(r *http.Request) ([]byte, error) {
+
+	if r.Body == nil {
+		return nil, nil
+	}
+
+	tooLarge := problems.Error{Code: problems.CodePayloadTooLarge, Detail: fmt.Sprintf("the body is over %d bytes", h.maxBodyBytes)}
+
+	if r.ContentLength > h.maxBodyBytes {
+		return nil, tooLarge
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, h.maxBodyBytes+1))
+
+	if err != nil {
+		return nil, problems.Error{Code: problems.CodeMalformedRequest, Detail: err.Error()}
+	}
+
+	if int64(len(data)) > h.maxBodyBytes {
+		return nil, tooLarge
+	}
+
+	return data, nil
+}
+
+//responseBody - returns the JSON of the response message, or of its field the route answers with
+func (h *Handler) responseBody(rt *route, resp protoreflect.Message) ([]byte, error) {
+
+	if rt.responseBody == "" {
+		return h.marshal.Marshal(resp.Interface())
+	}
+
+	//The unset fields are marshalled too, so that the field is answered with
+	//the JSON of its zero value when it is not set.
+	marshal := h.marshal
+	marshal.EmitUnpopulated = true
+
+	data, err := marshal.Marshal(resp.Interface())
+
+	if err != nil {
+		return nil, err
+	}
+
+	var members map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+
+	return members[rt.responseBody], nil
+}
+
+//StatusError - converts the status of a failed call into the error of its code of the catalogue: the one of its
+//ErrorInfo, or the one its status code is translated to. The field violations of its BadRequest are the fields of the
+//error
+func StatusError(err error) error {
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return problems.Error{Code: problems.CodeTimeout}
+	}
+
+	st := status.Convert(err)
+
+	coded := problems.Error{Code: problems.FromStatus(st), Detail: st.Message()}
+
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				coded.Fields = append(coded.Fields, problems.FieldError{Field: v.Field, Detail: v.Description})
+			}
+		}
+	}
+
+	return coded
+}
+
+//encodeProblem - answers the failed request with the problem document of its error
+func encodeProblem(ctx context.Context, err error, w http.ResponseWriter) {
+
+	coded := problems.Error{Code: problems.CodeInternal, Detail: err.Error()}
+	errors.As(err, &coded)
+
+	problem := problems.New(coded.Code, coded.Error(), "")
+	problem.Errors = coded.Fields
+
+	w.Header().Set("Content-Type", problems.ContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+//Routes - describes the routes the handler serves, as in "GET /v2/users/{email} /users.v2.Users/GetUser"
+func (h *Handler) Routes() []string {
+
+	var routes []string
+
+	for _, rt := range h.routes {
+		routes = append(routes, fmt.Sprintf("%s %s %s", rt.method, rt.template, rt.fullMethod))
+	}
+
+	return routes
+}
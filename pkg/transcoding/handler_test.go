@@ -0,0 +1,203 @@
+package transcoding_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	usersv2 "github.com/casmelad/GlobantPOC/api/users/v2"
+	"github.com/casmelad/GlobantPOC/pkg/problems"
+	"github.com/casmelad/GlobantPOC/pkg/transcoding"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+//usersServer records the last request it was called with and answers with
+//user, or fails with err.
+type usersServer struct {
+	usersv2.UnimplementedUsersServer
+	request proto.Message
+	user    *usersv2.User
+	err     error
+}
+
+func (s *usersServer) GetUser(ctx context.Context, req *usersv2.GetUserRequest) (*usersv2.User, error) {
+	s.request = req
+	return s.user, s.err
+}
+
+func (s *usersServer) ListUsers(ctx context.Context, req *usersv2.ListUsersRequest) (*usersv2.ListUsersResponse, error) {
+	s.request = req
+	return &usersv2.ListUsersResponse{Users: []*usersv2.User{s.user}, TotalSize: 1}, s.err
+}
+
+func (s *usersServer) CreateUser(ctx context.Context, req *usersv2.CreateUserRequest) (*usersv2.User, error) {
+	s.request = req
+	return s.user, s.err
+}
+
+func (s *usersServer) UpdateUser(ctx context.Context, req *usersv2.UpdateUserRequest) (*usersv2.User, error) {
+	s.request = req
+	return s.user, s.err
+}
+
+func (s *usersServer) DeleteUser(ctx context.Context, req *usersv2.DeleteUserRequest) (*emptypb.Empty, error) {
+	s.request = req
+	return &emptypb.Empty{}, s.err
+}
+
+//newHandler serves server in memory and returns the Handler transcoding
+//the requests into calls to it.
+func newHandler(t *testing.T, server *usersServer) http.Handler {
+	listener := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	usersv2.RegisterUsersServer(s, server)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	handler, err := transcoding.NewHandler(conn, []protoreflect.FileDescriptor{usersv2.File_users_v2_users_proto})
+	assert.Nil(t, err)
+	return handler
+}
+
+func serve(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) problems.Problem {
+	var problem problems.Problem
+	assert.Equal(t, problems.ContentType, w.Header().Get("Content-Type"))
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&problem))
+	return problem
+}
+
+func Test_Handler_PathAndQuery_BoundToRequest(t *testing.T) {
+	//Arrange
+	server := &usersServer{user: &usersv2.User{Id: 7, Email: "larry.page@gmail.com", LastName: "Page"}}
+	handler := newHandler(t, server)
+	//Act
+	w := serve(handler, http.MethodGet, "/v2/users/larry.page%40gmail.com?read_mask=email,last_name", "")
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":7,"email":"larry.page@gmail.com","last_name":"Page"}`, w.Body.String())
+	req := server.request.(*usersv2.GetUserRequest)
+	assert.Equal(t, "larry.page@gmail.com", req.Email)
+	assert.Equal(t, []string{"email", "last_name"}, req.ReadMask.Paths)
+}
+
+func Test_Handler_QueryParameters_ParsedByFieldType(t *testing.T) {
+	//Arrange
+	server := &usersServer{user: &usersv2.User{Id: 7}}
+	handler := newHandler(t, server)
+	//Act
+	w := serve(handler, http.MethodGet, "/v2/users?page_size=10&showDeleted=true&status=STATUS_ACTIVE&page_token=abc", "")
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, proto.Equal(&usersv2.ListUsersRequest{PageSize: 10, ShowDeleted: true, Status: usersv2.Status_STATUS_ACTIVE, PageToken: "abc"}, server.request))
+}
+
+func Test_Handler_BodyField_BoundWithPathVariable(t *testing.T) {
+	//Arrange
+	server := &usersServer{user: &usersv2.User{Id: 7, Version: 3}}
+	handler := newHandler(t, server)
+	//Act
+	w := serve(handler, http.MethodPatch, "/v2/users/larry.page@gmail.com?update_mask=name", `{"name":"Larry","version":2}`)
+	//Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	req := server.request.(*usersv2.UpdateUserRequest)
+	assert.True(t, proto.Equal(&usersv2.User{Email: "larry.page@gmail.com", Name: "Larry", Version: 2}, req.User))
+	assert.Equal(t, []string{"name"}, req.UpdateMask.Paths)
+}
+
+func Test_Handler_MalformedBody_Returns400(t *testing.T) {
+	//Arrange
+	handler := newHandler(t, &usersServer{})
+	//Act
+	w := serve(handler, http.MethodPost, "/v2/users", `{"email":`)
+	//Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, problems.CodeMalformedRequest, decodeProblem(t, w).Code)
+}
+
+func Test_Handler_InvalidQueryParameters_ListsEveryField(t *testing.T) {
+	//Arrange
+	server := &usersServer{}
+	handler := newHandler(t, server)
+	//Act
+	w := serve(handler, http.MethodGet, "/v2/users?page_size=ten&role=admin", "")
+	//Assert
+	problem := decodeProblem(t, w)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var rules []string
+	for _, f := range problem.Errors {
+		rules = append(rules, f.Field+"/"+f.Rule)
+	}
+	assert.ElementsMatch(t, []string{"page_size/type", "role/additionalProperties"}, rules)
+	assert.Nil(t, server.request)
+}
+
+func Test_Handler_UnknownRoute_Returns404(t *testing.T) {
+	//Arrange
+	handler := newHandler(t, &usersServer{})
+	//Act
+	w := serve(handler, http.MethodPut, "/v2/users/7", `{}`)
+	//Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, problems.CodeNotFound, decodeProblem(t, w).Code)
+}
+
+func TestCases_Handler_FailedCall_StatusMappedToHTTP(t *testing.T) {
+	invalid, _ := status.New(codes.InvalidArgument, "name is not valid").WithDetails(
+		problems.ErrorInfo(problems.CodeValidationFailed),
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "name", Description: "name is not valid"}}})
+
+	testCases := []struct {
+		name   string
+		err    error
+		status int
+		code   problems.Code
+		fields []problems.FieldError
+	}{
+		{"NotFound", status.Error(codes.NotFound, "user not found"), http.StatusNotFound, problems.CodeNotFound, nil},
+		{"Aborted", status.Error(codes.Aborted, "user version conflict"), http.StatusPreconditionFailed, problems.CodeVersionConflict, nil},
+		{"ErrorInfo", invalid.Err(), http.StatusUnprocessableEntity, problems.CodeValidationFailed, []problems.FieldError{{Field: "name", Detail: "name is not valid"}}},
+		{"Internal", status.Error(codes.Internal, "database is down"), http.StatusInternalServerError, problems.CodeInternal, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Arrange
+			handler := newHandler(t, &usersServer{err: tc.err})
+			//Act
+			w := serve(handler, http.MethodDelete, "/v2/users/7?version=2", "")
+			//Assert
+			problem := decodeProblem(t, w)
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.code, problem.Code)
+			assert.Equal(t, tc.fields, problem.Errors)
+		})
+	}
+}
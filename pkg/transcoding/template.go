@@ -0,0 +1,196 @@
+package transcoding
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+//template - a path template of a google.api.http rule, as in /v2/users/{user.email} or /v1/{name=shelves/*}:publish
+type template struct {
+	//segments - the literal segments, and the "*" and "**" wildcards
+	segments []string
+	//verb - the custom verb that follows the last segment, if any
+	verb string
+	//variables - the fields bound to the segments they match
+	variables []variable
+}
+
+//variable - a field bound to the segments [start, end) of a template
+type variable struct {
+	path       string
+	start, end int
+}
+
+//parseTemplate - parses the path template of a rule, the variables without a pattern match a single segment
+func parseTemplate(s string) (*template, error) {
+
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("template %q does not start with /", s)
+	}
+
+	t := &template{}
+	rest := s[1:]
+
+	if i := strings.LastIndex(rest, ":"); i >= 0 && !strings.ContainsAny(rest[i:], "/}") {
+		t.verb, rest = rest[i+1:], rest[:i]
+	}
+
+	for rest != "" {
+
+		if rest[0] == '{' {
+
+			end := strings.IndexByte(rest, '}')
+
+			if end < 0 {
+				return nil, fmt.Errorf("template %q has an unclosed variable", s)
+			}
+
+			path, pattern := rest[1:end], "*"
+
+			if i := strings.IndexByte(path, '='); i >= 0 {
+				path, pattern = path[:i], path[i+1:]
+			}
+
+			if path == "" || pattern == "" {
+				return nil, fmt.Errorf("template %q has an empty variable", s)
+			}
+
+			v := variable{path: path, start: len(t.segments)}
+			t.segments = append(t.segments, strings.Split(pattern, "/")...)
+			v.end = len(t.segments)
+			t.variables = append(t.variables, v)
+			rest = rest[end+1:]
+
+		} else {
+
+			end := strings.IndexByte(rest, '/')
+
+			if end < 0 {
+				end = len(rest)
+			}
+
+			t.segments = append(t.segments, rest[:end])
+			rest = rest[end:]
+		}
+
+		if rest == "" {
+			break
+		}
+
+		if rest[0] != '/' || rest == "/" {
+			return nil, fmt.Errorf("template %q is not valid", s)
+		}
+
+		rest = rest[1:]
+	}
+
+	for i, segment := range t.segments {
+		if segment == "" || strings.ContainsAny(segment, "{}=") {
+			return nil, fmt.Errorf("template %q has a segment that is not valid", s)
+		}
+		if segment == "**" && i != len(t.segments)-1 {
+			return nil, fmt.Errorf("template %q has ** before its last segment", s)
+		}
+	}
+
+	return t, nil
+}
+
+//match - returns the values of the variables of the template when the escaped path matches it
+func (t *template) match(path string) (map[string]string, bool) {
+
+	if !strings.HasPrefix(path, "/") {
+		return nil, false
+	}
+
+	path = path[1:]
+
+	if t.verb != "" {
+		if !strings.HasSuffix(path, ":"+t.verb) {
+			return nil, false
+		}
+		path = strings.TrimSuffix(path, ":"+t.verb)
+	}
+
+	var segments []string
+
+	if path != "" {
+		for _, segment := range strings.Split(path, "/") {
+			unescaped, err := url.PathUnescape(segment)
+			if err != nil {
+				return nil, false
+			}
+			segments = append(segments, unescaped)
+		}
+	}
+
+	deep := len(t.segments) > 0 && t.segments[len(t.segments)-1] == "**"
+
+	if (!deep && len(segments) != len(t.segments)) || (deep && len(segments) < len(t.segments)-1) {
+		return nil, false
+	}
+
+	for i, segment := range t.segments {
+		switch segment {
+		case "**":
+		case "*":
+			if segments[i] == "" {
+				return nil, false
+			}
+		default:
+			if segments[i] != segment {
+				return nil, false
+			}
+		}
+	}
+
+	values := map[string]string{}
+
+	for _, v := range t.variables {
+		end := v.end
+		if deep && end == len(t.segments) {
+			end = len(segments)
+		}
+		values[v.path] = strings.Join(segments[v.start:end], "/")
+	}
+
+	return values, true
+}
+
+//literals - how many literal segments the template has, the routes with more of them are preferred
+func (t *template) literals() int {
+
+	n := 0
+
+	for _, segment := range t.segments {
+		if segment != "*" && segment != "**" {
+			n++
+		}
+	}
+
+	return n
+}
+
+//String - the template as it is written in the rule
+func (t *template) String() string {
+
+	segments := append([]string{}, t.segments...)
+
+	for i := len(t.variables) - 1; i >= 0; i-- {
+		v := t.variables[i]
+		variable := "{" + v.path + "}"
+		if pattern := strings.Join(t.segments[v.start:v.end], "/"); pattern != "*" {
+			variable = "{" + v.path + "=" + pattern + "}"
+		}
+		segments = append(segments[:v.start], append([]string{variable}, segments[v.end:]...)...)
+	}
+
+	s := "/" + strings.Join(segments, "/")
+
+	if t.verb != "" {
+		s += ":" + t.verb
+	}
+
+	return s
+}
@@ -0,0 +1,50 @@
+package transcoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCases_Template_Match(t *testing.T) {
+	testCases := []struct {
+		template string
+		path     string
+		values   map[string]string
+	}{
+		{"/v2/users", "/v2/users", map[string]string{}},
+		{"/v2/users", "/v2/users/", nil},
+		{"/v2/users/{email}", "/v2/users/larry.page%40gmail.com", map[string]string{"email": "larry.page@gmail.com"}},
+		{"/v2/users/{user.email}", "/v2/users/larry", map[string]string{"user.email": "larry"}},
+		{"/v2/users/{email}", "/v2/users/larry/history", nil},
+		{"/v1/{name=shelves/*/books/*}", "/v1/shelves/1/books/2", map[string]string{"name": "shelves/1/books/2"}},
+		{"/v1/{name=files/**}", "/v1/files/a/b/c", map[string]string{"name": "files/a/b/c"}},
+		{"/v1/users/{id}:restore", "/v1/users/7:restore", map[string]string{"id": "7"}},
+		{"/v1/users/{id}:restore", "/v1/users/7", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.template+" "+tc.path, func(t *testing.T) {
+			//Arrange
+			template, err := parseTemplate(tc.template)
+			assert.Nil(t, err)
+			//Act
+			values, ok := template.match(tc.path)
+			//Assert
+			assert.Equal(t, tc.values != nil, ok)
+			assert.Equal(t, tc.values, values)
+			assert.Equal(t, tc.template, template.String())
+		})
+	}
+}
+
+func TestCases_Template_NotValid(t *testing.T) {
+	for _, s := range []string{"v2/users", "/v2/users/", "/v2/{email", "/v2/{}", "/v1/**/books", "/v2//users"} {
+		t.Run(s, func(t *testing.T) {
+			//Act
+			_, err := parseTemplate(s)
+			//Assert
+			assert.NotNil(t, err)
+		})
+	}
+}
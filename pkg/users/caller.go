@@ -0,0 +1,79 @@
+package users
+
+import "context"
+
+//AdminRole - role required for administrative operations such as purging users
+const AdminRole = "admin"
+
+//MFAMethod - the authentication method of a caller that gave a second factor, as in the amr claim of RFC 8176
+const MFAMethod = "mfa"
+
+//AnonymousActor - actor recorded for writes performed without an authenticated caller
+const AnonymousActor = "anonymous"
+
+//Caller - the authenticated principal performing an operation
+type Caller struct {
+	Subject string
+	Roles   []string
+	//Methods - how the caller authenticated, such as pwd, otp or mfa
+	Methods []string
+}
+
+type callerContextKey struct{}
+
+//HasRole - reports whether the caller was granted the given role
+func (c Caller) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+//HasMethod - reports whether the caller authenticated with the given method
+func (c Caller) HasMethod(method string) bool {
+	for _, m := range c.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+//authorizeDestructive - only administrators that gave a second factor can delete, purge or deactivate users,
+//the anonymous callers are refused
+func authorizeDestructive(ctx context.Context) error {
+	if caller, ok := CallerFromContext(ctx); !ok || !caller.HasRole(AdminRole) || !caller.HasMethod(MFAMethod) {
+		return UserError(ERRFORBIDDEN)
+	}
+	return nil
+}
+
+//authorizeSelfOrAdmin - only the user with the email or an administrator that gave a second factor can act on
+//the account, the anonymous callers are refused
+func authorizeSelfOrAdmin(ctx context.Context, email string) error {
+	if caller, ok := CallerFromContext(ctx); ok && (caller.Subject == email || (caller.HasRole(AdminRole) && caller.HasMethod(MFAMethod))) {
+		return nil
+	}
+	return UserError(ERRFORBIDDEN)
+}
+
+//WithCaller - returns a copy of the context carrying the caller
+func WithCaller(ctx context.Context, c Caller) context.Context {
+	return context.WithValue(ctx, callerContextKey{}, c)
+}
+
+//CallerFromContext - retrieves the caller stored in the context, if any
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	c, ok := ctx.Value(callerContextKey{}).(Caller)
+	return c, ok
+}
+
+//ActorFromContext - returns the subject of the caller stored in the context, or AnonymousActor
+func ActorFromContext(ctx context.Context) string {
+	if c, ok := CallerFromContext(ctx); ok && c.Subject != "" {
+		return c.Subject
+	}
+	return AnonymousActor
+}
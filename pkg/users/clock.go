@@ -0,0 +1,16 @@
+package users
+
+import "time"
+
+//Clock - source of the current time, injectable so tests can freeze it
+type Clock interface {
+	Now() time.Time
+}
+
+//SystemClock - a Clock backed by the system time in UTC
+type SystemClock struct{}
+
+//Now - returns the current system time in UTC
+func (SystemClock) Now() time.Time {
+	return time.Now().UTC()
+}
@@ -0,0 +1,258 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+var (
+	//ErrInvalidToken - the token is unknown, expired or already used
+	ErrInvalidToken = errors.New("invalid token")
+	//ErrEmailChangesDisabled - the service was built without WithEmailChanges
+	ErrEmailChangesDisabled = errors.New("email changes are not enabled")
+	//ErrVerificationDisabled - the service was built without WithVerification
+	ErrVerificationDisabled = errors.New("email verification is not enabled")
+)
+
+const emailTokenBytes = 32
+
+//EmailChange - a requested email change waiting for the new address to be confirmed
+type EmailChange struct {
+	UserID      int
+	OldEmail    string
+	NewEmail    string
+	TokenHash   string
+	RequestedAt time.Time
+	ExpiresAt   time.Time
+}
+
+//EmailStore - the persistence of the email changes and of the aliases left by them
+type EmailStore interface {
+	//SaveEmailChange - stores the pending change of a user, replacing the previous one
+	SaveEmailChange(ctx context.Context, c EmailChange) error
+	//GetEmailChange - retrieves the pending change with the given token hash, a zero change when there is none
+	GetEmailChange(ctx context.Context, tokenHash string) (EmailChange, error)
+	//DeleteEmailChange - removes the pending change of a user
+	DeleteEmailChange(ctx context.Context, userID int) error
+	//ChangeEmail - replaces the email of a user at the given version and keeps the old one as an alias until aliasUntil
+	ChangeEmail(ctx context.Context, userID int, version int, newEmail string, aliasUntil time.Time) error
+	//ResolveAlias - returns the id of the user an alias not expired at the given time points to, zero when there is none
+	ResolveAlias(ctx context.Context, email string, at time.Time) (int, error)
+}
+
+//EmailChanges - configures the change email flow
+type EmailChanges struct {
+	//Store - where the pending changes and the aliases are kept
+	Store EmailStore
+	//Notifier - delivers the verification token to the new address and the notice of the change to the old one
+	Notifier Notifier
+	//Clock - the time the tokens and the aliases expire against
+	Clock Clock
+	//TokenTTL - how long a verification token can be used
+	TokenTTL time.Duration
+	//AliasGrace - how long the old address keeps resolving to the user
+	AliasGrace time.Duration
+}
+
+//WithEmailChanges - enables the change email flow
+func WithEmailChanges(c EmailChanges) ServiceOption {
+	return func(us *UserService) {
+		us.emails = &c
+	}
+}
+
+//RequestEmailChange - checks the new address is free and sends it a verification token, nothing changes until it is confirmed.
+//Only the user itself or an administrator that gave a second factor can ask for it, the old address is told about it
+func (us *UserService) RequestEmailChange(ctx context.Context, email string, newEmail string) error {
+
+	if us.emails == nil {
+		return ErrEmailChangesDisabled
+	}
+
+	if err := authorizeSelfOrAdmin(ctx, email); err != nil {
+		return err
+	}
+
+	if err := validator.New().Var(newEmail, "required,email"); err != nil {
+		return errors.New("Email is not valid")
+	}
+
+	usr, err := us.repository.GetByEmail(ctx, email)
+
+	if err != nil {
+		return err
+	}
+
+	if usr.ID == 0 {
+		return errors.New("user not found")
+	}
+
+	if newEmail == usr.Email {
+		return UserError(ERRINVALIDDATA)
+	}
+
+	if err := us.checkEmailAvailable(ctx, usr.ID, newEmail); err != nil {
+		return err
+	}
+
+	token, tokenHash, err := newEmailToken()
+
+	if err != nil {
+		return err
+	}
+
+	now := us.emails.Clock.Now()
+
+	err = us.emails.Store.SaveEmailChange(ctx, EmailChange{
+		UserID:      usr.ID,
+		OldEmail:    usr.Email,
+		NewEmail:    newEmail,
+		TokenHash:   tokenHash,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(us.emails.TokenTTL),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	err = us.emails.Notifier.Notify(ctx, Notification{
+		To:      usr.Email,
+		Subject: "Your email address is being changed",
+		Body:    fmt.Sprintf("A change of your email address to %s was requested, it is applied once the new address confirms it. If you did not ask for it, secure your account.", newEmail),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return us.emails.Notifier.Notify(ctx, Notification{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body:    fmt.Sprintf("Use this token to confirm %s as your new email address, it expires at %s: %s", newEmail, now.Add(us.emails.TokenTTL).Format(time.RFC3339), token),
+	})
+}
+
+//ConfirmEmailChange - applies the change the token was issued for, the old address resolves to the user during the grace period
+func (us *UserService) ConfirmEmailChange(ctx context.Context, token string) (EmailChange, error) {
+
+	if us.emails == nil {
+		return EmailChange{}, ErrEmailChangesDisabled
+	}
+
+	change, err := us.emails.Store.GetEmailChange(ctx, hashEmailToken(token))
+
+	if err != nil {
+		return EmailChange{}, err
+	}
+
+	now := us.emails.Clock.Now()
+
+	if change.UserID == 0 || !now.Before(change.ExpiresAt) {
+		return EmailChange{}, ErrInvalidToken
+	}
+
+	usr, err := us.repository.GetByID(ctx, change.UserID)
+
+	if err != nil {
+		return EmailChange{}, err
+	}
+
+	//The address may have changed since the token was issued.
+	if usr.ID == 0 || usr.IsDeleted() || usr.Email != change.OldEmail {
+		return EmailChange{}, ErrInvalidToken
+	}
+
+	if err := us.checkEmailAvailable(ctx, usr.ID, change.NewEmail); err != nil {
+		return EmailChange{}, err
+	}
+
+	err = us.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := us.emails.Store.ChangeEmail(ctx, usr.ID, usr.Version, change.NewEmail, now.Add(us.emails.AliasGrace)); err != nil {
+			return err
+		}
+
+		if err := us.emails.Store.DeleteEmailChange(ctx, usr.ID); err != nil {
+			return err
+		}
+
+		return us.emit(ctx, usr.ID, func(u User) Event { return UserUpdated{User: u} })
+	})
+
+	if err != nil {
+		return EmailChange{}, err
+	}
+
+	return change, nil
+}
+
+//checkEmailAvailable - an address is taken by another user or by the alias another user still has
+func (us *UserService) checkEmailAvailable(ctx context.Context, usrID int, email string) error {
+
+	owner, err := us.repository.GetByEmail(ctx, email)
+
+	if err != nil {
+		return err
+	}
+
+	if owner.ID > 0 {
+		return errors.New("user already exists")
+	}
+
+	aliasOwner, err := us.emails.Store.ResolveAlias(ctx, email, us.emails.Clock.Now())
+
+	if err != nil {
+		return err
+	}
+
+	if aliasOwner > 0 && aliasOwner != usrID {
+		return errors.New("user already exists")
+	}
+
+	return nil
+}
+
+//resolveAlias - retrieves the user an old address still points to, a zero user when there is none
+func (us *UserService) resolveAlias(ctx context.Context, email string) (User, error) {
+
+	if us.emails == nil {
+		return User{}, nil
+	}
+
+	usrID, err := us.emails.Store.ResolveAlias(ctx, email, us.emails.Clock.Now())
+
+	if err != nil || usrID == 0 {
+		return User{}, err
+	}
+
+	usr, err := us.repository.GetByID(ctx, usrID)
+
+	if err != nil || usr.IsDeleted() {
+		return User{}, err
+	}
+
+	return usr, nil
+}
+
+//newEmailToken - returns a random token, handed to the user, and its hash, the only part stored
+func newEmailToken() (string, string, error) {
+	b := make([]byte, emailTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashEmailToken(token), nil
+}
+
+func hashEmailToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
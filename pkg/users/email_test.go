@@ -0,0 +1,186 @@
+package users_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type movableClock struct {
+	now time.Time
+}
+
+func (c *movableClock) Now() time.Time {
+	return c.now
+}
+
+//inbox - a notifier that keeps the notifications so the tests can read the tokens
+type inbox struct {
+	notifications []users.Notification
+}
+
+func (i *inbox) Notify(ctx context.Context, n users.Notification) error {
+	i.notifications = append(i.notifications, n)
+	return nil
+}
+
+//token - the token is the last word of the body
+func (i *inbox) token() string {
+	body := i.notifications[len(i.notifications)-1].Body
+	return body[strings.LastIndex(body, " ")+1:]
+}
+
+//as - the context of the user with the email
+func as(email string) context.Context {
+	return users.WithCaller(context.Background(), users.Caller{Subject: email})
+}
+
+func newEmailService() (*users.UserService, *inbox, *movableClock) {
+	clock := &movableClock{now: now}
+	notifications := &inbox{}
+	repository := memory.NewInMemoryUserRepository(memory.WithClock(clock))
+	service := users.NewUserService(repository, users.WithTransactor(repository), users.WithEmailChanges(users.EmailChanges{
+		Store:      repository,
+		Notifier:   notifications,
+		Clock:      clock,
+		TokenTTL:   time.Hour,
+		AliasGrace: 24 * time.Hour,
+	}))
+	ctx := context.Background()
+	service.Create(ctx, users.User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page"})
+	service.Create(ctx, users.User{Email: "sergey.brin@gmail.com", Name: "Sergey", LastName: "Brin"})
+	return service, notifications, clock
+}
+
+func Test_EmailChange_Confirmed_ChangesEmailAndKeepsAlias(t *testing.T) {
+	//Arrange
+	service, notifications, clock := newEmailService()
+	ctx := context.Background()
+	errR := service.RequestEmailChange(as("larry.page@gmail.com"), "larry.page@gmail.com", "larry@alphabet.com")
+	//Act
+	change, errC := service.ConfirmEmailChange(ctx, notifications.token())
+	byNew, _ := service.GetByEmail(ctx, "larry@alphabet.com")
+	byAlias, _ := service.GetByEmail(ctx, "larry.page@gmail.com")
+	clock.now = now.Add(25 * time.Hour)
+	_, errExpired := service.GetByEmail(ctx, "larry.page@gmail.com")
+	//Assert
+	assert.Nil(t, errR)
+	assert.Nil(t, errC)
+	assert.Equal(t, "larry.page@gmail.com", notifications.notifications[0].To)
+	assert.Equal(t, "larry@alphabet.com", notifications.notifications[1].To)
+	assert.Equal(t, "larry.page@gmail.com", change.OldEmail)
+	assert.Equal(t, 1, byNew.ID)
+	assert.Equal(t, 2, byNew.Version)
+	assert.Equal(t, byNew, byAlias)
+	assert.NotNil(t, errExpired)
+}
+
+func Test_EmailChange_NotConfirmed_ChangesNothing(t *testing.T) {
+	//Arrange
+	service, _, _ := newEmailService()
+	ctx := context.Background()
+	//Act
+	err := service.RequestEmailChange(as("larry.page@gmail.com"), "larry.page@gmail.com", "larry@alphabet.com")
+	usr, _ := service.GetByEmail(ctx, "larry.page@gmail.com")
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "larry.page@gmail.com", usr.Email)
+}
+
+func Test_EmailChange_ExpiredToken_IsRejected(t *testing.T) {
+	//Arrange
+	service, notifications, clock := newEmailService()
+	ctx := context.Background()
+	service.RequestEmailChange(as("larry.page@gmail.com"), "larry.page@gmail.com", "larry@alphabet.com")
+	clock.now = now.Add(time.Hour)
+	//Act
+	_, err := service.ConfirmEmailChange(ctx, notifications.token())
+	//Assert
+	assert.Equal(t, users.ErrInvalidToken, err)
+}
+
+func Test_EmailChange_TokenIsSingleUse(t *testing.T) {
+	//Arrange
+	service, notifications, _ := newEmailService()
+	ctx := context.Background()
+	service.RequestEmailChange(as("larry.page@gmail.com"), "larry.page@gmail.com", "larry@alphabet.com")
+	token := notifications.token()
+	service.ConfirmEmailChange(ctx, token)
+	//Act
+	_, err := service.ConfirmEmailChange(ctx, token)
+	//Assert
+	assert.Equal(t, users.ErrInvalidToken, err)
+}
+
+func TestCases_EmailChange_NotTheUserNorAdmin_ReturnsForbidden(t *testing.T) {
+	testCases := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{name: "anonymous", ctx: context.Background()},
+		{name: "another user", ctx: as("sergey.brin@gmail.com")},
+		{name: "admin without second factor", ctx: users.WithCaller(context.Background(), users.Caller{Subject: "root", Roles: []string{users.AdminRole}})},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, notifications, _ := newEmailService()
+			err := service.RequestEmailChange(tc.ctx, "larry.page@gmail.com", "larry@alphabet.com")
+			assert.True(t, users.IsUserErrorType(users.ERRFORBIDDEN, err))
+			assert.Empty(t, notifications.notifications)
+		})
+	}
+}
+
+func Test_EmailChange_AdminWithSecondFactor_IsAllowed(t *testing.T) {
+	//Arrange
+	service, notifications, _ := newEmailService()
+	ctx := users.WithCaller(context.Background(), users.Caller{Subject: "root", Roles: []string{users.AdminRole}, Methods: []string{users.MFAMethod}})
+	//Act
+	err := service.RequestEmailChange(ctx, "larry.page@gmail.com", "larry@alphabet.com")
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(notifications.notifications))
+}
+
+func TestCases_EmailChange_AddressTaken(t *testing.T) {
+	testCases := []struct {
+		name          string
+		sergeyChanges bool
+	}{
+		{name: "email of another user", sergeyChanges: false},
+		{name: "alias of another user", sergeyChanges: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, notifications, _ := newEmailService()
+			ctx := context.Background()
+			if tc.sergeyChanges {
+				service.RequestEmailChange(as("sergey.brin@gmail.com"), "sergey.brin@gmail.com", "sergey@alphabet.com")
+				service.ConfirmEmailChange(ctx, notifications.token())
+			}
+			err := service.RequestEmailChange(as("larry.page@gmail.com"), "larry.page@gmail.com", "sergey.brin@gmail.com")
+			assert.EqualError(t, err, "user already exists")
+		})
+	}
+}
+
+func Test_Create_WithEmailOfAlias_ReturnsError(t *testing.T) {
+	//Arrange
+	service, notifications, _ := newEmailService()
+	ctx := context.Background()
+	service.RequestEmailChange(as("larry.page@gmail.com"), "larry.page@gmail.com", "larry@alphabet.com")
+	service.ConfirmEmailChange(ctx, notifications.token())
+	//Act
+	_, err := service.Create(ctx, users.User{Email: "larry.page@gmail.com", Name: "Another", LastName: "Larry"})
+	//Assert
+	assert.EqualError(t, err, "user already exists")
+}
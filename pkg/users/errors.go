@@ -16,12 +16,18 @@ const (
 	NotFound
 	AlreadyExistingItem
 	InvalidData
+	VersionConflict
+	Forbidden
+	VersionRequired
 )
 
 const (
 	USERNOTFOUND      string = "user not found"
 	USERALREADYEXISTS string = "user already exists"
 	INVALIDDATA       string = "invalid data"
+	VERSIONCONFLICT   string = "user version conflict"
+	FORBIDDEN         string = "operation not allowed"
+	VERSIONREQUIRED   string = "user version required"
 )
 
 func (e UserError) Error() string {
@@ -43,4 +49,10 @@ var (
 	ERRNOTFOUND      = ConstUserError{code: NotFound, message: USERNOTFOUND}
 	ERRALREADYEXISTS = ConstUserError{code: AlreadyExistingItem, message: USERALREADYEXISTS}
 	ERRINVALIDDATA   = ConstUserError{code: InvalidData, message: INVALIDDATA}
+	//ERRVERSIONCONFLICT - the user was modified since the version the caller read
+	ERRVERSIONCONFLICT = ConstUserError{code: VersionConflict, message: VERSIONCONFLICT}
+	//ERRFORBIDDEN - the caller is not allowed to perform the operation
+	ERRFORBIDDEN = ConstUserError{code: Forbidden, message: FORBIDDEN}
+	//ERRVERSIONREQUIRED - the write was sent without the version the caller read
+	ERRVERSIONREQUIRED = ConstUserError{code: VersionRequired, message: VERSIONREQUIRED}
 )
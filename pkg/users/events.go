@@ -0,0 +1,89 @@
+package users
+
+import (
+	"context"
+	"time"
+)
+
+//EventType - the name of a domain event
+type EventType string
+
+const (
+	UserCreatedEvent EventType = "user.created"
+	UserUpdatedEvent EventType = "user.updated"
+	UserDeletedEvent EventType = "user.deleted"
+	UserPurgedEvent  EventType = "user.purged"
+)
+
+//Event - a change of a user emitted by the service
+type Event interface {
+	//Type - the name of the event
+	Type() EventType
+	//UserID - the id of the changed user
+	UserID() int
+	//OccurredAt - when the change was made
+	OccurredAt() time.Time
+}
+
+//EventEmitter - receives the events of the service, Emit runs within the transaction of the write
+type EventEmitter interface {
+	Emit(ctx context.Context, e Event) error
+}
+
+//UserCreated - a user was created
+type UserCreated struct {
+	User User `json:"user"`
+}
+
+//Type - returns UserCreatedEvent
+func (e UserCreated) Type() EventType { return UserCreatedEvent }
+
+//UserID - returns the id of the created user
+func (e UserCreated) UserID() int { return e.User.ID }
+
+//OccurredAt - returns when the user was created
+func (e UserCreated) OccurredAt() time.Time { return e.User.CreatedAt }
+
+//UserUpdated - the information of a user changed, restoring a deleted user is an update too
+type UserUpdated struct {
+	User User `json:"user"`
+}
+
+//Type - returns UserUpdatedEvent
+func (e UserUpdated) Type() EventType { return UserUpdatedEvent }
+
+//UserID - returns the id of the updated user
+func (e UserUpdated) UserID() int { return e.User.ID }
+
+//OccurredAt - returns when the user was updated
+func (e UserUpdated) OccurredAt() time.Time { return e.User.UpdatedAt }
+
+//UserDeleted - a user was soft deleted
+type UserDeleted struct {
+	User User `json:"user"`
+}
+
+//Type - returns UserDeletedEvent
+func (e UserDeleted) Type() EventType { return UserDeletedEvent }
+
+//UserID - returns the id of the deleted user
+func (e UserDeleted) UserID() int { return e.User.ID }
+
+//OccurredAt - returns when the user was deleted
+func (e UserDeleted) OccurredAt() time.Time { return e.User.UpdatedAt }
+
+//UserPurged - a soft deleted user was permanently removed, only its id is carried so that no personal data
+//outlives the user
+type UserPurged struct {
+	ID       int       `json:"id"`
+	PurgedAt time.Time `json:"purged_at"`
+}
+
+//Type - returns UserPurgedEvent
+func (e UserPurged) Type() EventType { return UserPurgedEvent }
+
+//UserID - returns the id of the purged user
+func (e UserPurged) UserID() int { return e.ID }
+
+//OccurredAt - returns when the user was purged
+func (e UserPurged) OccurredAt() time.Time { return e.PurgedAt }
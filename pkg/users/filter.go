@@ -0,0 +1,17 @@
+package users
+
+//Filter - criteria used to list users
+type Filter struct {
+	//IncludeDeleted - when true soft deleted users are listed too
+	IncludeDeleted bool
+	//Status - when set only the users in this status are listed
+	Status AccountStatus
+}
+
+//Matches - reports whether the user meets the criteria
+func (f Filter) Matches(u User) bool {
+	if u.IsDeleted() && !f.IncludeDeleted {
+		return false
+	}
+	return f.Status == "" || u.Status == f.Status
+}
@@ -0,0 +1,15 @@
+package users
+
+import "context"
+
+//Notification - a message addressed to a user, such as a verification link
+type Notification struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+//Notifier - delivers notifications to the users, email in production and a log for local use
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
@@ -0,0 +1,59 @@
+package users
+
+const (
+	//NamePath - the field mask path of the user name
+	NamePath = "name"
+	//LastNamePath - the field mask path of the user last name
+	LastNamePath = "last_name"
+)
+
+//patchableFields - the field mask paths a patch can change and the User fields they map to
+var patchableFields = map[string]string{
+	NamePath:     "Name",
+	LastNamePath: "LastName",
+}
+
+//Patch - a partial update of a user, only the fields named in Paths are changed,
+//the values of the other fields are ignored
+type Patch struct {
+	Email    string
+	Version  int
+	Paths    []string
+	Name     string
+	LastName string
+}
+
+//fields - returns the User fields the patch changes, an unknown or repeated path makes the patch invalid
+func (p Patch) fields() ([]string, error) {
+
+	if len(p.Paths) == 0 {
+		return nil, UserError(ERRINVALIDDATA)
+	}
+
+	fields := []string{}
+	seen := map[string]bool{}
+
+	for _, path := range p.Paths {
+		field, ok := patchableFields[path]
+		if !ok || seen[path] {
+			return nil, UserError(ERRINVALIDDATA)
+		}
+		seen[path] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+//apply - returns the user with the patched fields changed
+func (p Patch) apply(usr User) User {
+	for _, path := range p.Paths {
+		switch path {
+		case NamePath:
+			usr.Name = p.Name
+		case LastNamePath:
+			usr.LastName = p.LastName
+		}
+	}
+	return usr
+}
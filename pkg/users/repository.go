@@ -1,19 +1,30 @@
 package users
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 //Repository - repository interface for users
 type Repository interface {
 	//Add - adds a user to the repository
 	Add(context.Context, User) (int, error)
-	//GetByID - retrieves a user from the repository based on the integer id
+	//GetByID - retrieves a user from the repository based on the integer id, soft deleted users included
 	GetByID(context.Context, int) (User, error)
-	//GetByEmail - retrieves a user from the repository based on the email address
+	//GetByEmail - retrieves a user from the repository based on the email address, soft deleted users are hidden
 	GetByEmail(context.Context, string) (User, error)
-	//GetAll - retrieves all the users from the repository
-	GetAll(context.Context) ([]User, error)
-	//Update -  updates the information of a user
+	//GetAll - retrieves the users from the repository that match the filter
+	GetAll(context.Context, Filter) ([]User, error)
+	//Update -  updates the information of a user if the stored version matches the user version
 	Update(context.Context, User) error
-	//Delete - deletes a user from the repository
-	Delete(context.Context, int) error
+	//SetStatus - changes the status of a user if the stored version matches the given one
+	SetStatus(context.Context, int, int, AccountStatus) error
+	//Delete - soft deletes a user from the repository if the stored version matches the given one
+	Delete(context.Context, int, int) error
+	//Restore - undoes the soft delete of a user
+	Restore(context.Context, int) error
+	//Purge - permanently removes a user from the repository
+	Purge(context.Context, int) error
+	//DeletedBefore - retrieves the ids of the users soft deleted before the given time
+	DeletedBefore(context.Context, time.Time) ([]int, error)
 }
@@ -0,0 +1,49 @@
+package users
+
+import "context"
+
+const (
+	//TransportGRPC - source transport of the requests served by the gRPC service
+	TransportGRPC = "grpc"
+	//TransportREST - source transport of the requests served by the REST gateway
+	TransportREST = "rest"
+)
+
+type requestIDContextKey struct{}
+
+type transportContextKey struct{}
+
+type clientAddressContextKey struct{}
+
+//WithRequestID - returns a copy of the context carrying the id of the request being served
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+//RequestIDFromContext - returns the id of the request stored in the context, or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+//WithTransport - returns a copy of the context carrying the transport the request came from
+func WithTransport(ctx context.Context, transport string) context.Context {
+	return context.WithValue(ctx, transportContextKey{}, transport)
+}
+
+//TransportFromContext - returns the transport stored in the context, or an empty string
+func TransportFromContext(ctx context.Context) string {
+	transport, _ := ctx.Value(transportContextKey{}).(string)
+	return transport
+}
+
+//WithClientAddress - returns a copy of the context carrying the network address of the client that made the request
+func WithClientAddress(ctx context.Context, address string) context.Context {
+	return context.WithValue(ctx, clientAddressContextKey{}, address)
+}
+
+//ClientAddressFromContext - returns the address of the client stored in the context, or an empty string
+func ClientAddressFromContext(ctx context.Context) string {
+	address, _ := ctx.Value(clientAddressContextKey{}).(string)
+	return address
+}
@@ -0,0 +1,63 @@
+package users
+
+import (
+	"context"
+	"time"
+)
+
+//RetentionActor - actor recorded for the users purged by the retention job
+const RetentionActor = "retention-job"
+
+//Purger - permanently removes a soft deleted user, such as the audited Service
+type Purger interface {
+	Purge(context.Context, int) error
+}
+
+//RetentionJob - permanently removes users that were soft deleted longer than the retention period, every user is
+//purged through the purger so that it is audited and emitted as the purges requested by an administrator
+type RetentionJob struct {
+	repository Repository
+	purger     Purger
+	period     time.Duration
+	clock      Clock
+}
+
+//NewRetentionJob - returns a RetentionJob type pointer
+func NewRetentionJob(repo Repository, purger Purger, period time.Duration, clock Clock) *RetentionJob {
+	return &RetentionJob{repository: repo, purger: purger, period: period, clock: clock}
+}
+
+//RunOnce - purges the expired users and returns how many were removed
+func (j *RetentionJob) RunOnce(ctx context.Context) (int, error) {
+
+	before := j.clock.Now().Add(-j.period)
+
+	ids, err := j.repository.DeletedBefore(ctx, before)
+
+	if err != nil {
+		return 0, err
+	}
+
+	//The job runs within the service, it acts as an administrator that already gave its second factor.
+	ctx = WithCaller(ctx, Caller{Subject: RetentionActor, Roles: []string{AdminRole}, Methods: []string{MFAMethod}})
+
+	purged := 0
+
+	for _, id := range ids {
+
+		err := j.purger.Purge(ctx, id)
+
+		//The user was restored or purged since it was listed.
+		if IsUserErrorType(ERRINVALIDDATA, err) || IsUserErrorType(ERRNOTFOUND, err) {
+			continue
+		}
+
+		if err != nil {
+			return purged, err
+		}
+
+		purged++
+	}
+
+	return purged, nil
+}
@@ -11,19 +11,62 @@ import (
 type Service interface {
 	Create(context.Context, User) (int, error)
 	GetByEmail(context.Context, string) (User, error)
-	GetAll(context.Context) ([]User, error)
+	GetAll(context.Context, Filter) ([]User, error)
 	Update(context.Context, User) error
-	Delete(context.Context, int) error
+	Patch(context.Context, Patch) error
+	Delete(context.Context, int, int) error
+	Restore(context.Context, int) error
+	Purge(context.Context, int) error
+	RequestEmailChange(context.Context, string, string) error
+	ConfirmEmailChange(context.Context, string) (EmailChange, error)
+	SendVerification(context.Context, string) error
+	VerifyEmail(context.Context, string) (User, error)
+	Suspend(context.Context, int) error
+	Reactivate(context.Context, int) error
+	Deactivate(context.Context, int) error
 }
 
 //UserService - the implementation for the users logic
 type UserService struct {
-	repository Repository
+	repository   Repository
+	transactor   Transactor
+	emitter      EventEmitter
+	emails       *EmailChanges
+	verification *Verification
+	clock        Clock
+}
+
+//ServiceOption - configures a UserService
+type ServiceOption func(*UserService)
+
+//WithTransactor - runs every write and the events it emits in a single transaction
+func WithTransactor(t Transactor) ServiceOption {
+	return func(us *UserService) {
+		us.transactor = t
+	}
+}
+
+//WithEventEmitter - emits an event for every user created, updated, deleted or purged
+func WithEventEmitter(e EventEmitter) ServiceOption {
+	return func(us *UserService) {
+		us.emitter = e
+	}
+}
+
+//WithClock - sets the clock of the events whose time is not stored with the user, such as the purges
+func WithClock(c Clock) ServiceOption {
+	return func(us *UserService) {
+		us.clock = c
+	}
 }
 
 //NewUserService - returns a UserService type pointer
-func NewUserService(repo Repository) *UserService {
-	return &UserService{repository: repo}
+func NewUserService(repo Repository, opts ...ServiceOption) *UserService {
+	us := &UserService{repository: repo, transactor: noTransaction{}, clock: SystemClock{}}
+	for _, opt := range opts {
+		opt(us)
+	}
+	return us
 }
 
 //Create - validates business rules and sends a user to the repository
@@ -32,8 +75,7 @@ func (us *UserService) Create(ctx context.Context, usr User) (int, error) {
 	v := validator.New()
 
 	if errVal := v.Struct(usr); errVal != nil {
-		errorMessage := errVal.(validator.ValidationErrors)[0]
-		return 0, errors.New(errorMessage.Field() + " is not valid")
+		return 0, newValidationError(errVal)
 	}
 
 	dbUser, err := us.repository.GetByEmail(ctx, usr.Email)
@@ -44,10 +86,45 @@ func (us *UserService) Create(ctx context.Context, usr User) (int, error) {
 
 	if dbUser.ID > 0 {
 
-		return 0, errors.New("user already exists")
+		return 0, UserError(ERRALREADYEXISTS)
+	}
+
+	//The address may still be the alias of a user who changed it.
+	aliasOwner, err := us.resolveAlias(ctx, usr.Email)
+
+	if err != nil {
+		return 0, err
 	}
 
-	newID, errAdd := us.repository.Add(ctx, usr)
+	if aliasOwner.ID > 0 {
+		return 0, UserError(ERRALREADYEXISTS)
+	}
+
+	usr.Status = AccountActive
+
+	if us.verification != nil {
+		usr.Status = AccountPending
+	}
+
+	var newID int
+
+	errAdd := us.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		if newID, err = us.repository.Add(ctx, usr); err != nil {
+			return err
+		}
+
+		//The user is not created when its verification token cannot be sent.
+		if us.verification != nil {
+			usr.ID = newID
+			if err := us.sendVerification(ctx, usr); err != nil {
+				return err
+			}
+		}
+
+		return us.emit(ctx, newID, func(u User) Event { return UserCreated{User: u} })
+	})
 
 	if errAdd != nil {
 		return 0, errAdd
@@ -57,7 +134,7 @@ func (us *UserService) Create(ctx context.Context, usr User) (int, error) {
 
 }
 
-//GetByEmail - retrieves the information of a user based on the email address
+//GetByEmail - retrieves the information of a user based on the email address, or on an address it changed from during the grace period
 func (us *UserService) GetByEmail(ctx context.Context, email string) (User, error) {
 
 	dbUser, err := us.repository.GetByEmail(ctx, email)
@@ -66,6 +143,12 @@ func (us *UserService) GetByEmail(ctx context.Context, email string) (User, erro
 		return User{}, err
 	}
 
+	if dbUser.ID == 0 {
+		if dbUser, err = us.resolveAlias(ctx, email); err != nil {
+			return User{}, err
+		}
+	}
+
 	if dbUser.ID == 0 {
 		return User{}, errors.New("user not found")
 	}
@@ -74,10 +157,14 @@ func (us *UserService) GetByEmail(ctx context.Context, email string) (User, erro
 
 }
 
-//GetAll -  gets all the existing users
-func (us *UserService) GetAll(ctx context.Context) ([]User, error) {
+//GetAll -  gets all the existing users that match the filter
+func (us *UserService) GetAll(ctx context.Context, filter Filter) ([]User, error) {
+
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return []User{}, UserError(ERRINVALIDDATA)
+	}
 
-	users, err := us.repository.GetAll(ctx)
+	users, err := us.repository.GetAll(ctx, filter)
 
 	if err != nil {
 		return []User{}, err
@@ -86,14 +173,17 @@ func (us *UserService) GetAll(ctx context.Context) ([]User, error) {
 	return users, nil
 }
 
-//Update - validates the data and updates the user information
+//Update - validates the data and updates the user information, the version the caller read is required
 func (us *UserService) Update(ctx context.Context, usr User) error {
 
 	v := validator.New()
 
 	if errVal := v.Struct(usr); errVal != nil {
-		errorMessage := errVal.(validator.ValidationErrors)[0]
-		return errors.New(errorMessage.Field() + " is not valid")
+		return newValidationError(errVal)
+	}
+
+	if usr.Version < 1 {
+		return UserError(ERRVERSIONREQUIRED)
 	}
 
 	usrToUpdate, errU := us.repository.GetByEmail(ctx, usr.Email)
@@ -106,35 +196,213 @@ func (us *UserService) Update(ctx context.Context, usr User) error {
 		return errors.New("user not found")
 	}
 
+	if usr.Version != usrToUpdate.Version {
+		return UserError(ERRVERSIONCONFLICT)
+	}
+
 	usr.ID = usrToUpdate.ID
 
-	if err := us.repository.Update(ctx, usr); err != nil {
+	err := us.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := us.repository.Update(ctx, usr); err != nil {
+			return err
+		}
+
+		return us.emit(ctx, usr.ID, func(u User) Event { return UserUpdated{User: u} })
+	})
+
+	if err != nil {
+		if IsUserErrorType(ERRVERSIONCONFLICT, err) {
+			return err
+		}
+		return errors.New("cannot update the user")
+	}
+
+	return nil
+}
+
+//Patch - changes only the fields named by the patch, only those fields are validated, the version the caller read is
+//required
+func (us *UserService) Patch(ctx context.Context, p Patch) error {
+
+	fields, err := p.fields()
+
+	if err != nil {
+		return err
+	}
+
+	if p.Version < 1 {
+		return UserError(ERRVERSIONREQUIRED)
+	}
+
+	usrToPatch, errU := us.repository.GetByEmail(ctx, p.Email)
+
+	if errU != nil {
+		return errU
+	}
+
+	if usrToPatch.ID == 0 || usrToPatch.IsDeleted() {
+		return errors.New("user not found")
+	}
+
+	if p.Version != usrToPatch.Version {
+		return UserError(ERRVERSIONCONFLICT)
+	}
+
+	usr := p.apply(usrToPatch)
+
+	v := validator.New()
+
+	if errVal := v.StructPartial(usr, fields...); errVal != nil {
+		return newValidationError(errVal)
+	}
+
+	err = us.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := us.repository.Update(ctx, usr); err != nil {
+			return err
+		}
+
+		return us.emit(ctx, usr.ID, func(u User) Event { return UserUpdated{User: u} })
+	})
+
+	if err != nil {
+		if IsUserErrorType(ERRVERSIONCONFLICT, err) {
+			return err
+		}
 		return errors.New("cannot update the user")
 	}
 
 	return nil
 }
 
-//Delete - removes a user
-func (us *UserService) Delete(ctx context.Context, usrID int) error {
+//Delete - soft deletes a user, the version is required and must match the stored one. Only administrators that gave a
+//second factor are allowed to delete
+func (us *UserService) Delete(ctx context.Context, usrID int, version int) error {
+
+	if err := authorizeDestructive(ctx); err != nil {
+		return err
+	}
 
 	if usrID < 1 {
 		return errors.New("invalid id")
 	}
 
+	if version < 1 {
+		return UserError(ERRVERSIONREQUIRED)
+	}
+
 	usrToUpdate, err := us.repository.GetByID(ctx, usrID)
 
 	if err != nil {
 		return err
 	}
 
-	if usrToUpdate.ID == 0 {
+	if usrToUpdate.ID == 0 || usrToUpdate.IsDeleted() {
 		return errors.New("user not found")
 	}
 
-	if errD := us.repository.Delete(ctx, usrID); errD != nil {
+	if usrToUpdate.Version != version {
+		return UserError(ERRVERSIONCONFLICT)
+	}
+
+	errD := us.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := us.repository.Delete(ctx, usrID, version); err != nil {
+			return err
+		}
+
+		return us.emit(ctx, usrID, func(u User) Event { return UserDeleted{User: u} })
+	})
+
+	if errD != nil {
 		return errD
 	}
 
 	return nil
 }
+
+//Restore - undoes the soft delete of a user, only administrators are allowed to restore
+func (us *UserService) Restore(ctx context.Context, usrID int) error {
+
+	if caller, ok := CallerFromContext(ctx); !ok || !caller.HasRole(AdminRole) {
+		return UserError(ERRFORBIDDEN)
+	}
+
+	if usrID < 1 {
+		return errors.New("invalid id")
+	}
+
+	usrToRestore, err := us.repository.GetByID(ctx, usrID)
+
+	if err != nil {
+		return err
+	}
+
+	if usrToRestore.ID == 0 {
+		return errors.New("user not found")
+	}
+
+	if !usrToRestore.IsDeleted() {
+		return UserError(ERRINVALIDDATA)
+	}
+
+	return us.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := us.repository.Restore(ctx, usrID); err != nil {
+			return err
+		}
+
+		return us.emit(ctx, usrID, func(u User) Event { return UserUpdated{User: u} })
+	})
+}
+
+//Purge - permanently removes a soft deleted user, only administrators that gave a second factor are allowed to purge
+func (us *UserService) Purge(ctx context.Context, usrID int) error {
+
+	if err := authorizeDestructive(ctx); err != nil {
+		return err
+	}
+
+	if usrID < 1 {
+		return errors.New("invalid id")
+	}
+
+	usrToPurge, err := us.repository.GetByID(ctx, usrID)
+
+	if err != nil {
+		return err
+	}
+
+	if usrToPurge.ID == 0 {
+		return UserError(ERRNOTFOUND)
+	}
+
+	if !usrToPurge.IsDeleted() {
+		return UserError(ERRINVALIDDATA)
+	}
+
+	return us.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := us.repository.Purge(ctx, usrID); err != nil {
+			return err
+		}
+
+		if us.emitter == nil {
+			return nil
+		}
+
+		return us.emitter.Emit(ctx, UserPurged{ID: usrID, PurgedAt: us.clock.Now()})
+	})
+}
+
+//emit - reads the stored user and emits the event built from it, nothing is read when no emitter is configured
+func (us *UserService) emit(ctx context.Context, usrID int, event func(User) Event) error {
+
+	if us.emitter == nil {
+		return nil
+	}
+
+	usr, err := us.repository.GetByID(ctx, usrID)
+
+	if err != nil {
+		return err
+	}
+
+	return us.emitter.Emit(ctx, event(usr))
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+//adminContext - the context of an administrator that gave a second factor, allowed to delete and purge users
+var adminContext = WithCaller(context.Background(), Caller{Subject: "root", Roles: []string{AdminRole}, Methods: []string{MFAMethod}})
+
 type repositoryMock struct {
 	mock.Mock
 }
@@ -23,8 +27,8 @@ func (r *repositoryMock) Update(ctx context.Context, u User) error {
 	return args.Error(0)
 }
 
-func (r *repositoryMock) Delete(ctx context.Context, uid int) error {
-	args := r.Called(ctx, uid)
+func (r *repositoryMock) Delete(ctx context.Context, uid int, version int) error {
+	args := r.Called(ctx, uid, version)
 	return args.Error(0)
 }
 
@@ -38,17 +42,43 @@ func (r *repositoryMock) GetByEmail(ctx context.Context, email string) (User, er
 	return args.Get(0).(User), args.Error(1)
 }
 
-func (r *repositoryMock) GetAll(ctx context.Context) ([]User, error) {
-	args := r.Called(ctx)
+func (r *repositoryMock) GetAll(ctx context.Context, filter Filter) ([]User, error) {
+	args := r.Called(ctx, filter)
 	return args.Get(0).([]User), args.Error(1)
 }
 
+func (r *repositoryMock) SetStatus(ctx context.Context, uid int, version int, status AccountStatus) error {
+	args := r.Called(ctx, uid, version, status)
+	return args.Error(0)
+}
+
+func (r *repositoryMock) Restore(ctx context.Context, uid int) error {
+	args := r.Called(ctx, uid)
+	return args.Error(0)
+}
+
+func (r *repositoryMock) Purge(ctx context.Context, uid int) error {
+	args := r.Called(ctx, uid)
+	return args.Error(0)
+}
+
+func (r *repositoryMock) DeletedBefore(ctx context.Context, before time.Time) ([]int, error) {
+	args := r.Called(ctx, before)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
 func Test_Create_ValidData_OkResult(t *testing.T) {
 	//Arrange
 	repository := repositoryMock{}
 	service := NewUserService(&repository)
 	userToAdd := User{Email: "test@gmail.com", Name: "John", LastName: "Connor"}
-	repository.On("Add", context.Background(), userToAdd).Return(1, nil)
+	repository.On("Add", context.Background(), User{Email: "test@gmail.com", Name: "John", LastName: "Connor", Status: AccountActive}).Return(1, nil)
 	repository.On("GetByEmail", context.Background(), userToAdd.Email).Return(User{}, nil)
 	//Act
 	result, err := service.Create(context.Background(), userToAdd)
@@ -87,11 +117,29 @@ func Test_Create_InvalidData_ReturnsError(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func Test_Create_InvalidData_ListsEveryInvalidField(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	service := NewUserService(&repository)
+	userToAdd := User{Email: "not-an-email"}
+	//Act
+	_, err := service.Create(context.Background(), userToAdd)
+	//Assert
+	var validation ValidationError
+	assert.True(t, errors.As(err, &validation))
+	assert.Equal(t, []FieldViolation{
+		{Field: "email", Rule: "email", Description: "Email is not valid"},
+		{Field: "name", Rule: "required", Description: "Name is not valid"},
+		{Field: "lastname", Rule: "required", Description: "LastName is not valid"},
+	}, validation.Violations)
+	assert.Equal(t, "Email is not valid", err.Error())
+}
+
 func Test_Update_ValidData_OkResult(t *testing.T) {
 	//Arrange
 	repository := repositoryMock{}
 	service := NewUserService(&repository)
-	userToUpdate := User{ID: 1, Email: "test@gmail.com", Name: "John", LastName: "Connor"}
+	userToUpdate := User{ID: 1, Email: "test@gmail.com", Name: "John", LastName: "Connor", Version: 1}
 	repository.On("Update", context.Background(), userToUpdate).Return(nil)
 	repository.On("GetByEmail", context.Background(), userToUpdate.Email).Return(userToUpdate, nil)
 	//Act
@@ -118,7 +166,7 @@ func Test_Update_InvalidUser_ReturnsError(t *testing.T) {
 	//Arrange
 	repository := repositoryMock{}
 	service := NewUserService(&repository)
-	userToUpdate := User{ID: 1, Email: "test@gmail.com", Name: "John", LastName: "Connor"}
+	userToUpdate := User{ID: 1, Email: "test@gmail.com", Name: "John", LastName: "Connor", Version: 1}
 	repository.On("GetByEmail", context.Background(), userToUpdate.Email).Return(User{}, errors.New(""))
 	//Act
 	err := service.Update(context.Background(), userToUpdate)
@@ -128,14 +176,120 @@ func Test_Update_InvalidUser_ReturnsError(t *testing.T) {
 	repository.AssertNumberOfCalls(t, "GetByEmail", 1)
 }
 
+func TestCases_Writes_NoVersion_ReturnsVersionRequiredError(t *testing.T) {
+	testCases := []struct {
+		name  string
+		write func(*UserService) error
+	}{
+		{"Update", func(s *UserService) error {
+			return s.Update(context.Background(), User{Email: "test@gmail.com", Name: "John", LastName: "Connor"})
+		}},
+		{"Patch", func(s *UserService) error {
+			return s.Patch(context.Background(), Patch{Email: "test@gmail.com", Name: "John", Paths: []string{"name"}})
+		}},
+		{"Delete", func(s *UserService) error { return s.Delete(adminContext, 1, 0) }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Arrange
+			repository := repositoryMock{}
+			service := NewUserService(&repository)
+			//Act
+			err := tc.write(service)
+			//Assert
+			assert.True(t, IsUserErrorType(ERRVERSIONREQUIRED, err))
+			repository.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+			repository.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func Test_Patch_OnlyName_KeepsLastName(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	service := NewUserService(&repository)
+	stored := User{ID: 1, Email: "test@gmail.com", Name: "John", LastName: "Connor", Version: 2}
+	patched := User{ID: 1, Email: "test@gmail.com", Name: "Sarah", LastName: "Connor", Version: 2}
+	repository.On("GetByEmail", context.Background(), stored.Email).Return(stored, nil)
+	repository.On("Update", context.Background(), patched).Return(nil)
+	//Act
+	err := service.Patch(context.Background(), Patch{Email: stored.Email, Version: 2, Paths: []string{NamePath}, Name: "Sarah"})
+	//Assert
+	assert.Nil(t, err)
+	repository.AssertExpectations(t)
+}
+
+func Test_Patch_StaleVersion_ReturnsConflictError(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	service := NewUserService(&repository)
+	repository.On("GetByEmail", context.Background(), "test@gmail.com").Return(User{ID: 1, Email: "test@gmail.com", Name: "John", LastName: "Connor", Version: 3}, nil)
+	//Act
+	err := service.Patch(context.Background(), Patch{Email: "test@gmail.com", Version: 2, Paths: []string{NamePath}, Name: "Sarah"})
+	//Assert
+	assert.True(t, IsUserErrorType(ERRVERSIONCONFLICT, err))
+	repository.AssertNumberOfCalls(t, "Update", 0)
+}
+
+func TestCases_Patch_InvalidPatch_ReturnsError(t *testing.T) {
+	testCases := []struct {
+		name  string
+		patch Patch
+	}{
+		{name: "empty mask", patch: Patch{Email: "test@gmail.com", Version: 1}},
+		{name: "unknown path", patch: Patch{Email: "test@gmail.com", Version: 1, Paths: []string{"email"}}},
+		{name: "repeated path", patch: Patch{Email: "test@gmail.com", Version: 1, Paths: []string{NamePath, NamePath}, Name: "Sarah"}},
+		{name: "touched field is not valid", patch: Patch{Email: "test@gmail.com", Version: 1, Paths: []string{LastNamePath}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := repositoryMock{}
+			service := NewUserService(&repository)
+			repository.On("GetByEmail", context.Background(), "test@gmail.com").Return(User{ID: 1, Email: "test@gmail.com", Name: "John", LastName: "Connor", Version: 1}, nil)
+			err := service.Patch(context.Background(), tc.patch)
+			assert.NotNil(t, err)
+			repository.AssertNumberOfCalls(t, "Update", 0)
+		})
+	}
+}
+
+func Test_Update_StaleVersion_ReturnsConflictError(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	service := NewUserService(&repository)
+	userToUpdate := User{ID: 1, Email: "test@gmail.com", Name: "John", LastName: "Connor", Version: 1}
+	repository.On("GetByEmail", context.Background(), userToUpdate.Email).Return(User{ID: 1, Email: "test@gmail.com", Version: 2}, nil)
+	//Act
+	err := service.Update(context.Background(), userToUpdate)
+	//Assert
+	assert.True(t, IsUserErrorType(ERRVERSIONCONFLICT, err))
+	repository.AssertExpectations(t)
+	repository.AssertNumberOfCalls(t, "Update", 0)
+}
+
+func Test_Update_ConcurrentWrite_ReturnsConflictError(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	service := NewUserService(&repository)
+	userToUpdate := User{ID: 1, Email: "test@gmail.com", Name: "John", LastName: "Connor", Version: 1}
+	repository.On("GetByEmail", context.Background(), userToUpdate.Email).Return(userToUpdate, nil)
+	repository.On("Update", context.Background(), userToUpdate).Return(UserError(ERRVERSIONCONFLICT))
+	//Act
+	err := service.Update(context.Background(), userToUpdate)
+	//Assert
+	assert.True(t, IsUserErrorType(ERRVERSIONCONFLICT, err))
+	repository.AssertExpectations(t)
+}
+
 func Test_Delete_ValidId_DeletesUser(t *testing.T) {
 	//Arrange
 	repository := repositoryMock{}
 	service := NewUserService(&repository)
-	repository.On("GetByID", context.Background(), 1).Return(User{ID: 1}, nil)
-	repository.On("Delete", context.Background(), 1).Return(nil)
+	repository.On("GetByID", adminContext, 1).Return(User{ID: 1, Version: 1}, nil)
+	repository.On("Delete", adminContext, 1, 1).Return(nil)
 	//Act
-	result := service.Delete(context.Background(), 1)
+	result := service.Delete(adminContext, 1, 1)
 	//Assert
 	assert.Nil(t, result)
 	repository.AssertExpectations(t)
@@ -143,12 +297,224 @@ func Test_Delete_ValidId_DeletesUser(t *testing.T) {
 	repository.AssertNumberOfCalls(t, "Delete", 1)
 }
 
+func Test_Delete_StaleVersion_ReturnsConflictError(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	service := NewUserService(&repository)
+	repository.On("GetByID", adminContext, 1).Return(User{ID: 1, Version: 3}, nil)
+	//Act
+	result := service.Delete(adminContext, 1, 2)
+	//Assert
+	assert.True(t, IsUserErrorType(ERRVERSIONCONFLICT, result))
+	repository.AssertExpectations(t)
+	repository.AssertNumberOfCalls(t, "Delete", 0)
+}
+
+func Test_Delete_DeletedUser_ReturnsNotFoundError(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	service := NewUserService(&repository)
+	deletedAt := time.Now()
+	repository.On("GetByID", adminContext, 1).Return(User{ID: 1, Version: 2, DeletedAt: &deletedAt}, nil)
+	//Act
+	result := service.Delete(adminContext, 1, 2)
+	//Assert
+	assert.Equal(t, "user not found", result.Error())
+	repository.AssertNumberOfCalls(t, "Delete", 0)
+}
+
+func Test_Restore_DeletedUser_RestoresUser(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	service := NewUserService(&repository)
+	ctx := WithCaller(context.Background(), Caller{Subject: "root", Roles: []string{AdminRole}})
+	deletedAt := time.Now()
+	repository.On("GetByID", ctx, 1).Return(User{ID: 1, DeletedAt: &deletedAt}, nil)
+	repository.On("Restore", ctx, 1).Return(nil)
+	//Act
+	result := service.Restore(ctx, 1)
+	//Assert
+	assert.Nil(t, result)
+	repository.AssertExpectations(t)
+}
+
+func Test_Restore_ActiveUser_ReturnsInvalidDataError(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	service := NewUserService(&repository)
+	ctx := WithCaller(context.Background(), Caller{Subject: "root", Roles: []string{AdminRole}})
+	repository.On("GetByID", ctx, 1).Return(User{ID: 1}, nil)
+	//Act
+	result := service.Restore(ctx, 1)
+	//Assert
+	assert.True(t, IsUserErrorType(ERRINVALIDDATA, result))
+	repository.AssertNumberOfCalls(t, "Restore", 0)
+}
+
+func Test_Restore_NotAdmin_ReturnsForbiddenError(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	service := NewUserService(&repository)
+	ctx := WithCaller(context.Background(), Caller{Subject: "john", Roles: []string{"user"}})
+	//Act
+	resultUser := service.Restore(ctx, 1)
+	resultAnonymous := service.Restore(context.Background(), 1)
+	//Assert
+	assert.True(t, IsUserErrorType(ERRFORBIDDEN, resultUser))
+	assert.True(t, IsUserErrorType(ERRFORBIDDEN, resultAnonymous))
+	repository.AssertNumberOfCalls(t, "GetByID", 0)
+}
+
+func Test_Purge_NotAdmin_ReturnsForbiddenError(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	service := NewUserService(&repository)
+	ctx := WithCaller(context.Background(), Caller{Subject: "john", Roles: []string{"user"}})
+	//Act
+	result := service.Purge(ctx, 1)
+	//Assert
+	assert.True(t, IsUserErrorType(ERRFORBIDDEN, result))
+	repository.AssertNumberOfCalls(t, "GetByID", 0)
+}
+
+func Test_Purge_AdminDeletedUser_PurgesUser(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	service := NewUserService(&repository)
+	ctx := WithCaller(context.Background(), Caller{Subject: "root", Roles: []string{AdminRole}, Methods: []string{MFAMethod}})
+	deletedAt := time.Now()
+	repository.On("GetByID", ctx, 1).Return(User{ID: 1, DeletedAt: &deletedAt}, nil)
+	repository.On("Purge", ctx, 1).Return(nil)
+	//Act
+	result := service.Purge(ctx, 1)
+	//Assert
+	assert.Nil(t, result)
+	repository.AssertExpectations(t)
+}
+
+func TestCases_Destructive_NotAdminWithSecondFactor_ReturnsForbiddenError(t *testing.T) {
+	testCases := []struct {
+		name string
+		call func(*UserService, context.Context) error
+	}{
+		{"delete", func(s *UserService, ctx context.Context) error { return s.Delete(ctx, 1, 1) }},
+		{"purge", func(s *UserService, ctx context.Context) error { return s.Purge(ctx, 1) }},
+		{"deactivate", func(s *UserService, ctx context.Context) error { return s.Deactivate(ctx, 1) }},
+	}
+
+	callers := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"anonymous", context.Background()},
+		{"user", WithCaller(context.Background(), Caller{Subject: "john", Roles: []string{"user"}, Methods: []string{MFAMethod}})},
+		{"admin without second factor", WithCaller(context.Background(), Caller{Subject: "root", Roles: []string{AdminRole}, Methods: []string{"pwd"}})},
+	}
+
+	for _, tc := range testCases {
+		for _, caller := range callers {
+			t.Run(tc.name+" by "+caller.name, func(t *testing.T) {
+				//Arrange
+				repository := repositoryMock{}
+				service := NewUserService(&repository)
+				//Act
+				result := tc.call(service, caller.ctx)
+				//Assert
+				assert.True(t, IsUserErrorType(ERRFORBIDDEN, result))
+				repository.AssertNumberOfCalls(t, "GetByID", 0)
+			})
+		}
+	}
+}
+
+type purgerStub struct {
+	purged  []int
+	callers []Caller
+	err     error
+}
+
+func (p *purgerStub) Purge(ctx context.Context, id int) error {
+	caller, _ := CallerFromContext(ctx)
+	p.callers = append(p.callers, caller)
+	if p.err != nil {
+		return p.err
+	}
+	p.purged = append(p.purged, id)
+	return nil
+}
+
+func Test_RetentionJob_PurgesUsersDeletedBeforePeriod(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	purger := &purgerStub{}
+	now := time.Date(2021, 11, 20, 10, 0, 0, 0, time.UTC)
+	job := NewRetentionJob(&repository, purger, time.Hour, fixedClock(now))
+	repository.On("DeletedBefore", context.Background(), now.Add(-time.Hour)).Return([]int{1, 4}, nil)
+	//Act
+	purged, err := job.RunOnce(context.Background())
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 2, purged)
+	assert.Equal(t, []int{1, 4}, purger.purged)
+	assert.Equal(t, RetentionActor, purger.callers[0].Subject)
+	assert.Nil(t, authorizeDestructive(WithCaller(context.Background(), purger.callers[0])))
+	repository.AssertExpectations(t)
+}
+
+func Test_RetentionJob_RestoredUser_IsSkipped(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	purger := &purgerStub{err: UserError(ERRINVALIDDATA)}
+	now := time.Date(2021, 11, 20, 10, 0, 0, 0, time.UTC)
+	job := NewRetentionJob(&repository, purger, time.Hour, fixedClock(now))
+	repository.On("DeletedBefore", context.Background(), now.Add(-time.Hour)).Return([]int{1}, nil)
+	//Act
+	purged, err := job.RunOnce(context.Background())
+	//Assert
+	assert.Nil(t, err)
+	assert.Zero(t, purged)
+}
+
+func Test_RetentionJob_AlreadyPurgedUser_IsSkipped(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	purger := &purgerStub{err: UserError(ERRNOTFOUND)}
+	now := time.Date(2021, 11, 20, 10, 0, 0, 0, time.UTC)
+	job := NewRetentionJob(&repository, purger, time.Hour, fixedClock(now))
+	repository.On("DeletedBefore", context.Background(), now.Add(-time.Hour)).Return([]int{1}, nil)
+	//Act
+	purged, err := job.RunOnce(context.Background())
+	//Assert
+	assert.Nil(t, err)
+	assert.Zero(t, purged)
+}
+
+func Test_Purge_WithEmitter_EmitsUserPurged(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	emitter := &emitterMock{}
+	now := time.Date(2021, 11, 20, 10, 0, 0, 0, time.UTC)
+	service := NewUserService(&repository, WithEventEmitter(emitter), WithClock(fixedClock(now)))
+	ctx := WithCaller(context.Background(), Caller{Subject: "root", Roles: []string{AdminRole}, Methods: []string{MFAMethod}})
+	deletedAt := now.Add(-time.Hour)
+	usr := User{ID: 1, Email: "test@gmail.com", DeletedAt: &deletedAt}
+	repository.On("GetByID", ctx, 1).Return(usr, nil)
+	repository.On("Purge", ctx, 1).Return(nil)
+	emitter.On("Emit", ctx, UserPurged{ID: 1, PurgedAt: now}).Return(nil)
+	//Act
+	err := service.Purge(ctx, 1)
+	//Assert
+	assert.Nil(t, err)
+	repository.AssertExpectations(t)
+	emitter.AssertExpectations(t)
+}
+
 func Test_Delete_InvalidId_ReturnsError(t *testing.T) {
 	//Arrange
 	repository := repositoryMock{}
 	service := NewUserService(&repository)
 	//Act
-	result := service.Delete(context.Background(), 0)
+	result := service.Delete(adminContext, 0, 1)
 	//Assert
 	assert.NotNil(t, result)
 	assert.Equal(t, "invalid id", result.Error())
@@ -158,9 +524,9 @@ func Test_Delete_InvalidId_ReturnsNotFoundError(t *testing.T) {
 	//Arrange
 	repository := repositoryMock{}
 	service := NewUserService(&repository)
-	repository.On("GetByID", context.Background(), 999).Return(User{}, nil)
+	repository.On("GetByID", adminContext, 999).Return(User{}, nil)
 	//Act
-	result := service.Delete(context.Background(), 999)
+	result := service.Delete(adminContext, 999, 1)
 	//Assert
 	assert.NotNil(t, result)
 	assert.Equal(t, "user not found", result.Error())
@@ -201,9 +567,9 @@ func Test_GetAll_ReturnsNoError(t *testing.T) {
 	//Arrange
 	repository := repositoryMock{}
 	service := NewUserService(&repository)
-	repository.On("GetAll", context.Background()).Return([]User{}, nil)
+	repository.On("GetAll", context.Background(), Filter{}).Return([]User{}, nil)
 	//Act
-	_, err := service.GetAll(context.Background())
+	_, err := service.GetAll(context.Background(), Filter{})
 	//
 	assert.Nil(t, err)
 	repository.AssertExpectations(t)
@@ -240,3 +606,47 @@ var getByEmailTestCases []struct {
 	{"ValidId_ReturnsData", "test@gmail.com", User{ID: 1, Email: "test@gmail.com"}, nil},
 	{"NotValidId_ReturnsErrorNotFound", "test1@gmail.com", User{}, errors.New("user not found")},
 }
+
+type emitterMock struct {
+	mock.Mock
+}
+
+func (e *emitterMock) Emit(ctx context.Context, event Event) error {
+	args := e.Called(ctx, event)
+	return args.Error(0)
+}
+
+func Test_Create_WithEmitter_EmitsUserCreated(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	emitter := emitterMock{}
+	service := NewUserService(&repository, WithEventEmitter(&emitter))
+	userToAdd := User{Email: "test@gmail.com", Name: "John", LastName: "Connor"}
+	created := User{ID: 1, Email: "test@gmail.com", Name: "John", LastName: "Connor", Version: 1}
+	repository.On("GetByEmail", context.Background(), userToAdd.Email).Return(User{}, nil)
+	repository.On("Add", context.Background(), User{Email: "test@gmail.com", Name: "John", LastName: "Connor", Status: AccountActive}).Return(1, nil)
+	repository.On("GetByID", context.Background(), 1).Return(created, nil)
+	emitter.On("Emit", context.Background(), UserCreated{User: created}).Return(nil)
+	//Act
+	id, err := service.Create(context.Background(), userToAdd)
+	//Assert
+	assert.Equal(t, 1, id)
+	assert.Nil(t, err)
+	emitter.AssertExpectations(t)
+}
+
+func Test_Delete_EmitterFails_ReturnsError(t *testing.T) {
+	//Arrange
+	repository := repositoryMock{}
+	emitter := emitterMock{}
+	service := NewUserService(&repository, WithEventEmitter(&emitter))
+	stored := User{ID: 1, Email: "test@gmail.com", Name: "John", LastName: "Connor", Version: 1}
+	repository.On("GetByID", adminContext, 1).Return(stored, nil)
+	repository.On("Delete", adminContext, 1, 1).Return(nil)
+	emitter.On("Emit", adminContext, UserDeleted{User: stored}).Return(errors.New("outbox unavailable"))
+	//Act
+	err := service.Delete(adminContext, 1, 1)
+	//Assert
+	assert.EqualError(t, err, "outbox unavailable")
+	emitter.AssertExpectations(t)
+}
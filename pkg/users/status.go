@@ -0,0 +1,108 @@
+package users
+
+import (
+	"context"
+	"errors"
+)
+
+//AccountStatus - the stage of its lifecycle a user account is in
+type AccountStatus string
+
+const (
+	//AccountPending - the user was created and has not verified its email yet
+	AccountPending AccountStatus = "pending"
+	//AccountActive - the user verified its email, or was reactivated by an administrator
+	AccountActive AccountStatus = "active"
+	//AccountSuspended - an administrator suspended the user, it can be reactivated
+	AccountSuspended AccountStatus = "suspended"
+	//AccountDeactivated - the account was closed, it can be reactivated
+	AccountDeactivated AccountStatus = "deactivated"
+)
+
+//transitions - the statuses every status can move to
+var transitions = map[AccountStatus][]AccountStatus{
+	AccountPending:     {AccountActive, AccountDeactivated},
+	AccountActive:      {AccountSuspended, AccountDeactivated},
+	AccountSuspended:   {AccountActive, AccountDeactivated},
+	AccountDeactivated: {AccountActive},
+}
+
+//IsValid - reports whether the status is one of the known statuses
+func (s AccountStatus) IsValid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+//CanBecome - reports whether a user in this status can move to the given one
+func (s AccountStatus) CanBecome(to AccountStatus) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+//Suspend - suspends an active user, only administrators are allowed to suspend
+func (us *UserService) Suspend(ctx context.Context, usrID int) error {
+	return us.transition(ctx, usrID, AccountSuspended)
+}
+
+//Reactivate - makes a suspended or deactivated user active again, only administrators are allowed to reactivate
+func (us *UserService) Reactivate(ctx context.Context, usrID int) error {
+	return us.transition(ctx, usrID, AccountActive)
+}
+
+//Deactivate - closes the account of a user, only administrators that gave a second factor are allowed to deactivate
+func (us *UserService) Deactivate(ctx context.Context, usrID int) error {
+
+	if err := authorizeDestructive(ctx); err != nil {
+		return err
+	}
+
+	return us.transition(ctx, usrID, AccountDeactivated)
+}
+
+//transition - moves a user to the given status when its current status allows it
+func (us *UserService) transition(ctx context.Context, usrID int, to AccountStatus) error {
+
+	if caller, ok := CallerFromContext(ctx); !ok || !caller.HasRole(AdminRole) {
+		return UserError(ERRFORBIDDEN)
+	}
+
+	if usrID < 1 {
+		return errors.New("invalid id")
+	}
+
+	usr, err := us.repository.GetByID(ctx, usrID)
+
+	if err != nil {
+		return err
+	}
+
+	if usr.ID == 0 || usr.IsDeleted() {
+		return errors.New("user not found")
+	}
+
+	//A pending user only becomes active by verifying its email.
+	if usr.Status == AccountPending && to == AccountActive {
+		return UserError(ERRINVALIDDATA)
+	}
+
+	if !usr.Status.CanBecome(to) {
+		return UserError(ERRINVALIDDATA)
+	}
+
+	return us.setStatus(ctx, usr, to)
+}
+
+//setStatus - stores the new status of the user and emits the change
+func (us *UserService) setStatus(ctx context.Context, usr User, to AccountStatus) error {
+	return us.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := us.repository.SetStatus(ctx, usr.ID, usr.Version, to); err != nil {
+			return err
+		}
+
+		return us.emit(ctx, usr.ID, func(u User) Event { return UserUpdated{User: u} })
+	})
+}
@@ -0,0 +1,188 @@
+package users_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/stretchr/testify/assert"
+)
+
+var admin = users.WithCaller(context.Background(), users.Caller{Subject: "admin@globant.com", Roles: []string{users.AdminRole}, Methods: []string{users.MFAMethod}})
+
+func newVerifiedService() (*users.UserService, *inbox, *movableClock) {
+	clock := &movableClock{now: now}
+	notifications := &inbox{}
+	repository := memory.NewInMemoryUserRepository(memory.WithClock(clock))
+	service := users.NewUserService(repository, users.WithTransactor(repository), users.WithVerification(users.Verification{
+		Secret:   []byte("0123456789abcdef0123456789abcdef"),
+		Notifier: notifications,
+		Clock:    clock,
+		TokenTTL: time.Hour,
+	}))
+	return service, notifications, clock
+}
+
+func Test_Create_WithVerification_StartsPendingAndSendsToken(t *testing.T) {
+	//Arrange
+	service, notifications, _ := newVerifiedService()
+	ctx := context.Background()
+	//Act
+	_, err := service.Create(ctx, users.User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page"})
+	usr, _ := service.GetByEmail(ctx, "larry.page@gmail.com")
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, users.AccountPending, usr.Status)
+	assert.Equal(t, "larry.page@gmail.com", notifications.notifications[0].To)
+}
+
+func Test_Create_WithoutVerification_StartsActive(t *testing.T) {
+	//Arrange
+	service := users.NewUserService(memory.NewInMemoryUserRepository())
+	ctx := context.Background()
+	//Act
+	service.Create(ctx, users.User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page"})
+	usr, _ := service.GetByEmail(ctx, "larry.page@gmail.com")
+	//Assert
+	assert.Equal(t, users.AccountActive, usr.Status)
+}
+
+func Test_Create_EmailOfDeletedUser_ReturnsAlreadyExistsError(t *testing.T) {
+	//Arrange
+	service := users.NewUserService(memory.NewInMemoryUserRepository())
+	id, _ := service.Create(admin, users.User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page"})
+	service.Delete(admin, id, 1)
+	//Act
+	newID, err := service.Create(admin, users.User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page"})
+	//Assert
+	assert.Zero(t, newID)
+	assert.True(t, users.IsUserErrorType(users.ERRALREADYEXISTS, err))
+}
+
+func Test_Create_EmailOfPurgedUser_CreatesUser(t *testing.T) {
+	//Arrange
+	service := users.NewUserService(memory.NewInMemoryUserRepository())
+	id, _ := service.Create(admin, users.User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page"})
+	service.Delete(admin, id, 1)
+	service.Purge(admin, id)
+	//Act
+	newID, err := service.Create(admin, users.User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page"})
+	//Assert
+	assert.Nil(t, err)
+	assert.NotEqual(t, id, newID)
+}
+
+func Test_VerifyEmail_ValidToken_ActivatesUser(t *testing.T) {
+	//Arrange
+	service, notifications, _ := newVerifiedService()
+	ctx := context.Background()
+	service.Create(ctx, users.User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page"})
+	//Act
+	usr, err := service.VerifyEmail(ctx, notifications.token())
+	_, errReused := service.VerifyEmail(ctx, notifications.token())
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, users.AccountActive, usr.Status)
+	assert.Equal(t, 2, usr.Version)
+	assert.Equal(t, users.ErrInvalidToken, errReused)
+}
+
+func TestCases_VerifyEmail_InvalidToken(t *testing.T) {
+	testCases := []struct {
+		name   string
+		token  func(string) string
+		elapse time.Duration
+	}{
+		{name: "expired", token: func(t string) string { return t }, elapse: time.Hour},
+		{name: "tampered", token: func(t string) string { return "e30" + t[3:] }},
+		{name: "malformed", token: func(t string) string { return "not-a-token" }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, notifications, clock := newVerifiedService()
+			ctx := context.Background()
+			service.Create(ctx, users.User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page"})
+			clock.now = now.Add(tc.elapse)
+			_, err := service.VerifyEmail(ctx, tc.token(notifications.token()))
+			assert.Equal(t, users.ErrInvalidToken, err)
+		})
+	}
+}
+
+func Test_SendVerification_ActiveUser_ReturnsInvalidData(t *testing.T) {
+	//Arrange
+	service, notifications, _ := newVerifiedService()
+	ctx := context.Background()
+	service.Create(ctx, users.User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page"})
+	service.VerifyEmail(ctx, notifications.token())
+	//Act
+	err := service.SendVerification(ctx, "larry.page@gmail.com")
+	//Assert
+	assert.True(t, users.IsUserErrorType(users.ERRINVALIDDATA, err))
+}
+
+func TestCases_Transitions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		verify   bool
+		steps    []func(*users.UserService, context.Context, int) error
+		expected users.AccountStatus
+		valid    bool
+	}{
+		{name: "suspend active", verify: true, steps: []func(*users.UserService, context.Context, int) error{(*users.UserService).Suspend}, expected: users.AccountSuspended, valid: true},
+		{name: "reactivate suspended", verify: true, steps: []func(*users.UserService, context.Context, int) error{(*users.UserService).Suspend, (*users.UserService).Reactivate}, expected: users.AccountActive, valid: true},
+		{name: "reactivate deactivated", verify: true, steps: []func(*users.UserService, context.Context, int) error{(*users.UserService).Deactivate, (*users.UserService).Reactivate}, expected: users.AccountActive, valid: true},
+		{name: "deactivate pending", steps: []func(*users.UserService, context.Context, int) error{(*users.UserService).Deactivate}, expected: users.AccountDeactivated, valid: true},
+		{name: "suspend pending", steps: []func(*users.UserService, context.Context, int) error{(*users.UserService).Suspend}, expected: users.AccountPending},
+		{name: "reactivate pending", steps: []func(*users.UserService, context.Context, int) error{(*users.UserService).Reactivate}, expected: users.AccountPending},
+		{name: "suspend deactivated", verify: true, steps: []func(*users.UserService, context.Context, int) error{(*users.UserService).Deactivate, (*users.UserService).Suspend}, expected: users.AccountDeactivated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, notifications, _ := newVerifiedService()
+			id, _ := service.Create(admin, users.User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page"})
+			if tc.verify {
+				service.VerifyEmail(admin, notifications.token())
+			}
+			var err error
+			for _, step := range tc.steps {
+				err = step(service, admin, id)
+			}
+			usr, _ := service.GetByEmail(admin, "larry.page@gmail.com")
+			assert.Equal(t, tc.expected, usr.Status)
+			assert.Equal(t, tc.valid, err == nil)
+		})
+	}
+}
+
+func Test_Suspend_NotAdmin_ReturnsForbidden(t *testing.T) {
+	//Arrange
+	service, _, _ := newVerifiedService()
+	ctx := users.WithCaller(context.Background(), users.Caller{Subject: "larry.page@gmail.com"})
+	id, _ := service.Create(ctx, users.User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page"})
+	//Act
+	err := service.Suspend(ctx, id)
+	//Assert
+	assert.True(t, users.IsUserErrorType(users.ERRFORBIDDEN, err))
+}
+
+func Test_GetAll_FiltersByStatus(t *testing.T) {
+	//Arrange
+	service, notifications, _ := newVerifiedService()
+	ctx := context.Background()
+	service.Create(ctx, users.User{Email: "larry.page@gmail.com", Name: "Larry", LastName: "Page"})
+	service.VerifyEmail(ctx, notifications.token())
+	service.Create(ctx, users.User{Email: "sergey.brin@gmail.com", Name: "Sergey", LastName: "Brin"})
+	//Act
+	pending, err := service.GetAll(ctx, users.Filter{Status: users.AccountPending})
+	_, errInvalid := service.GetAll(ctx, users.Filter{Status: "unknown"})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(pending))
+	assert.Equal(t, "sergey.brin@gmail.com", pending[0].Email)
+	assert.True(t, users.IsUserErrorType(users.ERRINVALIDDATA, errInvalid))
+}
@@ -0,0 +1,15 @@
+package users
+
+import "context"
+
+//Transactor - runs a unit of work atomically, the repositories called with the context given to fn take part in it
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+//noTransaction - a Transactor for repositories without transactions, fn runs directly
+type noTransaction struct{}
+
+func (noTransaction) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -1,9 +1,24 @@
 package users
 
+import "time"
+
 //User - represents a user
 type User struct {
-	ID       int    `json:"id"`
-	Email    string `json:"email" validate:"required,email"`
-	Name     string `json:"name" validate:"required"`
-	LastName string `json:"lastname" validate:"required"`
+	ID        int        `json:"id"`
+	Email     string     `json:"email" validate:"required,email"`
+	Name      string     `json:"name" validate:"required"`
+	LastName  string     `json:"lastname" validate:"required"`
+	Version   int        `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	CreatedBy string     `json:"created_by"`
+	UpdatedBy string     `json:"updated_by"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	//Status - the stage of the account lifecycle, it changes through the service only
+	Status AccountStatus `json:"status"`
+}
+
+//IsDeleted - reports whether the user was soft deleted
+func (u User) IsDeleted() bool {
+	return u.DeletedAt != nil
 }
@@ -0,0 +1,69 @@
+package users
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+//FieldViolation - a field of a user whose value breaks a validation rule
+type FieldViolation struct {
+	//Field - the name of the field in the JSON representation of the user
+	Field string
+	//Rule - the validation tag that failed, as in required or email
+	Rule string
+	//Description - a readable description of the violation
+	Description string
+}
+
+//ValidationError - every field of a user that is not valid, not only the first one
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+//Error - describes the first violation, the message the service always returned for an invalid user
+func (e ValidationError) Error() string {
+	if len(e.Violations) == 0 {
+		return INVALIDDATA
+	}
+	return e.Violations[0].Description
+}
+
+//newValidationError - converts the errors of the validator into a ValidationError, any other error is returned as is
+func newValidationError(err error) error {
+
+	errs, ok := err.(validator.ValidationErrors)
+
+	if !ok {
+		return err
+	}
+
+	violations := make([]FieldViolation, 0, len(errs))
+
+	for _, fe := range errs {
+		violations = append(violations, FieldViolation{
+			Field:       jsonFieldName(fe.StructField()),
+			Rule:        fe.Tag(),
+			Description: fe.StructField() + " is not valid",
+		})
+	}
+
+	return ValidationError{Violations: violations}
+}
+
+//jsonFieldName - returns the name the field of a user is encoded with in JSON
+func jsonFieldName(field string) string {
+
+	f, ok := reflect.TypeOf(User{}).FieldByName(field)
+
+	if !ok {
+		return field
+	}
+
+	if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+
+	return field
+}
@@ -0,0 +1,166 @@
+package users
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+//Verification - configures the verification of the email of the new users
+type Verification struct {
+	//Secret - the key the verification tokens are signed with
+	Secret []byte
+	//Notifier - delivers the verification token to the user
+	Notifier Notifier
+	//Clock - the time the tokens expire against
+	Clock Clock
+	//TokenTTL - how long a verification token can be used
+	TokenTTL time.Duration
+}
+
+//WithVerification - new users start pending until they verify their email, without it they start active
+func WithVerification(v Verification) ServiceOption {
+	return func(us *UserService) {
+		us.verification = &v
+	}
+}
+
+//verificationClaims - the payload of a verification token
+type verificationClaims struct {
+	UserID    int    `json:"sub"`
+	Email     string `json:"email"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+//SendVerification - sends a new verification token to a pending user, the tokens sent before stay valid until they expire
+func (us *UserService) SendVerification(ctx context.Context, email string) error {
+
+	if us.verification == nil {
+		return ErrVerificationDisabled
+	}
+
+	usr, err := us.repository.GetByEmail(ctx, email)
+
+	if err != nil {
+		return err
+	}
+
+	if usr.ID == 0 {
+		return errors.New("user not found")
+	}
+
+	if usr.Status != AccountPending {
+		return UserError(ERRINVALIDDATA)
+	}
+
+	return us.sendVerification(ctx, usr)
+}
+
+//VerifyEmail - activates the pending user the token was issued for and returns it
+func (us *UserService) VerifyEmail(ctx context.Context, token string) (User, error) {
+
+	if us.verification == nil {
+		return User{}, ErrVerificationDisabled
+	}
+
+	claims, err := us.parseVerificationToken(token)
+
+	if err != nil {
+		return User{}, err
+	}
+
+	usr, err := us.repository.GetByID(ctx, claims.UserID)
+
+	if err != nil {
+		return User{}, err
+	}
+
+	//The token is spent once the user leaves pending, and void if the email changed since.
+	if usr.ID == 0 || usr.IsDeleted() || usr.Status != AccountPending || usr.Email != claims.Email {
+		return User{}, ErrInvalidToken
+	}
+
+	if err := us.setStatus(ctx, usr, AccountActive); err != nil {
+		return User{}, err
+	}
+
+	return us.repository.GetByID(ctx, usr.ID)
+}
+
+//sendVerification - signs a token for the user and notifies it
+func (us *UserService) sendVerification(ctx context.Context, usr User) error {
+
+	expiresAt := us.verification.Clock.Now().Add(us.verification.TokenTTL)
+
+	token, err := us.signVerificationToken(verificationClaims{UserID: usr.ID, Email: usr.Email, ExpiresAt: expiresAt.Unix()})
+
+	if err != nil {
+		return err
+	}
+
+	return us.verification.Notifier.Notify(ctx, Notification{
+		To:      usr.Email,
+		Subject: "Verify your email address",
+		Body:    fmt.Sprintf("Use this token to verify %s, it expires at %s: %s", usr.Email, expiresAt.Format(time.RFC3339), token),
+	})
+}
+
+//signVerificationToken - encodes the claims followed by their signature, both base64url encoded
+func (us *UserService) signVerificationToken(claims verificationClaims) (string, error) {
+
+	payload, err := json.Marshal(claims)
+
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(us.verificationSignature(encoded)), nil
+}
+
+//parseVerificationToken - returns the claims of a token signed by the service and not expired
+func (us *UserService) parseVerificationToken(token string) (verificationClaims, error) {
+
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 2 {
+		return verificationClaims{}, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+
+	if err != nil || !hmac.Equal(signature, us.verificationSignature(parts[0])) {
+		return verificationClaims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+
+	if err != nil {
+		return verificationClaims{}, ErrInvalidToken
+	}
+
+	var claims verificationClaims
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return verificationClaims{}, ErrInvalidToken
+	}
+
+	if !us.verification.Clock.Now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return verificationClaims{}, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func (us *UserService) verificationSignature(payload string) []byte {
+	mac := hmac.New(sha256.New, us.verification.Secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,195 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/events"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+//RetryPolicy - how many times and how often a failed delivery is attempted
+type RetryPolicy struct {
+	//MaxAttempts - attempts made before the delivery is moved to the dead-letter list
+	MaxAttempts int
+	//InitialBackoff - wait after the first failed attempt, it doubles after every failure
+	InitialBackoff time.Duration
+	//MaxBackoff - the longest wait between two attempts
+	MaxBackoff time.Duration
+}
+
+//Backoff - returns the wait after the given number of failed attempts
+func (p RetryPolicy) Backoff(failures int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < failures && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+//Dispatcher - an events.Publisher that queues a delivery for every subscription of the event type,
+//and delivers them with RunOnce
+type Dispatcher struct {
+	subscriptions SubscriptionRepository
+	deliveries    DeliveryRepository
+	client        *http.Client
+	policy        RetryPolicy
+	clock         users.Clock
+	batchSize     int
+}
+
+//NewDispatcher - returns a Dispatcher type pointer that attempts up to batchSize deliveries per run
+func NewDispatcher(subs SubscriptionRepository, deliveries DeliveryRepository, client *http.Client, policy RetryPolicy, clock users.Clock, batchSize int) *Dispatcher {
+	return &Dispatcher{
+		subscriptions: subs,
+		deliveries:    deliveries,
+		client:        client,
+		policy:        policy,
+		clock:         clock,
+		batchSize:     batchSize,
+	}
+}
+
+//Publish - queues the message for every subscription that accepts its type, the message is delivered by RunOnce
+func (d *Dispatcher) Publish(ctx context.Context, m events.Message) error {
+
+	subs, err := d.subscriptions.GetAll(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(m)
+
+	if err != nil {
+		return err
+	}
+
+	now := d.clock.Now()
+
+	for _, s := range subs {
+		if !s.Accepts(m.Type) {
+			continue
+		}
+
+		_, err := d.deliveries.Add(ctx, Delivery{
+			SubscriptionID: s.ID,
+			Sequence:       m.Sequence,
+			EventType:      m.Type,
+			Payload:        payload,
+			Status:         DeliveryPending,
+			Attempts:       []Attempt{},
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//RunOnce - attempts the deliveries that are due and returns how many succeeded, failed attempts are
+//rescheduled with exponential backoff until the policy gives up on them
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+
+	due, err := d.deliveries.Due(ctx, d.clock.Now(), d.batchSize)
+
+	if err != nil {
+		return 0, err
+	}
+
+	succeeded := 0
+
+	for _, delivery := range due {
+		delivery = d.attempt(ctx, delivery)
+
+		if err := d.deliveries.Update(ctx, delivery); err != nil {
+			return succeeded, err
+		}
+
+		if delivery.Status == DeliverySucceeded {
+			succeeded++
+		}
+	}
+
+	return succeeded, nil
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery Delivery) Delivery {
+
+	now := d.clock.Now()
+	attempt := Attempt{At: now}
+
+	sub, err := d.subscriptions.GetByID(ctx, delivery.SubscriptionID)
+
+	switch {
+	case err != nil:
+		attempt.Error = err.Error()
+	case sub.ID == 0:
+		//Nobody is listening anymore, retrying would not help.
+		attempt.Error = ErrSubscriptionNotFound.Error()
+		delivery.Attempts = append(delivery.Attempts, attempt)
+		delivery.Status = DeliveryDead
+		return delivery
+	default:
+		attempt.StatusCode, err = d.send(ctx, sub, delivery, now)
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+	}
+
+	delivery.Attempts = append(delivery.Attempts, attempt)
+
+	switch {
+	case attempt.Error == "":
+		delivery.Status = DeliverySucceeded
+	case len(delivery.Attempts) >= d.policy.MaxAttempts:
+		delivery.Status = DeliveryDead
+	default:
+		delivery.NextAttemptAt = now.Add(d.policy.Backoff(len(delivery.Attempts)))
+	}
+
+	return delivery
+}
+
+func (d *Dispatcher) send(ctx context.Context, sub Subscription, delivery Delivery, now time.Time) (int, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := now.Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
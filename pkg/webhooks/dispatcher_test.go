@@ -0,0 +1,234 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/events"
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/casmelad/GlobantPOC/pkg/webhooks"
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+var now = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+//movableClock - a clock the tests move forward to make the retries due
+type movableClock struct {
+	now time.Time
+}
+
+func (c *movableClock) Now() time.Time {
+	return c.now
+}
+
+//receiver - an httptest server that answers with the queued status codes, 200 once they run out
+type receiver struct {
+	mtx      sync.Mutex
+	server   *httptest.Server
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(statuses ...int) *receiver {
+	r := &receiver{statuses: statuses}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		r.mtx.Lock()
+		defer r.mtx.Unlock()
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	return r
+}
+
+var policy = webhooks.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: time.Hour}
+
+type fixture struct {
+	clock         *movableClock
+	subscriptions *memory.InMemorySubscriptionRepository
+	deliveries    *memory.InMemoryDeliveryRepository
+	dispatcher    *webhooks.Dispatcher
+	relay         *events.Relay
+	service       *users.UserService
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		clock:         &movableClock{now: now},
+		subscriptions: memory.NewInMemorySubscriptionRepository(),
+		deliveries:    memory.NewInMemoryDeliveryRepository(),
+	}
+	outbox := memory.NewInMemoryOutboxRepository()
+	repository := memory.NewInMemoryUserRepository(memory.WithClock(f.clock))
+	f.service = users.NewUserService(repository, users.WithTransactor(repository), users.WithEventEmitter(events.NewOutboxEmitter(outbox)))
+	f.dispatcher = webhooks.NewDispatcher(f.subscriptions, f.deliveries, http.DefaultClient, policy, f.clock, 10)
+	f.relay = events.NewRelay(outbox, f.dispatcher, 10, f.clock)
+	return f
+}
+
+func (f *fixture) subscribe(url string, types ...users.EventType) int {
+	id, _ := f.subscriptions.Add(context.Background(), webhooks.Subscription{URL: url, EventTypes: types, Secret: secret, CreatedAt: now})
+	return id
+}
+
+func (f *fixture) createUser() {
+	ctx := context.Background()
+	f.service.Create(ctx, users.User{Email: "test@gmail.com", Name: "Test", LastName: "LastName"})
+	f.relay.RunOnce(ctx)
+}
+
+func Test_Dispatcher_DeliversSignedEvent(t *testing.T) {
+	//Arrange
+	r := newReceiver()
+	defer r.server.Close()
+	f := newFixture()
+	f.subscribe(r.server.URL, users.UserCreatedEvent)
+	f.createUser()
+	//Act
+	delivered, err := f.dispatcher.RunOnce(context.Background())
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Len(t, r.requests, 1)
+	req := r.requests[0]
+	timestamp, _ := strconv.ParseInt(req.Header.Get(webhooks.TimestampHeader), 10, 64)
+	assert.Equal(t, now.Unix(), timestamp)
+	assert.True(t, webhooks.Verify(secret, timestamp, r.bodies[0], req.Header.Get(webhooks.SignatureHeader)))
+	assert.Equal(t, string(users.UserCreatedEvent), req.Header.Get(webhooks.EventHeader))
+	assert.Equal(t, "1", req.Header.Get(webhooks.DeliveryHeader))
+	var m events.Message
+	assert.Nil(t, json.Unmarshal(r.bodies[0], &m))
+	assert.Equal(t, users.UserCreatedEvent, m.Type)
+	assert.Equal(t, int64(1), m.Sequence)
+}
+
+func Test_Dispatcher_SkipsSubscriptionsOfOtherEventTypes(t *testing.T) {
+	//Arrange
+	r := newReceiver()
+	defer r.server.Close()
+	f := newFixture()
+	f.subscribe(r.server.URL, users.UserDeletedEvent)
+	f.createUser()
+	//Act
+	delivered, err := f.dispatcher.RunOnce(context.Background())
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 0, delivered)
+	assert.Len(t, r.requests, 0)
+}
+
+func Test_Dispatcher_FailedDelivery_IsRetriedWithBackoff(t *testing.T) {
+	//Arrange
+	r := newReceiver(http.StatusInternalServerError, http.StatusServiceUnavailable)
+	defer r.server.Close()
+	f := newFixture()
+	subID := f.subscribe(r.server.URL, users.UserCreatedEvent)
+	f.createUser()
+	ctx := context.Background()
+	//Act
+	f.dispatcher.RunOnce(ctx)
+	notDueYet, _ := f.dispatcher.RunOnce(ctx)
+	f.clock.now = now.Add(time.Minute)
+	f.dispatcher.RunOnce(ctx)
+	f.clock.now = now.Add(3 * time.Minute)
+	delivered, err := f.dispatcher.RunOnce(ctx)
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 0, notDueYet)
+	assert.Equal(t, 1, delivered)
+	assert.Len(t, r.requests, 3)
+	history, _ := f.deliveries.Find(ctx, webhooks.DeliveryQuery{SubscriptionID: subID})
+	assert.Len(t, history, 1)
+	assert.Equal(t, webhooks.DeliverySucceeded, history[0].Status)
+	assert.Equal(t, []webhooks.Attempt{
+		{At: now, StatusCode: http.StatusInternalServerError, Error: "receiver answered 500"},
+		{At: now.Add(time.Minute), StatusCode: http.StatusServiceUnavailable, Error: "receiver answered 503"},
+		{At: now.Add(3 * time.Minute), StatusCode: http.StatusOK},
+	}, history[0].Attempts)
+}
+
+func Test_Dispatcher_ExhaustedDelivery_IsDeadLettered(t *testing.T) {
+	//Arrange
+	r := newReceiver(http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	defer r.server.Close()
+	f := newFixture()
+	f.subscribe(r.server.URL, users.UserCreatedEvent)
+	f.createUser()
+	ctx := context.Background()
+	//Act
+	for i := 0; i < 5; i++ {
+		f.dispatcher.RunOnce(ctx)
+		f.clock.now = f.clock.now.Add(time.Hour)
+	}
+	//Assert
+	assert.Len(t, r.requests, policy.MaxAttempts)
+	dead, _ := f.deliveries.Find(ctx, webhooks.DeliveryQuery{Status: webhooks.DeliveryDead})
+	assert.Len(t, dead, 1)
+	assert.Len(t, dead[0].Attempts, policy.MaxAttempts)
+}
+
+func Test_Dispatcher_DeliveryOfRemovedSubscription_IsDeadLettered(t *testing.T) {
+	//Arrange
+	r := newReceiver()
+	defer r.server.Close()
+	f := newFixture()
+	subID := f.subscribe(r.server.URL, users.UserCreatedEvent)
+	f.createUser()
+	ctx := context.Background()
+	f.subscriptions.Delete(ctx, subID)
+	//Act
+	delivered, err := f.dispatcher.RunOnce(ctx)
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 0, delivered)
+	assert.Len(t, r.requests, 0)
+	dead, _ := f.deliveries.Find(ctx, webhooks.DeliveryQuery{Status: webhooks.DeliveryDead})
+	assert.Len(t, dead, 1)
+	assert.Equal(t, webhooks.ErrSubscriptionNotFound.Error(), dead[0].Attempts[0].Error)
+}
+
+func TestCases_RetryPolicy_Backoff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		failures int
+		expected time.Duration
+	}{
+		{name: "first failure waits the initial backoff", failures: 1, expected: time.Minute},
+		{name: "every failure doubles the wait", failures: 3, expected: 4 * time.Minute},
+		{name: "the wait is capped", failures: 10, expected: time.Hour},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, policy.Backoff(tc.failures))
+		})
+	}
+}
+
+func Test_Verify_TamperedBody_IsRejected(t *testing.T) {
+	//Arrange
+	body := []byte(`{"type":"user.created"}`)
+	signature := webhooks.Sign(secret, now.Unix(), body)
+	//Act
+	valid := webhooks.Verify(secret, now.Unix(), []byte(`{"type":"user.deleted"}`), signature)
+	//Assert
+	assert.False(t, valid)
+	assert.True(t, webhooks.Verify(secret, now.Unix(), body, signature))
+	assert.False(t, webhooks.Verify("another secret value", now.Unix(), body, signature))
+}
@@ -0,0 +1,161 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+const (
+	//MinSecretLength - the shortest secret a subscription can be created with
+	MinSecretLength = 16
+
+	generatedSecretBytes = 32
+)
+
+//Service - manages the webhook subscriptions and exposes their deliveries, only administrators are allowed to use it
+type Service struct {
+	subscriptions SubscriptionRepository
+	deliveries    DeliveryRepository
+	clock         users.Clock
+}
+
+//NewService - returns a Service type pointer
+func NewService(subs SubscriptionRepository, deliveries DeliveryRepository, clock users.Clock) *Service {
+	return &Service{subscriptions: subs, deliveries: deliveries, clock: clock}
+}
+
+//Subscribe - validates and stores a subscription, a secret is generated when none is given.
+//The returned subscription is the only place the secret is handed back
+func (s *Service) Subscribe(ctx context.Context, sub Subscription) (Subscription, error) {
+
+	if err := authorize(ctx); err != nil {
+		return Subscription{}, err
+	}
+
+	if err := validate(sub); err != nil {
+		return Subscription{}, err
+	}
+
+	if sub.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return Subscription{}, err
+		}
+		sub.Secret = secret
+	}
+
+	sub.CreatedAt = s.clock.Now()
+	sub.CreatedBy = users.ActorFromContext(ctx)
+
+	id, err := s.subscriptions.Add(ctx, sub)
+
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	sub.ID = id
+
+	return sub, nil
+}
+
+//Subscription - retrieves a subscription
+func (s *Service) Subscription(ctx context.Context, id int) (Subscription, error) {
+
+	if err := authorize(ctx); err != nil {
+		return Subscription{}, err
+	}
+
+	sub, err := s.subscriptions.GetByID(ctx, id)
+
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	if sub.ID == 0 {
+		return Subscription{}, ErrSubscriptionNotFound
+	}
+
+	return sub, nil
+}
+
+//Subscriptions - retrieves every subscription
+func (s *Service) Subscriptions(ctx context.Context) ([]Subscription, error) {
+
+	if err := authorize(ctx); err != nil {
+		return []Subscription{}, err
+	}
+
+	return s.subscriptions.GetAll(ctx)
+}
+
+//Unsubscribe - removes a subscription, its pending deliveries are moved to the dead-letter list when attempted
+func (s *Service) Unsubscribe(ctx context.Context, id int) error {
+
+	if _, err := s.Subscription(ctx, id); err != nil {
+		return err
+	}
+
+	return s.subscriptions.Delete(ctx, id)
+}
+
+//Deliveries - retrieves the deliveries that match the query, the dead-letter list is the query by DeliveryDead
+func (s *Service) Deliveries(ctx context.Context, q DeliveryQuery) ([]Delivery, error) {
+
+	if err := authorize(ctx); err != nil {
+		return []Delivery{}, err
+	}
+
+	switch q.Status {
+	case "", DeliveryPending, DeliverySucceeded, DeliveryDead:
+	default:
+		return []Delivery{}, ErrInvalidDeliveryQuery
+	}
+
+	return s.deliveries.Find(ctx, q)
+}
+
+func authorize(ctx context.Context) error {
+	if caller, ok := users.CallerFromContext(ctx); !ok || !caller.HasRole(users.AdminRole) {
+		return users.UserError(users.ERRFORBIDDEN)
+	}
+	return nil
+}
+
+func validate(sub Subscription) error {
+
+	target, err := url.Parse(sub.URL)
+
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return ErrInvalidSubscription
+	}
+
+	if len(sub.EventTypes) == 0 {
+		return ErrInvalidSubscription
+	}
+
+	for _, t := range sub.EventTypes {
+		switch t {
+		case users.UserCreatedEvent, users.UserUpdatedEvent, users.UserDeletedEvent, users.UserPurgedEvent:
+		default:
+			return ErrInvalidSubscription
+		}
+	}
+
+	if sub.Secret != "" && len(sub.Secret) < MinSecretLength {
+		return ErrInvalidSubscription
+	}
+
+	return nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, generatedSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
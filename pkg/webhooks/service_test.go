@@ -0,0 +1,78 @@
+package webhooks_test
+
+import (
+	"context"
+	"testing"
+
+	memory "github.com/casmelad/GlobantPOC/pkg/repository/memory"
+	"github.com/casmelad/GlobantPOC/pkg/users"
+	"github.com/casmelad/GlobantPOC/pkg/webhooks"
+	"github.com/stretchr/testify/assert"
+)
+
+var admin = users.WithCaller(context.Background(), users.Caller{Subject: "root", Roles: []string{users.AdminRole}})
+
+func newService() *webhooks.Service {
+	return webhooks.NewService(memory.NewInMemorySubscriptionRepository(), memory.NewInMemoryDeliveryRepository(), &movableClock{now: now})
+}
+
+func Test_Subscribe_WithoutSecret_GeneratesOne(t *testing.T) {
+	//Arrange
+	service := newService()
+	//Act
+	sub, err := service.Subscribe(admin, webhooks.Subscription{URL: "https://partner.example.com/hooks", EventTypes: []users.EventType{users.UserCreatedEvent}})
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 1, sub.ID)
+	assert.Len(t, sub.Secret, 64)
+	assert.Equal(t, now, sub.CreatedAt)
+	assert.Equal(t, "root", sub.CreatedBy)
+}
+
+func Test_Subscribe_NotAdmin_IsForbidden(t *testing.T) {
+	//Arrange
+	service := newService()
+	ctx := users.WithCaller(context.Background(), users.Caller{Subject: "someone"})
+	//Act
+	_, err := service.Subscribe(ctx, webhooks.Subscription{URL: "https://partner.example.com/hooks", EventTypes: []users.EventType{users.UserCreatedEvent}})
+	//Assert
+	assert.True(t, users.IsUserErrorType(users.ERRFORBIDDEN, err))
+}
+
+func TestCases_Subscribe_InvalidSubscription(t *testing.T) {
+	testCases := []struct {
+		name string
+		sub  webhooks.Subscription
+	}{
+		{name: "relative url", sub: webhooks.Subscription{URL: "/hooks", EventTypes: []users.EventType{users.UserCreatedEvent}}},
+		{name: "unsupported scheme", sub: webhooks.Subscription{URL: "ftp://partner.example.com", EventTypes: []users.EventType{users.UserCreatedEvent}}},
+		{name: "no event types", sub: webhooks.Subscription{URL: "https://partner.example.com/hooks"}},
+		{name: "unknown event type", sub: webhooks.Subscription{URL: "https://partner.example.com/hooks", EventTypes: []users.EventType{"user.renamed"}}},
+		{name: "short secret", sub: webhooks.Subscription{URL: "https://partner.example.com/hooks", EventTypes: []users.EventType{users.UserCreatedEvent}, Secret: "short"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newService().Subscribe(admin, tc.sub)
+			assert.Equal(t, webhooks.ErrInvalidSubscription, err)
+		})
+	}
+}
+
+func Test_Unsubscribe_UnknownSubscription_ReturnsNotFound(t *testing.T) {
+	//Arrange
+	service := newService()
+	//Act
+	err := service.Unsubscribe(admin, 7)
+	//Assert
+	assert.Equal(t, webhooks.ErrSubscriptionNotFound, err)
+}
+
+func Test_Deliveries_UnknownStatus_IsRejected(t *testing.T) {
+	//Arrange
+	service := newService()
+	//Act
+	_, err := service.Deliveries(admin, webhooks.DeliveryQuery{Status: "lost"})
+	//Assert
+	assert.Equal(t, webhooks.ErrInvalidDeliveryQuery, err)
+}
@@ -0,0 +1,35 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+const (
+	//SignatureHeader - carries the HMAC-SHA256 of the timestamp and the body, keyed with the subscription secret
+	SignatureHeader = "X-Webhook-Signature"
+	//TimestampHeader - carries the unix time the attempt was signed at, receivers should reject stale ones
+	TimestampHeader = "X-Webhook-Timestamp"
+	//EventHeader - carries the type of the delivered event
+	EventHeader = "X-Webhook-Event"
+	//DeliveryHeader - carries the id of the delivery, the same for every attempt so receivers can deduplicate
+	DeliveryHeader = "X-Webhook-Delivery"
+
+	signaturePrefix = "sha256="
+)
+
+//Sign - returns the signature of a delivery attempt, computed over "<timestamp>.<body>"
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+//Verify - reports whether the signature matches the timestamp and the body, for receivers
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
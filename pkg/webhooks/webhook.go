@@ -0,0 +1,111 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/casmelad/GlobantPOC/pkg/users"
+)
+
+var (
+	//ErrSubscriptionNotFound - there is no subscription with the given id
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	//ErrInvalidSubscription - the subscription url or event types are not valid
+	ErrInvalidSubscription = errors.New("invalid subscription")
+	//ErrInvalidDeliveryQuery - the delivery status to filter by is not known
+	ErrInvalidDeliveryQuery = errors.New("invalid delivery query")
+)
+
+//DeliveryStatus - the state of a delivery
+type DeliveryStatus string
+
+const (
+	//DeliveryPending - the delivery is waiting for its next attempt
+	DeliveryPending DeliveryStatus = "pending"
+	//DeliverySucceeded - the receiver acknowledged the delivery
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	//DeliveryDead - every attempt failed, the delivery is in the dead-letter list
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+//Subscription - a partner endpoint that receives the user events of the given types
+type Subscription struct {
+	ID         int               `json:"id"`
+	URL        string            `json:"url"`
+	EventTypes []users.EventType `json:"event_types"`
+	Secret     string            `json:"-"`
+	CreatedAt  time.Time         `json:"created_at"`
+	CreatedBy  string            `json:"created_by"`
+}
+
+//Accepts - reports whether the subscription receives events of the given type
+func (s Subscription) Accepts(eventType users.EventType) bool {
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+//Attempt - the outcome of a single delivery attempt
+type Attempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+//Delivery - an event to be delivered to a subscription and the attempts made so far
+type Delivery struct {
+	ID             int             `json:"id"`
+	SubscriptionID int             `json:"subscription_id"`
+	Sequence       int64           `json:"sequence"`
+	EventType      users.EventType `json:"event_type"`
+	Payload        []byte          `json:"-"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       []Attempt       `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+//DeliveryQuery - narrows the deliveries returned by Find, zero values are not applied
+type DeliveryQuery struct {
+	SubscriptionID int
+	Status         DeliveryStatus
+}
+
+//Matches - reports whether the delivery satisfies the query
+func (q DeliveryQuery) Matches(d Delivery) bool {
+	if q.SubscriptionID != 0 && d.SubscriptionID != q.SubscriptionID {
+		return false
+	}
+	if q.Status != "" && d.Status != q.Status {
+		return false
+	}
+	return true
+}
+
+//SubscriptionRepository - the interface for the subscriptions persistence
+type SubscriptionRepository interface {
+	//Add - stores a subscription and returns its id
+	Add(ctx context.Context, s Subscription) (int, error)
+	//GetByID - retrieves a subscription, a zero subscription when it does not exist
+	GetByID(ctx context.Context, id int) (Subscription, error)
+	//GetAll - retrieves every subscription
+	GetAll(ctx context.Context) ([]Subscription, error)
+	//Delete - removes a subscription, its deliveries are kept
+	Delete(ctx context.Context, id int) error
+}
+
+//DeliveryRepository - the interface for the deliveries persistence
+type DeliveryRepository interface {
+	//Add - stores a delivery and returns its id
+	Add(ctx context.Context, d Delivery) (int, error)
+	//Update - stores the status, the attempts and the next attempt of a delivery
+	Update(ctx context.Context, d Delivery) error
+	//Due - retrieves up to limit pending deliveries whose next attempt is not after the given time, oldest first
+	Due(ctx context.Context, at time.Time, limit int) ([]Delivery, error)
+	//Find - retrieves the deliveries that match the query, oldest first
+	Find(ctx context.Context, q DeliveryQuery) ([]Delivery, error)
+}
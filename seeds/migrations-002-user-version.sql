@@ -0,0 +1,3 @@
+USE Users;
+
+ALTER TABLE Users ADD COLUMN Version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+USE Users;
+
+ALTER TABLE Users ADD COLUMN DeletedAt DATETIME NULL;
+
+CREATE INDEX IX_Users_DeletedAt ON Users(DeletedAt);
@@ -0,0 +1,7 @@
+USE Users;
+
+ALTER TABLE Users
+    ADD COLUMN CreatedAt DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    ADD COLUMN UpdatedAt DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    ADD COLUMN CreatedBy VARCHAR(100) NOT NULL DEFAULT 'system',
+    ADD COLUMN UpdatedBy VARCHAR(100) NOT NULL DEFAULT 'system';
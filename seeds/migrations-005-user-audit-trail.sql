@@ -0,0 +1,14 @@
+USE Users;
+
+CREATE TABLE UserAudit (
+    Id BIGINT NOT NULL AUTO_INCREMENT,
+    UserId INT NOT NULL,
+    Action VARCHAR(16) NOT NULL,
+    Changes JSON NOT NULL,
+    Actor VARCHAR(100) NOT NULL,
+    RequestId VARCHAR(64) NOT NULL DEFAULT '',
+    Transport VARCHAR(16) NOT NULL DEFAULT '',
+    OccurredAt DATETIME(6) NOT NULL,
+    PRIMARY KEY (Id),
+    INDEX IX_UserAudit_UserId_OccurredAt (UserId, OccurredAt)
+);
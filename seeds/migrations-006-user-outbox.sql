@@ -0,0 +1,12 @@
+USE Users;
+
+CREATE TABLE UserOutbox (
+    Sequence BIGINT NOT NULL AUTO_INCREMENT,
+    EventType VARCHAR(32) NOT NULL,
+    UserId INT NOT NULL,
+    Payload JSON NOT NULL,
+    OccurredAt DATETIME(6) NOT NULL,
+    PublishedAt DATETIME(6) NULL,
+    PRIMARY KEY (Sequence),
+    INDEX IX_UserOutbox_PublishedAt (PublishedAt, Sequence)
+);
@@ -0,0 +1,8 @@
+USE Users;
+
+ALTER TABLE UserOutbox ADD COLUMN Position BIGINT NULL AFTER Sequence;
+
+SET @position = 0;
+UPDATE UserOutbox SET Position = (@position := @position + 1) WHERE PublishedAt IS NOT NULL ORDER BY Sequence;
+
+ALTER TABLE UserOutbox ADD UNIQUE INDEX UX_UserOutbox_Position (Position);
@@ -0,0 +1,26 @@
+USE Users;
+
+CREATE TABLE WebhookSubscription (
+    Id INT NOT NULL AUTO_INCREMENT,
+    Url VARCHAR(2048) NOT NULL,
+    EventTypes JSON NOT NULL,
+    Secret VARCHAR(128) NOT NULL,
+    CreatedAt DATETIME(6) NOT NULL,
+    CreatedBy VARCHAR(100) NOT NULL,
+    PRIMARY KEY (Id)
+);
+
+CREATE TABLE WebhookDelivery (
+    Id INT NOT NULL AUTO_INCREMENT,
+    SubscriptionId INT NOT NULL,
+    Sequence BIGINT NOT NULL,
+    EventType VARCHAR(32) NOT NULL,
+    Payload JSON NOT NULL,
+    Status VARCHAR(16) NOT NULL,
+    Attempts JSON NOT NULL,
+    NextAttemptAt DATETIME(6) NOT NULL,
+    CreatedAt DATETIME(6) NOT NULL,
+    PRIMARY KEY (Id),
+    INDEX IX_WebhookDelivery_Status_NextAttemptAt (Status, NextAttemptAt),
+    INDEX IX_WebhookDelivery_SubscriptionId (SubscriptionId, Id)
+);
@@ -0,0 +1,19 @@
+USE Users;
+
+CREATE TABLE UserEmailChange (
+    UserId INT NOT NULL,
+    OldEmail VARCHAR(50) NOT NULL,
+    NewEmail VARCHAR(50) NOT NULL,
+    TokenHash CHAR(64) NOT NULL,
+    RequestedAt DATETIME(6) NOT NULL,
+    ExpiresAt DATETIME(6) NOT NULL,
+    PRIMARY KEY (UserId),
+    UNIQUE INDEX UX_UserEmailChange_TokenHash (TokenHash)
+);
+
+CREATE TABLE UserEmailAlias (
+    Email VARCHAR(50) NOT NULL,
+    UserId INT NOT NULL,
+    ExpiresAt DATETIME(6) NOT NULL,
+    PRIMARY KEY (Email)
+);
@@ -0,0 +1,6 @@
+USE Users;
+
+-- The users created before the lifecycle existed are taken as verified.
+ALTER TABLE Users ADD COLUMN Status VARCHAR(16) NOT NULL DEFAULT 'active';
+
+CREATE INDEX IX_Users_Status ON Users(Status);
@@ -0,0 +1,24 @@
+USE Users;
+
+CREATE TABLE UserCredential (
+    UserId INT NOT NULL,
+    PasswordHash VARCHAR(255) NOT NULL,
+    Roles JSON NOT NULL,
+    FailedAttempts INT NOT NULL DEFAULT 0,
+    LockedUntil DATETIME(6) NULL,
+    UpdatedAt DATETIME(6) NOT NULL,
+    PRIMARY KEY (UserId)
+);
+
+CREATE TABLE RefreshToken (
+    TokenHash CHAR(64) NOT NULL,
+    UserId INT NOT NULL,
+    FamilyId VARCHAR(32) NOT NULL,
+    CreatedAt DATETIME(6) NOT NULL,
+    ExpiresAt DATETIME(6) NOT NULL,
+    RevokedAt DATETIME(6) NULL,
+    ReplacedBy CHAR(64) NOT NULL DEFAULT '',
+    PRIMARY KEY (TokenHash),
+    INDEX IX_RefreshToken_FamilyId (FamilyId),
+    INDEX IX_RefreshToken_UserId (UserId)
+);
@@ -0,0 +1,10 @@
+USE Users;
+
+CREATE TABLE PasswordReset (
+    UserId INT NOT NULL,
+    TokenHash CHAR(64) NOT NULL,
+    RequestedAt DATETIME(6) NOT NULL,
+    ExpiresAt DATETIME(6) NOT NULL,
+    PRIMARY KEY (UserId),
+    UNIQUE INDEX UX_PasswordReset_TokenHash (TokenHash)
+);
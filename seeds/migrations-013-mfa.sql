@@ -0,0 +1,21 @@
+USE Users;
+
+ALTER TABLE RefreshToken ADD COLUMN Methods JSON NULL AFTER FamilyId;
+UPDATE RefreshToken SET Methods = JSON_ARRAY('pwd');
+ALTER TABLE RefreshToken MODIFY Methods JSON NOT NULL;
+
+CREATE TABLE UserMFA (
+    UserId INT NOT NULL,
+    Secret VARCHAR(64) NOT NULL,
+    ConfirmedAt DATETIME(6) NULL,
+    LastStep BIGINT NOT NULL DEFAULT 0,
+    RecoveryCodes JSON NOT NULL,
+    PRIMARY KEY (UserId)
+);
+
+CREATE TABLE MFAChallenge (
+    TokenHash CHAR(64) NOT NULL,
+    UserId INT NOT NULL,
+    ExpiresAt DATETIME(6) NOT NULL,
+    PRIMARY KEY (TokenHash)
+);
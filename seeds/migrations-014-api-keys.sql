@@ -0,0 +1,14 @@
+USE Users;
+
+CREATE TABLE APIKey (
+    Id VARCHAR(32) NOT NULL,
+    Name VARCHAR(100) NOT NULL,
+    KeyHash CHAR(64) NOT NULL,
+    Scopes JSON NOT NULL,
+    CreatedBy VARCHAR(100) NOT NULL,
+    CreatedAt DATETIME(6) NOT NULL,
+    ExpiresAt DATETIME(6) NULL,
+    LastUsedAt DATETIME(6) NULL,
+    RevokedAt DATETIME(6) NULL,
+    PRIMARY KEY (Id)
+);